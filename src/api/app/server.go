@@ -1,34 +1,140 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maria/src/api/assignment"
+	"maria/src/api/auth"
+	"maria/src/api/client"
 	"maria/src/api/db"
+	"maria/src/api/event"
+	"maria/src/api/mail"
+	"maria/src/api/migration"
+	"maria/src/api/role"
+	"maria/src/api/sweeper"
+	"maria/src/api/task"
 	"maria/src/api/user"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// grantsCacheTTL is how long the role types granted to a caller are kept before loading them again.
+	grantsCacheTTL = 30 * time.Second
+
+	defaultSweeperInterval = time.Minute
+	shutdownTimeout        = 10 * time.Second
+
+	// trustedGatewayMode is the CALLER_HEADER_MODE acknowledging that auth.CallerHeader is set by a trusted gateway.
+	trustedGatewayMode = "trusted_gateway"
+)
+
 type controller interface {
 	SetURLMapping(router *gin.Engine)
+	RequiredRoles() auth.Routes
 }
 
 func main() {
+	checkCallerHeaderMode()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	router := gin.Default()
 	controllers := make([]controller, 0)
 
+	dbClient := db.NewSQLClient(getSQLClientConfig())
+	if err := migration.NewMigrator(dbClient).Run(getMigrationMode()); err != nil {
+		panic(err)
+	}
+
+	expirySweeper := sweeper.NewSweeper(
+		sweeper.NewRelationalDB(dbClient),
+		event.NewLogEmitter(os.Stdout),
+		getSweeperInterval(),
+	)
+
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		expirySweeper.Run(ctx)
+	}()
+
 	controllers = append(controllers, user.NewController(
 		user.NewService(
-			user.NewRelationalDB(
-				db.NewSQLClient(
-					getSQLClientConfig(),
-				)))))
+			user.NewRelationalDB(dbClient),
+			getMailer(),
+		)))
+
+	controllers = append(controllers, role.NewController(
+		role.NewService(
+			role.NewRelationalDB(dbClient),
+		)))
+
+	controllers = append(controllers, client.NewController(
+		client.NewService(
+			client.NewRelationalDB(dbClient),
+		)))
+
+	controllers = append(controllers, task.NewController(
+		task.NewService(
+			task.NewRelationalDB(dbClient),
+		)))
+
+	controllers = append(controllers, assignment.NewController(
+		assignment.NewService(
+			assignment.NewRelationalDB(dbClient),
+			assignment.NewLifecycle(getTaskTransitions()),
+			getTaskRoleTypes(),
+		)))
+
+	routes := make([]auth.Routes, 0, len(controllers))
+	for i := range controllers {
+		routes = append(routes, controllers[i].RequiredRoles())
+	}
+
+	// the middleware must be used before setting the routes, otherwise they are not protected
+	authorizer := auth.NewAuthorizer(auth.NewCachedLoader(auth.NewRelationalDB(dbClient), grantsCacheTTL), routes...)
+	router.Use(authorizer.Authorize)
+	router.Use(auth.Tenant)
 
 	for i := range controllers {
 		controllers[i].SetURLMapping(router)
 	}
 
-	if err := router.Run("localhost:8080"); err != nil {
-		panic(err)
+	server := &http.Server{Addr: "localhost:8080", Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			panic(err)
+		}
+	}()
+
+	<-ctx.Done()
+
+	// requests in flight and the sweeper are given the chance to finish before exiting
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		println(fmt.Sprintf("error shutting down server cause: %s", err.Error()))
+	}
+	workers.Wait()
+}
+
+// checkCallerHeaderMode refuses to start unless CALLER_HEADER_MODE is "trusted_gateway". The caller is taken from
+// auth.CallerHeader without verifying it, so the API must only be exposed behind a gateway which authenticates the
+// caller and sets that header.
+func checkCallerHeaderMode() {
+	if mode := os.Getenv("CALLER_HEADER_MODE"); mode != trustedGatewayMode {
+		panic(fmt.Sprintf("invalid CALLER_HEADER_MODE %q, the %s header is only trusted when it is %q",
+			mode, auth.CallerHeader, trustedGatewayMode))
 	}
 }
 
@@ -49,3 +155,58 @@ func getSQLClientConfig() db.Config {
 		MaxIdleConns: 2,
 	}
 }
+
+// getMigrationMode returns MIGRATION_MODE, pending migrations are applied at startup unless it is "check".
+func getMigrationMode() string {
+	if mode := os.Getenv("MIGRATION_MODE"); mode != "" {
+		return mode
+	}
+	return migration.ModeApply
+}
+
+// getSweeperInterval returns SWEEPER_INTERVAL, as "30s" or "5m", the time between two sweeps of expired grants.
+func getSweeperInterval() time.Duration {
+	if value := os.Getenv("SWEEPER_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			panic(fmt.Sprintf("invalid SWEEPER_INTERVAL %q", value))
+		}
+		return interval
+	}
+	return defaultSweeperInterval
+}
+
+// getTaskTransitions returns TASK_TRANSITIONS, a JSON object overriding the allowed status changes by task type, as
+// {"bug": {"done": ["in_progress"]}}. The statuses given replace their default targets, the others keep them. Every
+// type follows the default lifecycle when it is not set.
+func getTaskTransitions() map[string]assignment.Transitions {
+	if value := os.Getenv("TASK_TRANSITIONS"); value != "" {
+		overrides, err := assignment.ParseTransitions(value)
+		if err != nil {
+			panic(fmt.Sprintf("invalid TASK_TRANSITIONS: %s", err.Error()))
+		}
+		return overrides
+	}
+	return nil
+}
+
+// getTaskRoleTypes returns TASK_ROLE_TYPES, a JSON object with the role type a user needs for being assigned tasks of
+// a type, as {"bug": "developer"}. Tasks of any type can be assigned to any user when it is not set.
+func getTaskRoleTypes() map[string]string {
+	if value := os.Getenv("TASK_ROLE_TYPES"); value != "" {
+		roleTypes, err := assignment.ParseRoleTypes(value)
+		if err != nil {
+			panic(fmt.Sprintf("invalid TASK_ROLE_TYPES: %s", err.Error()))
+		}
+		return roleTypes
+	}
+	return nil
+}
+
+// getMailer returns a mailer writing to MAIL_OUTBOX_FILE when it is set, otherwise messages are kept in memory.
+func getMailer() mail.Mailer {
+	if path := os.Getenv("MAIL_OUTBOX_FILE"); path != "" {
+		return mail.NewFileMailer(path)
+	}
+	return mail.NewOutbox()
+}
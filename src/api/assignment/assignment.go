@@ -0,0 +1,221 @@
+package assignment
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Statuses of the lifecycle of a task assigned to a user. A task is assigned as pending.
+const (
+	StatusPending    = "pending"
+	StatusInProgress = "in_progress"
+	StatusBlocked    = "blocked"
+	StatusDone       = "done"
+	StatusCancelled  = "cancelled"
+)
+
+const (
+	defaultColumnLimit = 20
+	maxColumnLimit     = 100
+)
+
+// boardStatuses are the columns every board has, in the order they are shown.
+var boardStatuses = []string{StatusPending, StatusInProgress, StatusBlocked, StatusDone, StatusCancelled}
+
+// UserTask is a task assigned to a user for a client.
+type UserTask struct {
+	ID          int64     `json:"user_task_id"`
+	UserID      int64     `json:"user_id"`
+	TaskID      int64     `json:"task_id"`
+	TaskName    string    `json:"task_name"`
+	TaskType    string    `json:"task_type"`
+	ClientID    int64     `json:"client_id"`
+	Status      string    `json:"status"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+func (ut UserTask) isEmpty() bool {
+	return ut.ID == 0
+}
+
+type AssignTaskRequest struct {
+	TaskID   int64 `json:"task_id" binding:"required"`
+	ClientID int64 `json:"client_id" binding:"required"`
+}
+
+type TransitionRequest struct {
+	Status  string  `json:"status" binding:"required"`
+	Comment *string `json:"comment"`
+}
+
+// StatusChange is a change of the status of a user task made by actor. The first change of a task is its assignment,
+// which has no previous status.
+type StatusChange struct {
+	ID          int64     `json:"id"`
+	Actor       string    `json:"actor"`
+	FromStatus  *string   `json:"from_status"`
+	ToStatus    string    `json:"to_status"`
+	Comment     *string   `json:"comment"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+// StatusTime is the time a user task has spent in a status, added up when it has been in it more than once.
+type StatusTime struct {
+	Status  string `json:"status"`
+	Seconds int64  `json:"seconds"`
+}
+
+// Timeline is the history of the status of a user task.
+type Timeline struct {
+	UserTask     UserTask       `json:"user_task"`
+	Changes      []StatusChange `json:"changes"`
+	TimeInStatus []StatusTime   `json:"time_in_status"`
+}
+
+// Assignee summarizes the user a task is assigned to.
+type Assignee struct {
+	UserID   int64  `json:"user_id"`
+	UserName string `json:"user_name"`
+	Alias    string `json:"alias"`
+}
+
+// BoardCard is a user task shown in a column of the board.
+type BoardCard struct {
+	ID          int64     `json:"user_task_id"`
+	TaskID      int64     `json:"task_id"`
+	TaskName    string    `json:"task_name"`
+	TaskType    string    `json:"task_type"`
+	Assignee    Assignee  `json:"assignee"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+// BoardColumn keeps the user tasks in a status. Count is the number of tasks in the status, which is greater than
+// the number of cards when the column has been limited.
+type BoardColumn struct {
+	Status string      `json:"status"`
+	Count  int         `json:"count"`
+	Cards  []BoardCard `json:"cards"`
+}
+
+// Board groups the user tasks of a client by status.
+type Board struct {
+	ClientID int64         `json:"client_id"`
+	Columns  []BoardColumn `json:"columns"`
+}
+
+// BoardFilter limits the tasks shown in a board to a task type or an assignee, and the cards of each column to Limit.
+type BoardFilter struct {
+	TaskType   string
+	AssigneeID int64
+	Limit      int
+}
+
+// normalize returns the filter with its limit bounded.
+func (f BoardFilter) normalize() BoardFilter {
+	if f.Limit <= 0 {
+		f.Limit = defaultColumnLimit
+	}
+	if f.Limit > maxColumnLimit {
+		f.Limit = maxColumnLimit
+	}
+	return f
+}
+
+// boardRow is a card of the board together with its status and the number of tasks in that status.
+type boardRow struct {
+	card   BoardCard
+	status string
+	count  int
+}
+
+// openStatuses are the statuses counted as open in a workload report, in the order they are exported.
+var openStatuses = []string{StatusPending, StatusInProgress, StatusBlocked}
+
+// WorkloadFilter selects the client of a workload report and the window, From inclusive and To exclusive, in which
+// completed tasks are counted.
+type WorkloadFilter struct {
+	ClientID int64
+	From     time.Time
+	To       time.Time
+}
+
+// UserWorkload is the workload of a user for a client. OpenTasks counts the tasks of the user in each open status at
+// the time of the report, and Completed the tasks done in the window of the report, which took
+// AverageCompletionSeconds on average since they were assigned.
+type UserWorkload struct {
+	Assignee
+	OpenTasks                map[string]int `json:"open_tasks"`
+	Completed                int            `json:"completed"`
+	AverageCompletionSeconds *int64         `json:"average_completion_seconds"`
+}
+
+// WorkloadReport keeps the workload of every user who has open tasks for the client or completed any in the window.
+type WorkloadReport struct {
+	ClientID int64          `json:"client_id"`
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Users    []UserWorkload `json:"users"`
+}
+
+// openTasksRow is the number of tasks of an assignee in an open status.
+type openTasksRow struct {
+	assignee Assignee
+	status   string
+	count    int
+}
+
+// completionsRow is the number of tasks an assignee completed in a window and the average seconds they took.
+type completionsRow struct {
+	assignee       Assignee
+	completed      int
+	averageSeconds float64
+}
+
+// ruleError is a rule broken by a request, its code lets callers tell the rules apart without parsing the message.
+type ruleError struct {
+	code    string
+	message string
+}
+
+func (e ruleError) Error() string {
+	return e.message
+}
+
+// user keeps the fields of a user needed for assigning a task to it.
+type user struct {
+	ID     int64
+	Active bool
+}
+
+func (u user) isEmpty() bool {
+	return u.ID == 0
+}
+
+// task keeps the fields of a task needed for assigning it.
+type task struct {
+	ID     int64
+	Type   string
+	Active bool
+}
+
+func (t task) isEmpty() bool {
+	return t.ID == 0
+}
+
+// ParseRoleTypes reads the role type required by task type from a JSON object, as {"bug": "developer"}. Users are
+// assigned tasks of those types only when one of their effective roles has the required type.
+func ParseRoleTypes(value string) (map[string]string, error) {
+	var roleTypes map[string]string
+	if err := json.Unmarshal([]byte(value), &roleTypes); err != nil {
+		return nil, fmt.Errorf("role types are not valid: %w", err)
+	}
+
+	for taskType, roleType := range roleTypes {
+		if roleType == "" {
+			return nil, fmt.Errorf("role type of %s is empty", taskType)
+		}
+	}
+
+	return roleTypes, nil
+}
@@ -0,0 +1,47 @@
+package assignment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRoleTypes(t *testing.T) {
+	type test struct {
+		name          string
+		value         string
+		expected      map[string]string
+		expectedError string
+	}
+
+	tests := []test{
+		{
+			name:          "value is not json",
+			value:         "bug=developer",
+			expectedError: "role types are not valid: invalid character 'b' looking for beginning of value",
+		},
+		{
+			name:          "role type is empty",
+			value:         `{"bug": ""}`,
+			expectedError: "role type of bug is empty",
+		},
+		{
+			name:     "happy case",
+			value:    `{"bug": "developer", "hr": "admin"}`,
+			expected: map[string]string{"bug": "developer", "hr": "admin"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := ParseRoleTypes(test.value)
+
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
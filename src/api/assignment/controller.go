@@ -0,0 +1,371 @@
+package assignment
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maria/src/api/auth"
+	// aliased since task is the assignment view of a task
+	tasks "maria/src/api/task"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	userIDMissedError     = newBadRequestResponse("user_id param is missed")
+	userTaskIDMissedError = newBadRequestResponse("user_task_id param is missed")
+	clientIDMissedError   = newBadRequestResponse("client_id param is missed")
+)
+
+type Controller struct {
+	service       Service
+	integerParser func(s string, base int, bitSize int) (i int64, err error)
+}
+
+func NewController(service Service) Controller {
+	return Controller{
+		service:       service,
+		integerParser: strconv.ParseInt,
+	}
+}
+
+// PostTask assigns a task to the user for a client.
+func (c Controller) PostTask(ctx *gin.Context) {
+	var request AssignTaskRequest
+
+	userID, ok := c.parseUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	userTask, err := c.service.assignTask(auth.Context(ctx), userID, request)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userTask)
+}
+
+// PostTransition moves the user task to the status given in the body.
+func (c Controller) PostTransition(ctx *gin.Context) {
+	var request TransitionRequest
+
+	userID, ok := c.parseUserID(ctx)
+	if !ok {
+		return
+	}
+
+	userTaskID, ok := c.parseUserTaskID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	userTask, err := c.service.transition(auth.Context(ctx), userID, userTaskID, request)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userTask)
+}
+
+// GetTimeline returns the status changes of the user task together with the time spent in each status.
+func (c Controller) GetTimeline(ctx *gin.Context) {
+	userID, ok := c.parseUserID(ctx)
+	if !ok {
+		return
+	}
+
+	userTaskID, ok := c.parseUserTaskID(ctx)
+	if !ok {
+		return
+	}
+
+	timeline, err := c.service.getTimeline(auth.Context(ctx), userID, userTaskID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, timeline)
+}
+
+// GetBoard returns the user tasks of the client grouped by status. The board can be limited to a task type and an
+// assignee with the type and assignee query params, and limit bounds the cards of each column. The type must follow
+// the rule of the task types.
+func (c Controller) GetBoard(ctx *gin.Context) {
+	param := ctx.Param("client_id")
+	if param == "" {
+		ctx.JSON(http.StatusBadRequest, clientIDMissedError)
+		return
+	}
+
+	clientID, err := c.integerParser(param, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	filter, err := parseBoardFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	board, err := c.service.getBoard(auth.Context(ctx), clientID, filter)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, board)
+}
+
+func parseBoardFilter(ctx *gin.Context) (BoardFilter, error) {
+	var (
+		filter = BoardFilter{TaskType: ctx.Query("type")}
+		err    error
+	)
+
+	if _, ok := ctx.GetQuery("type"); ok {
+		if err = tasks.ValidateType(filter.TaskType); err != nil {
+			return filter, err
+		}
+	}
+
+	if value, ok := ctx.GetQuery("assignee"); ok {
+		if filter.AssigneeID, err = strconv.ParseInt(value, 10, 64); err != nil || filter.AssigneeID <= 0 {
+			return filter, errors.New("assignee must be a positive integer")
+		}
+	}
+
+	if value, ok := ctx.GetQuery("limit"); ok {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit <= 0 || filter.Limit > maxColumnLimit {
+			return filter, fmt.Errorf("limit must be an integer between 1 and %d", maxColumnLimit)
+		}
+	}
+
+	return filter, nil
+}
+
+// GetWorkload returns the workload of the users of the client_id query param, counting the tasks completed from the
+// from query param to the to query param. The report is written as JSON, or as CSV when format query param is csv.
+func (c Controller) GetWorkload(ctx *gin.Context) {
+	filter, err := parseWorkloadFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	format := ctx.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse("format must be json or csv"))
+		return
+	}
+
+	report, err := c.service.getWorkload(auth.Context(ctx), filter)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	if format == "json" {
+		ctx.JSON(http.StatusOK, report)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", `attachment; filename="workload.csv"`)
+	ctx.Status(http.StatusOK)
+	if err = writeWorkloadCSV(ctx.Writer, report); err != nil {
+		// the response is already being written, so it can only be left incomplete
+		_ = ctx.Error(err)
+	}
+}
+
+func parseWorkloadFilter(ctx *gin.Context) (WorkloadFilter, error) {
+	var (
+		filter WorkloadFilter
+		err    error
+	)
+
+	if filter.ClientID, err = strconv.ParseInt(ctx.Query("client_id"), 10, 64); err != nil || filter.ClientID <= 0 {
+		return filter, errors.New("client_id must be a positive integer")
+	}
+
+	for _, dateParam := range []struct {
+		name  string
+		field *time.Time
+	}{
+		{name: "from", field: &filter.From},
+		{name: "to", field: &filter.To},
+	} {
+		if *dateParam.field, err = parseDate(ctx.Query(dateParam.name)); err != nil {
+			return filter, fmt.Errorf("%s must be a date (YYYY-MM-DD) or a RFC 3339 timestamp", dateParam.name)
+		}
+	}
+
+	if !filter.From.Before(filter.To) {
+		return filter, errors.New("from must be before to")
+	}
+
+	return filter, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// writeWorkloadCSV writes a row by user with a column for each open status, the average completion is left empty
+// for users who completed no task.
+func writeWorkloadCSV(w io.Writer, report WorkloadReport) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"user_id", "user_name", "alias"}
+	header = append(header, openStatuses...)
+	header = append(header, "completed", "average_completion_seconds")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, workload := range report.Users {
+		record := []string{strconv.FormatInt(workload.UserID, 10), workload.UserName, workload.Alias}
+		for _, status := range openStatuses {
+			record = append(record, strconv.Itoa(workload.OpenTasks[status]))
+		}
+		average := ""
+		if workload.AverageCompletionSeconds != nil {
+			average = strconv.FormatInt(*workload.AverageCompletionSeconds, 10)
+		}
+		record = append(record, strconv.Itoa(workload.Completed), average)
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// respondError writes the response of a service error. Broken rules are answered with their code, as not found when
+// the element they refer to does not exist and as bad request otherwise.
+func respondError(ctx *gin.Context, err error) {
+	var rule ruleError
+	if !errors.As(err, &rule) {
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	switch rule {
+	case userNotFoundError, clientNotFoundError, taskNotFoundError, userTaskNotFoundError:
+		ctx.JSON(http.StatusNotFound, newRuleErrorResponse(http.StatusNotFound, rule.code, err))
+	default:
+		ctx.JSON(http.StatusBadRequest, newRuleErrorResponse(http.StatusBadRequest, rule.code, err))
+	}
+}
+
+// parseUserID returns user_id param. When it is not valid the bad request response is written and false is returned.
+func (c Controller) parseUserID(ctx *gin.Context) (int64, bool) {
+	param := ctx.Param("user_id")
+	if param == "" {
+		ctx.JSON(http.StatusBadRequest, userIDMissedError)
+		return 0, false
+	}
+
+	userID, err := c.integerParser(param, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// parseUserTaskID returns user_task_id param. When it is not valid the bad request response is written and false is
+// returned.
+func (c Controller) parseUserTaskID(ctx *gin.Context) (int64, bool) {
+	param := ctx.Param("user_task_id")
+	if param == "" {
+		ctx.JSON(http.StatusBadRequest, userTaskIDMissedError)
+		return 0, false
+	}
+
+	userTaskID, err := c.integerParser(param, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return 0, false
+	}
+
+	return userTaskID, true
+}
+
+func (c Controller) SetURLMapping(router *gin.Engine) {
+	router.POST("/user/:user_id/tasks", c.PostTask)
+	router.POST("/user/:user_id/tasks/:user_task_id/transition", c.PostTransition)
+	router.GET("/user/:user_id/tasks/:user_task_id/timeline", c.GetTimeline)
+	router.GET("/client/:client_id/board", c.GetBoard)
+	router.GET("/reports/workload", c.GetWorkload)
+}
+
+// RequiredRoles declares the role types or permissions required by the routes set in SetURLMapping.
+func (c Controller) RequiredRoles() auth.Routes {
+	var (
+		readers = auth.Requirement{
+			RoleTypes:   []string{auth.AdminRoleType, auth.ViewerRoleType},
+			Permissions: []string{auth.PermissionTaskRead},
+		}
+		assigners = auth.Requirement{
+			RoleTypes:   []string{auth.AdminRoleType},
+			Permissions: []string{auth.PermissionTaskAssign},
+		}
+	)
+
+	return auth.Routes{
+		auth.Route(http.MethodPost, "/user/:user_id/tasks"):                          assigners,
+		auth.Route(http.MethodPost, "/user/:user_id/tasks/:user_task_id/transition"): assigners,
+		auth.Route(http.MethodGet, "/user/:user_id/tasks/:user_task_id/timeline"):    readers,
+		auth.Route(http.MethodGet, "/client/:client_id/board"):                       readers,
+		auth.Route(http.MethodGet, "/reports/workload"):                              readers,
+	}
+}
+
+func newBadRequestResponse(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     message,
+		"status_code": http.StatusBadRequest,
+	}
+}
+
+func newRuleErrorResponse(statusCode int, code string, err error) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     err.Error(),
+		"code":        code,
+		"status_code": statusCode,
+	}
+}
+
+func newInternalServerError(cause error) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     "internal server error",
+		"cause":       cause,
+		"status_code": http.StatusInternalServerError,
+	}
+}
@@ -0,0 +1,848 @@
+package assignment
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maria/src/api/auth"
+	"maria/src/api/util"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ControllerSuite struct {
+	suite.Suite
+}
+
+func TestControllerSuite(t *testing.T) {
+	suite.Run(t, new(ControllerSuite))
+}
+
+func (c *ControllerSuite) BeforeTest(suiteName, testName string) {
+}
+
+func (c *ControllerSuite) AfterTest(suiteName, testName string) {
+}
+
+func (c *ControllerSuite) TestPostTask() {
+	const (
+		bindMSGError = "Key: 'AssignTaskRequest.ClientID' Error:Field validation for 'ClientID' failed on the 'required' tag"
+	)
+	var (
+		userID          = int64(20)
+		customError     = errors.New("custom error")
+		missingRoleType = fmt.Errorf("%w: bug tasks require developer", missingRoleTypeError)
+		request         = AssignTaskRequest{TaskID: 30, ClientID: 10}
+		userTask        = UserTask{ID: 1, UserID: userID, TaskID: 30, ClientID: 10, Status: StatusPending}
+	)
+
+	type test struct {
+		name           string
+		param          string
+		body           AssignTaskRequest
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "user_id param missed",
+			param:        "",
+			body:         request,
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_id param is missed")),
+		},
+		{
+			name:         "body fields missed",
+			param:        "20",
+			body:         AssignTaskRequest{TaskID: 30},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(bindMSGError)),
+		},
+		{
+			name:           "client not found",
+			param:          "20",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAssignTaskMock(UserTask{}, clientNotFoundError, userID, request),
+			expectedCode:   http.StatusNotFound,
+			expectedBody: util.RenderToJSON(
+				newRuleErrorResponse(http.StatusNotFound, "client_not_found", clientNotFoundError)),
+		},
+		{
+			name:           "user is not a member of the client",
+			param:          "20",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAssignTaskMock(UserTask{}, membershipNotFoundError, userID, request),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(
+				newRuleErrorResponse(http.StatusBadRequest, "membership_not_found", membershipNotFoundError)),
+		},
+		{
+			name:           "user does not have the role type",
+			param:          "20",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAssignTaskMock(UserTask{}, missingRoleType, userID, request),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(
+				newRuleErrorResponse(http.StatusBadRequest, "role_type_missing", missingRoleType)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "20",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAssignTaskMock(UserTask{}, customError, userID, request),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "20",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAssignTaskMock(userTask, nil, userID, request),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(userTask),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"user_id": test.param}, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.PostTask(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestPostTransition() {
+	var (
+		userID      = int64(20)
+		userTaskID  = int64(1)
+		customError = errors.New("custom error")
+		illegal     = fmt.Errorf("%w from pending to done for hr tasks", illegalTransitionError)
+		request     = TransitionRequest{Status: StatusInProgress}
+		userTask    = UserTask{ID: userTaskID, UserID: userID, Status: StatusInProgress}
+	)
+
+	type test struct {
+		name           string
+		params         map[string]string
+		body           TransitionRequest
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "user_id param missed",
+			params:       map[string]string{"user_id": "", "user_task_id": "1"},
+			body:         request,
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_id param is missed")),
+		},
+		{
+			name:         "user_task_id param missed",
+			params:       map[string]string{"user_id": "20", "user_task_id": ""},
+			body:         request,
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_task_id param is missed")),
+		},
+		{
+			name:   "it cannot parse user_task_id param",
+			params: map[string]string{"user_id": "20", "user_task_id": "word"},
+			body:   request,
+			controller: Controller{
+				integerParser: func(s string, base int, bitSize int) (i int64, err error) {
+					if s == "word" {
+						return 0, customError
+					}
+					return userID, nil
+				},
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(customError.Error())),
+		},
+		{
+			name:         "status missed",
+			params:       map[string]string{"user_id": "20", "user_task_id": "1"},
+			body:         TransitionRequest{},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(
+				"Key: 'TransitionRequest.Status' Error:Field validation for 'Status' failed on the 'required' tag")),
+		},
+		{
+			name:       "user task not found",
+			params:     map[string]string{"user_id": "20", "user_task_id": "1"},
+			body:       request,
+			controller: NewController(newServiceMock()),
+			applyMockCalls: setServiceTransitionMock(
+				UserTask{}, userTaskNotFoundError, userID, userTaskID, request),
+			expectedCode: http.StatusNotFound,
+			expectedBody: util.RenderToJSON(
+				newRuleErrorResponse(http.StatusNotFound, "user_task_not_found", userTaskNotFoundError)),
+		},
+		{
+			name:       "status is unknown",
+			params:     map[string]string{"user_id": "20", "user_task_id": "1"},
+			body:       TransitionRequest{Status: "open"},
+			controller: NewController(newServiceMock()),
+			applyMockCalls: setServiceTransitionMock(
+				UserTask{}, unknownStatusError, userID, userTaskID, TransitionRequest{Status: "open"}),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(
+				newRuleErrorResponse(http.StatusBadRequest, "status_unknown", unknownStatusError)),
+		},
+		{
+			name:           "transition is not allowed",
+			params:         map[string]string{"user_id": "20", "user_task_id": "1"},
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceTransitionMock(UserTask{}, illegal, userID, userTaskID, request),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(
+				newRuleErrorResponse(http.StatusBadRequest, "transition_not_allowed", illegal)),
+		},
+		{
+			name:           "service return internal error",
+			params:         map[string]string{"user_id": "20", "user_task_id": "1"},
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceTransitionMock(UserTask{}, customError, userID, userTaskID, request),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			params:         map[string]string{"user_id": "20", "user_task_id": "1"},
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceTransitionMock(userTask, nil, userID, userTaskID, request),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(userTask),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(test.params, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.PostTransition(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestGetTimeline() {
+	var (
+		userID      = int64(20)
+		userTaskID  = int64(1)
+		customError = errors.New("custom error")
+		timeline    = Timeline{
+			UserTask:     UserTask{ID: userTaskID, UserID: userID, Status: StatusPending},
+			Changes:      []StatusChange{{ID: 1, Actor: "admin", ToStatus: StatusPending}},
+			TimeInStatus: []StatusTime{{Status: StatusPending, Seconds: 60}},
+		}
+	)
+
+	type test struct {
+		name           string
+		params         map[string]string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "user_id param missed",
+			params:       map[string]string{"user_id": "", "user_task_id": "1"},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_id param is missed")),
+		},
+		{
+			name:         "user_task_id param missed",
+			params:       map[string]string{"user_id": "20", "user_task_id": ""},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_task_id param is missed")),
+		},
+		{
+			name:           "user task not found",
+			params:         map[string]string{"user_id": "20", "user_task_id": "1"},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetTimelineMock(Timeline{}, userTaskNotFoundError, userID, userTaskID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody: util.RenderToJSON(
+				newRuleErrorResponse(http.StatusNotFound, "user_task_not_found", userTaskNotFoundError)),
+		},
+		{
+			name:           "service return internal error",
+			params:         map[string]string{"user_id": "20", "user_task_id": "1"},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetTimelineMock(Timeline{}, customError, userID, userTaskID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			params:         map[string]string{"user_id": "20", "user_task_id": "1"},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetTimelineMock(timeline, nil, userID, userTaskID),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(timeline),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(test.params, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.GetTimeline(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestGetBoard() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+		board       = Board{
+			ClientID: clientID,
+			Columns: []BoardColumn{{
+				Status: StatusPending,
+				Count:  1,
+				Cards:  []BoardCard{{ID: 1, TaskID: 30, Assignee: Assignee{UserID: 20, UserName: "john"}}},
+			}},
+		}
+	)
+
+	type test struct {
+		name           string
+		param          string
+		queryString    string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "client_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("client_id param is missed")),
+		},
+		{
+			name:         "assignee is not a positive integer",
+			param:        "10",
+			queryString:  "assignee=-1",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("assignee must be a positive integer")),
+		},
+		{
+			name:         "type is not valid",
+			param:        "10",
+			queryString:  "type=Bug",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(`type "Bug" must start with a lower case letter ` +
+				"followed by lower case letters, digits or underscores")),
+		},
+		{
+			name:         "limit out of range",
+			param:        "10",
+			queryString:  "limit=101",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("limit must be an integer between 1 and 100")),
+		},
+		{
+			name:           "client not found",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetBoardMock(Board{}, clientNotFoundError, clientID, BoardFilter{}),
+			expectedCode:   http.StatusNotFound,
+			expectedBody: util.RenderToJSON(
+				newRuleErrorResponse(http.StatusNotFound, "client_not_found", clientNotFoundError)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetBoardMock(Board{}, customError, clientID, BoardFilter{}),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:        "happy case",
+			param:       "10",
+			queryString: "type=bug&assignee=20&limit=5",
+			controller:  NewController(newServiceMock()),
+			applyMockCalls: setServiceGetBoardMock(
+				board, nil, clientID, BoardFilter{TaskType: "bug", AssigneeID: 20, Limit: 5}),
+			expectedCode: http.StatusOK,
+			expectedBody: util.RenderToJSON(board),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"client_id": test.param}, test.queryString, nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.GetBoard(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestGetWorkload() {
+	var (
+		customError = errors.New("custom error")
+		average     = int64(5400)
+		filter      = WorkloadFilter{
+			ClientID: 10,
+			From:     time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:       time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC),
+		}
+		report = WorkloadReport{
+			ClientID: filter.ClientID,
+			From:     filter.From,
+			To:       filter.To,
+			Users: []UserWorkload{
+				{
+					Assignee:                 Assignee{UserID: 20, UserName: "john, jr", Alias: "john"},
+					OpenTasks:                map[string]int{StatusPending: 1, StatusInProgress: 2, StatusBlocked: 0},
+					Completed:                3,
+					AverageCompletionSeconds: &average,
+				},
+				{
+					Assignee:  Assignee{UserID: 21, UserName: "jane", Alias: "jane"},
+					OpenTasks: map[string]int{StatusPending: 0, StatusInProgress: 0, StatusBlocked: 4},
+				},
+			},
+		}
+	)
+
+	type test struct {
+		name                string
+		queryString         string
+		controller          Controller
+		applyMockCalls      func(controller *Controller) (func(t *testing.T), error)
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}
+
+	tests := []test{
+		{
+			name:                "client_id is not valid",
+			queryString:         "from=2022-01-01&to=2022-02-01",
+			controller:          NewController(newServiceMock()),
+			expectedCode:        http.StatusBadRequest,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        util.RenderToJSON(newBadRequestResponse("client_id must be a positive integer")),
+		},
+		{
+			name:                "from is not valid",
+			queryString:         "client_id=10&from=yesterday&to=2022-02-01",
+			controller:          NewController(newServiceMock()),
+			expectedCode:        http.StatusBadRequest,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody: util.RenderToJSON(
+				newBadRequestResponse("from must be a date (YYYY-MM-DD) or a RFC 3339 timestamp")),
+		},
+		{
+			name:                "to is missed",
+			queryString:         "client_id=10&from=2022-01-01",
+			controller:          NewController(newServiceMock()),
+			expectedCode:        http.StatusBadRequest,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody: util.RenderToJSON(
+				newBadRequestResponse("to must be a date (YYYY-MM-DD) or a RFC 3339 timestamp")),
+		},
+		{
+			name:                "from is not before to",
+			queryString:         "client_id=10&from=2022-02-01&to=2022-02-01T00:00:00Z",
+			controller:          NewController(newServiceMock()),
+			expectedCode:        http.StatusBadRequest,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        util.RenderToJSON(newBadRequestResponse("from must be before to")),
+		},
+		{
+			name:                "format is not valid",
+			queryString:         "client_id=10&from=2022-01-01&to=2022-02-01&format=xml",
+			controller:          NewController(newServiceMock()),
+			expectedCode:        http.StatusBadRequest,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        util.RenderToJSON(newBadRequestResponse("format must be json or csv")),
+		},
+		{
+			name:                "client not found",
+			queryString:         "client_id=10&from=2022-01-01&to=2022-02-01&format=csv",
+			controller:          NewController(newServiceMock()),
+			applyMockCalls:      setServiceGetWorkloadMock(WorkloadReport{}, clientNotFoundError, filter),
+			expectedCode:        http.StatusNotFound,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody: util.RenderToJSON(
+				newRuleErrorResponse(http.StatusNotFound, "client_not_found", clientNotFoundError)),
+		},
+		{
+			name:                "service return internal error",
+			queryString:         "client_id=10&from=2022-01-01&to=2022-02-01",
+			controller:          NewController(newServiceMock()),
+			applyMockCalls:      setServiceGetWorkloadMock(WorkloadReport{}, customError, filter),
+			expectedCode:        http.StatusInternalServerError,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:                "json",
+			queryString:         "client_id=10&from=2022-01-01&to=2022-02-01T00:00:00Z",
+			controller:          NewController(newServiceMock()),
+			applyMockCalls:      setServiceGetWorkloadMock(report, nil, filter),
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        util.RenderToJSON(report),
+		},
+		{
+			name:                "csv",
+			queryString:         "client_id=10&from=2022-01-01&to=2022-02-01&format=csv",
+			controller:          NewController(newServiceMock()),
+			applyMockCalls:      setServiceGetWorkloadMock(report, nil, filter),
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody: "user_id,user_name,alias,pending,in_progress,blocked,completed,average_completion_seconds\n" +
+				"20,\"john, jr\",john,1,2,0,3,5400\n" +
+				"21,jane,jane,0,0,4,0,\n",
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(nil, test.queryString, nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.GetWorkload(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedContentType, r.Header().Get("Content-Type"))
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestRequiredRoles() {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := NewController(newServiceMock())
+	controller.SetURLMapping(router)
+
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		registered[auth.Route(route.Method, route.Path)] = true
+	}
+
+	for route, requirement := range controller.RequiredRoles() {
+		assert.True(c.T(), registered[route], "%s is not registered", route)
+		assert.NotEmpty(c.T(), requirement.RoleTypes, "%s does not require any role type", route)
+		assert.NotEmpty(c.T(), requirement.Permissions, "%s does not require any permission", route)
+	}
+}
+
+func (c *ControllerSuite) TestSetURLMapping() {
+	var (
+		userID            = int64(20)
+		userTaskID        = int64(1)
+		assignRequest     = AssignTaskRequest{TaskID: 30, ClientID: 10}
+		transitionRequest = TransitionRequest{Status: StatusInProgress}
+	)
+
+	type test struct {
+		name           string
+		path           string
+		method         string
+		body           any
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+	}
+
+	tests := []test{
+		{
+			name:           "post user task",
+			path:           "/user/20/tasks",
+			method:         http.MethodPost,
+			body:           assignRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAssignTaskMock(UserTask{ID: userTaskID}, nil, userID, assignRequest),
+		},
+		{
+			name:       "post user task transition",
+			path:       "/user/20/tasks/1/transition",
+			method:     http.MethodPost,
+			body:       transitionRequest,
+			controller: NewController(newServiceMock()),
+			applyMockCalls: setServiceTransitionMock(
+				UserTask{ID: userTaskID}, nil, userID, userTaskID, transitionRequest),
+		},
+		{
+			name:           "get user task timeline",
+			path:           "/user/20/tasks/1/timeline",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetTimelineMock(Timeline{}, nil, userID, userTaskID),
+		},
+		{
+			name:           "get client board",
+			path:           "/client/10/board",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetBoardMock(Board{}, nil, int64(10), BoardFilter{}),
+		},
+		{
+			name:       "get workload report",
+			path:       "/reports/workload?client_id=10&from=2022-01-01&to=2022-02-01",
+			method:     http.MethodGet,
+			controller: NewController(newServiceMock()),
+			applyMockCalls: setServiceGetWorkloadMock(WorkloadReport{}, nil, WorkloadFilter{
+				ClientID: 10,
+				From:     time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:       time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC),
+			}),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			test.controller.SetURLMapping(router)
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			var b bytes.Buffer
+			if test.body != nil {
+				if err := json.NewEncoder(&b).Encode(test.body); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				}
+			}
+
+			req := httptest.NewRequest(test.method, test.path, &b)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(c.T(), http.StatusOK, w.Code)
+		})
+	}
+}
+
+func setServiceAssignTaskMock(
+	userTaskResponse UserTask,
+	errorResponse error,
+	userID int64,
+	request AssignTaskRequest,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.assignTask), mock.Anything, userID, request).
+			Return(userTaskResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceTransitionMock(
+	userTaskResponse UserTask,
+	errorResponse error,
+	userID, userTaskID int64,
+	request TransitionRequest,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.transition), mock.Anything, userID, userTaskID, request).
+			Return(userTaskResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceGetTimelineMock(
+	timelineResponse Timeline,
+	errorResponse error,
+	userID, userTaskID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.getTimeline), mock.Anything, userID, userTaskID).
+			Return(timelineResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceGetBoardMock(
+	boardResponse Board,
+	errorResponse error,
+	clientID int64,
+	filter BoardFilter,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.getBoard), mock.Anything, clientID, filter).
+			Return(boardResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceGetWorkloadMock(
+	reportResponse WorkloadReport,
+	errorResponse error,
+	filter WorkloadFilter,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.getWorkload), mock.Anything, filter).
+			Return(reportResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
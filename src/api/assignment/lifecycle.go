@@ -0,0 +1,123 @@
+package assignment
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Transitions maps each status to the statuses a task can be moved to from it.
+type Transitions map[string][]string
+
+// defaultTransitions is the lifecycle of the tasks whose type does not override it. Done and cancelled are final.
+var defaultTransitions = Transitions{
+	StatusPending:    {StatusInProgress, StatusBlocked, StatusCancelled},
+	StatusInProgress: {StatusBlocked, StatusDone, StatusCancelled},
+	StatusBlocked:    {StatusInProgress, StatusCancelled},
+	StatusDone:       {},
+	StatusCancelled:  {},
+}
+
+// Lifecycle decides which status changes are allowed for each task type.
+type Lifecycle struct {
+	overrides map[string]Transitions
+}
+
+// NewLifecycle returns a lifecycle where the transitions given for a task type replace the default ones of the same
+// statuses, the statuses not given keep the default transitions.
+func NewLifecycle(overrides map[string]Transitions) Lifecycle {
+	return Lifecycle{overrides: overrides}
+}
+
+// ParseTransitions reads the overrides by task type from a JSON object, as
+// {"bug": {"done": ["in_progress"]}}. The targets given for a status replace its default ones rather than being
+// added to them. Every status must be known, and every status but done and cancelled must still lead to one of them,
+// so no task can get stuck.
+func ParseTransitions(value string) (map[string]Transitions, error) {
+	var overrides map[string]Transitions
+	if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+		return nil, fmt.Errorf("transitions are not valid: %w", err)
+	}
+
+	for taskType, transitions := range overrides {
+		for from, targets := range transitions {
+			if !isStatus(from) {
+				return nil, fmt.Errorf("transitions of %s: unknown status %q", taskType, from)
+			}
+			for _, to := range targets {
+				if !isStatus(to) {
+					return nil, fmt.Errorf("transitions of %s: unknown status %q", taskType, to)
+				}
+			}
+		}
+	}
+
+	lifecycle := NewLifecycle(overrides)
+	for taskType := range overrides {
+		if status, ok := lifecycle.strandedStatus(taskType); ok {
+			return nil, fmt.Errorf("transitions of %s: status %q cannot reach %s or %s",
+				taskType, status, StatusDone, StatusCancelled)
+		}
+	}
+
+	return overrides, nil
+}
+
+// strandedStatus returns the first status of the given task type, other than done and cancelled, from which a task
+// can never reach done nor cancelled.
+func (l Lifecycle) strandedStatus(taskType string) (string, bool) {
+	for _, status := range []string{StatusPending, StatusInProgress, StatusBlocked} {
+		if !l.reachesEnd(taskType, status) {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+// reachesEnd returns whether a task of the given type can be moved from the status to done or cancelled, through
+// any number of transitions.
+func (l Lifecycle) reachesEnd(taskType, status string) bool {
+	visited := map[string]bool{status: true}
+	pending := []string{status}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		for _, target := range l.targets(taskType, current) {
+			if target == StatusDone || target == StatusCancelled {
+				return true
+			}
+			if !visited[target] {
+				visited[target] = true
+				pending = append(pending, target)
+			}
+		}
+	}
+	return false
+}
+
+// allows returns whether a task of the given type can be moved from one status to the other.
+func (l Lifecycle) allows(taskType, from, to string) bool {
+	for _, target := range l.targets(taskType, from) {
+		if target == to {
+			return true
+		}
+	}
+	return false
+}
+
+// isFinal returns whether a task of the given type cannot leave the status.
+func (l Lifecycle) isFinal(taskType, status string) bool {
+	return len(l.targets(taskType, status)) == 0
+}
+
+// targets returns the statuses a task of the given type can be moved to from the status.
+func (l Lifecycle) targets(taskType, status string) []string {
+	if targets, ok := l.overrides[taskType][status]; ok {
+		return targets
+	}
+	return defaultTransitions[status]
+}
+
+func isStatus(status string) bool {
+	_, ok := defaultTransitions[status]
+	return ok
+}
@@ -0,0 +1,100 @@
+package assignment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycleAllows(t *testing.T) {
+	lifecycle := NewLifecycle(map[string]Transitions{
+		"bug": {StatusDone: {StatusInProgress}, StatusPending: {StatusInProgress}},
+	})
+
+	type test struct {
+		name     string
+		taskType string
+		from     string
+		to       string
+		expected bool
+	}
+
+	tests := []test{
+		{name: "default transition", taskType: "hr", from: StatusPending, to: StatusInProgress, expected: true},
+		{name: "default final status", taskType: "hr", from: StatusDone, to: StatusInProgress, expected: false},
+		{name: "default skipped status", taskType: "hr", from: StatusPending, to: StatusDone, expected: false},
+		{name: "same status", taskType: "hr", from: StatusBlocked, to: StatusBlocked, expected: false},
+		{name: "unknown current status", taskType: "hr", from: "open", to: StatusDone, expected: false},
+		{name: "overridden transition", taskType: "bug", from: StatusDone, to: StatusInProgress, expected: true},
+		{name: "overridden status drops defaults", taskType: "bug", from: StatusPending, to: StatusCancelled},
+		{name: "not overridden status", taskType: "bug", from: StatusInProgress, to: StatusDone, expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, lifecycle.allows(test.taskType, test.from, test.to))
+		})
+	}
+}
+
+func TestParseTransitions(t *testing.T) {
+	type test struct {
+		name          string
+		value         string
+		expected      map[string]Transitions
+		expectedError string
+	}
+
+	tests := []test{
+		{
+			name:          "value is not json",
+			value:         "bug=done",
+			expectedError: "transitions are not valid: invalid character 'b' looking for beginning of value",
+		},
+		{
+			name:          "unknown source status",
+			value:         `{"bug": {"open": ["done"]}}`,
+			expectedError: `transitions of bug: unknown status "open"`,
+		},
+		{
+			name:          "unknown target status",
+			value:         `{"bug": {"done": ["reopened"]}}`,
+			expectedError: `transitions of bug: unknown status "reopened"`,
+		},
+		{
+			name:          "status without targets",
+			value:         `{"bug": {"pending": []}}`,
+			expectedError: `transitions of bug: status "pending" cannot reach done or cancelled`,
+		},
+		{
+			name:          "statuses only lead to each other",
+			value:         `{"bug": {"in_progress": ["blocked"], "blocked": ["in_progress"]}}`,
+			expectedError: `transitions of bug: status "in_progress" cannot reach done or cancelled`,
+		},
+		{
+			name:          "override replaces the default targets",
+			value:         `{"bug": {"blocked": ["blocked"]}}`,
+			expectedError: `transitions of bug: status "blocked" cannot reach done or cancelled`,
+		},
+		{
+			name:  "happy case",
+			value: `{"bug": {"done": ["in_progress"], "pending": ["in_progress"], "blocked": ["in_progress"]}}`,
+			expected: map[string]Transitions{
+				"bug": {StatusDone: {StatusInProgress}, StatusPending: {StatusInProgress}, StatusBlocked: {StatusInProgress}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := ParseTransitions(test.value)
+
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+}
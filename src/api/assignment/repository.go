@@ -0,0 +1,457 @@
+package assignment
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
+	"maria/src/api/db"
+)
+
+const (
+	userTasksQuery = `SELECT ut.id, ut.user_id, ut.task_id, t.task_name, t.type, ut.client_id, ut.status, ` +
+		`ut.date_created FROM user_task ut JOIN task t ON t.id = ut.task_id`
+	getUserTaskQuery          = userTasksQuery + ` WHERE ut.id = ? AND ut.user_id = ?`
+	getUserTaskForUpdateQuery = getUserTaskQuery + ` FOR UPDATE`
+	// the rows checked before assigning are share locked, so they cannot be changed until the assignment is stored
+	getTaskQuery             = `SELECT id, type, active FROM task WHERE id = ? LOCK IN SHARE MODE`
+	getUserQuery             = `SELECT id, active FROM user WHERE id = ? LOCK IN SHARE MODE`
+	getActiveMembershipQuery = `SELECT id FROM user_client WHERE user_id = ? AND client_id = ? ` +
+		`AND (date_expired IS NULL OR date_expired > NOW()) LIMIT 1 LOCK IN SHARE MODE`
+	hasClientQuery   = `SELECT EXISTS (SELECT 1 FROM client WHERE id = ?)`
+	hasRoleTypeQuery = auth.EffectiveRolesCTE +
+		`SELECT EXISTS (SELECT 1 FROM effective e JOIN role r ON r.id = e.role_id WHERE r.type = ?)`
+	insertUserTaskQuery     = `INSERT INTO user_task (user_id, task_id, client_id, status) VALUES (?, ?, ?, ?)`
+	updateStatusQuery       = `UPDATE user_task SET status = ? WHERE id = ?`
+	insertStatusChangeQuery = `INSERT INTO user_task_status_history ` +
+		`(user_task_id, actor, from_status, to_status, comment) VALUES (?, ?, ?, ?, ?)`
+	statusChangesQuery = `SELECT id, actor, from_status, to_status, comment, date_created ` +
+		`FROM user_task_status_history WHERE user_task_id = ? ORDER BY id`
+	// boardQuery numbers the tasks of each status and counts them in the same pass, so the cards of every column are
+	// limited while their count is not
+	boardQuery = `SELECT id, task_id, task_name, type, user_id, user_name, alias, date_created, status, total ` +
+		`FROM (SELECT ut.id, ut.task_id, t.task_name, t.type, u.id AS user_id, u.user_name, u.alias, ` +
+		`ut.date_created, ut.status, ` +
+		`COUNT(*) OVER (PARTITION BY ut.status) AS total, ` +
+		`ROW_NUMBER() OVER (PARTITION BY ut.status ORDER BY ut.id) AS position ` +
+		`FROM user_task ut JOIN task t ON t.id = ut.task_id JOIN user u ON u.id = ut.user_id ` +
+		`WHERE ut.client_id = ?%s) board WHERE position <= ? ORDER BY id`
+	openTasksQuery = `SELECT u.id, u.user_name, u.alias, ut.status, COUNT(*) ` +
+		`FROM user_task ut JOIN user u ON u.id = ut.user_id ` +
+		`WHERE ut.client_id = ? AND ut.status IN (?, ?, ?) ` +
+		`GROUP BY u.id, u.user_name, u.alias, ut.status ORDER BY u.id`
+	// completionsQuery takes the last time each task was done in the window, as a task reopened and done again is
+	// completed once, and measures it from the assignment, which is the change without a previous status
+	completionsQuery = `SELECT u.id, u.user_name, u.alias, COUNT(*), ` +
+		`AVG(TIMESTAMPDIFF(SECOND, assigned.date_created, completed.date_created)) ` +
+		`FROM user_task ut JOIN user u ON u.id = ut.user_id ` +
+		`JOIN (SELECT user_task_id, MAX(date_created) AS date_created FROM user_task_status_history ` +
+		`WHERE to_status = ? AND date_created >= ? AND date_created < ? GROUP BY user_task_id) completed ` +
+		`ON completed.user_task_id = ut.id ` +
+		`JOIN user_task_status_history assigned ON assigned.user_task_id = ut.id AND assigned.from_status IS NULL ` +
+		`WHERE ut.client_id = ? GROUP BY u.id, u.user_name, u.alias ORDER BY u.id`
+)
+
+type Querier interface {
+	selectByID(userTaskID, userID int64) (UserTask, error)
+	selectByIDForUpdate(userTaskID, userID int64) (UserTask, error)
+	selectTask(int64) (task, error)
+	selectUser(int64) (user, error)
+	hasActiveMembership(userID, clientID int64) (bool, error)
+	hasRoleType(userID int64, roleType string) (bool, error)
+	hasClient(clientID int64) (bool, error)
+	createUserTask(userID int64, request AssignTaskRequest, status string) (int64, error)
+	updateStatus(userTaskID int64, status string) (bool, error)
+	createStatusChange(userTaskID int64, change StatusChange) error
+	selectStatusChanges(userTaskID int64) ([]StatusChange, error)
+	selectBoard(clientID int64, filter BoardFilter) ([]boardRow, error)
+	selectOpenTasks(clientID int64) ([]openTasksRow, error)
+	selectCompletions(filter WorkloadFilter) ([]completionsRow, error)
+	record(audit.Entry) error
+}
+
+type Persister interface {
+	Querier
+	withTransaction(fn func(tx Transactioner) error) error
+}
+
+type Transactioner interface {
+	Querier
+	commit() error
+	rollback() error
+}
+
+func NewRelationalDB(client db.Client) Persister {
+	return &relationalDB{
+		client: client,
+	}
+}
+
+type relationalDB struct {
+	client db.Client
+}
+
+func (r *relationalDB) selectByID(userTaskID, userID int64) (UserTask, error) {
+	return r.selectOne(getUserTaskQuery, userTaskID, userID)
+}
+
+// selectByIDForUpdate locks the user task until the transaction ends, so its status is not changed concurrently.
+func (r *relationalDB) selectByIDForUpdate(userTaskID, userID int64) (UserTask, error) {
+	return r.selectOne(getUserTaskForUpdateQuery, userTaskID, userID)
+}
+
+func (r *relationalDB) selectOne(query string, args ...any) (UserTask, error) {
+	var userTask UserTask
+
+	if err := r.client.QueryRow(query, args...).Scan(
+		&userTask.ID,
+		&userTask.UserID,
+		&userTask.TaskID,
+		&userTask.TaskName,
+		&userTask.TaskType,
+		&userTask.ClientID,
+		&userTask.Status,
+		&userTask.DateCreated,
+	); err != nil {
+		return userTask, db.ScanError(err, query)
+	}
+
+	return userTask, nil
+}
+
+func (r *relationalDB) selectTask(taskID int64) (task, error) {
+	var t task
+
+	if err := r.client.QueryRow(getTaskQuery, taskID).Scan(&t.ID, &t.Type, &t.Active); err != nil {
+		return t, db.ScanError(err, getTaskQuery)
+	}
+
+	return t, nil
+}
+
+func (r *relationalDB) selectUser(userID int64) (user, error) {
+	var u user
+
+	if err := r.client.QueryRow(getUserQuery, userID).Scan(&u.ID, &u.Active); err != nil {
+		return u, db.ScanError(err, getUserQuery)
+	}
+
+	return u, nil
+}
+
+// hasActiveMembership returns whether the user is a member of the client whose membership has not expired.
+func (r *relationalDB) hasActiveMembership(userID, clientID int64) (bool, error) {
+	var membershipID int64
+
+	if err := r.client.QueryRow(getActiveMembershipQuery, userID, clientID).Scan(&membershipID); err != nil {
+		return false, db.ScanError(err, getActiveMembershipQuery)
+	}
+
+	return membershipID != 0, nil
+}
+
+// hasRoleType returns whether any effective role of the user, granted or inherited, has the role type.
+func (r *relationalDB) hasRoleType(userID int64, roleType string) (bool, error) {
+	var has bool
+
+	if err := r.client.QueryRow(hasRoleTypeQuery, userID, roleType).Scan(&has); err != nil {
+		return false, db.ScanError(err, hasRoleTypeQuery)
+	}
+
+	return has, nil
+}
+
+// hasClient returns whether the client exists, active or not.
+func (r *relationalDB) hasClient(clientID int64) (bool, error) {
+	var has bool
+
+	if err := r.client.QueryRow(hasClientQuery, clientID).Scan(&has); err != nil {
+		return false, db.ScanError(err, hasClientQuery)
+	}
+
+	return has, nil
+}
+
+func (r *relationalDB) createUserTask(userID int64, request AssignTaskRequest, status string) (int64, error) {
+	result, err := r.client.Exec(insertUserTaskQuery, userID, request.TaskID, request.ClientID, status)
+	if err != nil {
+		return 0, db.ExecError(err, insertUserTaskQuery)
+	}
+
+	userTaskID, err := result.LastInsertId()
+	if err != nil {
+		return 0, db.LastInsertedError(err, insertUserTaskQuery)
+	}
+
+	return userTaskID, nil
+}
+
+func (r *relationalDB) updateStatus(userTaskID int64, status string) (bool, error) {
+	result, err := r.client.Exec(updateStatusQuery, status, userTaskID)
+	if err != nil {
+		return false, db.ExecError(err, updateStatusQuery)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, db.RowsAffectedError(err, updateStatusQuery)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *relationalDB) createStatusChange(userTaskID int64, change StatusChange) error {
+	if _, err := r.client.Exec(
+		insertStatusChangeQuery,
+		userTaskID,
+		change.Actor,
+		change.FromStatus,
+		change.ToStatus,
+		change.Comment,
+	); err != nil {
+		return db.ExecError(err, insertStatusChangeQuery)
+	}
+	return nil
+}
+
+// selectStatusChanges returns the status changes of the user task from the oldest to the newest.
+func (r *relationalDB) selectStatusChanges(userTaskID int64) ([]StatusChange, error) {
+	var (
+		rows    *sql.Rows
+		err     error
+		changes []StatusChange
+	)
+
+	if rows, err = r.client.Query(statusChangesQuery, userTaskID); err != nil {
+		return nil, db.QueryError(err, statusChangesQuery)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var change StatusChange
+		if err = rows.Scan(
+			&change.ID,
+			&change.Actor,
+			&change.FromStatus,
+			&change.ToStatus,
+			&change.Comment,
+			&change.DateCreated,
+		); err != nil {
+			return nil, db.ScanError(err, statusChangesQuery)
+		}
+		changes = append(changes, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, statusChangesQuery)
+	}
+
+	return changes, nil
+}
+
+// selectBoard returns the first filter.Limit tasks of each status of the client in a single query.
+func (r *relationalDB) selectBoard(clientID int64, filter BoardFilter) ([]boardRow, error) {
+	var (
+		rows      *sql.Rows
+		err       error
+		boardRows []boardRow
+	)
+
+	query, args := buildBoardQuery(clientID, filter)
+	if rows, err = r.client.Query(query, args...); err != nil {
+		return nil, db.QueryError(err, query)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var row boardRow
+		if err = rows.Scan(
+			&row.card.ID,
+			&row.card.TaskID,
+			&row.card.TaskName,
+			&row.card.TaskType,
+			&row.card.Assignee.UserID,
+			&row.card.Assignee.UserName,
+			&row.card.Assignee.Alias,
+			&row.card.DateCreated,
+			&row.status,
+			&row.count,
+		); err != nil {
+			return nil, db.ScanError(err, query)
+		}
+		boardRows = append(boardRows, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, query)
+	}
+
+	return boardRows, nil
+}
+
+func buildBoardQuery(clientID int64, filter BoardFilter) (string, []any) {
+	var (
+		conditions string
+		args       = []any{clientID}
+	)
+
+	if filter.TaskType != "" {
+		conditions += " AND t.type = ?"
+		args = append(args, filter.TaskType)
+	}
+	if filter.AssigneeID != 0 {
+		conditions += " AND ut.user_id = ?"
+		args = append(args, filter.AssigneeID)
+	}
+
+	return fmt.Sprintf(boardQuery, conditions), append(args, filter.Limit)
+}
+
+// selectOpenTasks returns the number of tasks of the client in each open status by assignee.
+func (r *relationalDB) selectOpenTasks(clientID int64) ([]openTasksRow, error) {
+	var (
+		rows          *sql.Rows
+		err           error
+		openTasksRows []openTasksRow
+	)
+
+	args := []any{clientID}
+	for _, status := range openStatuses {
+		args = append(args, status)
+	}
+	if rows, err = r.client.Query(openTasksQuery, args...); err != nil {
+		return nil, db.QueryError(err, openTasksQuery)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var row openTasksRow
+		if err = rows.Scan(
+			&row.assignee.UserID,
+			&row.assignee.UserName,
+			&row.assignee.Alias,
+			&row.status,
+			&row.count,
+		); err != nil {
+			return nil, db.ScanError(err, openTasksQuery)
+		}
+		openTasksRows = append(openTasksRows, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, openTasksQuery)
+	}
+
+	return openTasksRows, nil
+}
+
+// selectCompletions returns the number of tasks of the client done in the window of the filter by assignee, together
+// with the average seconds they took since they were assigned.
+func (r *relationalDB) selectCompletions(filter WorkloadFilter) ([]completionsRow, error) {
+	var (
+		rows           *sql.Rows
+		err            error
+		completionRows []completionsRow
+	)
+
+	if rows, err = r.client.Query(
+		completionsQuery, StatusDone, filter.From, filter.To, filter.ClientID,
+	); err != nil {
+		return nil, db.QueryError(err, completionsQuery)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var row completionsRow
+		if err = rows.Scan(
+			&row.assignee.UserID,
+			&row.assignee.UserName,
+			&row.assignee.Alias,
+			&row.completed,
+			&row.averageSeconds,
+		); err != nil {
+			return nil, db.ScanError(err, completionsQuery)
+		}
+		completionRows = append(completionRows, row)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, completionsQuery)
+	}
+
+	return completionRows, nil
+}
+
+func (r *relationalDB) record(entry audit.Entry) error {
+	return audit.NewRelationalDB(r.client).Record(entry)
+}
+
+func (r *relationalDB) getTransactioner() (Transactioner, error) {
+	client, ok := r.client.(*sql.DB)
+	if !ok {
+		return nil, errors.New("persister cannot generate transactional db")
+	}
+
+	tx, err := client.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("persister cannot generate transactional due to: %w", err)
+	}
+
+	return &transactionalDB{relationalDB: relationalDB{client: tx}, tx: tx}, nil
+}
+
+func (r *relationalDB) withTransaction(fn func(tx Transactioner) error) error {
+	tx, err := r.getTransactioner()
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		if err := tx.rollback(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	return tx.commit()
+}
+
+type transactionalDB struct {
+	relationalDB
+	tx *sql.Tx
+}
+
+func (tx *transactionalDB) commit() error {
+	if err := tx.tx.Commit(); err != nil {
+		return db.CommitError(err)
+	}
+	return nil
+}
+
+func (tx *transactionalDB) rollback() error {
+	if err := tx.tx.Rollback(); err != nil {
+		return db.RollbackError(err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package assignment
+
+import (
+	"fmt"
+	"maria/src/api/audit"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type dbMock struct {
+	mock.Mock
+}
+
+func newDBMock() *dbMock {
+	return &dbMock{}
+}
+
+func mockTask(args mock.Arguments, index int) task {
+	obj := args.Get(index)
+	var s task
+	var ok bool
+	if s, ok = obj.(task); !ok {
+		panic(fmt.Sprintf("assert: arguments: task(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockUser(args mock.Arguments, index int) user {
+	obj := args.Get(index)
+	var s user
+	var ok bool
+	if s, ok = obj.(user); !ok {
+		panic(fmt.Sprintf("assert: arguments: user(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockStatusChanges(args mock.Arguments, index int) []StatusChange {
+	obj := args.Get(index)
+	var s []StatusChange
+	var ok bool
+	if s, ok = obj.([]StatusChange); !ok {
+		panic(fmt.Sprintf("assert: arguments: StatusChange(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockBoardRows(args mock.Arguments, index int) []boardRow {
+	obj := args.Get(index)
+	var s []boardRow
+	var ok bool
+	if s, ok = obj.([]boardRow); !ok {
+		panic(fmt.Sprintf("assert: arguments: boardRow(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockOpenTasksRows(args mock.Arguments, index int) []openTasksRow {
+	obj := args.Get(index)
+	var s []openTasksRow
+	var ok bool
+	if s, ok = obj.([]openTasksRow); !ok {
+		panic(fmt.Sprintf("assert: arguments: openTasksRow(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockCompletionsRows(args mock.Arguments, index int) []completionsRow {
+	obj := args.Get(index)
+	var s []completionsRow
+	var ok bool
+	if s, ok = obj.([]completionsRow); !ok {
+		panic(fmt.Sprintf("assert: arguments: completionsRow(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func (m *dbMock) selectByID(userTaskID, userID int64) (UserTask, error) {
+	args := m.Called(userTaskID, userID)
+	return mockUserTask(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectByIDForUpdate(userTaskID, userID int64) (UserTask, error) {
+	args := m.Called(userTaskID, userID)
+	return mockUserTask(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectTask(taskID int64) (task, error) {
+	args := m.Called(taskID)
+	return mockTask(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectUser(userID int64) (user, error) {
+	args := m.Called(userID)
+	return mockUser(args, 0), args.Error(1)
+}
+
+func (m *dbMock) hasActiveMembership(userID, clientID int64) (bool, error) {
+	args := m.Called(userID, clientID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) hasRoleType(userID int64, roleType string) (bool, error) {
+	args := m.Called(userID, roleType)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) hasClient(clientID int64) (bool, error) {
+	args := m.Called(clientID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) createUserTask(userID int64, request AssignTaskRequest, status string) (int64, error) {
+	args := m.Called(userID, request, status)
+	return mockInt64(args, 0), args.Error(1)
+}
+
+func (m *dbMock) updateStatus(userTaskID int64, status string) (bool, error) {
+	args := m.Called(userTaskID, status)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) createStatusChange(userTaskID int64, change StatusChange) error {
+	args := m.Called(userTaskID, change)
+	return args.Error(0)
+}
+
+func (m *dbMock) selectStatusChanges(userTaskID int64) ([]StatusChange, error) {
+	args := m.Called(userTaskID)
+	return mockStatusChanges(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectBoard(clientID int64, filter BoardFilter) ([]boardRow, error) {
+	args := m.Called(clientID, filter)
+	return mockBoardRows(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectOpenTasks(clientID int64) ([]openTasksRow, error) {
+	args := m.Called(clientID)
+	return mockOpenTasksRows(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectCompletions(filter WorkloadFilter) ([]completionsRow, error) {
+	args := m.Called(filter)
+	return mockCompletionsRows(args, 0), args.Error(1)
+}
+
+func (m *dbMock) record(entry audit.Entry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *dbMock) withTransaction(fn func(tx Transactioner) error) error {
+	args := m.Called(fn)
+
+	if err := args.Error(0); err != nil {
+		return err
+	}
+
+	return fn(m)
+}
+
+func (m *dbMock) commit() error {
+	args := m.Called()
+	return args.Error(1)
+}
+
+func (m *dbMock) rollback() error {
+	args := m.Called()
+	return args.Error(1)
+}
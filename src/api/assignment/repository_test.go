@@ -0,0 +1,987 @@
+package assignment
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"maria/src/api/db"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type relationalDBSuite struct {
+	suite.Suite
+}
+
+func TestRelationalDBSuite(t *testing.T) {
+	suite.Run(t, new(relationalDBSuite))
+}
+
+func (s *relationalDBSuite) BeforeTest(suiteName, testName string) {
+}
+
+func (s *relationalDBSuite) AfterTest(suiteName, testName string) {
+}
+
+func (s *relationalDBSuite) TestSelectByID() {
+	var (
+		userTask = UserTask{
+			ID:          1,
+			UserID:      20,
+			TaskID:      30,
+			TaskName:    "onboarding",
+			TaskType:    "hr",
+			ClientID:    10,
+			Status:      StatusPending,
+			DateCreated: time.Now(),
+		}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name             string
+		query            string
+		mockCalls        mockDBApplier
+		expectedError    error
+		expectedUserTask UserTask
+	}
+
+	tests := []test{
+		{
+			name:  "scan error",
+			query: getUserTaskQuery,
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getUserTaskMockRows([]UserTask{userTask}), getUserTaskQuery, customError, userTask.ID, userTask.UserID)},
+			expectedError:    db.ScanError(customError, getUserTaskQuery),
+			expectedUserTask: UserTask{},
+		},
+		{
+			name:  "user task not found",
+			query: getUserTaskQuery,
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getUserTaskMockRows(nil), getUserTaskQuery, nil, userTask.ID, userTask.UserID)},
+			expectedError:    nil,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name:  "happy case",
+			query: getUserTaskQuery,
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getUserTaskMockRows([]UserTask{userTask}), getUserTaskQuery, nil, userTask.ID, userTask.UserID)},
+			expectedError:    nil,
+			expectedUserTask: userTask,
+		},
+		{
+			name:  "locked for update",
+			query: getUserTaskForUpdateQuery,
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getUserTaskMockRows([]UserTask{userTask}), getUserTaskForUpdateQuery, nil, userTask.ID, userTask.UserID)},
+			expectedError:    nil,
+			expectedUserTask: userTask,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			var result UserTask
+			if test.query == getUserTaskForUpdateQuery {
+				result, err = rDB.selectByIDForUpdate(userTask.ID, userTask.UserID)
+			} else {
+				result, err = rDB.selectByID(userTask.ID, userTask.UserID)
+			}
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedUserTask, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectTask() {
+	var (
+		onboarding  = task{ID: 30, Type: "hr", Active: true}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTask  task
+	}
+
+	tests := []test{
+		{
+			name: "scan error",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getTaskMockRows([]task{onboarding}), getTaskQuery, customError, onboarding.ID)},
+			expectedError: db.ScanError(customError, getTaskQuery),
+			expectedTask:  task{},
+		},
+		{
+			name: "task not found",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getTaskMockRows(nil), getTaskQuery, nil, onboarding.ID)},
+			expectedError: nil,
+			expectedTask:  task{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getTaskMockRows([]task{onboarding}), getTaskQuery, nil, onboarding.ID)},
+			expectedError: nil,
+			expectedTask:  onboarding,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectTask(onboarding.ID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTask, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectUser() {
+	var (
+		active      = user{ID: 20, Active: true}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedUser  user
+	}
+
+	tests := []test{
+		{
+			name: "scan error",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getUserMockRows([]user{active}), getUserQuery, customError, active.ID)},
+			expectedError: db.ScanError(customError, getUserQuery),
+			expectedUser:  user{},
+		},
+		{
+			name: "user not found",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getUserMockRows(nil), getUserQuery, nil, active.ID)},
+			expectedError: nil,
+			expectedUser:  user{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getUserMockRows([]user{active}), getUserQuery, nil, active.ID)},
+			expectedError: nil,
+			expectedUser:  active,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectUser(active.ID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedUser, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestHasActiveMembership() {
+	var (
+		userID      = int64(20)
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name: "scan error",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				sqlmock.NewRows([]string{"id"}).AddRow(1), getActiveMembershipQuery, customError, userID, clientID)},
+			expectedError: db.ScanError(customError, getActiveMembershipQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "membership not found",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				sqlmock.NewRows([]string{"id"}), getActiveMembershipQuery, nil, userID, clientID)},
+			expectedError: nil,
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				sqlmock.NewRows([]string{"id"}).AddRow(1), getActiveMembershipQuery, nil, userID, clientID)},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.hasActiveMembership(userID, clientID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestHasRoleType() {
+	var (
+		userID      = int64(20)
+		roleType    = "developer"
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name: "scan error",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				sqlmock.NewRows([]string{"has"}).AddRow(true), hasRoleTypeQuery, customError, userID, roleType)},
+			expectedError: db.ScanError(customError, hasRoleTypeQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "role type is not effective",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				sqlmock.NewRows([]string{"has"}).AddRow(false), hasRoleTypeQuery, nil, userID, roleType)},
+			expectedError: nil,
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				sqlmock.NewRows([]string{"has"}).AddRow(true), hasRoleTypeQuery, nil, userID, roleType)},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.hasRoleType(userID, roleType)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestHasClient() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedHas   bool
+	}
+
+	tests := []test{
+		{
+			name: "scan error",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				sqlmock.NewRows([]string{"has"}).AddRow(true), hasClientQuery, customError, clientID)},
+			expectedError: db.ScanError(customError, hasClientQuery),
+			expectedHas:   false,
+		},
+		{
+			name: "client does not exist",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				sqlmock.NewRows([]string{"has"}).AddRow(false), hasClientQuery, nil, clientID)},
+			expectedError: nil,
+			expectedHas:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				sqlmock.NewRows([]string{"has"}).AddRow(true), hasClientQuery, nil, clientID)},
+			expectedError: nil,
+			expectedHas:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.hasClient(clientID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedHas, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestCreateUserTask() {
+	var (
+		userID      = int64(20)
+		request     = AssignTaskRequest{TaskID: 30, ClientID: 10}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name               string
+		mockCalls          mockDBApplier
+		expectedError      error
+		expectedUserTaskID int64
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertUserTaskQuery, customError, userID, request.TaskID, request.ClientID, StatusPending)},
+			expectedError:      db.ExecError(customError, insertUserTaskQuery),
+			expectedUserTaskID: 0,
+		},
+		{
+			name: "last inserted error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewErrorResult(customError),
+				insertUserTaskQuery,
+				nil,
+				userID,
+				request.TaskID,
+				request.ClientID,
+				StatusPending)},
+			expectedError:      db.LastInsertedError(customError, insertUserTaskQuery),
+			expectedUserTaskID: 0,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(1, 1), insertUserTaskQuery, nil, userID, request.TaskID, request.ClientID, StatusPending)},
+			expectedError:      nil,
+			expectedUserTaskID: 1,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.createUserTask(userID, request, StatusPending)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedUserTaskID, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestUpdateStatus() {
+	var (
+		userTaskID  = int64(1)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, updateStatusQuery, customError, StatusDone, userTaskID)},
+			expectedError: db.ExecError(customError, updateStatusQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "rows affected error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewErrorResult(customError), updateStatusQuery, nil, StatusDone, userTaskID)},
+			expectedError: db.RowsAffectedError(customError, updateStatusQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), updateStatusQuery, nil, StatusDone, userTaskID)},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.updateStatus(userTaskID, StatusDone)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestCreateStatusChange() {
+	var (
+		userTaskID  = int64(1)
+		from        = StatusPending
+		comment     = "waiting for the laptop"
+		change      = StatusChange{Actor: "admin", FromStatus: &from, ToStatus: StatusBlocked, Comment: &comment}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertStatusChangeQuery, customError, userTaskID, change.Actor, from, change.ToStatus, comment)},
+			expectedError: db.ExecError(customError, insertStatusChangeQuery),
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(1, 1),
+				insertStatusChangeQuery,
+				nil,
+				userTaskID,
+				change.Actor,
+				from,
+				change.ToStatus,
+				comment)},
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			err = rDB.createStatusChange(userTaskID, change)
+
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectStatusChanges() {
+	var (
+		userTaskID = int64(1)
+		from       = StatusPending
+		changes    = []StatusChange{
+			{ID: 1, Actor: "admin", ToStatus: StatusPending, DateCreated: time.Now()},
+			{ID: 2, Actor: "admin", FromStatus: &from, ToStatus: StatusInProgress, DateCreated: time.Now()},
+		}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name            string
+		mockCalls       mockDBApplier
+		expectedError   error
+		expectedChanges []StatusChange
+	}
+
+	tests := []test{
+		{
+			name: "query error",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				nil, statusChangesQuery, customError, nil, userTaskID)},
+			expectedError:   db.QueryError(customError, statusChangesQuery),
+			expectedChanges: nil,
+		},
+		{
+			name: "rows error",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getStatusChangeMockRows(changes), statusChangesQuery, nil, customError, userTaskID)},
+			expectedError:   db.RowsError(customError, statusChangesQuery),
+			expectedChanges: nil,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getStatusChangeMockRows(changes), statusChangesQuery, nil, nil, userTaskID)},
+			expectedError:   nil,
+			expectedChanges: changes,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectStatusChanges(userTaskID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedChanges, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectBoard() {
+	var (
+		clientID      = int64(10)
+		filter        = BoardFilter{Limit: 20}
+		filtered      = BoardFilter{TaskType: "bug", AssigneeID: 20, Limit: 5}
+		query         = fmt.Sprintf(boardQuery, "")
+		filteredQuery = fmt.Sprintf(boardQuery, " AND t.type = ? AND ut.user_id = ?")
+		assignee      = Assignee{UserID: 20, UserName: "john", Alias: "jd"}
+		rows          = []boardRow{
+			{card: BoardCard{ID: 1, TaskID: 30, TaskName: "fix", TaskType: "bug", Assignee: assignee, DateCreated: time.Now()},
+				status: StatusPending, count: 2},
+			{card: BoardCard{ID: 2, TaskID: 31, TaskName: "fix", TaskType: "bug", Assignee: assignee, DateCreated: time.Now()},
+				status: StatusPending, count: 2},
+		}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		filter        BoardFilter
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedRows  []boardRow
+	}
+
+	tests := []test{
+		{
+			name:   "query error",
+			filter: filter,
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				nil, query, customError, nil, clientID, filter.Limit)},
+			expectedError: db.QueryError(customError, query),
+			expectedRows:  nil,
+		},
+		{
+			name:   "rows error",
+			filter: filter,
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getBoardMockRows(rows), query, nil, customError, clientID, filter.Limit)},
+			expectedError: db.RowsError(customError, query),
+			expectedRows:  nil,
+		},
+		{
+			name:   "happy case",
+			filter: filter,
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getBoardMockRows(rows), query, nil, nil, clientID, filter.Limit)},
+			expectedError: nil,
+			expectedRows:  rows,
+		},
+		{
+			name:   "filtered by type and assignee",
+			filter: filtered,
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getBoardMockRows(rows), filteredQuery, nil, nil,
+				clientID, filtered.TaskType, filtered.AssigneeID, filtered.Limit)},
+			expectedError: nil,
+			expectedRows:  rows,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectBoard(clientID, test.filter)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRows, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectOpenTasks() {
+	var (
+		clientID = int64(10)
+		rows     = []openTasksRow{
+			{assignee: Assignee{UserID: 20, UserName: "john", Alias: "jd"}, status: StatusPending, count: 2},
+			{assignee: Assignee{UserID: 20, UserName: "john", Alias: "jd"}, status: StatusBlocked, count: 1},
+		}
+		args        = []driver.Value{clientID, StatusPending, StatusInProgress, StatusBlocked}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedRows  []openTasksRow
+	}
+
+	tests := []test{
+		{
+			name: "query error",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				nil, openTasksQuery, customError, nil, args...)},
+			expectedError: db.QueryError(customError, openTasksQuery),
+			expectedRows:  nil,
+		},
+		{
+			name: "rows error",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getOpenTasksMockRows(rows), openTasksQuery, nil, customError, args...)},
+			expectedError: db.RowsError(customError, openTasksQuery),
+			expectedRows:  nil,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getOpenTasksMockRows(rows), openTasksQuery, nil, nil, args...)},
+			expectedError: nil,
+			expectedRows:  rows,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectOpenTasks(clientID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRows, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectCompletions() {
+	var (
+		filter = WorkloadFilter{
+			ClientID: 10,
+			From:     time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+			To:       time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC),
+		}
+		rows = []completionsRow{
+			{assignee: Assignee{UserID: 20, UserName: "john", Alias: "jd"}, completed: 2, averageSeconds: 5400.5},
+		}
+		args        = []driver.Value{StatusDone, filter.From, filter.To, filter.ClientID}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedRows  []completionsRow
+	}
+
+	tests := []test{
+		{
+			name: "query error",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				nil, completionsQuery, customError, nil, args...)},
+			expectedError: db.QueryError(customError, completionsQuery),
+			expectedRows:  nil,
+		},
+		{
+			name: "rows error",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getCompletionsMockRows(rows), completionsQuery, nil, customError, args...)},
+			expectedError: db.RowsError(customError, completionsQuery),
+			expectedRows:  nil,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getCompletionsMockRows(rows), completionsQuery, nil, nil, args...)},
+			expectedError: nil,
+			expectedRows:  rows,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectCompletions(filter)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRows, result)
+		})
+	}
+}
+
+func getUserTaskMockRows(userTasks []UserTask) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "user_id", "task_id", "task_name", "type", "client_id", "status", "date_created"})
+	for _, ut := range userTasks {
+		rows.AddRow(ut.ID, ut.UserID, ut.TaskID, ut.TaskName, ut.TaskType, ut.ClientID, ut.Status, ut.DateCreated)
+	}
+	return rows
+}
+
+func getStatusChangeMockRows(changes []StatusChange) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "actor", "from_status", "to_status", "comment", "date_created"})
+	for _, c := range changes {
+		rows.AddRow(c.ID, c.Actor, c.FromStatus, c.ToStatus, c.Comment, c.DateCreated)
+	}
+	return rows
+}
+
+func getBoardMockRows(boardRows []boardRow) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "task_id", "task_name", "type", "user_id", "user_name", "alias", "date_created", "status", "total"})
+	for _, r := range boardRows {
+		rows.AddRow(r.card.ID, r.card.TaskID, r.card.TaskName, r.card.TaskType, r.card.Assignee.UserID,
+			r.card.Assignee.UserName, r.card.Assignee.Alias, r.card.DateCreated, r.status, r.count)
+	}
+	return rows
+}
+
+func getOpenTasksMockRows(openRows []openTasksRow) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "user_name", "alias", "status", "count"})
+	for _, r := range openRows {
+		rows.AddRow(r.assignee.UserID, r.assignee.UserName, r.assignee.Alias, r.status, r.count)
+	}
+	return rows
+}
+
+func getCompletionsMockRows(completionRows []completionsRow) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "user_name", "alias", "completed", "average"})
+	for _, r := range completionRows {
+		rows.AddRow(r.assignee.UserID, r.assignee.UserName, r.assignee.Alias, r.completed, r.averageSeconds)
+	}
+	return rows
+}
+
+func getTaskMockRows(tasks []task) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "type", "active"})
+	for _, t := range tasks {
+		rows.AddRow(t.ID, t.Type, t.Active)
+	}
+	return rows
+}
+
+func getUserMockRows(users []user) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "active"})
+	for _, u := range users {
+		rows.AddRow(u.ID, u.Active)
+	}
+	return rows
+}
+
+type mockDBApplier []func(m sqlmock.Sqlmock) func() error
+
+func (appliers mockDBApplier) apply(m sqlmock.Sqlmock) func() error {
+	var assertCalls []func() error
+	for i := range appliers {
+		assertCall := appliers[i](m)
+		assertCalls = append(assertCalls, assertCall)
+	}
+	return func() error {
+		for i := range assertCalls {
+			if err := assertCalls[i](); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
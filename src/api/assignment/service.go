@@ -0,0 +1,390 @@
+package assignment
+
+import (
+	"context"
+	"fmt"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
+	"math"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	userNotFoundError       = ruleError{code: "user_not_found", message: "user not found"}
+	inactiveUserError       = ruleError{code: "user_inactive", message: "user is not active"}
+	clientNotFoundError     = ruleError{code: "client_not_found", message: "client not found"}
+	membershipNotFoundError = ruleError{
+		code:    "membership_not_found",
+		message: "user is not an unexpired member of the client",
+	}
+	taskNotFoundError    = ruleError{code: "task_not_found", message: "task not found"}
+	inactiveTaskError    = ruleError{code: "task_inactive", message: "task is not active"}
+	missingRoleTypeError = ruleError{
+		code:    "role_type_missing",
+		message: "user does not have the role type required by the task type",
+	}
+	userTaskNotFoundError = ruleError{code: "user_task_not_found", message: "user task not found"}
+	unknownStatusError    = ruleError{
+		code:    "status_unknown",
+		message: "status must be one of pending, in_progress, blocked, done or cancelled",
+	}
+	illegalTransitionError = ruleError{code: "transition_not_allowed", message: "status cannot be changed"}
+	longCommentError       = ruleError{
+		code:    "comment_too_long",
+		message: fmt.Sprintf("comment cannot be longer than %d characters", maxCommentLength),
+	}
+)
+
+const (
+	auditEntity = "user_task"
+
+	maxCommentLength = 500
+)
+
+// Service methods receiving a context use it for knowing the caller, who is recorded in the audit log, and the
+// tenant, which limits the clients whose tasks are reachable.
+type Service interface {
+	assignTask(ctx context.Context, userID int64, request AssignTaskRequest) (UserTask, error)
+	transition(ctx context.Context, userID, userTaskID int64, request TransitionRequest) (UserTask, error)
+	getTimeline(ctx context.Context, userID, userTaskID int64) (Timeline, error)
+	getBoard(ctx context.Context, clientID int64, filter BoardFilter) (Board, error)
+	getWorkload(ctx context.Context, filter WorkloadFilter) (WorkloadReport, error)
+}
+
+type assignmentService struct {
+	assignmentRepository Persister
+	lifecycle            Lifecycle
+	roleTypes            map[string]string
+	now                  func() time.Time
+}
+
+// NewService returns the service assigning tasks. roleTypes keeps the role type required by task type, the types
+// not in it can be assigned to any user.
+func NewService(assignmentRepository Persister, lifecycle Lifecycle, roleTypes map[string]string) Service {
+	return assignmentService{
+		assignmentRepository: assignmentRepository,
+		lifecycle:            lifecycle,
+		roleTypes:            roleTypes,
+		now:                  time.Now,
+	}
+}
+
+// assignTask assigns the task to the user for the client given in the request, the assignment starts as pending.
+// The user must be active and an unexpired member of the client, the task must be active and, when its type
+// requires a role type, the user must have it. Every rule is checked in the transaction storing the assignment.
+func (as assignmentService) assignTask(ctx context.Context, userID int64, request AssignTaskRequest) (UserTask, error) {
+	if !inTenant(ctx, request.ClientID) {
+		return UserTask{}, clientNotFoundError
+	}
+
+	var userTask UserTask
+	if err := as.assignmentRepository.withTransaction(func(tx Transactioner) error {
+		if err := as.checkAssignable(tx, userID, request); err != nil {
+			return err
+		}
+
+		userTaskID, err := tx.createUserTask(userID, request, StatusPending)
+		if err != nil {
+			return err
+		}
+
+		if err = tx.createStatusChange(userTaskID, StatusChange{
+			Actor:    auth.CallerFrom(ctx),
+			ToStatus: StatusPending,
+		}); err != nil {
+			return err
+		}
+
+		if userTask, err = tx.selectByID(userTaskID, userID); err != nil {
+			return err
+		}
+
+		return record(ctx, tx, audit.ActionAssign, nil, &userTask)
+	}); err != nil {
+		return UserTask{}, err
+	}
+
+	return userTask, nil
+}
+
+// checkAssignable returns the rule error of the first rule not met by the assignment.
+func (as assignmentService) checkAssignable(tx Transactioner, userID int64, request AssignTaskRequest) error {
+	u, err := tx.selectUser(userID)
+	if err != nil {
+		return err
+	}
+	if u.isEmpty() {
+		return userNotFoundError
+	}
+	if !u.Active {
+		return inactiveUserError
+	}
+
+	member, err := tx.hasActiveMembership(userID, request.ClientID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return membershipNotFoundError
+	}
+
+	t, err := tx.selectTask(request.TaskID)
+	if err != nil {
+		return err
+	}
+	if t.isEmpty() {
+		return taskNotFoundError
+	}
+	if !t.Active {
+		return inactiveTaskError
+	}
+
+	roleType, required := as.roleTypes[t.Type]
+	if !required {
+		return nil
+	}
+	has, err := tx.hasRoleType(userID, roleType)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return fmt.Errorf("%w: %s tasks require %s", missingRoleTypeError, t.Type, roleType)
+	}
+
+	return nil
+}
+
+// transition moves the user task to the requested status when the lifecycle of its task type allows it.
+func (as assignmentService) transition(
+	ctx context.Context,
+	userID, userTaskID int64,
+	request TransitionRequest,
+) (UserTask, error) {
+	if !isStatus(request.Status) {
+		return UserTask{}, unknownStatusError
+	}
+	if request.Comment != nil && utf8.RuneCountInString(*request.Comment) > maxCommentLength {
+		return UserTask{}, longCommentError
+	}
+
+	var userTask UserTask
+	if err := as.assignmentRepository.withTransaction(func(tx Transactioner) error {
+		before, err := tx.selectByIDForUpdate(userTaskID, userID)
+		if err != nil {
+			return err
+		}
+		if before.isEmpty() || !inTenant(ctx, before.ClientID) {
+			return userTaskNotFoundError
+		}
+		if !as.lifecycle.allows(before.TaskType, before.Status, request.Status) {
+			return fmt.Errorf("%w from %s to %s for %s tasks",
+				illegalTransitionError, before.Status, request.Status, before.TaskType)
+		}
+
+		if _, err = tx.updateStatus(userTaskID, request.Status); err != nil {
+			return err
+		}
+
+		if err = tx.createStatusChange(userTaskID, StatusChange{
+			Actor:      auth.CallerFrom(ctx),
+			FromStatus: &before.Status,
+			ToStatus:   request.Status,
+			Comment:    request.Comment,
+		}); err != nil {
+			return err
+		}
+
+		if userTask, err = tx.selectByID(userTaskID, userID); err != nil {
+			return err
+		}
+
+		return record(ctx, tx, audit.ActionTransition, &before, &userTask)
+	}); err != nil {
+		return UserTask{}, err
+	}
+
+	return userTask, nil
+}
+
+// getTimeline returns the status changes of the user task and the time it has spent in each status. The time in the
+// current status is counted until now unless the status is final.
+func (as assignmentService) getTimeline(ctx context.Context, userID, userTaskID int64) (Timeline, error) {
+	userTask, err := as.assignmentRepository.selectByID(userTaskID, userID)
+	if err != nil {
+		return Timeline{}, err
+	}
+	if userTask.isEmpty() || !inTenant(ctx, userTask.ClientID) {
+		return Timeline{}, userTaskNotFoundError
+	}
+
+	changes, err := as.assignmentRepository.selectStatusChanges(userTaskID)
+	if err != nil {
+		return Timeline{}, err
+	}
+	if changes == nil {
+		changes = []StatusChange{}
+	}
+
+	return Timeline{
+		UserTask:     userTask,
+		Changes:      changes,
+		TimeInStatus: as.timeInStatus(userTask.TaskType, changes),
+	}, nil
+}
+
+// timeInStatus adds up the time between each change and the next one by status, in the order the statuses were
+// first reached.
+func (as assignmentService) timeInStatus(taskType string, changes []StatusChange) []StatusTime {
+	times := make([]StatusTime, 0)
+	positions := make(map[string]int)
+
+	for i, change := range changes {
+		var until time.Time
+		switch {
+		case i+1 < len(changes):
+			until = changes[i+1].DateCreated
+		case !as.lifecycle.isFinal(taskType, change.ToStatus):
+			until = as.now()
+		default:
+			until = change.DateCreated
+		}
+
+		position, ok := positions[change.ToStatus]
+		if !ok {
+			position = len(times)
+			positions[change.ToStatus] = position
+			times = append(times, StatusTime{Status: change.ToStatus})
+		}
+		// the clock of the database may be ahead of ours, the time in the current status is never negative
+		if spent := until.Sub(change.DateCreated); spent > 0 {
+			times[position].Seconds += int64(spent / time.Second)
+		}
+	}
+
+	return times
+}
+
+// getBoard returns the user tasks of the client grouped by status. Every status of the lifecycle has a column, even
+// when it is empty, and the statuses out of it which are still stored are shown after them.
+func (as assignmentService) getBoard(ctx context.Context, clientID int64, filter BoardFilter) (Board, error) {
+	if !inTenant(ctx, clientID) {
+		return Board{}, clientNotFoundError
+	}
+
+	if exists, err := as.assignmentRepository.hasClient(clientID); err != nil {
+		return Board{}, err
+	} else if !exists {
+		return Board{}, clientNotFoundError
+	}
+
+	rows, err := as.assignmentRepository.selectBoard(clientID, filter.normalize())
+	if err != nil {
+		return Board{}, err
+	}
+
+	columns := make([]BoardColumn, 0, len(boardStatuses))
+	positions := make(map[string]int, len(boardStatuses))
+	for _, status := range boardStatuses {
+		positions[status] = len(columns)
+		columns = append(columns, BoardColumn{Status: status, Cards: []BoardCard{}})
+	}
+
+	for _, row := range rows {
+		position, ok := positions[row.status]
+		if !ok {
+			position = len(columns)
+			positions[row.status] = position
+			columns = append(columns, BoardColumn{Status: row.status, Cards: []BoardCard{}})
+		}
+		columns[position].Count = row.count
+		columns[position].Cards = append(columns[position].Cards, row.card)
+	}
+
+	return Board{ClientID: clientID, Columns: columns}, nil
+}
+
+// getWorkload returns the workload of the users of the client, ordered by user id. Open tasks are counted as they are
+// now, while completions are only counted in the window of the filter.
+func (as assignmentService) getWorkload(ctx context.Context, filter WorkloadFilter) (WorkloadReport, error) {
+	if !inTenant(ctx, filter.ClientID) {
+		return WorkloadReport{}, clientNotFoundError
+	}
+
+	if exists, err := as.assignmentRepository.hasClient(filter.ClientID); err != nil {
+		return WorkloadReport{}, err
+	} else if !exists {
+		return WorkloadReport{}, clientNotFoundError
+	}
+
+	openRows, err := as.assignmentRepository.selectOpenTasks(filter.ClientID)
+	if err != nil {
+		return WorkloadReport{}, err
+	}
+
+	completionRows, err := as.assignmentRepository.selectCompletions(filter)
+	if err != nil {
+		return WorkloadReport{}, err
+	}
+
+	workloads := make(map[int64]*UserWorkload)
+	workloadOf := func(assignee Assignee) *UserWorkload {
+		if workload, ok := workloads[assignee.UserID]; ok {
+			return workload
+		}
+		workload := &UserWorkload{Assignee: assignee, OpenTasks: make(map[string]int, len(openStatuses))}
+		for _, status := range openStatuses {
+			workload.OpenTasks[status] = 0
+		}
+		workloads[assignee.UserID] = workload
+		return workload
+	}
+
+	for _, row := range openRows {
+		workloadOf(row.assignee).OpenTasks[row.status] = row.count
+	}
+	for _, row := range completionRows {
+		workload := workloadOf(row.assignee)
+		workload.Completed = row.completed
+		average := int64(math.Round(row.averageSeconds))
+		workload.AverageCompletionSeconds = &average
+	}
+
+	users := make([]UserWorkload, 0, len(workloads))
+	for _, workload := range workloads {
+		users = append(users, *workload)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].UserID < users[j].UserID
+	})
+
+	return WorkloadReport{ClientID: filter.ClientID, From: filter.From, To: filter.To, Users: users}, nil
+}
+
+// inTenant returns whether the client is reachable by the tenant of the request, any client is when it is not scoped.
+func inTenant(ctx context.Context, clientID int64) bool {
+	tenant, scoped := auth.TenantFrom(ctx)
+	return !scoped || tenant == clientID
+}
+
+func record(ctx context.Context, tx Transactioner, action string, before, after *UserTask) error {
+	var (
+		userTaskID                int64
+		beforeEntity, afterEntity any
+	)
+
+	if before != nil {
+		userTaskID, beforeEntity = before.ID, *before
+	}
+	if after != nil {
+		userTaskID, afterEntity = after.ID, *after
+	}
+
+	entry, err := audit.NewEntry(auth.CallerFrom(ctx), action, auditEntity, userTaskID, beforeEntity, afterEntity)
+	if err != nil {
+		return err
+	}
+
+	return tx.record(entry)
+}
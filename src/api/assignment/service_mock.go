@@ -0,0 +1,95 @@
+package assignment
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type serviceMock struct {
+	mock.Mock
+}
+
+func newServiceMock() *serviceMock {
+	return &serviceMock{}
+}
+
+func mockUserTask(args mock.Arguments, index int) UserTask {
+	obj := args.Get(index)
+	var s UserTask
+	var ok bool
+	if s, ok = obj.(UserTask); !ok {
+		panic(fmt.Sprintf("assert: arguments: UserTask(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockInt64(args mock.Arguments, index int) int64 {
+	obj := args.Get(index)
+	var s int64
+	var ok bool
+	if s, ok = obj.(int64); !ok {
+		panic(fmt.Sprintf("assert: arguments: Int64(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func (m *serviceMock) assignTask(ctx context.Context, userID int64, request AssignTaskRequest) (UserTask, error) {
+	args := m.Called(ctx, userID, request)
+	return mockUserTask(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) transition(
+	ctx context.Context,
+	userID, userTaskID int64,
+	request TransitionRequest,
+) (UserTask, error) {
+	args := m.Called(ctx, userID, userTaskID, request)
+	return mockUserTask(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) getTimeline(ctx context.Context, userID, userTaskID int64) (Timeline, error) {
+	args := m.Called(ctx, userID, userTaskID)
+	return mockTimeline(args, 0), args.Error(1)
+}
+
+func mockTimeline(args mock.Arguments, index int) Timeline {
+	obj := args.Get(index)
+	var s Timeline
+	var ok bool
+	if s, ok = obj.(Timeline); !ok {
+		panic(fmt.Sprintf("assert: arguments: Timeline(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func (m *serviceMock) getBoard(ctx context.Context, clientID int64, filter BoardFilter) (Board, error) {
+	args := m.Called(ctx, clientID, filter)
+	return mockBoard(args, 0), args.Error(1)
+}
+
+func mockBoard(args mock.Arguments, index int) Board {
+	obj := args.Get(index)
+	var s Board
+	var ok bool
+	if s, ok = obj.(Board); !ok {
+		panic(fmt.Sprintf("assert: arguments: Board(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func (m *serviceMock) getWorkload(ctx context.Context, filter WorkloadFilter) (WorkloadReport, error) {
+	args := m.Called(ctx, filter)
+	return mockWorkloadReport(args, 0), args.Error(1)
+}
+
+func mockWorkloadReport(args mock.Arguments, index int) WorkloadReport {
+	obj := args.Get(index)
+	var s WorkloadReport
+	var ok bool
+	if s, ok = obj.(WorkloadReport); !ok {
+		panic(fmt.Sprintf("assert: arguments: WorkloadReport(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
@@ -0,0 +1,1120 @@
+package assignment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
+	"maria/src/api/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const testCaller = "admin"
+
+var (
+	testNow = time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+	testCtx = auth.WithCaller(context.Background(), testCaller)
+)
+
+type AssignmentServiceSuite struct {
+	suite.Suite
+}
+
+func TestAssignmentServiceSuite(t *testing.T) {
+	suite.Run(t, new(AssignmentServiceSuite))
+}
+
+func (s *AssignmentServiceSuite) BeforeTest(suiteName, testName string) {
+}
+
+func (s *AssignmentServiceSuite) AfterTest(suiteName, testName string) {
+}
+
+func (s *AssignmentServiceSuite) TestAssignTask() {
+	var (
+		userID      = int64(20)
+		userTaskID  = int64(1)
+		customError = errors.New("custom error")
+		request     = AssignTaskRequest{TaskID: 30, ClientID: 10}
+		active      = user{ID: userID, Active: true}
+		onboarding  = task{ID: request.TaskID, Type: "hr", Active: true}
+		bug         = task{ID: request.TaskID, Type: "bug", Active: true}
+		assigned    = StatusChange{Actor: testCaller, ToStatus: StatusPending}
+		userTask    = UserTask{
+			ID:          userTaskID,
+			UserID:      userID,
+			TaskID:      request.TaskID,
+			TaskName:    "onboarding",
+			TaskType:    onboarding.Type,
+			ClientID:    request.ClientID,
+			Status:      StatusPending,
+			DateCreated: testNow,
+		}
+	)
+
+	type test struct {
+		name             string
+		ctx              context.Context
+		mockCalls        mockPersisterApplier
+		expectedError    error
+		expectedUserTask UserTask
+	}
+
+	tests := []test{
+		{
+			name:             "client is not the tenant",
+			ctx:              auth.WithTenant(testCtx, request.ClientID+1),
+			mockCalls:        mockPersisterApplier{},
+			expectedError:    clientNotFoundError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name: "transaction cannot be started",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(customError),
+			},
+			expectedError:    customError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name: "user not found",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectUserMock(user{}, nil, userID),
+			},
+			expectedError:    userNotFoundError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name: "user is not active",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectUserMock(user{ID: userID}, nil, userID),
+			},
+			expectedError:    inactiveUserError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name: "user is not a member of the client",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectUserMock(active, nil, userID),
+				setPersiterHasActiveMembershipMock(false, nil, userID, request.ClientID),
+			},
+			expectedError:    membershipNotFoundError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name: "task not found",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectUserMock(active, nil, userID),
+				setPersiterHasActiveMembershipMock(true, nil, userID, request.ClientID),
+				setPersiterSelectTaskMock(task{}, nil, request.TaskID),
+			},
+			expectedError:    taskNotFoundError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name: "task is not active",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectUserMock(active, nil, userID),
+				setPersiterHasActiveMembershipMock(true, nil, userID, request.ClientID),
+				setPersiterSelectTaskMock(task{ID: request.TaskID, Type: "hr"}, nil, request.TaskID),
+			},
+			expectedError:    inactiveTaskError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name: "user does not have the role type required by the task type",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectUserMock(active, nil, userID),
+				setPersiterHasActiveMembershipMock(true, nil, userID, request.ClientID),
+				setPersiterSelectTaskMock(bug, nil, request.TaskID),
+				setPersiterHasRoleTypeMock(false, nil, userID, "developer"),
+			},
+			expectedError:    fmt.Errorf("%w: bug tasks require developer", missingRoleTypeError),
+			expectedUserTask: UserTask{},
+		},
+		{
+			name: "create user task return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectUserMock(active, nil, userID),
+				setPersiterHasActiveMembershipMock(true, nil, userID, request.ClientID),
+				setPersiterSelectTaskMock(onboarding, nil, request.TaskID),
+				setPersiterCreateUserTaskMock(0, customError, userID, request),
+			},
+			expectedError:    customError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name: "create status change return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectUserMock(active, nil, userID),
+				setPersiterHasActiveMembershipMock(true, nil, userID, request.ClientID),
+				setPersiterSelectTaskMock(onboarding, nil, request.TaskID),
+				setPersiterCreateUserTaskMock(userTaskID, nil, userID, request),
+				setPersiterCreateStatusChangeMock(customError, userTaskID, assigned),
+			},
+			expectedError:    customError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectUserMock(active, nil, userID),
+				setPersiterHasActiveMembershipMock(true, nil, userID, request.ClientID),
+				setPersiterSelectTaskMock(onboarding, nil, request.TaskID),
+				setPersiterCreateUserTaskMock(userTaskID, nil, userID, request),
+				setPersiterCreateStatusChangeMock(nil, userTaskID, assigned),
+				setPersiterSelectByIDMock(userTask, nil, userTaskID, userID),
+				setPersiterRecordMock(nil, audit.ActionAssign, nil, &userTask),
+			},
+			expectedError:    nil,
+			expectedUserTask: userTask,
+		},
+		{
+			name: "user has the role type required by the task type",
+			ctx:  auth.WithTenant(testCtx, request.ClientID),
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectUserMock(active, nil, userID),
+				setPersiterHasActiveMembershipMock(true, nil, userID, request.ClientID),
+				setPersiterSelectTaskMock(bug, nil, request.TaskID),
+				setPersiterHasRoleTypeMock(true, nil, userID, "developer"),
+				setPersiterCreateUserTaskMock(userTaskID, nil, userID, request),
+				setPersiterCreateStatusChangeMock(nil, userTaskID, assigned),
+				setPersiterSelectByIDMock(userTask, nil, userTaskID, userID),
+				setPersiterRecordMock(nil, audit.ActionAssign, nil, &userTask),
+			},
+			expectedError:    nil,
+			expectedUserTask: userTask,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			ctx := testCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			result, err := serv.assignTask(ctx, userID, request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedUserTask, result)
+		})
+	}
+}
+
+func (s *AssignmentServiceSuite) TestTransition() {
+	var (
+		userID      = int64(20)
+		userTaskID  = int64(1)
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+		pending     = UserTask{ID: userTaskID, UserID: userID, TaskType: "hr", ClientID: clientID, Status: StatusPending}
+		started     = UserTask{ID: userTaskID, UserID: userID, TaskType: "hr", ClientID: clientID, Status: StatusInProgress}
+		done        = UserTask{ID: userTaskID, UserID: userID, TaskType: "bug", ClientID: clientID, Status: StatusDone}
+		reopened    = UserTask{ID: userTaskID, UserID: userID, TaskType: "bug", ClientID: clientID, Status: StatusInProgress}
+		comment     = "waiting for the laptop"
+		longComment = strings.Repeat("a", maxCommentLength+1)
+		startChange = StatusChange{Actor: testCaller, FromStatus: &pending.Status, ToStatus: StatusInProgress}
+	)
+
+	type test struct {
+		name             string
+		ctx              context.Context
+		request          TransitionRequest
+		mockCalls        mockPersisterApplier
+		expectedError    error
+		expectedUserTask UserTask
+	}
+
+	tests := []test{
+		{
+			name:             "status is unknown",
+			request:          TransitionRequest{Status: "open"},
+			mockCalls:        mockPersisterApplier{},
+			expectedError:    unknownStatusError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name:             "comment is too long",
+			request:          TransitionRequest{Status: StatusBlocked, Comment: &longComment},
+			mockCalls:        mockPersisterApplier{},
+			expectedError:    longCommentError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name:    "user task not found",
+			request: TransitionRequest{Status: StatusInProgress},
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(UserTask{}, nil, userTaskID, userID),
+			},
+			expectedError:    userTaskNotFoundError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name:    "user task is not in the tenant",
+			ctx:     auth.WithTenant(testCtx, clientID+1),
+			request: TransitionRequest{Status: StatusInProgress},
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(pending, nil, userTaskID, userID),
+			},
+			expectedError:    userTaskNotFoundError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name:    "transition is not allowed",
+			request: TransitionRequest{Status: StatusDone},
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(pending, nil, userTaskID, userID),
+			},
+			expectedError:    fmt.Errorf("%w from pending to done for hr tasks", illegalTransitionError),
+			expectedUserTask: UserTask{},
+		},
+		{
+			name:    "update status return error",
+			request: TransitionRequest{Status: StatusInProgress},
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(pending, nil, userTaskID, userID),
+				setPersiterUpdateStatusMock(false, customError, userTaskID, StatusInProgress),
+			},
+			expectedError:    customError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name:    "create status change return error",
+			request: TransitionRequest{Status: StatusInProgress},
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(pending, nil, userTaskID, userID),
+				setPersiterUpdateStatusMock(true, nil, userTaskID, StatusInProgress),
+				setPersiterCreateStatusChangeMock(customError, userTaskID, startChange),
+			},
+			expectedError:    customError,
+			expectedUserTask: UserTask{},
+		},
+		{
+			name:    "happy case",
+			request: TransitionRequest{Status: StatusInProgress},
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(pending, nil, userTaskID, userID),
+				setPersiterUpdateStatusMock(true, nil, userTaskID, StatusInProgress),
+				setPersiterCreateStatusChangeMock(nil, userTaskID, startChange),
+				setPersiterSelectByIDMock(started, nil, userTaskID, userID),
+				setPersiterRecordMock(nil, audit.ActionTransition, &pending, &started),
+			},
+			expectedError:    nil,
+			expectedUserTask: started,
+		},
+		{
+			name:    "transition allowed by the task type",
+			request: TransitionRequest{Status: StatusInProgress, Comment: &comment},
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(done, nil, userTaskID, userID),
+				setPersiterUpdateStatusMock(true, nil, userTaskID, StatusInProgress),
+				setPersiterCreateStatusChangeMock(nil, userTaskID, StatusChange{
+					Actor:      testCaller,
+					FromStatus: &done.Status,
+					ToStatus:   StatusInProgress,
+					Comment:    &comment,
+				}),
+				setPersiterSelectByIDMock(reopened, nil, userTaskID, userID),
+				setPersiterRecordMock(nil, audit.ActionTransition, &done, &reopened),
+			},
+			expectedError:    nil,
+			expectedUserTask: reopened,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			ctx := testCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			result, err := serv.transition(ctx, userID, userTaskID, test.request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedUserTask, result)
+		})
+	}
+}
+
+func (s *AssignmentServiceSuite) TestGetTimeline() {
+	var (
+		userID      = int64(20)
+		userTaskID  = int64(1)
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+		pending     = StatusPending
+		inProgress  = StatusInProgress
+		blocked     = StatusBlocked
+		active      = UserTask{ID: userTaskID, UserID: userID, TaskType: "hr", ClientID: clientID, Status: blocked}
+		done        = UserTask{ID: userTaskID, UserID: userID, TaskType: "hr", ClientID: clientID, Status: StatusDone}
+		changes     = []StatusChange{
+			{ID: 1, Actor: testCaller, ToStatus: pending, DateCreated: testNow.Add(-10 * time.Hour)},
+			{ID: 2, Actor: testCaller, FromStatus: &pending, ToStatus: inProgress, DateCreated: testNow.Add(-8 * time.Hour)},
+			{ID: 3, Actor: testCaller, FromStatus: &inProgress, ToStatus: blocked, DateCreated: testNow.Add(-5 * time.Hour)},
+			{ID: 4, Actor: testCaller, FromStatus: &blocked, ToStatus: inProgress, DateCreated: testNow.Add(-4 * time.Hour)},
+			{ID: 5, Actor: testCaller, FromStatus: &inProgress, ToStatus: blocked, DateCreated: testNow.Add(-time.Hour)},
+		}
+		finished = append(changes[:4:4], StatusChange{
+			ID: 5, Actor: testCaller, FromStatus: &inProgress, ToStatus: StatusDone, DateCreated: testNow.Add(-time.Hour),
+		})
+	)
+
+	type test struct {
+		name             string
+		ctx              context.Context
+		mockCalls        mockPersisterApplier
+		expectedError    error
+		expectedTimeline Timeline
+	}
+
+	tests := []test{
+		{
+			name:             "user task not found",
+			mockCalls:        mockPersisterApplier{setPersiterSelectByIDMock(UserTask{}, nil, userTaskID, userID)},
+			expectedError:    userTaskNotFoundError,
+			expectedTimeline: Timeline{},
+		},
+		{
+			name:             "user task is not in the tenant",
+			ctx:              auth.WithTenant(testCtx, clientID+1),
+			mockCalls:        mockPersisterApplier{setPersiterSelectByIDMock(active, nil, userTaskID, userID)},
+			expectedError:    userTaskNotFoundError,
+			expectedTimeline: Timeline{},
+		},
+		{
+			name: "select status changes return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(active, nil, userTaskID, userID),
+				setPersiterSelectStatusChangesMock(nil, customError, userTaskID),
+			},
+			expectedError:    customError,
+			expectedTimeline: Timeline{},
+		},
+		{
+			name: "user task without history",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(active, nil, userTaskID, userID),
+				setPersiterSelectStatusChangesMock(nil, nil, userTaskID),
+			},
+			expectedError: nil,
+			expectedTimeline: Timeline{
+				UserTask:     active,
+				Changes:      []StatusChange{},
+				TimeInStatus: []StatusTime{},
+			},
+		},
+		{
+			name: "current status is counted until now",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(active, nil, userTaskID, userID),
+				setPersiterSelectStatusChangesMock(changes, nil, userTaskID),
+			},
+			expectedError: nil,
+			expectedTimeline: Timeline{
+				UserTask: active,
+				Changes:  changes,
+				TimeInStatus: []StatusTime{
+					{Status: pending, Seconds: 2 * 3600},
+					{Status: inProgress, Seconds: 6 * 3600},
+					{Status: blocked, Seconds: 2 * 3600},
+				},
+			},
+		},
+		{
+			name: "final status is not counted",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(done, nil, userTaskID, userID),
+				setPersiterSelectStatusChangesMock(finished, nil, userTaskID),
+			},
+			expectedError: nil,
+			expectedTimeline: Timeline{
+				UserTask: done,
+				Changes:  finished,
+				TimeInStatus: []StatusTime{
+					{Status: pending, Seconds: 2 * 3600},
+					{Status: inProgress, Seconds: 6 * 3600},
+					{Status: blocked, Seconds: 3600},
+					{Status: StatusDone, Seconds: 0},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			ctx := testCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			result, err := serv.getTimeline(ctx, userID, userTaskID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTimeline, result)
+		})
+	}
+}
+
+func (s *AssignmentServiceSuite) TestGetBoard() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+		filter      = BoardFilter{TaskType: "bug", AssigneeID: 20}
+		normalized  = BoardFilter{TaskType: "bug", AssigneeID: 20, Limit: defaultColumnLimit}
+		first       = BoardCard{ID: 1, TaskID: 30, TaskType: "bug", Assignee: Assignee{UserID: 20, UserName: "john"}}
+		second      = BoardCard{ID: 2, TaskID: 31, TaskType: "bug", Assignee: Assignee{UserID: 20, UserName: "john"}}
+		legacy      = BoardCard{ID: 3, TaskID: 32, TaskType: "bug", Assignee: Assignee{UserID: 20, UserName: "john"}}
+		rows        = []boardRow{
+			{card: first, status: StatusBlocked, count: 5},
+			{card: second, status: StatusBlocked, count: 5},
+			{card: legacy, status: "archived", count: 1},
+		}
+	)
+
+	emptyColumns := func() []BoardColumn {
+		columns := make([]BoardColumn, 0, len(boardStatuses))
+		for _, status := range boardStatuses {
+			columns = append(columns, BoardColumn{Status: status, Cards: []BoardCard{}})
+		}
+		return columns
+	}
+	filledColumns := emptyColumns()
+	filledColumns[2] = BoardColumn{Status: StatusBlocked, Count: 5, Cards: []BoardCard{first, second}}
+	filledColumns = append(filledColumns, BoardColumn{Status: "archived", Count: 1, Cards: []BoardCard{legacy}})
+
+	type test struct {
+		name          string
+		ctx           context.Context
+		filter        BoardFilter
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedBoard Board
+	}
+
+	tests := []test{
+		{
+			name:          "client is not in the tenant",
+			ctx:           auth.WithTenant(testCtx, clientID+1),
+			filter:        filter,
+			expectedError: clientNotFoundError,
+			expectedBoard: Board{},
+		},
+		{
+			name:          "has client return error",
+			filter:        filter,
+			mockCalls:     mockPersisterApplier{setPersiterHasClientMock(false, customError, clientID)},
+			expectedError: customError,
+			expectedBoard: Board{},
+		},
+		{
+			name:          "client does not exist",
+			filter:        filter,
+			mockCalls:     mockPersisterApplier{setPersiterHasClientMock(false, nil, clientID)},
+			expectedError: clientNotFoundError,
+			expectedBoard: Board{},
+		},
+		{
+			name:   "select board return error",
+			filter: filter,
+			mockCalls: mockPersisterApplier{
+				setPersiterHasClientMock(true, nil, clientID),
+				setPersiterSelectBoardMock(nil, customError, clientID, normalized),
+			},
+			expectedError: customError,
+			expectedBoard: Board{},
+		},
+		{
+			name:   "empty board has every column",
+			filter: filter,
+			mockCalls: mockPersisterApplier{
+				setPersiterHasClientMock(true, nil, clientID),
+				setPersiterSelectBoardMock(nil, nil, clientID, normalized),
+			},
+			expectedError: nil,
+			expectedBoard: Board{ClientID: clientID, Columns: emptyColumns()},
+		},
+		{
+			name:   "limit is bounded",
+			filter: BoardFilter{Limit: 1000},
+			mockCalls: mockPersisterApplier{
+				setPersiterHasClientMock(true, nil, clientID),
+				setPersiterSelectBoardMock(nil, nil, clientID, BoardFilter{Limit: maxColumnLimit}),
+			},
+			expectedError: nil,
+			expectedBoard: Board{ClientID: clientID, Columns: emptyColumns()},
+		},
+		{
+			name:   "happy case",
+			ctx:    auth.WithTenant(testCtx, clientID),
+			filter: filter,
+			mockCalls: mockPersisterApplier{
+				setPersiterHasClientMock(true, nil, clientID),
+				setPersiterSelectBoardMock(rows, nil, clientID, normalized),
+			},
+			expectedError: nil,
+			expectedBoard: Board{ClientID: clientID, Columns: filledColumns},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			ctx := testCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			result, err := serv.getBoard(ctx, clientID, test.filter)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedBoard, result)
+		})
+	}
+}
+
+func (s *AssignmentServiceSuite) TestGetWorkload() {
+	var (
+		customError = errors.New("custom error")
+		filter      = WorkloadFilter{ClientID: 10, From: testNow.Add(-24 * time.Hour), To: testNow}
+		john        = Assignee{UserID: 20, UserName: "john", Alias: "jd"}
+		jane        = Assignee{UserID: 21, UserName: "jane", Alias: "js"}
+		mary        = Assignee{UserID: 22, UserName: "mary", Alias: "mr"}
+		openRows    = []openTasksRow{
+			{assignee: john, status: StatusPending, count: 2},
+			{assignee: john, status: StatusBlocked, count: 1},
+			{assignee: mary, status: StatusInProgress, count: 3},
+		}
+		completionRows = []completionsRow{
+			{assignee: jane, completed: 1, averageSeconds: 3600},
+			{assignee: john, completed: 2, averageSeconds: 5400.5},
+		}
+		johnAverage = int64(5401)
+		janeAverage = int64(3600)
+	)
+
+	type test struct {
+		name           string
+		ctx            context.Context
+		mockCalls      mockPersisterApplier
+		expectedError  error
+		expectedReport WorkloadReport
+	}
+
+	tests := []test{
+		{
+			name:           "client is not in the tenant",
+			ctx:            auth.WithTenant(testCtx, filter.ClientID+1),
+			expectedError:  clientNotFoundError,
+			expectedReport: WorkloadReport{},
+		},
+		{
+			name:           "has client return error",
+			mockCalls:      mockPersisterApplier{setPersiterHasClientMock(false, customError, filter.ClientID)},
+			expectedError:  customError,
+			expectedReport: WorkloadReport{},
+		},
+		{
+			name:           "client does not exist",
+			mockCalls:      mockPersisterApplier{setPersiterHasClientMock(false, nil, filter.ClientID)},
+			expectedError:  clientNotFoundError,
+			expectedReport: WorkloadReport{},
+		},
+		{
+			name: "select open tasks return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterHasClientMock(true, nil, filter.ClientID),
+				setPersiterSelectOpenTasksMock(nil, customError, filter.ClientID),
+			},
+			expectedError:  customError,
+			expectedReport: WorkloadReport{},
+		},
+		{
+			name: "select completions return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterHasClientMock(true, nil, filter.ClientID),
+				setPersiterSelectOpenTasksMock(openRows, nil, filter.ClientID),
+				setPersiterSelectCompletionsMock(nil, customError, filter),
+			},
+			expectedError:  customError,
+			expectedReport: WorkloadReport{},
+		},
+		{
+			name: "client without tasks",
+			mockCalls: mockPersisterApplier{
+				setPersiterHasClientMock(true, nil, filter.ClientID),
+				setPersiterSelectOpenTasksMock(nil, nil, filter.ClientID),
+				setPersiterSelectCompletionsMock(nil, nil, filter),
+			},
+			expectedError: nil,
+			expectedReport: WorkloadReport{
+				ClientID: filter.ClientID,
+				From:     filter.From,
+				To:       filter.To,
+				Users:    []UserWorkload{},
+			},
+		},
+		{
+			name: "happy case",
+			ctx:  auth.WithTenant(testCtx, filter.ClientID),
+			mockCalls: mockPersisterApplier{
+				setPersiterHasClientMock(true, nil, filter.ClientID),
+				setPersiterSelectOpenTasksMock(openRows, nil, filter.ClientID),
+				setPersiterSelectCompletionsMock(completionRows, nil, filter),
+			},
+			expectedError: nil,
+			expectedReport: WorkloadReport{
+				ClientID: filter.ClientID,
+				From:     filter.From,
+				To:       filter.To,
+				Users: []UserWorkload{
+					{
+						Assignee:                 john,
+						OpenTasks:                map[string]int{StatusPending: 2, StatusInProgress: 0, StatusBlocked: 1},
+						Completed:                2,
+						AverageCompletionSeconds: &johnAverage,
+					},
+					{
+						Assignee:                 jane,
+						OpenTasks:                map[string]int{StatusPending: 0, StatusInProgress: 0, StatusBlocked: 0},
+						Completed:                1,
+						AverageCompletionSeconds: &janeAverage,
+					},
+					{
+						Assignee:  mary,
+						OpenTasks: map[string]int{StatusPending: 0, StatusInProgress: 3, StatusBlocked: 0},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			ctx := testCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			result, err := serv.getWorkload(ctx, filter)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedReport, result)
+		})
+	}
+}
+
+// newServiceForTest returns a service with a mocked repository and a fixed clock, where bug tasks can be reopened
+// once done and can only be assigned to developers.
+func newServiceForTest() assignmentService {
+	lifecycle := NewLifecycle(map[string]Transitions{"bug": {StatusDone: {StatusInProgress}}})
+	serv := NewService(newDBMock(), lifecycle, map[string]string{"bug": "developer"}).(assignmentService)
+	serv.now = func() time.Time {
+		return testNow
+	}
+	return serv
+}
+
+type mockPersisterApplier []func(as *assignmentService) (func(t *testing.T), error)
+
+func (appliers mockPersisterApplier) apply(as *assignmentService) (func(t *testing.T), error) {
+	var assertCalls []func(t *testing.T)
+	for i := range appliers {
+		if assertCall, err := appliers[i](as); err != nil {
+			return func(t *testing.T) {}, err
+		} else {
+			assertCalls = append(assertCalls, assertCall)
+		}
+	}
+	return func(t *testing.T) {
+		for i := range assertCalls {
+			assertCalls[i](t)
+		}
+	}, nil
+}
+
+func setPersiterWithTransactionMock(
+	errorResponse error,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.withTransaction), mock.Anything).
+			Return(errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByIDMock(
+	userTaskResponse UserTask,
+	errorResponse error,
+	userTaskID, userID int64,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectByID), userTaskID, userID).
+			Return(userTaskResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByIDForUpdateMock(
+	userTaskResponse UserTask,
+	errorResponse error,
+	userTaskID, userID int64,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectByIDForUpdate), userTaskID, userID).
+			Return(userTaskResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectTaskMock(
+	taskResponse task,
+	errorResponse error,
+	taskID int64,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectTask), taskID).
+			Return(taskResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectUserMock(
+	userResponse user,
+	errorResponse error,
+	userID int64,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectUser), userID).
+			Return(userResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterHasActiveMembershipMock(
+	member bool,
+	errorResponse error,
+	userID, clientID int64,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.hasActiveMembership), userID, clientID).
+			Return(member, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterHasRoleTypeMock(
+	has bool,
+	errorResponse error,
+	userID int64,
+	roleType string,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.hasRoleType), userID, roleType).
+			Return(has, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterHasClientMock(
+	has bool,
+	errorResponse error,
+	clientID int64,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.hasClient), clientID).
+			Return(has, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterCreateUserTaskMock(
+	userTaskID int64,
+	errorResponse error,
+	userID int64,
+	request AssignTaskRequest,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.createUserTask), userID, request, StatusPending).
+			Return(userTaskID, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterUpdateStatusMock(
+	updated bool,
+	errorResponse error,
+	userTaskID int64,
+	status string,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.updateStatus), userTaskID, status).
+			Return(updated, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterCreateStatusChangeMock(
+	errorResponse error,
+	userTaskID int64,
+	change StatusChange,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.createStatusChange), userTaskID, change).
+			Return(errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectStatusChangesMock(
+	changesResponse []StatusChange,
+	errorResponse error,
+	userTaskID int64,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectStatusChanges), userTaskID).
+			Return(changesResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectBoardMock(
+	rowsResponse []boardRow,
+	errorResponse error,
+	clientID int64,
+	filter BoardFilter,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectBoard), clientID, filter).
+			Return(rowsResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectOpenTasksMock(
+	rowsResponse []openTasksRow,
+	errorResponse error,
+	clientID int64,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectOpenTasks), clientID).
+			Return(rowsResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectCompletionsMock(
+	rowsResponse []completionsRow,
+	errorResponse error,
+	filter WorkloadFilter,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectCompletions), filter).
+			Return(rowsResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterRecordMock(
+	err error,
+	action string,
+	before, after *UserTask,
+) func(as *assignmentService) (func(t *testing.T), error) {
+	return func(as *assignmentService) (func(t *testing.T), error) {
+		r, ok := as.assignmentRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+
+		var (
+			userTaskID                int64
+			beforeEntity, afterEntity any
+		)
+		if before != nil {
+			userTaskID, beforeEntity = before.ID, *before
+		}
+		if after != nil {
+			userTaskID, afterEntity = after.ID, *after
+		}
+		entry, entryErr := audit.NewEntry(testCaller, action, auditEntity, userTaskID, beforeEntity, afterEntity)
+		if entryErr != nil {
+			return nil, entryErr
+		}
+
+		r.On(util.GetFunctionName(r.record), entry).
+			Return(err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const (
+	ActionCreate           = "create"
+	ActionModify           = "modify"
+	ActionDeactivate       = "deactivate"
+	ActionDelete           = "delete"
+	ActionVerify           = "verify"
+	ActionGrant            = "grant"
+	ActionRevoke           = "revoke"
+	ActionExpire           = "expire"
+	ActionAddParent        = "add_parent"
+	ActionRemoveParent     = "remove_parent"
+	ActionAddPermission    = "add_permission"
+	ActionRemovePermission = "remove_permission"
+	ActionAddMember        = "add_member"
+	ActionRemoveMember     = "remove_member"
+	ActionAssign           = "assign"
+	ActionTransition       = "transition"
+
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// Entry records a change applied to an entity, like a user or a role.
+type Entry struct {
+	ID          int64           `json:"id"`
+	Actor       string          `json:"actor"`
+	Action      string          `json:"action"`
+	Entity      string          `json:"entity"`
+	EntityID    int64           `json:"entity_id"`
+	Changes     json.RawMessage `json:"changes"`
+	DateCreated time.Time       `json:"date_created"`
+}
+
+// Change keeps the previous and the new value of a field.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// NewEntry generates an entry whose changes are the fields that differ between before and after. Both of them must
+// be marshalled as JSON objects, a nil value means that the entity did not exist before or does not exist after.
+func NewEntry(actor, action, entity string, entityID int64, before, after any) (Entry, error) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return Entry{
+		Actor:    actor,
+		Action:   action,
+		Entity:   entity,
+		EntityID: entityID,
+		Changes:  changes,
+	}, nil
+}
+
+// NewSnapshotEntry generates an entry whose changes are every field of before and after, even the ones that do not
+// differ. It is used when the changed fields alone would not tell which entity changed, as for an expired grant.
+func NewSnapshotEntry(actor, action, entity string, entityID int64, before, after any) (Entry, error) {
+	changes, err := Snapshot(before, after)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return Entry{
+		Actor:    actor,
+		Action:   action,
+		Entity:   entity,
+		EntityID: entityID,
+		Changes:  changes,
+	}, nil
+}
+
+// Diff returns a JSON object with a Change by every field whose value differs between before and after.
+func Diff(before, after any) (json.RawMessage, error) {
+	return compare(before, after, false)
+}
+
+// Snapshot returns a JSON object with a Change by every field of before or after, whether it differs or not.
+func Snapshot(before, after any) (json.RawMessage, error) {
+	return compare(before, after, true)
+}
+
+func compare(before, after any, keepUnchanged bool) (json.RawMessage, error) {
+	beforeFields, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]Change{}
+	for field, value := range beforeFields {
+		if afterValue, ok := afterFields[field]; keepUnchanged || !ok || !reflect.DeepEqual(value, afterValue) {
+			changes[field] = Change{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = Change{After: value}
+		}
+	}
+
+	// map keys are sorted by encoding/json, so the same changes always generate the same JSON
+	return json.Marshal(changes)
+}
+
+func toFields(v any) (map[string]any, error) {
+	fields := map[string]any{}
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil()) {
+		return fields, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("audit cannot marshal entity: %w", err)
+	}
+
+	if err = json.Unmarshal(b, &fields); err != nil {
+		return nil, fmt.Errorf("audit entity must be a JSON object: %w", err)
+	}
+
+	return fields, nil
+}
+
+// Page selects entries older than BeforeID. Zero BeforeID selects from the newest entry.
+type Page struct {
+	Limit    int
+	BeforeID int64
+}
+
+// normalize returns the page with its limit bounded.
+func (p Page) normalize() Page {
+	if p.Limit <= 0 {
+		p.Limit = defaultPageLimit
+	}
+	if p.Limit > maxPageLimit {
+		p.Limit = maxPageLimit
+	}
+	return p
+}
+
+type Paging struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type History struct {
+	Results []Entry `json:"results"`
+	Paging  Paging  `json:"paging"`
+}
@@ -0,0 +1,235 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"maria/src/api/db"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type entity struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+}
+
+func TestDiff(t *testing.T) {
+	type test struct {
+		name            string
+		before          any
+		after           any
+		expectedChanges string
+		expectedError   bool
+	}
+
+	tests := []test{
+		{
+			name:            "created entity",
+			before:          nil,
+			after:           entity{Name: "name", Active: true},
+			expectedChanges: `{"active":{"before":null,"after":true},"name":{"before":null,"after":"name"}}`,
+		},
+		{
+			name:            "deleted entity",
+			before:          &entity{Name: "name"},
+			after:           (*entity)(nil),
+			expectedChanges: `{"active":{"before":false,"after":null},"name":{"before":"name","after":null}}`,
+		},
+		{
+			name:            "modified entity",
+			before:          entity{Name: "name"},
+			after:           entity{Name: "name", Active: true},
+			expectedChanges: `{"active":{"before":false,"after":true}}`,
+		},
+		{
+			name:            "nothing changed",
+			before:          entity{Name: "name"},
+			after:           entity{Name: "name"},
+			expectedChanges: `{}`,
+		},
+		{
+			name:          "entity is not an object",
+			before:        "name",
+			after:         nil,
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changes, err := Diff(test.before, test.after)
+
+			if test.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, test.expectedChanges, string(changes))
+		})
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	changes, err := Snapshot(entity{Name: "name"}, entity{Name: "name", Active: true})
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"active":{"before":false,"after":true},"name":{"before":"name","after":"name"}}`,
+		string(changes))
+
+	_, err = Snapshot("name", nil)
+
+	assert.Error(t, err)
+}
+
+func TestRecord(t *testing.T) {
+	var (
+		customError = errors.New("custom error")
+		entry       = Entry{
+			Actor:    "admin",
+			Action:   ActionModify,
+			Entity:   "user",
+			EntityID: 10,
+			Changes:  json.RawMessage(`{}`),
+		}
+	)
+
+	type test struct {
+		name          string
+		mockCall      func(m sqlmock.Sqlmock) func() error
+		expectedError error
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCall: db.SetClientExecMock(
+				nil, insertEntryQuery, customError, "admin", ActionModify, "user", int64(10), "{}"),
+			expectedError: db.ExecError(customError, insertEntryQuery),
+		},
+		{
+			name: "happy case",
+			mockCall: db.SetClientExecMock(
+				sqlmock.NewResult(1, 1), insertEntryQuery, nil, "admin", ActionModify, "user", int64(10), "{}"),
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCall(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			err = NewRelationalDB(client).Record(entry)
+
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+}
+
+func TestSelectHistory(t *testing.T) {
+	var (
+		customError = errors.New("custom error")
+		now         = time.Now()
+	)
+
+	getRows := func(ids ...int64) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"id", "actor", "action", "entity", "entity_id", "changes", "date_created"})
+		for _, id := range ids {
+			rows.AddRow(id, "admin", ActionModify, "user", 10, []byte(`{}`), now)
+		}
+		return rows
+	}
+	getEntries := func(ids ...int64) []Entry {
+		entries := make([]Entry, 0, len(ids))
+		for _, id := range ids {
+			entries = append(entries, Entry{
+				ID:          id,
+				Actor:       "admin",
+				Action:      ActionModify,
+				Entity:      "user",
+				EntityID:    10,
+				Changes:     json.RawMessage(`{}`),
+				DateCreated: now,
+			})
+		}
+		return entries
+	}
+
+	type test struct {
+		name            string
+		page            Page
+		mockCall        func(m sqlmock.Sqlmock) func() error
+		expectedError   error
+		expectedHistory History
+	}
+
+	tests := []test{
+		{
+			name: "query error",
+			page: Page{},
+			mockCall: db.SetClientQueryMock(
+				nil, getEntriesQuery, customError, nil, "user", int64(10), int64(0), int64(0), defaultPageLimit+1),
+			expectedError:   db.QueryError(customError, getEntriesQuery),
+			expectedHistory: History{},
+		},
+		{
+			name: "last page",
+			page: Page{Limit: 2, BeforeID: 5},
+			mockCall: db.SetClientQueryMock(
+				getRows(4), getEntriesQuery, nil, nil, "user", int64(10), int64(5), int64(5), 3),
+			expectedError:   nil,
+			expectedHistory: History{Results: getEntries(4), Paging: Paging{Limit: 2}},
+		},
+		{
+			name: "there is a next page",
+			page: Page{Limit: 2},
+			mockCall: db.SetClientQueryMock(
+				getRows(9, 8, 7), getEntriesQuery, nil, nil, "user", int64(10), int64(0), int64(0), 3),
+			expectedError:   nil,
+			expectedHistory: History{Results: getEntries(9, 8), Paging: Paging{Limit: 2, NextCursor: "8"}},
+		},
+		{
+			name: "limit is bounded",
+			page: Page{Limit: 1000},
+			mockCall: db.SetClientQueryMock(
+				getRows(), getEntriesQuery, nil, nil, "user", int64(10), int64(0), int64(0), maxPageLimit+1),
+			expectedError:   nil,
+			expectedHistory: History{Results: []Entry{}, Paging: Paging{Limit: maxPageLimit}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCall(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			history, err := NewRelationalDB(client).SelectHistory("user", 10, test.page)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedHistory, history)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"database/sql"
+	"fmt"
+	"maria/src/api/db"
+	"strconv"
+)
+
+const (
+	insertEntryQuery = `INSERT INTO audit_log (actor, action, entity, entity_id, changes) VALUES (?, ?, ?, ?, ?)`
+	getEntriesQuery  = `SELECT id, actor, action, entity, entity_id, changes, date_created FROM audit_log ` +
+		`WHERE entity = ? AND entity_id = ? AND (? = 0 OR id < ?) ORDER BY id DESC LIMIT ?`
+)
+
+// Persister writes and reads audit entries. It can be built over a transaction, so entries are written together
+// with the changes they record.
+type Persister interface {
+	Record(Entry) error
+	SelectHistory(entity string, entityID int64, page Page) (History, error)
+}
+
+func NewRelationalDB(client db.Client) Persister {
+	return &relationalDB{
+		client: client,
+	}
+}
+
+type relationalDB struct {
+	client db.Client
+}
+
+func (r *relationalDB) Record(entry Entry) error {
+	if _, err := r.client.Exec(
+		insertEntryQuery,
+		entry.Actor,
+		entry.Action,
+		entry.Entity,
+		entry.EntityID,
+		string(entry.Changes),
+	); err != nil {
+		return db.ExecError(err, insertEntryQuery)
+	}
+	return nil
+}
+
+// SelectHistory returns the entries of an entity from the newest to the oldest one.
+func (r *relationalDB) SelectHistory(entity string, entityID int64, page Page) (History, error) {
+	var (
+		rows    *sql.Rows
+		err     error
+		entries []Entry
+	)
+
+	page = page.normalize()
+
+	// one extra entry is requested for knowing whether there is a next page
+	if rows, err = r.client.Query(getEntriesQuery, entity, entityID, page.BeforeID, page.BeforeID, page.Limit+1); err != nil {
+		return History{}, db.QueryError(err, getEntriesQuery)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			e       Entry
+			changes []byte
+		)
+		if err = rows.Scan(
+			&e.ID,
+			&e.Actor,
+			&e.Action,
+			&e.Entity,
+			&e.EntityID,
+			&changes,
+			&e.DateCreated,
+		); err != nil {
+			return History{}, db.ScanError(err, getEntriesQuery)
+		}
+		e.Changes = changes
+		entries = append(entries, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return History{}, db.RowsError(err, getEntriesQuery)
+	}
+
+	history := History{
+		Results: make([]Entry, 0, page.Limit),
+		Paging:  Paging{Limit: page.Limit},
+	}
+
+	if len(entries) > page.Limit {
+		entries = entries[:page.Limit]
+		history.Paging.NextCursor = strconv.FormatInt(entries[page.Limit-1].ID, 10)
+	}
+
+	history.Results = append(history.Results, entries...)
+
+	return history, nil
+}
@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// AdminRoleType is required for changing users and roles.
+	AdminRoleType = "admin"
+
+	// ViewerRoleType is enough for reading them.
+	ViewerRoleType = "viewer"
+)
+
+// Permissions of the catalogue, they are attached to roles through role_permission.
+const (
+	PermissionUserRead    = "user:read"
+	PermissionUserWrite   = "user:write"
+	PermissionRoleRead    = "role:read"
+	PermissionRoleWrite   = "role:write"
+	PermissionClientRead  = "client:read"
+	PermissionClientWrite = "client:write"
+	PermissionTaskRead    = "task:read"
+	PermissionTaskWrite   = "task:write"
+	PermissionTaskAssign  = "task:assign"
+)
+
+// resolvedGrantsKey keeps in the gin context the grants of the caller loaded by the authorizer for protected routes.
+const resolvedGrantsKey = "auth.grants"
+
+var (
+	callerNotIdentifiedError = newUnauthorizedResponse(CallerHeader + " header must contain the id of the calling user")
+)
+
+// Requirement is what a route requires from the caller. Any of its role types or any of its permissions gives
+// access.
+type Requirement struct {
+	RoleTypes   []string
+	Permissions []string
+}
+
+// merge returns a requirement satisfied by what satisfies r or other.
+func (r Requirement) merge(other Requirement) Requirement {
+	return Requirement{
+		RoleTypes:   append(append([]string(nil), r.RoleTypes...), other.RoleTypes...),
+		Permissions: append(append([]string(nil), r.Permissions...), other.Permissions...),
+	}
+}
+
+func (r Requirement) String() string {
+	var parts []string
+	if len(r.RoleTypes) > 0 {
+		parts = append(parts, "role types ("+strings.Join(r.RoleTypes, ", ")+")")
+	}
+	if len(r.Permissions) > 0 {
+		parts = append(parts, "permissions ("+strings.Join(r.Permissions, ", ")+")")
+	}
+	return strings.Join(parts, " or ")
+}
+
+// Grants keeps what has been granted to a user, directly or through the role hierarchy, and the clients the user is
+// an unexpired member of.
+type Grants struct {
+	RoleTypes   []string
+	Permissions []string
+	ClientIDs   []int64
+}
+
+func (g Grants) satisfies(r Requirement) bool {
+	return hasAny(g.RoleTypes, r.RoleTypes) || hasAny(g.Permissions, r.Permissions)
+}
+
+// IsAdmin returns whether the user has the admin role type, which gives access to everything.
+func (g Grants) IsAdmin() bool {
+	return hasAny(g.RoleTypes, []string{AdminRoleType})
+}
+
+// CanGrant returns whether the user can grant a role which gives roleTypes and permissions, directly or through its
+// parents. Admins can grant any role, any other user can neither grant the admin role type nor a role type or
+// permission giving access to routes which they do not have, so granting never widens the access of the granter.
+func (g Grants) CanGrant(roleTypes, permissions []string) bool {
+	if g.IsAdmin() {
+		return true
+	}
+
+	for _, roleType := range roleTypes {
+		if roleType == AdminRoleType || (roleType == ViewerRoleType && !hasAny(g.RoleTypes, []string{roleType})) {
+			return false
+		}
+	}
+	for _, permission := range permissions {
+		if !hasAny(g.Permissions, []string{permission}) {
+			return false
+		}
+	}
+	return true
+}
+
+type grantsKey struct{}
+
+// WithGrants returns a copy of ctx which carries the grants of the caller.
+func WithGrants(ctx context.Context, grants Grants) context.Context {
+	return context.WithValue(ctx, grantsKey{}, grants)
+}
+
+// GrantsFrom returns the grants of the caller carried by ctx. They are only set for protected routes, so an empty
+// Grants is returned for the public ones.
+func GrantsFrom(ctx context.Context) Grants {
+	grants, _ := ctx.Value(grantsKey{}).(Grants)
+	return grants
+}
+
+// Routes maps routes, built with Route, to the requirement to call them.
+type Routes map[string]Requirement
+
+// Route returns the key of the route in Routes, path must be written as it is registered in the router.
+func Route(method, path string) string {
+	return method + " " + path
+}
+
+// Authorizer protects the routes it knows, the calling user must satisfy their requirement. Routes which are not
+// declared are public.
+type Authorizer struct {
+	loader        GrantLoader
+	routes        Routes
+	integerParser func(s string, base int, bitSize int) (i int64, err error)
+}
+
+func NewAuthorizer(loader GrantLoader, routes ...Routes) Authorizer {
+	merged := make(Routes)
+	for i := range routes {
+		for route, requirement := range routes[i] {
+			merged[route] = merged[route].merge(requirement)
+		}
+	}
+
+	return Authorizer{
+		loader:        loader,
+		routes:        merged,
+		integerParser: strconv.ParseInt,
+	}
+}
+
+// Authorize is a gin middleware, it must be used by the router before setting the routes.
+func (a Authorizer) Authorize(ctx *gin.Context) {
+	required, ok := a.routes[Route(ctx.Request.Method, ctx.FullPath())]
+	if !ok {
+		ctx.Next()
+		return
+	}
+
+	callerID, err := a.integerParser(ctx.GetHeader(CallerHeader), 10, 64)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, callerNotIdentifiedError)
+		return
+	}
+
+	grants, err := a.loader.selectGrants(callerID)
+	if errors.Is(err, callerNotFoundError) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, callerNotIdentifiedError)
+		return
+	}
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	if !grants.satisfies(required) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, newForbiddenResponse(
+			fmt.Sprintf("caller is not granted any of the required %s", required)))
+		return
+	}
+
+	if !resolveTenant(ctx, grants) {
+		return
+	}
+
+	ctx.Set(resolvedGrantsKey, grants)
+	ctx.Next()
+}
+
+func hasAny(granted, required []string) bool {
+	for i := range required {
+		for j := range granted {
+			if granted[j] == required[i] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newUnauthorizedResponse(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     message,
+		"status_code": http.StatusUnauthorized,
+	}
+}
+
+func newBadRequestResponse(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     message,
+		"status_code": http.StatusBadRequest,
+	}
+}
+
+func newForbiddenResponse(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     message,
+		"status_code": http.StatusForbidden,
+	}
+}
+
+func newInternalServerError(cause error) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     "internal server error",
+		"cause":       cause,
+		"status_code": http.StatusInternalServerError,
+	}
+}
@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"errors"
+	"maria/src/api/util"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorize(t *testing.T) {
+	var (
+		callerID    = int64(10)
+		customError = errors.New("custom error")
+		routes      = Routes{
+			Route(http.MethodGet, "/user/:user_id"): {
+				RoleTypes:   []string{AdminRoleType, ViewerRoleType},
+				Permissions: []string{PermissionUserRead},
+			},
+			Route(http.MethodDelete, "/user/:user_id"): {
+				RoleTypes:   []string{AdminRoleType},
+				Permissions: []string{PermissionUserWrite},
+			},
+		}
+	)
+
+	type test struct {
+		name             string
+		method           string
+		caller           string
+		tenant           string
+		mockCalls        func(m *loaderMock)
+		expectedStatus   int
+		expectedResponse string
+		expectedTenant   int64
+		expectedScoped   bool
+	}
+
+	tests := []test{
+		{
+			name:             "public route",
+			method:           http.MethodPut,
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "",
+		},
+		{
+			name:             "caller is missed",
+			method:           http.MethodGet,
+			expectedStatus:   http.StatusUnauthorized,
+			expectedResponse: util.RenderToJSON(callerNotIdentifiedError),
+		},
+		{
+			name:             "caller is not a user id",
+			method:           http.MethodGet,
+			caller:           "admin",
+			expectedStatus:   http.StatusUnauthorized,
+			expectedResponse: util.RenderToJSON(callerNotIdentifiedError),
+		},
+		{
+			name:   "loader error",
+			method: http.MethodGet,
+			caller: "10",
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), callerID).Return(Grants{}, customError).Once()
+			},
+			expectedStatus:   http.StatusInternalServerError,
+			expectedResponse: util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:   "caller does not exist",
+			method: http.MethodGet,
+			caller: "10",
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), callerID).Return(Grants{}, callerNotFoundError).Once()
+			},
+			expectedStatus:   http.StatusUnauthorized,
+			expectedResponse: util.RenderToJSON(callerNotIdentifiedError),
+		},
+		{
+			name:   "requirement is not satisfied",
+			method: http.MethodDelete,
+			caller: "10",
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), callerID).
+					Return(Grants{RoleTypes: []string{ViewerRoleType}, Permissions: []string{PermissionUserRead}}, nil).
+					Once()
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedResponse: util.RenderToJSON(newForbiddenResponse(
+				"caller is not granted any of the required role types (admin) or permissions (user:write)")),
+		},
+		{
+			name:   "one of the required role types is granted",
+			method: http.MethodGet,
+			caller: "10",
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), callerID).
+					Return(Grants{RoleTypes: []string{ViewerRoleType}, ClientIDs: []int64{7}}, nil).
+					Once()
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "",
+			expectedTenant:   7,
+			expectedScoped:   true,
+		},
+		{
+			name:   "one of the required permissions is granted",
+			method: http.MethodDelete,
+			caller: "10",
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), callerID).
+					Return(Grants{Permissions: []string{PermissionUserWrite}, ClientIDs: []int64{7}}, nil).
+					Once()
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "",
+			expectedTenant:   7,
+			expectedScoped:   true,
+		},
+		{
+			name:   "tenant is not a client id",
+			method: http.MethodGet,
+			caller: "10",
+			tenant: "acme",
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), callerID).
+					Return(Grants{RoleTypes: []string{AdminRoleType}}, nil).
+					Once()
+			},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: util.RenderToJSON(invalidTenantError),
+		},
+		{
+			name:   "admin without tenant is not scoped",
+			method: http.MethodGet,
+			caller: "10",
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), callerID).
+					Return(Grants{RoleTypes: []string{AdminRoleType}}, nil).
+					Once()
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "",
+			expectedTenant:   0,
+			expectedScoped:   false,
+		},
+		{
+			name:   "admin is scoped to any tenant",
+			method: http.MethodGet,
+			caller: "10",
+			tenant: "9",
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), callerID).
+					Return(Grants{RoleTypes: []string{AdminRoleType}}, nil).
+					Once()
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "",
+			expectedTenant:   9,
+			expectedScoped:   true,
+		},
+		{
+			name:   "caller is not a member of the tenant",
+			method: http.MethodGet,
+			caller: "10",
+			tenant: "9",
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), callerID).
+					Return(Grants{RoleTypes: []string{ViewerRoleType}, ClientIDs: []int64{7}}, nil).
+					Once()
+			},
+			expectedStatus:   http.StatusForbidden,
+			expectedResponse: util.RenderToJSON(newForbiddenResponse("caller is not a member of client 9")),
+		},
+		{
+			name:   "caller is not a member of any client",
+			method: http.MethodGet,
+			caller: "10",
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), callerID).
+					Return(Grants{RoleTypes: []string{ViewerRoleType}}, nil).
+					Once()
+			},
+			expectedStatus:   http.StatusForbidden,
+			expectedResponse: util.RenderToJSON(noTenantError),
+		},
+		{
+			name:   "caller of several clients must set the tenant",
+			method: http.MethodGet,
+			caller: "10",
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), callerID).
+					Return(Grants{RoleTypes: []string{ViewerRoleType}, ClientIDs: []int64{7, 9}}, nil).
+					Once()
+			},
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: util.RenderToJSON(requiredTenantError),
+		},
+		{
+			name:   "caller of several clients is scoped to the tenant set",
+			method: http.MethodGet,
+			caller: "10",
+			tenant: "9",
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), callerID).
+					Return(Grants{RoleTypes: []string{ViewerRoleType}, ClientIDs: []int64{7, 9}}, nil).
+					Once()
+			},
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "",
+			expectedTenant:   9,
+			expectedScoped:   true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := newLoaderMock()
+			if test.mockCalls != nil {
+				test.mockCalls(m)
+			}
+			defer m.AssertExpectations(t)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(NewAuthorizer(m, routes).Authorize)
+			router.Handle(test.method, "/user/:user_id", func(ctx *gin.Context) {
+				clientID, scoped := TenantFrom(Context(ctx))
+				assert.Equal(t, test.expectedTenant, clientID)
+				assert.Equal(t, test.expectedScoped, scoped)
+				ctx.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(test.method, "/user/10", nil)
+			if test.caller != "" {
+				req.Header.Set(CallerHeader, test.caller)
+			}
+			if test.tenant != "" {
+				req.Header.Set(TenantHeader, test.tenant)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, test.expectedResponse, w.Body.String())
+		})
+	}
+}
+
+func TestAuthorizeCarriesGrants(t *testing.T) {
+	var (
+		grants = Grants{Permissions: []string{PermissionUserRead}, ClientIDs: []int64{7}}
+		routes = Routes{Route(http.MethodGet, "/user/:user_id"): {Permissions: []string{PermissionUserRead}}}
+	)
+
+	m := newLoaderMock()
+	m.On(util.GetFunctionName(m.selectGrants), int64(10)).Return(grants, nil).Once()
+	defer m.AssertExpectations(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewAuthorizer(m, routes).Authorize)
+	router.GET("/user/:user_id", func(ctx *gin.Context) {
+		assert.Equal(t, grants, GrantsFrom(Context(ctx)))
+		ctx.Status(http.StatusOK)
+	})
+	router.GET("/public", func(ctx *gin.Context) {
+		assert.Equal(t, Grants{}, GrantsFrom(Context(ctx)))
+		ctx.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/user/10", "/public"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(CallerHeader, "10")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+}
+
+func TestGrantsCanGrant(t *testing.T) {
+	var (
+		admin  = Grants{RoleTypes: []string{AdminRoleType}}
+		viewer = Grants{RoleTypes: []string{ViewerRoleType}}
+		writer = Grants{RoleTypes: []string{"client"}, Permissions: []string{PermissionUserRead, PermissionUserWrite}}
+	)
+
+	type test struct {
+		name        string
+		grants      Grants
+		roleTypes   []string
+		permissions []string
+		expected    bool
+	}
+
+	tests := []test{
+		{name: "admin grants an admin role", grants: admin, roleTypes: []string{AdminRoleType}, expected: true},
+		{
+			name:        "admin grants permissions they do not have",
+			grants:      admin,
+			roleTypes:   []string{"client"},
+			permissions: []string{PermissionRoleWrite},
+			expected:    true,
+		},
+		{name: "writer grants an admin role", grants: writer, roleTypes: []string{"client", AdminRoleType}},
+		{name: "writer grants a viewer role", grants: writer, roleTypes: []string{ViewerRoleType}},
+		{name: "viewer grants a viewer role", grants: viewer, roleTypes: []string{ViewerRoleType}, expected: true},
+		{
+			name:        "writer grants a permission they do not have",
+			grants:      writer,
+			roleTypes:   []string{"client"},
+			permissions: []string{PermissionUserWrite, PermissionRoleWrite},
+		},
+		{
+			name:        "writer grants permissions they have",
+			grants:      writer,
+			roleTypes:   []string{"developer"},
+			permissions: []string{PermissionUserWrite},
+			expected:    true,
+		},
+		{name: "caller without grants grants a role without access", roleTypes: []string{"developer"}, expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.grants.CanGrant(test.roleTypes, test.permissions))
+		})
+	}
+}
+
+func TestNewAuthorizer(t *testing.T) {
+	route := Route(http.MethodGet, "/user")
+
+	authorizer := NewAuthorizer(newLoaderMock(),
+		Routes{route: {RoleTypes: []string{AdminRoleType}}},
+		Routes{route: {RoleTypes: []string{ViewerRoleType}, Permissions: []string{PermissionUserRead}}},
+	)
+
+	assert.Equal(t, Routes{route: {
+		RoleTypes:   []string{AdminRoleType, ViewerRoleType},
+		Permissions: []string{PermissionUserRead},
+	}}, authorizer.routes)
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CallerHeader identifies the user calling the API. It is not verified, so it is trusted only when every request
+	// comes through a gateway which authenticates the caller and overwrites the header sent by the client. The API must
+	// never be reachable without that gateway, otherwise anyone may act as any user.
+	CallerHeader = "X-Caller-ID"
+
+	// SystemCaller identifies changes which are not requested by a user, as background jobs.
+	SystemCaller = "system"
+
+	anonymousCaller = "anonymous"
+)
+
+type callerKey struct{}
+
+// WithCaller returns a copy of ctx which carries the caller.
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFrom returns the caller carried by ctx, or "anonymous" when there is none.
+func CallerFrom(ctx context.Context) string {
+	if caller, ok := ctx.Value(callerKey{}).(string); ok && caller != "" {
+		return caller
+	}
+	return anonymousCaller
+}
+
+// Context returns the request context carrying the caller taken from CallerHeader, its grants and the tenant the
+// request is scoped to. It must be used by controllers for passing the request to services.
+func Context(ctx *gin.Context) context.Context {
+	requestCtx := context.Background()
+	if ctx.Request != nil {
+		requestCtx = ctx.Request.Context()
+	}
+
+	if caller := ctx.GetHeader(CallerHeader); caller != "" {
+		requestCtx = WithCaller(requestCtx, caller)
+	}
+	if grants, ok := ctx.Get(resolvedGrantsKey); ok {
+		requestCtx = WithGrants(requestCtx, grants.(Grants))
+	}
+	if clientID := tenantOf(ctx); clientID > 0 {
+		requestCtx = WithTenant(requestCtx, clientID)
+	}
+	return requestCtx
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"maria/src/api/db"
+	"sync"
+	"time"
+)
+
+const (
+	// EffectiveRolesCTE selects the roles giving access to a user, it is the only place resolving the role hierarchy.
+	// Unexpired grants of active roles to active users give access, together with the active roles they inherit.
+	// Every row keeps the grant it comes from, its depth, which is zero for granted roles, and its path, the comma
+	// separated roles walked from the granted role to reach it. A role already in the path is not walked again, so a
+	// cycle in the hierarchy cannot make the recursion endless. A role reached in several ways has a row for each of
+	// them. It is exported for other packages checking what a user is granted, the user id is its only argument.
+	EffectiveRolesCTE = `WITH RECURSIVE effective (role_id, grant_id, depth, path) AS (` +
+		`SELECT ur.role_id, ur.id, 0, CAST(ur.role_id AS CHAR(1000)) FROM user_role ur ` +
+		`JOIN user u ON u.id = ur.user_id ` +
+		`JOIN role r ON r.id = ur.role_id ` +
+		`WHERE ur.user_id = ? AND u.active = true AND r.active = true ` +
+		`AND (ur.date_expired IS NULL OR ur.date_expired > NOW()) ` +
+		`UNION ALL ` +
+		`SELECT rp.parent_id, e.grant_id, e.depth + 1, CONCAT(e.path, ',', rp.parent_id) FROM role_parent rp ` +
+		`JOIN effective e ON e.role_id = rp.role_id ` +
+		`JOIN role p ON p.id = rp.parent_id ` +
+		`WHERE p.active = true AND FIND_IN_SET(rp.parent_id, e.path) = 0) `
+	getUserExistsQuery         = `SELECT EXISTS (SELECT 1 FROM user WHERE id = ?)`
+	getEffectiveRoleTypesQuery = EffectiveRolesCTE +
+		`SELECT DISTINCT r.type FROM effective e JOIN role r ON r.id = e.role_id`
+	getEffectivePermissionsQuery = EffectiveRolesCTE +
+		`SELECT DISTINCT p.name FROM effective e ` +
+		`JOIN role_permission rp ON rp.role_id = e.role_id ` +
+		`JOIN permission p ON p.id = rp.permission_id`
+	// inactive users are not members of any client, as they are not granted any role either
+	getMembershipsQuery = `SELECT uc.client_id FROM user_client uc ` +
+		`JOIN user u ON u.id = uc.user_id ` +
+		`JOIN client c ON c.id = uc.client_id ` +
+		`WHERE uc.user_id = ? AND u.active = true AND c.active = true ` +
+		`AND (uc.date_expired IS NULL OR uc.date_expired > NOW()) ORDER BY uc.client_id`
+)
+
+// maxCachedGrants bounds the callers whose grants are cached.
+const maxCachedGrants = 10000
+
+// callerNotFoundError is returned when the grants of a user who does not exist are loaded.
+var callerNotFoundError = errors.New("caller not found")
+
+// GrantLoader loads what a user has been granted, including what is inherited through the role hierarchy.
+type GrantLoader interface {
+	selectGrants(userID int64) (Grants, error)
+}
+
+type relationalDB struct {
+	client db.Client
+}
+
+func NewRelationalDB(client db.Client) GrantLoader {
+	return &relationalDB{
+		client: client,
+	}
+}
+
+func (r *relationalDB) selectGrants(userID int64) (Grants, error) {
+	var exists bool
+	if err := r.client.QueryRow(getUserExistsQuery, userID).Scan(&exists); err != nil {
+		return Grants{}, db.ScanError(err, getUserExistsQuery)
+	}
+	if !exists {
+		return Grants{}, callerNotFoundError
+	}
+
+	roleTypes, err := r.selectNames(getEffectiveRoleTypesQuery, userID)
+	if err != nil {
+		return Grants{}, err
+	}
+
+	permissions, err := r.selectNames(getEffectivePermissionsQuery, userID)
+	if err != nil {
+		return Grants{}, err
+	}
+
+	clientIDs, err := r.selectClientIDs(userID)
+	if err != nil {
+		return Grants{}, err
+	}
+
+	return Grants{RoleTypes: roleTypes, Permissions: permissions, ClientIDs: clientIDs}, nil
+}
+
+// selectClientIDs returns the clients the user is an unexpired member of.
+func (r *relationalDB) selectClientIDs(userID int64) ([]int64, error) {
+	rows, err := r.client.Query(getMembershipsQuery, userID)
+	if err != nil {
+		return nil, db.QueryError(err, getMembershipsQuery)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	var clientIDs []int64
+	for rows.Next() {
+		var clientID int64
+		if err = rows.Scan(&clientID); err != nil {
+			return nil, db.ScanError(err, getMembershipsQuery)
+		}
+		clientIDs = append(clientIDs, clientID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, getMembershipsQuery)
+	}
+
+	return clientIDs, nil
+}
+
+// selectNames returns the single string column selected by query.
+func (r *relationalDB) selectNames(query string, userID int64) ([]string, error) {
+	rows, err := r.client.Query(query, userID)
+	if err != nil {
+		return nil, db.QueryError(err, query)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, db.ScanError(err, query)
+		}
+		names = append(names, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, query)
+	}
+
+	return names, nil
+}
+
+type cacheEntry struct {
+	grants    Grants
+	expiresAt time.Time
+}
+
+// cachedLoader keeps loaded grants during ttl, so grants and revokes are taken into account once the entry expires.
+// It keeps maxEntries at most, errors, including unknown users, are not cached.
+type cachedLoader struct {
+	loader     GrantLoader
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+	mu         *sync.Mutex
+	entries    map[int64]cacheEntry
+}
+
+func NewCachedLoader(loader GrantLoader, ttl time.Duration) GrantLoader {
+	return cachedLoader{
+		loader:     loader,
+		ttl:        ttl,
+		maxEntries: maxCachedGrants,
+		now:        time.Now,
+		mu:         &sync.Mutex{},
+		entries:    make(map[int64]cacheEntry),
+	}
+}
+
+func (c cachedLoader) selectGrants(userID int64) (Grants, error) {
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[userID]
+	if ok && !now.Before(entry.expiresAt) {
+		delete(c.entries, userID)
+		ok = false
+	}
+	c.mu.Unlock()
+	if ok {
+		return entry.grants, nil
+	}
+
+	grants, err := c.loader.selectGrants(userID)
+	if err != nil {
+		return Grants{}, err
+	}
+
+	c.mu.Lock()
+	c.store(userID, cacheEntry{grants: grants, expiresAt: now.Add(c.ttl)}, now)
+	c.mu.Unlock()
+
+	return grants, nil
+}
+
+// store keeps the entry of userID. When the cache is full the expired entries are removed and, if none has expired,
+// the one expiring first. It must be called holding mu.
+func (c cachedLoader) store(userID int64, entry cacheEntry, now time.Time) {
+	if _, ok := c.entries[userID]; !ok && len(c.entries) >= c.maxEntries {
+		var (
+			firstID      int64
+			firstExpires time.Time
+		)
+		for id, cached := range c.entries {
+			if !now.Before(cached.expiresAt) {
+				delete(c.entries, id)
+				continue
+			}
+			if firstExpires.IsZero() || cached.expiresAt.Before(firstExpires) {
+				firstID, firstExpires = id, cached.expiresAt
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			delete(c.entries, firstID)
+		}
+	}
+
+	c.entries[userID] = entry
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type loaderMock struct {
+	mock.Mock
+}
+
+func newLoaderMock() *loaderMock {
+	return &loaderMock{}
+}
+
+func (m *loaderMock) selectGrants(userID int64) (Grants, error) {
+	args := m.Called(userID)
+	return mockGrants(args, 0), args.Error(1)
+}
+
+func mockGrants(args mock.Arguments, index int) Grants {
+	obj := args.Get(index)
+	var s Grants
+	var ok bool
+	if s, ok = obj.(Grants); !ok {
+		panic(fmt.Sprintf("assert: arguments: Grants(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
@@ -0,0 +1,258 @@
+package auth
+
+import (
+	"errors"
+	"maria/src/api/db"
+	"maria/src/api/util"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectGrants(t *testing.T) {
+	var (
+		userID      = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		mockCalls      []func(m sqlmock.Sqlmock) func() error
+		expectedError  error
+		expectedGrants Grants
+	}
+
+	tests := []test{
+		{
+			name: "user exists query error",
+			mockCalls: []func(m sqlmock.Sqlmock) func() error{
+				db.SetClientQueryMock(existsRows(true), getUserExistsQuery, nil, customError, userID),
+			},
+			expectedError:  db.ScanError(customError, getUserExistsQuery),
+			expectedGrants: Grants{},
+		},
+		{
+			name: "user does not exist",
+			mockCalls: []func(m sqlmock.Sqlmock) func() error{
+				db.SetClientQueryMock(existsRows(false), getUserExistsQuery, nil, nil, userID),
+			},
+			expectedError:  callerNotFoundError,
+			expectedGrants: Grants{},
+		},
+		{
+			name: "role types query error",
+			mockCalls: []func(m sqlmock.Sqlmock) func() error{
+				db.SetClientQueryMock(existsRows(true), getUserExistsQuery, nil, nil, userID),
+				db.SetClientQueryMock(nil, getEffectiveRoleTypesQuery, customError, nil, userID),
+			},
+			expectedError:  db.QueryError(customError, getEffectiveRoleTypesQuery),
+			expectedGrants: Grants{},
+		},
+		{
+			name: "role types rows error",
+			mockCalls: []func(m sqlmock.Sqlmock) func() error{
+				db.SetClientQueryMock(existsRows(true), getUserExistsQuery, nil, nil, userID),
+				db.SetClientQueryMock(
+					sqlmock.NewRows([]string{"type"}).AddRow(AdminRoleType), getEffectiveRoleTypesQuery, nil, customError, userID),
+			},
+			expectedError:  db.RowsError(customError, getEffectiveRoleTypesQuery),
+			expectedGrants: Grants{},
+		},
+		{
+			name: "permissions query error",
+			mockCalls: []func(m sqlmock.Sqlmock) func() error{
+				db.SetClientQueryMock(existsRows(true), getUserExistsQuery, nil, nil, userID),
+				db.SetClientQueryMock(
+					sqlmock.NewRows([]string{"type"}).AddRow(AdminRoleType), getEffectiveRoleTypesQuery, nil, nil, userID),
+				db.SetClientQueryMock(nil, getEffectivePermissionsQuery, customError, nil, userID),
+			},
+			expectedError:  db.QueryError(customError, getEffectivePermissionsQuery),
+			expectedGrants: Grants{},
+		},
+		{
+			name: "memberships query error",
+			mockCalls: []func(m sqlmock.Sqlmock) func() error{
+				db.SetClientQueryMock(existsRows(true), getUserExistsQuery, nil, nil, userID),
+				db.SetClientQueryMock(
+					sqlmock.NewRows([]string{"type"}).AddRow(AdminRoleType), getEffectiveRoleTypesQuery, nil, nil, userID),
+				db.SetClientQueryMock(
+					sqlmock.NewRows([]string{"name"}).AddRow(PermissionUserRead),
+					getEffectivePermissionsQuery, nil, nil, userID),
+				db.SetClientQueryMock(nil, getMembershipsQuery, customError, nil, userID),
+			},
+			expectedError:  db.QueryError(customError, getMembershipsQuery),
+			expectedGrants: Grants{},
+		},
+		{
+			name: "happy case",
+			mockCalls: []func(m sqlmock.Sqlmock) func() error{
+				db.SetClientQueryMock(existsRows(true), getUserExistsQuery, nil, nil, userID),
+				db.SetClientQueryMock(
+					sqlmock.NewRows([]string{"type"}).AddRow(AdminRoleType).AddRow(ViewerRoleType),
+					getEffectiveRoleTypesQuery, nil, nil, userID),
+				db.SetClientQueryMock(
+					sqlmock.NewRows([]string{"name"}).AddRow(PermissionUserRead),
+					getEffectivePermissionsQuery, nil, nil, userID),
+				db.SetClientQueryMock(
+					sqlmock.NewRows([]string{"client_id"}).AddRow(int64(7)).AddRow(int64(9)),
+					getMembershipsQuery, nil, nil, userID),
+			},
+			expectedError: nil,
+			expectedGrants: Grants{
+				RoleTypes:   []string{AdminRoleType, ViewerRoleType},
+				Permissions: []string{PermissionUserRead},
+				ClientIDs:   []int64{7, 9},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			var assertsCalls func() error
+			for i := range test.mockCalls {
+				assertsCalls = test.mockCalls[i](mock)
+			}
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			grants, err := NewRelationalDB(client).selectGrants(userID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedGrants, grants)
+		})
+	}
+}
+
+func TestCachedLoader(t *testing.T) {
+	var (
+		userID      = int64(10)
+		now         = time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+		ttl         = time.Minute
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		elapsed        time.Duration
+		mockCalls      func(m *loaderMock)
+		expectedError  error
+		expectedGrants Grants
+	}
+
+	tests := []test{
+		{
+			name:    "entry is still valid",
+			elapsed: ttl - time.Second,
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), userID).Return(Grants{RoleTypes: []string{AdminRoleType}}, nil).Once()
+			},
+			expectedError:  nil,
+			expectedGrants: Grants{RoleTypes: []string{AdminRoleType}},
+		},
+		{
+			name:    "entry expired",
+			elapsed: ttl,
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), userID).Return(Grants{RoleTypes: []string{AdminRoleType}}, nil).Once()
+				m.On(util.GetFunctionName(m.selectGrants), userID).Return(Grants{RoleTypes: []string{ViewerRoleType}}, nil).Once()
+			},
+			expectedError:  nil,
+			expectedGrants: Grants{RoleTypes: []string{ViewerRoleType}},
+		},
+		{
+			name:    "unknown users are not cached",
+			elapsed: 0,
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), userID).Return(Grants{}, callerNotFoundError).Once()
+				m.On(util.GetFunctionName(m.selectGrants), userID).Return(Grants{}, callerNotFoundError).Once()
+			},
+			expectedError:  callerNotFoundError,
+			expectedGrants: Grants{},
+		},
+		{
+			name:    "errors are not cached",
+			elapsed: 0,
+			mockCalls: func(m *loaderMock) {
+				m.On(util.GetFunctionName(m.selectGrants), userID).Return(Grants{}, customError).Once()
+				m.On(util.GetFunctionName(m.selectGrants), userID).Return(Grants{}, customError).Once()
+			},
+			expectedError:  customError,
+			expectedGrants: Grants{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := newLoaderMock()
+			test.mockCalls(m)
+			defer m.AssertExpectations(t)
+
+			clock := now
+			loader := NewCachedLoader(m, ttl).(cachedLoader)
+			loader.now = func() time.Time { return clock }
+
+			_, _ = loader.selectGrants(userID)
+			clock = clock.Add(test.elapsed)
+			grants, err := loader.selectGrants(userID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedGrants, grants)
+			m.AssertNumberOfCalls(t, util.GetFunctionName(m.selectGrants), len(m.ExpectedCalls))
+		})
+	}
+}
+
+func TestCachedLoaderBounds(t *testing.T) {
+	var (
+		now = time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+		ttl = time.Minute
+	)
+
+	m := newLoaderMock()
+	for _, userID := range []int64{1, 2, 3, 4} {
+		m.On(util.GetFunctionName(m.selectGrants), userID).Return(Grants{RoleTypes: []string{ViewerRoleType}}, nil)
+	}
+
+	clock := now
+	loader := NewCachedLoader(m, ttl).(cachedLoader)
+	loader.now = func() time.Time { return clock }
+	loader.maxEntries = 2
+
+	_, _ = loader.selectGrants(1)
+	clock = clock.Add(time.Second)
+	_, _ = loader.selectGrants(2)
+	_, _ = loader.selectGrants(3)
+	assert.Len(t, loader.entries, 2, "the cache keeps more entries than its bound")
+	assert.NotContains(t, loader.entries, int64(1), "the entry expiring first is not evicted")
+
+	clock = clock.Add(ttl)
+	_, _ = loader.selectGrants(2)
+	assert.Len(t, loader.entries, 2)
+	_, _ = loader.selectGrants(4)
+	assert.Equal(t, []int64{2, 4}, cachedIDs(loader), "expired entries are not removed")
+}
+
+func cachedIDs(loader cachedLoader) []int64 {
+	ids := make([]int64, 0, len(loader.entries))
+	for id := range loader.entries {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func existsRows(exists bool) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"exists"}).AddRow(exists)
+}
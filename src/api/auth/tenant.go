@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantHeader identifies the client the caller acts on behalf of. When the request is scoped to a client, users are
+// only visible through their unexpired memberships of that client.
+const TenantHeader = "X-Client-ID"
+
+// resolvedTenantKey keeps in the gin context the tenant resolved by the authorizer for protected routes.
+const resolvedTenantKey = "auth.tenant"
+
+var (
+	invalidTenantError  = newBadRequestResponse(TenantHeader + " header must contain the id of a client")
+	requiredTenantError = newBadRequestResponse(
+		TenantHeader + " header is required for callers who are members of several clients")
+	noTenantError = newForbiddenResponse("caller is not a member of any client")
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx which is scoped to the client clientID.
+func WithTenant(ctx context.Context, clientID int64) context.Context {
+	return context.WithValue(ctx, tenantKey{}, clientID)
+}
+
+// TenantFrom returns the client ctx is scoped to. It returns false when ctx is not scoped, so every client is
+// visible.
+func TenantFrom(ctx context.Context) (int64, bool) {
+	clientID, ok := ctx.Value(tenantKey{}).(int64)
+	return clientID, ok && clientID > 0
+}
+
+// Tenant is a gin middleware rejecting requests whose TenantHeader is not a client id. It must be used by the router
+// before setting the routes, a malformed header would otherwise leave the request unscoped.
+func Tenant(ctx *gin.Context) {
+	if _, ok := parseTenant(ctx); !ok {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, invalidTenantError)
+		return
+	}
+
+	ctx.Next()
+}
+
+// resolveTenant scopes a protected route to the tenant of the caller. Admins act on every client unless they set
+// TenantHeader. Any other caller is scoped to a client it is an unexpired member of, the one set in TenantHeader or,
+// when the header is not set, its only client, so leaving the header out never widens what is reachable. When the
+// tenant cannot be resolved the response is written and false is returned.
+func resolveTenant(ctx *gin.Context, grants Grants) bool {
+	clientID, ok := parseTenant(ctx)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, invalidTenantError)
+		return false
+	}
+
+	if !hasAny(grants.RoleTypes, []string{AdminRoleType}) {
+		switch {
+		case clientID > 0 && !isMember(grants.ClientIDs, clientID):
+			ctx.AbortWithStatusJSON(http.StatusForbidden, newForbiddenResponse(
+				fmt.Sprintf("caller is not a member of client %d", clientID)))
+			return false
+		case clientID == 0 && len(grants.ClientIDs) == 0:
+			ctx.AbortWithStatusJSON(http.StatusForbidden, noTenantError)
+			return false
+		case clientID == 0 && len(grants.ClientIDs) > 1:
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, requiredTenantError)
+			return false
+		case clientID == 0:
+			clientID = grants.ClientIDs[0]
+		}
+	}
+
+	ctx.Set(resolvedTenantKey, clientID)
+	return true
+}
+
+func isMember(clientIDs []int64, clientID int64) bool {
+	for i := range clientIDs {
+		if clientIDs[i] == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// tenantOf returns the client the request is scoped to, which is only resolved by the authorizer for protected routes.
+// TenantHeader is ignored by public routes, since anonymous callers are not members of any client and a sign-up
+// could otherwise join the client of its choice. Zero means the request is not scoped.
+func tenantOf(ctx *gin.Context) int64 {
+	value, _ := ctx.Get(resolvedTenantKey)
+	clientID, _ := value.(int64)
+	return clientID
+}
+
+// parseTenant returns the client id of TenantHeader, which is zero when the header is not set. It returns false when
+// the header is not a client id.
+func parseTenant(ctx *gin.Context) (int64, bool) {
+	header := ctx.GetHeader(TenantHeader)
+	if header == "" {
+		return 0, true
+	}
+
+	clientID, err := strconv.ParseInt(header, 10, 64)
+	if err != nil || clientID <= 0 {
+		return 0, false
+	}
+
+	return clientID, true
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"maria/src/api/util"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenant(t *testing.T) {
+	type test struct {
+		name             string
+		tenant           string
+		expectedStatus   int
+		expectedResponse string
+		expectedTenant   int64
+		expectedScoped   bool
+	}
+
+	tests := []test{
+		{
+			name:             "tenant is not set",
+			tenant:           "",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "",
+			expectedTenant:   0,
+			expectedScoped:   false,
+		},
+		{
+			name:             "tenant is not a client id",
+			tenant:           "acme",
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: util.RenderToJSON(invalidTenantError),
+		},
+		{
+			name:             "tenant is not positive",
+			tenant:           "0",
+			expectedStatus:   http.StatusBadRequest,
+			expectedResponse: util.RenderToJSON(invalidTenantError),
+		},
+		{
+			name:             "public route ignores the tenant",
+			tenant:           "7",
+			expectedStatus:   http.StatusOK,
+			expectedResponse: "",
+			expectedTenant:   0,
+			expectedScoped:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(Tenant)
+			router.GET("/user/:user_id", func(ctx *gin.Context) {
+				clientID, scoped := TenantFrom(Context(ctx))
+				assert.Equal(t, test.expectedTenant, clientID)
+				assert.Equal(t, test.expectedScoped, scoped)
+				ctx.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/user/10", nil)
+			if test.tenant != "" {
+				req.Header.Set(TenantHeader, test.tenant)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, test.expectedResponse, w.Body.String())
+		})
+	}
+}
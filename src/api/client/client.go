@@ -0,0 +1,114 @@
+package client
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	ID          int64     `json:"client_id"`
+	ClientName  string    `json:"client_name"`
+	Active      bool      `json:"active"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+func (c Client) isEmptyClient() bool {
+	return c.ID == 0
+}
+
+type NewClientRequest struct {
+	ClientName string `json:"client_name" binding:"required"`
+}
+
+type RenameClientRequest struct {
+	ClientName string `json:"client_name" binding:"required"`
+}
+
+// validate checks that the new name is not blank.
+func (r RenameClientRequest) validate() error {
+	if strings.TrimSpace(r.ClientName) == "" {
+		return errors.New("client_name cannot be empty")
+	}
+	return nil
+}
+
+const (
+	MembershipActive  = "active"
+	MembershipExpired = "expired"
+	MembershipAll     = "all"
+
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// ClientFilter keeps the conditions used for listing clients. Empty fields are ignored.
+type ClientFilter struct {
+	Active *bool
+
+	// id is the tenant of the request, it is never taken from the query params.
+	id int64
+}
+
+// Membership links a user to a client, it gives access to the client until DateExpired. Expired memberships are kept
+// as history.
+type Membership struct {
+	ID          int64      `json:"membership_id"`
+	UserID      int64      `json:"user_id"`
+	UserName    string     `json:"user_name"`
+	ClientID    int64      `json:"client_id"`
+	ClientName  string     `json:"client_name"`
+	DateExpired *time.Time `json:"date_expired"`
+	DateCreated time.Time  `json:"date_created"`
+}
+
+func (m Membership) isEmpty() bool {
+	return m.ID == 0
+}
+
+type AddMemberRequest struct {
+	UserID      int64      `json:"user_id" binding:"required"`
+	DateExpired *time.Time `json:"date_expired"`
+}
+
+// member keeps the user fields needed for adding them to a client.
+type member struct {
+	ID     int64
+	Active bool
+}
+
+func (m member) isEmpty() bool {
+	return m.ID == 0
+}
+
+// MembershipFilter selects memberships by Status, one of MembershipActive, MembershipExpired or MembershipAll. They
+// are sorted by id, AfterID skips the ones already returned and zero selects from the first one.
+type MembershipFilter struct {
+	Status  string
+	Limit   int
+	AfterID int64
+
+	// clientID is the tenant of the request, it is never taken from the query params.
+	clientID int64
+}
+
+// normalize returns the filter with its limit bounded.
+func (f MembershipFilter) normalize() MembershipFilter {
+	if f.Limit <= 0 {
+		f.Limit = defaultPageLimit
+	}
+	if f.Limit > maxPageLimit {
+		f.Limit = maxPageLimit
+	}
+	return f
+}
+
+type Paging struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type MembershipPage struct {
+	Results []Membership `json:"results"`
+	Paging  Paging       `json:"paging"`
+}
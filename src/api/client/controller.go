@@ -0,0 +1,382 @@
+package client
+
+import (
+	"errors"
+	"maria/src/api/auth"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	clientIDMissedError = newBadRequestResponse("client_id param is missed")
+	userIDMissedError   = newBadRequestResponse("user_id param is missed")
+)
+
+type Controller struct {
+	service       Service
+	integerParser func(s string, base int, bitSize int) (i int64, err error)
+}
+
+func NewController(service Service) Controller {
+	return Controller{
+		service:       service,
+		integerParser: strconv.ParseInt,
+	}
+}
+
+func (c Controller) GetByID(ctx *gin.Context) {
+	clientID, ok := c.parseClientID(ctx)
+	if !ok {
+		return
+	}
+
+	client, err := c.service.getByID(auth.Context(ctx), clientID)
+	if err != nil {
+		if errors.Is(err, clientNotFoundError) {
+			ctx.JSON(http.StatusNotFound, newNotFoundError("client_id", clientID))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, client)
+}
+
+func (c Controller) Post(ctx *gin.Context) {
+	var request NewClientRequest
+
+	if err := ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	client, err := c.service.createClient(auth.Context(ctx), request)
+	if err != nil {
+		if errors.Is(err, clientWithSameNameError) {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, client)
+}
+
+func (c Controller) List(ctx *gin.Context) {
+	var filter ClientFilter
+
+	if value, ok := ctx.GetQuery("active"); ok {
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse("active must be a boolean"))
+			return
+		}
+		filter.Active = &active
+	}
+
+	clients, err := c.service.listClients(auth.Context(ctx), filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, clients)
+}
+
+// Put renames the client, the name is the only field which can be changed.
+func (c Controller) Put(ctx *gin.Context) {
+	var request RenameClientRequest
+
+	clientID, ok := c.parseClientID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	if err := request.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	client, err := c.service.renameClient(auth.Context(ctx), request, clientID)
+	respondModified(ctx, clientID, client, err)
+}
+
+// Delete deactivates the client. Clients are never removed because memberships and tasks keep referencing them.
+func (c Controller) Delete(ctx *gin.Context) {
+	clientID, ok := c.parseClientID(ctx)
+	if !ok {
+		return
+	}
+
+	client, err := c.service.deactivateClient(auth.Context(ctx), clientID)
+	respondModified(ctx, clientID, client, err)
+}
+
+// GetMembers lists the memberships of the client, the active ones unless status query param says otherwise.
+func (c Controller) GetMembers(ctx *gin.Context) {
+	clientID, ok := c.parseClientID(ctx)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseMembershipFilter(ctx)
+	if !ok {
+		return
+	}
+
+	page, err := c.service.listMembers(auth.Context(ctx), clientID, filter)
+	if err != nil {
+		if errors.Is(err, clientNotFoundError) {
+			ctx.JSON(http.StatusNotFound, newNotFoundError("client_id", clientID))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+func (c Controller) PostMember(ctx *gin.Context) {
+	var request AddMemberRequest
+
+	clientID, ok := c.parseClientID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	membership, err := c.service.addMember(auth.Context(ctx), clientID, request)
+	if err != nil {
+		switch {
+		case errors.Is(err, clientNotFoundError):
+			ctx.JSON(http.StatusNotFound, newNotFoundError("client_id", clientID))
+		case errors.Is(err, userNotFoundError):
+			ctx.JSON(http.StatusNotFound, newNotFoundError("user_id", request.UserID))
+		case errors.Is(err, inactiveClientError),
+			errors.Is(err, inactiveUserError),
+			errors.Is(err, membershipAlreadyActiveError),
+			errors.Is(err, invalidMembershipExpirationError):
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, membership)
+}
+
+// DeleteMember expires the active membership of the user in the client.
+func (c Controller) DeleteMember(ctx *gin.Context) {
+	clientID, ok := c.parseClientID(ctx)
+	if !ok {
+		return
+	}
+
+	userID, ok := c.parseUserID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.service.removeMember(auth.Context(ctx), clientID, userID); err != nil {
+		switch {
+		case errors.Is(err, clientNotFoundError):
+			ctx.JSON(http.StatusNotFound, newNotFoundError("client_id", clientID))
+		case errors.Is(err, membershipNotFoundError):
+			ctx.JSON(http.StatusNotFound, newNotFoundError("user_id", userID))
+		default:
+			ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetUserClients lists the memberships of the user, the active ones unless status query param says otherwise.
+func (c Controller) GetUserClients(ctx *gin.Context) {
+	userID, ok := c.parseUserID(ctx)
+	if !ok {
+		return
+	}
+
+	filter, ok := parseMembershipFilter(ctx)
+	if !ok {
+		return
+	}
+
+	page, err := c.service.listUserClients(auth.Context(ctx), userID, filter)
+	if err != nil {
+		if errors.Is(err, userNotFoundError) {
+			ctx.JSON(http.StatusNotFound, newNotFoundError("user_id", userID))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, page)
+}
+
+// parseMembershipFilter returns the filter set by status, limit and cursor query params. When any of them is not
+// valid the bad request response is written and false is returned.
+func parseMembershipFilter(ctx *gin.Context) (MembershipFilter, bool) {
+	var (
+		filter = MembershipFilter{Status: MembershipActive}
+		err    error
+	)
+
+	if value, ok := ctx.GetQuery("status"); ok {
+		switch value {
+		case MembershipActive, MembershipExpired, MembershipAll:
+			filter.Status = value
+		default:
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse("status must be one of active, expired or all"))
+			return filter, false
+		}
+	}
+	if value, ok := ctx.GetQuery("limit"); ok {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit <= 0 {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse("limit must be a positive integer"))
+			return filter, false
+		}
+	}
+	if value, ok := ctx.GetQuery("cursor"); ok {
+		if filter.AfterID, err = strconv.ParseInt(value, 10, 64); err != nil || filter.AfterID <= 0 {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(invalidCursorError.Error()))
+			return filter, false
+		}
+	}
+
+	return filter, true
+}
+
+// respondModified writes the response of a service call which modified the client.
+func respondModified(ctx *gin.Context, clientID int64, client Client, err error) {
+	if err != nil {
+		if errors.Is(err, clientNotFoundError) {
+			ctx.JSON(http.StatusNotFound, newNotFoundError("client_id", clientID))
+			return
+		}
+		if errors.Is(err, clientWithSameNameError) {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, client)
+}
+
+// parseClientID returns client_id param. When it is not valid the bad request response is written and false is
+// returned.
+func (c Controller) parseClientID(ctx *gin.Context) (int64, bool) {
+	param := ctx.Param("client_id")
+	if param == "" {
+		ctx.JSON(http.StatusBadRequest, clientIDMissedError)
+		return 0, false
+	}
+
+	clientID, err := c.integerParser(param, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return 0, false
+	}
+
+	return clientID, true
+}
+
+// parseUserID returns user_id param. When it is not valid the bad request response is written and false is returned.
+func (c Controller) parseUserID(ctx *gin.Context) (int64, bool) {
+	param := ctx.Param("user_id")
+	if param == "" {
+		ctx.JSON(http.StatusBadRequest, userIDMissedError)
+		return 0, false
+	}
+
+	userID, err := c.integerParser(param, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return 0, false
+	}
+
+	return userID, true
+}
+
+func (c Controller) SetURLMapping(router *gin.Engine) {
+	router.GET("/client", c.List)
+	router.GET("/client/:client_id", c.GetByID)
+	router.POST("/client", c.Post)
+	router.PUT("/client/:client_id", c.Put)
+	router.DELETE("/client/:client_id", c.Delete)
+	router.GET("/client/:client_id/users", c.GetMembers)
+	router.POST("/client/:client_id/users", c.PostMember)
+	router.DELETE("/client/:client_id/users/:user_id", c.DeleteMember)
+	router.GET("/user/:user_id/clients", c.GetUserClients)
+}
+
+// RequiredRoles declares the role types or permissions required by the routes set in SetURLMapping.
+func (c Controller) RequiredRoles() auth.Routes {
+	var (
+		readers = auth.Requirement{
+			RoleTypes:   []string{auth.AdminRoleType, auth.ViewerRoleType},
+			Permissions: []string{auth.PermissionClientRead},
+		}
+		writers = auth.Requirement{
+			RoleTypes:   []string{auth.AdminRoleType},
+			Permissions: []string{auth.PermissionClientWrite},
+		}
+	)
+
+	return auth.Routes{
+		auth.Route(http.MethodGet, "/client"):               readers,
+		auth.Route(http.MethodGet, "/client/:client_id"):    readers,
+		auth.Route(http.MethodPost, "/client"):              writers,
+		auth.Route(http.MethodPut, "/client/:client_id"):    writers,
+		auth.Route(http.MethodDelete, "/client/:client_id"): writers,
+
+		auth.Route(http.MethodGet, "/client/:client_id/users"):             readers,
+		auth.Route(http.MethodPost, "/client/:client_id/users"):            writers,
+		auth.Route(http.MethodDelete, "/client/:client_id/users/:user_id"): writers,
+		auth.Route(http.MethodGet, "/user/:user_id/clients"):               readers,
+	}
+}
+
+func newBadRequestResponse(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     message,
+		"status_code": http.StatusBadRequest,
+	}
+}
+
+func newNotFoundError(by string, id any) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     "element not found",
+		"by":          by,
+		"id":          id,
+		"status_code": http.StatusNotFound,
+	}
+}
+
+func newInternalServerError(cause error) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     "internal server error",
+		"cause":       cause,
+		"status_code": http.StatusInternalServerError,
+	}
+}
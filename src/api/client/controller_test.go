@@ -0,0 +1,1107 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maria/src/api/auth"
+	"maria/src/api/util"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ControllerSuite struct {
+	suite.Suite
+}
+
+func TestControllerSuite(t *testing.T) {
+	suite.Run(t, new(ControllerSuite))
+}
+
+func (c *ControllerSuite) BeforeTest(suiteName, testName string) {
+}
+
+func (c *ControllerSuite) AfterTest(suiteName, testName string) {
+}
+
+func (c *ControllerSuite) TestGetClientByID() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		param          string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "client_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("client_id param is missed")),
+		},
+		{
+			name:  "it cannot parse client_id param",
+			param: "word",
+			controller: Controller{
+				integerParser: func(s string, base int, bitSize int) (i int64, err error) {
+					return 0, customError
+				},
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(customError.Error())),
+		},
+		{
+			name:           "client not found",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetByIDMock(Client{}, clientNotFoundError, clientID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("client_id", clientID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetByIDMock(Client{}, customError, clientID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetByIDMock(Client{ID: clientID}, nil, clientID),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(Client{ID: clientID}),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"client_id": test.param}, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.GetByID(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestPost() {
+	const (
+		bindMSGError = "Key: 'NewClientRequest.ClientName' Error:Field validation for 'ClientName' failed on the 'required' tag"
+	)
+	var (
+		customError = errors.New("custom error")
+		request     = NewClientRequest{ClientName: "acme"}
+		client      = Client{ID: 10, ClientName: request.ClientName, Active: true, DateCreated: time.Now()}
+	)
+
+	type test struct {
+		name           string
+		body           NewClientRequest
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "body fields missed",
+			body:         NewClientRequest{},
+			controller:   Controller{},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(bindMSGError)),
+		},
+		{
+			name:           "client name is taken",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMock(Client{}, clientWithSameNameError, request),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   util.RenderToJSON(newBadRequestResponse(clientWithSameNameError.Error())),
+		},
+		{
+			name:           "service return internal error",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMock(Client{}, customError, request),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMock(client, nil, request),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(client),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(nil, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Post(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestList() {
+	var (
+		active      = true
+		customError = errors.New("custom error")
+		clients     = []Client{{ID: 1, ClientName: "acme", Active: true}}
+	)
+
+	type test struct {
+		name           string
+		queryString    string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "active is not a boolean",
+			queryString:  "active=yes",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("active must be a boolean")),
+		},
+		{
+			name:           "service return internal error",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListMock(nil, customError, ClientFilter{}),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			queryString:    "active=true",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListMock(clients, nil, ClientFilter{Active: &active}),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(clients),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(nil, test.queryString, nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.List(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestPut() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+		request     = RenameClientRequest{ClientName: "globex"}
+	)
+
+	type test struct {
+		name           string
+		param          string
+		body           any
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "client_id param missed",
+			param:        "",
+			body:         request,
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("client_id param is missed")),
+		},
+		{
+			name:         "client name is blank",
+			param:        "10",
+			body:         RenameClientRequest{ClientName: " "},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("client_name cannot be empty")),
+		},
+		{
+			name:           "client not found",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Client{}, clientNotFoundError, request, clientID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("client_id", clientID)),
+		},
+		{
+			name:           "client name is taken",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Client{}, clientWithSameNameError, request, clientID),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   util.RenderToJSON(newBadRequestResponse(clientWithSameNameError.Error())),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Client{}, customError, request, clientID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Client{ID: clientID, ClientName: request.ClientName}, nil, request, clientID),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(Client{ID: clientID, ClientName: request.ClientName}),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"client_id": test.param}, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Put(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestDelete() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		param          string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "client_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("client_id param is missed")),
+		},
+		{
+			name:           "client not found",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(Client{}, clientNotFoundError, clientID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("client_id", clientID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(Client{}, customError, clientID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "client deactivated",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(Client{ID: clientID}, nil, clientID),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(Client{ID: clientID}),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"client_id": test.param}, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Delete(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestGetMembers() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+		page        = MembershipPage{Results: []Membership{{ID: 1, ClientID: clientID}}, Paging: Paging{Limit: 20}}
+	)
+
+	type test struct {
+		name           string
+		param          string
+		query          string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "client_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("client_id param is missed")),
+		},
+		{
+			name:         "status is not valid",
+			param:        "10",
+			query:        "status=pending",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("status must be one of active, expired or all")),
+		},
+		{
+			name:         "limit is not valid",
+			param:        "10",
+			query:        "limit=0",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("limit must be a positive integer")),
+		},
+		{
+			name:         "cursor is not valid",
+			param:        "10",
+			query:        "cursor=abc",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(invalidCursorError.Error())),
+		},
+		{
+			name:           "client not found",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListMembersMock(MembershipPage{}, clientNotFoundError, clientID, MembershipFilter{Status: MembershipActive}),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("client_id", clientID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListMembersMock(MembershipPage{}, customError, clientID, MembershipFilter{Status: MembershipActive}),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:       "happy case",
+			param:      "10",
+			query:      "status=expired&limit=5&cursor=3",
+			controller: NewController(newServiceMock()),
+			applyMockCalls: setServiceListMembersMock(
+				page, nil, clientID, MembershipFilter{Status: MembershipExpired, Limit: 5, AfterID: 3}),
+			expectedCode: http.StatusOK,
+			expectedBody: util.RenderToJSON(page),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"client_id": test.param}, test.query, nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.GetMembers(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestPostMember() {
+	const (
+		bindMSGError = "Key: 'AddMemberRequest.UserID' Error:Field validation for 'UserID' failed on the 'required' tag"
+	)
+	var (
+		clientID    = int64(10)
+		userID      = int64(20)
+		customError = errors.New("custom error")
+		request     = AddMemberRequest{UserID: userID}
+		membership  = Membership{ID: 1, UserID: userID, ClientID: clientID, DateCreated: time.Now()}
+	)
+
+	type test struct {
+		name           string
+		body           AddMemberRequest
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "body fields missed",
+			body:         AddMemberRequest{},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(bindMSGError)),
+		},
+		{
+			name:           "client not found",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMemberMock(Membership{}, clientNotFoundError, clientID, request),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("client_id", clientID)),
+		},
+		{
+			name:           "user not found",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMemberMock(Membership{}, userNotFoundError, clientID, request),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("user_id", userID)),
+		},
+		{
+			name:           "client is not active",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMemberMock(Membership{}, inactiveClientError, clientID, request),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   util.RenderToJSON(newBadRequestResponse(inactiveClientError.Error())),
+		},
+		{
+			name:           "membership is already active",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMemberMock(Membership{}, membershipAlreadyActiveError, clientID, request),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   util.RenderToJSON(newBadRequestResponse(membershipAlreadyActiveError.Error())),
+		},
+		{
+			name:           "service return internal error",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMemberMock(Membership{}, customError, clientID, request),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMemberMock(membership, nil, clientID, request),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(membership),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"client_id": "10"}, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.PostMember(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestDeleteMember() {
+	var (
+		clientID    = int64(10)
+		userID      = int64(20)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		params         map[string]string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "user_id param missed",
+			params:       map[string]string{"client_id": "10"},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_id param is missed")),
+		},
+		{
+			name:           "membership not found",
+			params:         map[string]string{"client_id": "10", "user_id": "20"},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMemberMock(membershipNotFoundError, clientID, userID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("user_id", userID)),
+		},
+		{
+			name:           "service return internal error",
+			params:         map[string]string{"client_id": "10", "user_id": "20"},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMemberMock(customError, clientID, userID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "member removed",
+			params:         map[string]string{"client_id": "10", "user_id": "20"},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMemberMock(nil, clientID, userID),
+			expectedCode:   http.StatusNoContent,
+			expectedBody:   "",
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(test.params, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.DeleteMember(ctx)
+
+			assert.Equal(t, test.expectedCode, ctx.Writer.Status())
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestGetUserClients() {
+	var (
+		userID      = int64(20)
+		customError = errors.New("custom error")
+		filter      = MembershipFilter{Status: MembershipAll}
+		page        = MembershipPage{Results: []Membership{{ID: 1, UserID: userID}}, Paging: Paging{Limit: 20}}
+	)
+
+	type test struct {
+		name           string
+		param          string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "user_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_id param is missed")),
+		},
+		{
+			name:           "user not found",
+			param:          "20",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListUserClientsMock(MembershipPage{}, userNotFoundError, userID, filter),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("user_id", userID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "20",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListUserClientsMock(MembershipPage{}, customError, userID, filter),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "20",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListUserClientsMock(page, nil, userID, filter),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(page),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"user_id": test.param}, "status=all", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.GetUserClients(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestRequiredRoles() {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := NewController(newServiceMock())
+	controller.SetURLMapping(router)
+
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		registered[auth.Route(route.Method, route.Path)] = true
+	}
+
+	for route, requirement := range controller.RequiredRoles() {
+		assert.True(c.T(), registered[route], "%s is not registered", route)
+		assert.NotEmpty(c.T(), requirement.RoleTypes, "%s does not require any role type", route)
+		assert.NotEmpty(c.T(), requirement.Permissions, "%s does not require any permission", route)
+	}
+}
+
+func (c *ControllerSuite) TestSetURLMapping() {
+	var (
+		clientID      = int64(10)
+		active        = true
+		clientRequest = NewClientRequest{ClientName: "acme"}
+		renameRequest = RenameClientRequest{ClientName: "globex"}
+		memberRequest = AddMemberRequest{UserID: 20}
+	)
+
+	type test struct {
+		name           string
+		path           string
+		method         string
+		body           any
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+	}
+
+	tests := []test{
+		{
+			name:           "list clients",
+			path:           "/client?active=true",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListMock([]Client{}, nil, ClientFilter{Active: &active}),
+		},
+		{
+			name:           "get client by id",
+			path:           "/client/10",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetByIDMock(Client{ID: clientID}, nil, clientID),
+		},
+		{
+			name:           "post client",
+			path:           "/client",
+			method:         http.MethodPost,
+			body:           clientRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMock(Client{ID: clientID}, nil, clientRequest),
+		},
+		{
+			name:           "put client",
+			path:           "/client/10",
+			method:         http.MethodPut,
+			body:           renameRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Client{ID: clientID}, nil, renameRequest, clientID),
+		},
+		{
+			name:           "delete client",
+			path:           "/client/10",
+			method:         http.MethodDelete,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(Client{ID: clientID}, nil, clientID),
+		},
+		{
+			name:       "list client members",
+			path:       "/client/10/users?status=all",
+			method:     http.MethodGet,
+			controller: NewController(newServiceMock()),
+			applyMockCalls: setServiceListMembersMock(
+				MembershipPage{}, nil, clientID, MembershipFilter{Status: MembershipAll}),
+		},
+		{
+			name:       "post client member",
+			path:       "/client/10/users",
+			method:     http.MethodPost,
+			body:       memberRequest,
+			controller: NewController(newServiceMock()),
+			applyMockCalls: setServicePostMemberMock(
+				Membership{ID: 1}, nil, clientID, memberRequest),
+		},
+		{
+			name:       "list user clients",
+			path:       "/user/20/clients",
+			method:     http.MethodGet,
+			controller: NewController(newServiceMock()),
+			applyMockCalls: setServiceListUserClientsMock(
+				MembershipPage{}, nil, memberRequest.UserID, MembershipFilter{Status: MembershipActive}),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			test.controller.SetURLMapping(router)
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			var b bytes.Buffer
+			if test.body != nil {
+				if err := json.NewEncoder(&b).Encode(test.body); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				}
+			}
+
+			req := httptest.NewRequest(test.method, test.path, &b)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(c.T(), http.StatusOK, w.Code)
+		})
+	}
+}
+
+func setServiceGetByIDMock(
+	clientResponse Client,
+	errorResponse error,
+	clientID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.getByID), mock.Anything, clientID).
+			Return(clientResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServicePostMock(
+	clientResponse Client,
+	errorResponse error,
+	request NewClientRequest,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.createClient), mock.Anything, request).
+			Return(clientResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceListMock(
+	clientsResponse []Client,
+	errorResponse error,
+	filter ClientFilter,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.listClients), mock.Anything, filter).
+			Return(clientsResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServicePutMock(
+	clientResponse Client,
+	errorResponse error,
+	request RenameClientRequest,
+	clientID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.renameClient), mock.Anything, request, clientID).
+			Return(clientResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceDeleteMock(
+	clientResponse Client,
+	errorResponse error,
+	clientID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.deactivateClient), mock.Anything, clientID).
+			Return(clientResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceListMembersMock(
+	pageResponse MembershipPage,
+	errorResponse error,
+	clientID int64,
+	filter MembershipFilter,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.listMembers), mock.Anything, clientID, filter).
+			Return(pageResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServicePostMemberMock(
+	membershipResponse Membership,
+	errorResponse error,
+	clientID int64,
+	request AddMemberRequest,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.addMember), mock.Anything, clientID, request).
+			Return(membershipResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceDeleteMemberMock(
+	errorResponse error,
+	clientID int64,
+	userID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.removeMember), mock.Anything, clientID, userID).
+			Return(errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceListUserClientsMock(
+	pageResponse MembershipPage,
+	errorResponse error,
+	userID int64,
+	filter MembershipFilter,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.listUserClients), mock.Anything, userID, filter).
+			Return(pageResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
@@ -0,0 +1,465 @@
+package client
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maria/src/api/audit"
+	"maria/src/api/db"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	getClientByIDQuery    = `SELECT id, client_name, active, date_created FROM client WHERE id = ?`
+	getClientByNameQuery  = `SELECT id, client_name, active, date_created FROM client WHERE client_name = ?`
+	listClientsQuery      = `SELECT id, client_name, active, date_created FROM client`
+	insertClientQuery     = `INSERT INTO client (client_name, active) VALUES (?, true)`
+	renameClientQuery     = `UPDATE client SET client_name = ? WHERE id = ?`
+	deactivateClientQuery = `UPDATE client SET active = false WHERE id = ?`
+	// links are expired rather than deleted, so memberships keep their history
+	expireClientLinksQuery = `UPDATE user_client SET date_expired = NOW() ` +
+		`WHERE client_id = ? AND (date_expired IS NULL OR date_expired > NOW())`
+
+	// the client row is locked, so memberships of a client are not changed concurrently nor while it is deactivated
+	getClientByIDForUpdateQuery = getClientByIDQuery + ` FOR UPDATE`
+	getMemberQuery              = `SELECT id, active FROM user WHERE id = ?`
+	membershipsQuery            = `SELECT uc.id, uc.user_id, u.user_name, uc.client_id, c.client_name, ` +
+		`uc.date_expired, uc.date_created FROM user_client uc ` +
+		`JOIN user u ON u.id = uc.user_id JOIN client c ON c.id = uc.client_id`
+	getMembershipByIDQuery   = membershipsQuery + ` WHERE uc.id = ?`
+	getActiveMembershipQuery = membershipsQuery + ` WHERE uc.user_id = ? AND uc.client_id = ? ` +
+		`AND (uc.date_expired IS NULL OR uc.date_expired > NOW())`
+	// a user with unexpired memberships of other clients belongs to other tenants
+	hasOtherMembershipsQuery = `SELECT EXISTS (SELECT 1 FROM user_client WHERE user_id = ? AND client_id <> ? ` +
+		`AND (date_expired IS NULL OR date_expired > NOW()))`
+	insertMembershipQuery = `INSERT INTO user_client (user_id, client_id, date_expired) VALUES (?, ?, ?)`
+	expireMembershipQuery = `UPDATE user_client SET date_expired = NOW() ` +
+		`WHERE id = ? AND (date_expired IS NULL OR date_expired > NOW())`
+
+	// clientNameUniqueKey keeps client_name unique.
+	clientNameUniqueKey = "client_client_name_uindex"
+)
+
+type Querier interface {
+	selectByID(int64) (Client, error)
+	selectByName(string) (Client, error)
+	selectByFilter(ClientFilter) ([]Client, error)
+	createClient(NewClientRequest) (int64, error)
+	renameClient(int64, string) (bool, error)
+	deactivateClient(int64) (bool, error)
+	expireLinks(int64) (int64, error)
+	selectByIDForUpdate(int64) (Client, error)
+	selectMember(int64) (member, error)
+	selectMembershipByID(int64) (Membership, error)
+	selectActiveMembership(userID, clientID int64) (Membership, error)
+	hasOtherMemberships(userID, clientID int64) (bool, error)
+	selectClientMemberships(int64, MembershipFilter) (MembershipPage, error)
+	selectUserMemberships(int64, MembershipFilter) (MembershipPage, error)
+	createMembership(userID, clientID int64, dateExpired *time.Time) (int64, error)
+	expireMembership(int64) (bool, error)
+	record(audit.Entry) error
+}
+
+type Persister interface {
+	Querier
+	withTransaction(fn func(tx Transactioner) error) error
+}
+
+type Transactioner interface {
+	Querier
+	commit() error
+	rollback() error
+}
+
+func NewRelationalDB(client db.Client) Persister {
+	return &relationalDB{
+		client: client,
+	}
+}
+
+type relationalDB struct {
+	client db.Client
+}
+
+func (r *relationalDB) selectByID(clientID int64) (Client, error) {
+	return r.selectOne(getClientByIDQuery, clientID)
+}
+
+func (r *relationalDB) selectByName(clientName string) (Client, error) {
+	return r.selectOne(getClientByNameQuery, clientName)
+}
+
+func (r *relationalDB) selectOne(query string, args ...any) (Client, error) {
+	var client Client
+
+	if err := r.client.QueryRow(query, args...).Scan(
+		&client.ID,
+		&client.ClientName,
+		&client.Active,
+		&client.DateCreated,
+	); err != nil {
+		return client, db.ScanError(err, query)
+	}
+
+	return client, nil
+}
+
+func (r *relationalDB) selectByFilter(filter ClientFilter) ([]Client, error) {
+	var (
+		rows    *sql.Rows
+		err     error
+		clients []Client
+	)
+
+	query, args := buildListQuery(filter)
+	if rows, err = r.client.Query(query, args...); err != nil {
+		return nil, db.QueryError(err, query)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var client Client
+		if err = rows.Scan(
+			&client.ID,
+			&client.ClientName,
+			&client.Active,
+			&client.DateCreated,
+		); err != nil {
+			return nil, db.ScanError(err, query)
+		}
+		clients = append(clients, client)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, query)
+	}
+
+	return clients, nil
+}
+
+// buildListQuery returns the query listing the clients matching filter sorted by name.
+func buildListQuery(filter ClientFilter) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if filter.Active != nil {
+		conditions = append(conditions, "active = ?")
+		args = append(args, *filter.Active)
+	}
+	if filter.id != 0 {
+		conditions = append(conditions, "id = ?")
+		args = append(args, filter.id)
+	}
+
+	query := listClientsQuery
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return query + " ORDER BY client_name, id", args
+}
+
+func (r *relationalDB) createClient(request NewClientRequest) (int64, error) {
+	result, err := r.client.Exec(insertClientQuery, request.ClientName)
+	if err != nil {
+		return 0, clientExecError(err, insertClientQuery)
+	}
+
+	clientID, err := result.LastInsertId()
+	if err != nil {
+		return 0, db.LastInsertedError(err, insertClientQuery)
+	}
+
+	return clientID, nil
+}
+
+func (r *relationalDB) renameClient(clientID int64, clientName string) (bool, error) {
+	result, err := r.client.Exec(renameClientQuery, clientName, clientID)
+	if err != nil {
+		return false, clientExecError(err, renameClientQuery)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, db.RowsAffectedError(err, renameClientQuery)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *relationalDB) deactivateClient(clientID int64) (bool, error) {
+	result, err := r.client.Exec(deactivateClientQuery, clientID)
+	if err != nil {
+		return false, db.ExecError(err, deactivateClientQuery)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, db.RowsAffectedError(err, deactivateClientQuery)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// expireLinks expires the unexpired links between users and the client, it returns how many were expired.
+func (r *relationalDB) expireLinks(clientID int64) (int64, error) {
+	result, err := r.client.Exec(expireClientLinksQuery, clientID)
+	if err != nil {
+		return 0, db.ExecError(err, expireClientLinksQuery)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, db.RowsAffectedError(err, expireClientLinksQuery)
+	}
+
+	return rowsAffected, nil
+}
+
+func (r *relationalDB) selectByIDForUpdate(clientID int64) (Client, error) {
+	return r.selectOne(getClientByIDForUpdateQuery, clientID)
+}
+
+func (r *relationalDB) selectMember(userID int64) (member, error) {
+	var m member
+
+	if err := r.client.QueryRow(getMemberQuery, userID).Scan(&m.ID, &m.Active); err != nil {
+		return m, db.ScanError(err, getMemberQuery)
+	}
+
+	return m, nil
+}
+
+func (r *relationalDB) selectMembershipByID(membershipID int64) (Membership, error) {
+	return r.selectOneMembership(getMembershipByIDQuery, membershipID)
+}
+
+// selectActiveMembership returns the unexpired membership of the user in the client, there is one at most.
+func (r *relationalDB) selectActiveMembership(userID, clientID int64) (Membership, error) {
+	return r.selectOneMembership(getActiveMembershipQuery, userID, clientID)
+}
+
+// hasOtherMemberships tells whether the user is an unexpired member of any client other than clientID.
+func (r *relationalDB) hasOtherMemberships(userID, clientID int64) (bool, error) {
+	var exists bool
+
+	if err := r.client.QueryRow(hasOtherMembershipsQuery, userID, clientID).Scan(&exists); err != nil {
+		return false, db.ScanError(err, hasOtherMembershipsQuery)
+	}
+
+	return exists, nil
+}
+
+func (r *relationalDB) selectOneMembership(query string, args ...any) (Membership, error) {
+	var (
+		m           Membership
+		dateExpired sql.NullTime
+	)
+
+	if err := r.client.QueryRow(query, args...).Scan(
+		&m.ID,
+		&m.UserID,
+		&m.UserName,
+		&m.ClientID,
+		&m.ClientName,
+		&dateExpired,
+		&m.DateCreated,
+	); err != nil {
+		return m, db.ScanError(err, query)
+	}
+
+	if dateExpired.Valid {
+		m.DateExpired = &dateExpired.Time
+	}
+
+	return m, nil
+}
+
+func (r *relationalDB) selectClientMemberships(clientID int64, filter MembershipFilter) (MembershipPage, error) {
+	return r.selectMemberships("uc.client_id", clientID, filter)
+}
+
+func (r *relationalDB) selectUserMemberships(userID int64, filter MembershipFilter) (MembershipPage, error) {
+	return r.selectMemberships("uc.user_id", userID, filter)
+}
+
+// selectMemberships returns a page of the memberships whose column is id.
+func (r *relationalDB) selectMemberships(column string, id int64, filter MembershipFilter) (MembershipPage, error) {
+	var (
+		rows        *sql.Rows
+		err         error
+		memberships []Membership
+	)
+
+	filter = filter.normalize()
+	query, args := buildMembershipsQuery(column, id, filter)
+
+	if rows, err = r.client.Query(query, args...); err != nil {
+		return MembershipPage{}, db.QueryError(err, query)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			m           Membership
+			dateExpired sql.NullTime
+		)
+		if err = rows.Scan(
+			&m.ID,
+			&m.UserID,
+			&m.UserName,
+			&m.ClientID,
+			&m.ClientName,
+			&dateExpired,
+			&m.DateCreated,
+		); err != nil {
+			return MembershipPage{}, db.ScanError(err, query)
+		}
+		if dateExpired.Valid {
+			m.DateExpired = &dateExpired.Time
+		}
+		memberships = append(memberships, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return MembershipPage{}, db.RowsError(err, query)
+	}
+
+	page := MembershipPage{
+		Results: make([]Membership, 0, filter.Limit),
+		Paging:  Paging{Limit: filter.Limit},
+	}
+
+	if len(memberships) > filter.Limit {
+		memberships = memberships[:filter.Limit]
+		page.Paging.NextCursor = strconv.FormatInt(memberships[filter.Limit-1].ID, 10)
+	}
+
+	page.Results = append(page.Results, memberships...)
+
+	return page, nil
+}
+
+// buildMembershipsQuery returns the query selecting the memberships whose column is id and match filter. One extra
+// membership is requested for knowing whether there is a next page.
+func buildMembershipsQuery(column string, id int64, filter MembershipFilter) (string, []any) {
+	query := membershipsQuery + " WHERE " + column + " = ? AND uc.id > ?"
+	args := []any{id, filter.AfterID}
+
+	if filter.clientID != 0 {
+		query += " AND uc.client_id = ?"
+		args = append(args, filter.clientID)
+	}
+
+	switch filter.Status {
+	case MembershipActive:
+		query += " AND (uc.date_expired IS NULL OR uc.date_expired > NOW())"
+	case MembershipExpired:
+		query += " AND uc.date_expired <= NOW()"
+	}
+
+	return query + " ORDER BY uc.id LIMIT ?", append(args, filter.Limit+1)
+}
+
+func (r *relationalDB) createMembership(userID, clientID int64, dateExpired *time.Time) (int64, error) {
+	result, err := r.client.Exec(insertMembershipQuery, userID, clientID, dateExpired)
+	if err != nil {
+		return 0, db.ExecError(err, insertMembershipQuery)
+	}
+
+	membershipID, err := result.LastInsertId()
+	if err != nil {
+		return 0, db.LastInsertedError(err, insertMembershipQuery)
+	}
+
+	return membershipID, nil
+}
+
+func (r *relationalDB) expireMembership(membershipID int64) (bool, error) {
+	result, err := r.client.Exec(expireMembershipQuery, membershipID)
+	if err != nil {
+		return false, db.ExecError(err, expireMembershipQuery)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, db.RowsAffectedError(err, expireMembershipQuery)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// clientExecError translates the violation of the client name unique index into a clientWithSameNameError.
+func clientExecError(err error, query string) error {
+	if key, ok := db.DuplicateEntryKey(err); ok && key == clientNameUniqueKey {
+		return clientWithSameNameError
+	}
+	return db.ExecError(err, query)
+}
+
+func (r *relationalDB) record(entry audit.Entry) error {
+	return audit.NewRelationalDB(r.client).Record(entry)
+}
+
+func (r *relationalDB) getTransactioner() (Transactioner, error) {
+	client, ok := r.client.(*sql.DB)
+	if !ok {
+		return nil, errors.New("persister cannot generate transactional db")
+	}
+
+	tx, err := client.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("persister cannot generate transactional due to: %w", err)
+	}
+
+	return &transactionalDB{relationalDB: relationalDB{client: tx}, tx: tx}, nil
+}
+
+func (r *relationalDB) withTransaction(fn func(tx Transactioner) error) error {
+	tx, err := r.getTransactioner()
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		if err := tx.rollback(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	return tx.commit()
+}
+
+type transactionalDB struct {
+	relationalDB
+	tx *sql.Tx
+}
+
+func (tx *transactionalDB) commit() error {
+	if err := tx.tx.Commit(); err != nil {
+		return db.CommitError(err)
+	}
+	return nil
+}
+
+func (tx *transactionalDB) rollback() error {
+	if err := tx.tx.Rollback(); err != nil {
+		return db.RollbackError(err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"maria/src/api/audit"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type dbMock struct {
+	mock.Mock
+}
+
+func newDBMock() *dbMock {
+	return &dbMock{}
+}
+
+func (m *dbMock) selectByID(clientID int64) (Client, error) {
+	args := m.Called(clientID)
+	return mockClient(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectByName(clientName string) (Client, error) {
+	args := m.Called(clientName)
+	return mockClient(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectByFilter(filter ClientFilter) ([]Client, error) {
+	args := m.Called(filter)
+	return mockClients(args, 0), args.Error(1)
+}
+
+func (m *dbMock) createClient(request NewClientRequest) (int64, error) {
+	args := m.Called(request)
+	return mockInt64(args, 0), args.Error(1)
+}
+
+func (m *dbMock) renameClient(clientID int64, clientName string) (bool, error) {
+	args := m.Called(clientID, clientName)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) deactivateClient(clientID int64) (bool, error) {
+	args := m.Called(clientID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) expireLinks(clientID int64) (int64, error) {
+	args := m.Called(clientID)
+	return mockInt64(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectByIDForUpdate(clientID int64) (Client, error) {
+	args := m.Called(clientID)
+	return mockClient(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectMember(userID int64) (member, error) {
+	args := m.Called(userID)
+	return mockMember(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectMembershipByID(membershipID int64) (Membership, error) {
+	args := m.Called(membershipID)
+	return mockMembership(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectActiveMembership(userID, clientID int64) (Membership, error) {
+	args := m.Called(userID, clientID)
+	return mockMembership(args, 0), args.Error(1)
+}
+
+func (m *dbMock) hasOtherMemberships(userID, clientID int64) (bool, error) {
+	args := m.Called(userID, clientID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) selectClientMemberships(clientID int64, filter MembershipFilter) (MembershipPage, error) {
+	args := m.Called(clientID, filter)
+	return mockMembershipPage(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectUserMemberships(userID int64, filter MembershipFilter) (MembershipPage, error) {
+	args := m.Called(userID, filter)
+	return mockMembershipPage(args, 0), args.Error(1)
+}
+
+func (m *dbMock) createMembership(userID, clientID int64, dateExpired *time.Time) (int64, error) {
+	args := m.Called(userID, clientID, dateExpired)
+	return mockInt64(args, 0), args.Error(1)
+}
+
+func (m *dbMock) expireMembership(membershipID int64) (bool, error) {
+	args := m.Called(membershipID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) record(entry audit.Entry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *dbMock) withTransaction(fn func(tx Transactioner) error) error {
+	args := m.Called(fn)
+
+	if err := args.Error(0); err != nil {
+		return err
+	}
+
+	return fn(m)
+}
+
+func (m *dbMock) commit() error {
+	args := m.Called()
+	return args.Error(1)
+}
+
+func (m *dbMock) rollback() error {
+	args := m.Called()
+	return args.Error(1)
+}
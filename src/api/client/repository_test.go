@@ -0,0 +1,822 @@
+package client
+
+import (
+	"errors"
+	"maria/src/api/db"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type relationalDBSuite struct {
+	suite.Suite
+}
+
+func TestRelationalDBSuite(t *testing.T) {
+	suite.Run(t, new(relationalDBSuite))
+}
+
+func (s *relationalDBSuite) BeforeTest(suiteName, testName string) {
+}
+
+func (s *relationalDBSuite) AfterTest(suiteName, testName string) {
+}
+
+func (s *relationalDBSuite) TestSelectByName() {
+	var (
+		acme        = Client{ID: 10, ClientName: "acme", Active: true, DateCreated: time.Now()}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		mockCalls      mockDBApplier
+		expectedError  error
+		expectedClient Client
+	}
+
+	tests := []test{
+		{
+			name: "scan error",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getClientMockRows([]Client{acme}), getClientByNameQuery, customError, acme.ClientName)},
+			expectedError:  db.ScanError(customError, getClientByNameQuery),
+			expectedClient: Client{},
+		},
+		{
+			name: "client not found",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getClientMockRows(nil), getClientByNameQuery, nil, acme.ClientName)},
+			expectedError:  nil,
+			expectedClient: Client{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getClientMockRows([]Client{acme}), getClientByNameQuery, nil, acme.ClientName)},
+			expectedError:  nil,
+			expectedClient: acme,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectByName(acme.ClientName)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedClient, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectByFilter() {
+	var (
+		active      = true
+		clients     = []Client{{ID: 10, ClientName: "acme", Active: true, DateCreated: time.Now()}}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name            string
+		filter          ClientFilter
+		mockCalls       mockDBApplier
+		expectedError   error
+		expectedClients []Client
+	}
+
+	tests := []test{
+		{
+			name:   "query error",
+			filter: ClientFilter{},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				nil, listClientsQuery+" ORDER BY client_name, id", customError, nil)},
+			expectedError:   db.QueryError(customError, listClientsQuery+" ORDER BY client_name, id"),
+			expectedClients: nil,
+		},
+		{
+			name:   "rows error",
+			filter: ClientFilter{},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getClientMockRows(clients), listClientsQuery+" ORDER BY client_name, id", nil, customError)},
+			expectedError:   db.RowsError(customError, listClientsQuery+" ORDER BY client_name, id"),
+			expectedClients: nil,
+		},
+		{
+			name:   "filtered by active",
+			filter: ClientFilter{Active: &active},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getClientMockRows(clients),
+				listClientsQuery+" WHERE active = ? ORDER BY client_name, id",
+				nil,
+				nil,
+				true)},
+			expectedError:   nil,
+			expectedClients: clients,
+		},
+		{
+			name:   "scoped to a tenant",
+			filter: ClientFilter{Active: &active, id: 10},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getClientMockRows(clients),
+				listClientsQuery+" WHERE active = ? AND id = ? ORDER BY client_name, id",
+				nil,
+				nil,
+				true, int64(10))},
+			expectedError:   nil,
+			expectedClients: clients,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectByFilter(test.filter)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedClients, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestCreateClient() {
+	var (
+		request     = NewClientRequest{ClientName: "acme"}
+		customError = errors.New("custom error")
+		duplicated  = &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'acme' for key 'client_client_name_uindex'"}
+	)
+
+	type test struct {
+		name             string
+		mockCalls        mockDBApplier
+		expectedError    error
+		expectedClientID int64
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertClientQuery, customError, request.ClientName)},
+			expectedError:    db.ExecError(customError, insertClientQuery),
+			expectedClientID: 0,
+		},
+		{
+			name: "client name is duplicated",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertClientQuery, duplicated, request.ClientName)},
+			expectedError:    clientWithSameNameError,
+			expectedClientID: 0,
+		},
+		{
+			name: "last inserted error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewErrorResult(customError), insertClientQuery, nil, request.ClientName)},
+			expectedError:    db.LastInsertedError(customError, insertClientQuery),
+			expectedClientID: 0,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(10, 1), insertClientQuery, nil, request.ClientName)},
+			expectedError:    nil,
+			expectedClientID: 10,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			clientID, err := rDB.createClient(request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedClientID, clientID)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestRenameClient() {
+	var (
+		clientID    = int64(10)
+		clientName  = "acme"
+		customError = errors.New("custom error")
+		duplicated  = &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'acme' for key 'client_client_name_uindex'"}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name: "client name is duplicated",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, renameClientQuery, duplicated, clientName, clientID)},
+			expectedError: clientWithSameNameError,
+			expectedTag:   false,
+		},
+		{
+			name: "rows affected error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewErrorResult(customError), renameClientQuery, nil, clientName, clientID)},
+			expectedError: db.RowsAffectedError(customError, renameClientQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), renameClientQuery, nil, clientName, clientID)},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			renamed, err := rDB.renameClient(clientID, clientName)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, renamed)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestDeactivateClient() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name:          "exec error",
+			mockCalls:     mockDBApplier{db.SetClientExecMock(nil, deactivateClientQuery, customError, clientID)},
+			expectedError: db.ExecError(customError, deactivateClientQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), deactivateClientQuery, nil, clientID)},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			deactivated, err := rDB.deactivateClient(clientID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, deactivated)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestExpireLinks() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedCount int64
+	}
+
+	tests := []test{
+		{
+			name:          "exec error",
+			mockCalls:     mockDBApplier{db.SetClientExecMock(nil, expireClientLinksQuery, customError, clientID)},
+			expectedError: db.ExecError(customError, expireClientLinksQuery),
+			expectedCount: 0,
+		},
+		{
+			name: "rows affected error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewErrorResult(customError), expireClientLinksQuery, nil, clientID)},
+			expectedError: db.RowsAffectedError(customError, expireClientLinksQuery),
+			expectedCount: 0,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 3), expireClientLinksQuery, nil, clientID)},
+			expectedError: nil,
+			expectedCount: 3,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			expired, err := rDB.expireLinks(clientID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedCount, expired)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectActiveMembership() {
+	var (
+		userID      = int64(20)
+		clientID    = int64(10)
+		expiration  = time.Now().Add(time.Hour)
+		membership  = Membership{ID: 1, UserID: userID, UserName: "jdoe", ClientID: clientID, ClientName: "acme"}
+		expiring    = membership
+		customError = errors.New("custom error")
+	)
+	expiring.DateExpired = &expiration
+
+	type test struct {
+		name               string
+		mockCalls          mockDBApplier
+		expectedError      error
+		expectedMembership Membership
+	}
+
+	tests := []test{
+		{
+			name: "scan error",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getMembershipMockRows([]Membership{membership}), getActiveMembershipQuery, customError, userID, clientID)},
+			expectedError:      db.ScanError(customError, getActiveMembershipQuery),
+			expectedMembership: Membership{},
+		},
+		{
+			name: "membership not found",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getMembershipMockRows(nil), getActiveMembershipQuery, nil, userID, clientID)},
+			expectedError:      nil,
+			expectedMembership: Membership{},
+		},
+		{
+			name: "membership without expiration",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getMembershipMockRows([]Membership{membership}), getActiveMembershipQuery, nil, userID, clientID)},
+			expectedError:      nil,
+			expectedMembership: membership,
+		},
+		{
+			name: "membership with expiration",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getMembershipMockRows([]Membership{expiring}), getActiveMembershipQuery, nil, userID, clientID)},
+			expectedError:      nil,
+			expectedMembership: expiring,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectActiveMembership(userID, clientID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedMembership, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestHasOtherMemberships() {
+	var (
+		userID      = int64(20)
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedOther bool
+	}
+
+	tests := []test{
+		{
+			name: "scan error",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				sqlmock.NewRows([]string{"exists"}).AddRow(true), hasOtherMembershipsQuery, customError, userID, clientID)},
+			expectedError: db.ScanError(customError, hasOtherMembershipsQuery),
+			expectedOther: false,
+		},
+		{
+			name: "user has no other memberships",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				sqlmock.NewRows([]string{"exists"}).AddRow(false), hasOtherMembershipsQuery, nil, userID, clientID)},
+			expectedError: nil,
+			expectedOther: false,
+		},
+		{
+			name: "user has other memberships",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				sqlmock.NewRows([]string{"exists"}).AddRow(true), hasOtherMembershipsQuery, nil, userID, clientID)},
+			expectedError: nil,
+			expectedOther: true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			other, err := rDB.hasOtherMemberships(userID, clientID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedOther, other)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectClientMemberships() {
+	var (
+		clientID    = int64(10)
+		memberships = []Membership{
+			{ID: 1, UserID: 20, UserName: "jdoe", ClientID: clientID, ClientName: "acme"},
+			{ID: 2, UserID: 21, UserName: "jroe", ClientID: clientID, ClientName: "acme"},
+		}
+		customError = errors.New("custom error")
+		activeQuery = membershipsQuery + " WHERE uc.client_id = ? AND uc.id > ?" +
+			" AND (uc.date_expired IS NULL OR uc.date_expired > NOW()) ORDER BY uc.id LIMIT ?"
+		expiredQuery = membershipsQuery + " WHERE uc.client_id = ? AND uc.id > ?" +
+			" AND uc.date_expired <= NOW() ORDER BY uc.id LIMIT ?"
+		allQuery = membershipsQuery + " WHERE uc.client_id = ? AND uc.id > ? ORDER BY uc.id LIMIT ?"
+	)
+
+	type test struct {
+		name          string
+		filter        MembershipFilter
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedPage  MembershipPage
+	}
+
+	tests := []test{
+		{
+			name:   "query error",
+			filter: MembershipFilter{Status: MembershipActive},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				nil, activeQuery, customError, nil, clientID, int64(0), defaultPageLimit+1)},
+			expectedError: db.QueryError(customError, activeQuery),
+			expectedPage:  MembershipPage{},
+		},
+		{
+			name:   "rows error",
+			filter: MembershipFilter{Status: MembershipExpired},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getMembershipMockRows(memberships), expiredQuery, nil, customError, clientID, int64(0), defaultPageLimit+1)},
+			expectedError: db.RowsError(customError, expiredQuery),
+			expectedPage:  MembershipPage{},
+		},
+		{
+			name:   "last page",
+			filter: MembershipFilter{Status: MembershipAll, AfterID: 1},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getMembershipMockRows(memberships[1:]), allQuery, nil, nil, clientID, int64(1), defaultPageLimit+1)},
+			expectedError: nil,
+			expectedPage: MembershipPage{
+				Results: memberships[1:],
+				Paging:  Paging{Limit: defaultPageLimit},
+			},
+		},
+		{
+			name:   "page with next cursor",
+			filter: MembershipFilter{Status: MembershipActive, Limit: 1},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getMembershipMockRows(memberships), activeQuery, nil, nil, clientID, int64(0), 2)},
+			expectedError: nil,
+			expectedPage: MembershipPage{
+				Results: memberships[:1],
+				Paging:  Paging{Limit: 1, NextCursor: "1"},
+			},
+		},
+		{
+			name:   "no memberships",
+			filter: MembershipFilter{Status: MembershipActive, Limit: maxPageLimit + 1},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getMembershipMockRows(nil), activeQuery, nil, nil, clientID, int64(0), maxPageLimit+1)},
+			expectedError: nil,
+			expectedPage: MembershipPage{
+				Results: []Membership{},
+				Paging:  Paging{Limit: maxPageLimit},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectClientMemberships(clientID, test.filter)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedPage, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestCreateMembership() {
+	var (
+		userID      = int64(20)
+		clientID    = int64(10)
+		expiration  = time.Now().Add(time.Hour)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedID    int64
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertMembershipQuery, customError, userID, clientID, &expiration)},
+			expectedError: db.ExecError(customError, insertMembershipQuery),
+			expectedID:    0,
+		},
+		{
+			name: "last inserted id error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewErrorResult(customError), insertMembershipQuery, nil, userID, clientID, &expiration)},
+			expectedError: db.LastInsertedError(customError, insertMembershipQuery),
+			expectedID:    0,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(5, 1), insertMembershipQuery, nil, userID, clientID, &expiration)},
+			expectedError: nil,
+			expectedID:    5,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			membershipID, err := rDB.createMembership(userID, clientID, &expiration)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedID, membershipID)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestExpireMembership() {
+	var (
+		membershipID = int64(5)
+		customError  = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name:          "exec error",
+			mockCalls:     mockDBApplier{db.SetClientExecMock(nil, expireMembershipQuery, customError, membershipID)},
+			expectedError: db.ExecError(customError, expireMembershipQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "already expired",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 0), expireMembershipQuery, nil, membershipID)},
+			expectedError: nil,
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), expireMembershipQuery, nil, membershipID)},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			expired, err := rDB.expireMembership(membershipID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, expired)
+		})
+	}
+}
+
+func getMembershipMockRows(memberships []Membership) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{
+		"id", "user_id", "user_name", "client_id", "client_name", "date_expired", "date_created"})
+	for _, m := range memberships {
+		var dateExpired any
+		if m.DateExpired != nil {
+			dateExpired = *m.DateExpired
+		}
+		rows.AddRow(m.ID, m.UserID, m.UserName, m.ClientID, m.ClientName, dateExpired, m.DateCreated)
+	}
+	return rows
+}
+
+func getClientMockRows(clients []Client) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "client_name", "active", "date_created"})
+	for _, c := range clients {
+		rows.AddRow(c.ID, c.ClientName, c.Active, c.DateCreated)
+	}
+	return rows
+}
+
+type mockDBApplier []func(m sqlmock.Sqlmock) func() error
+
+func (appliers mockDBApplier) apply(m sqlmock.Sqlmock) func() error {
+	var assertCalls []func() error
+	for i := range appliers {
+		assertCall := appliers[i](m)
+		assertCalls = append(assertCalls, assertCall)
+	}
+	return func() error {
+		for i := range assertCalls {
+			if err := assertCalls[i](); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
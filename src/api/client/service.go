@@ -0,0 +1,336 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
+	"time"
+)
+
+var (
+	clientNotFoundError     = errors.New("client not found")
+	clientWithSameNameError = errors.New("there is already a client with same client_name")
+
+	userNotFoundError                = errors.New("user not found")
+	inactiveUserError                = errors.New("user is not active")
+	inactiveClientError              = errors.New("client is not active")
+	membershipAlreadyActiveError     = errors.New("user is already an active member of the client")
+	membershipNotFoundError          = errors.New("user is not an active member of the client")
+	invalidMembershipExpirationError = errors.New("date_expired must be in the future")
+	invalidCursorError               = errors.New("cursor is not valid")
+)
+
+const auditEntity = "client"
+
+// Service methods receiving a context use it for knowing the caller, who is recorded in the audit log, and the
+// tenant. When the context is scoped to a client, any other client is not found.
+type Service interface {
+	getByID(context.Context, int64) (Client, error)
+	createClient(context.Context, NewClientRequest) (Client, error)
+	listClients(context.Context, ClientFilter) ([]Client, error)
+	renameClient(context.Context, RenameClientRequest, int64) (Client, error)
+	deactivateClient(context.Context, int64) (Client, error)
+	addMember(context.Context, int64, AddMemberRequest) (Membership, error)
+	removeMember(context.Context, int64, int64) error
+	listMembers(context.Context, int64, MembershipFilter) (MembershipPage, error)
+	listUserClients(context.Context, int64, MembershipFilter) (MembershipPage, error)
+}
+
+type clientService struct {
+	clientRepository Persister
+	now              func() time.Time
+}
+
+func NewService(clientRepository Persister) Service {
+	return clientService{
+		clientRepository: clientRepository,
+		now:              time.Now,
+	}
+}
+
+func (cs clientService) getByID(ctx context.Context, clientID int64) (Client, error) {
+	if err := checkTenant(ctx, clientID); err != nil {
+		return Client{}, err
+	}
+
+	client, err := cs.clientRepository.selectByID(clientID)
+	if err == nil && client.isEmptyClient() {
+		return client, clientNotFoundError
+	}
+	return client, err
+}
+
+func (cs clientService) createClient(ctx context.Context, request NewClientRequest) (Client, error) {
+	var client Client
+
+	if err := cs.clientRepository.withTransaction(func(tx Transactioner) error {
+		if err := checkNameIsFree(tx, request.ClientName, 0); err != nil {
+			return err
+		}
+
+		clientID, err := tx.createClient(request)
+		if err != nil {
+			return err
+		}
+
+		if client, err = tx.selectByID(clientID); err != nil {
+			return err
+		}
+
+		return record(ctx, tx, audit.ActionCreate, nil, &client)
+	}); err != nil {
+		return Client{}, err
+	}
+
+	return client, nil
+}
+
+func (cs clientService) listClients(ctx context.Context, filter ClientFilter) ([]Client, error) {
+	filter.id, _ = auth.TenantFrom(ctx)
+
+	clients, err := cs.clientRepository.selectByFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if clients == nil {
+		clients = []Client{}
+	}
+	return clients, nil
+}
+
+func (cs clientService) renameClient(ctx context.Context, request RenameClientRequest, clientID int64) (Client, error) {
+	client, err := cs.getByID(ctx, clientID)
+	if err != nil {
+		return Client{}, err
+	}
+
+	if err = cs.clientRepository.withTransaction(func(tx Transactioner) error {
+		if err := checkNameIsFree(tx, request.ClientName, clientID); err != nil {
+			return err
+		}
+
+		if _, err := tx.renameClient(clientID, request.ClientName); err != nil {
+			return err
+		}
+
+		before := client
+		if client, err = tx.selectByID(clientID); err != nil {
+			return err
+		}
+
+		return record(ctx, tx, audit.ActionModify, &before, &client)
+	}); err != nil {
+		return Client{}, err
+	}
+
+	return client, nil
+}
+
+// deactivateClient marks the client as inactive and expires its links with users in the same transaction. The
+// expired links are picked up by the expiry sweeper, which emits their events.
+func (cs clientService) deactivateClient(ctx context.Context, clientID int64) (Client, error) {
+	client, err := cs.getByID(ctx, clientID)
+	if err != nil {
+		return Client{}, err
+	}
+
+	if err = cs.clientRepository.withTransaction(func(tx Transactioner) error {
+		if _, err := tx.deactivateClient(clientID); err != nil {
+			return err
+		}
+
+		if _, err := tx.expireLinks(clientID); err != nil {
+			return err
+		}
+
+		before := client
+		if client, err = tx.selectByID(clientID); err != nil {
+			return err
+		}
+
+		return record(ctx, tx, audit.ActionDeactivate, &before, &client)
+	}); err != nil {
+		return Client{}, err
+	}
+
+	return client, nil
+}
+
+// addMember makes the user an active member of the client. A user can have a single active membership by client,
+// the expired ones are kept as history. When the context is scoped to a client, the users belonging to other tenants
+// are not found, so a tenant cannot take them over nor learn that they exist.
+func (cs clientService) addMember(ctx context.Context, clientID int64, request AddMemberRequest) (Membership, error) {
+	if err := checkTenant(ctx, clientID); err != nil {
+		return Membership{}, err
+	}
+	if request.DateExpired != nil && !request.DateExpired.After(cs.now()) {
+		return Membership{}, invalidMembershipExpirationError
+	}
+
+	var membership Membership
+	if err := cs.clientRepository.withTransaction(func(tx Transactioner) error {
+		client, err := tx.selectByIDForUpdate(clientID)
+		if err != nil {
+			return err
+		}
+		if client.isEmptyClient() {
+			return clientNotFoundError
+		}
+		if !client.Active {
+			return inactiveClientError
+		}
+
+		user, err := tx.selectMember(request.UserID)
+		if err != nil {
+			return err
+		}
+		if user.isEmpty() {
+			return userNotFoundError
+		}
+		if _, scoped := auth.TenantFrom(ctx); scoped {
+			other, err := tx.hasOtherMemberships(request.UserID, clientID)
+			if err != nil {
+				return err
+			}
+			if other {
+				return userNotFoundError
+			}
+		}
+		if !user.Active {
+			return inactiveUserError
+		}
+
+		active, err := tx.selectActiveMembership(request.UserID, clientID)
+		if err != nil {
+			return err
+		}
+		if !active.isEmpty() {
+			return membershipAlreadyActiveError
+		}
+
+		membershipID, err := tx.createMembership(request.UserID, clientID, request.DateExpired)
+		if err != nil {
+			return err
+		}
+
+		if membership, err = tx.selectMembershipByID(membershipID); err != nil {
+			return err
+		}
+
+		return recordEntry(ctx, tx, audit.ActionAddMember, clientID, nil, membership)
+	}); err != nil {
+		return Membership{}, err
+	}
+
+	return membership, nil
+}
+
+// removeMember expires the active membership of the user in the client, so it is kept as history.
+func (cs clientService) removeMember(ctx context.Context, clientID, userID int64) error {
+	if _, err := cs.getByID(ctx, clientID); err != nil {
+		return err
+	}
+
+	return cs.clientRepository.withTransaction(func(tx Transactioner) error {
+		membership, err := tx.selectActiveMembership(userID, clientID)
+		if err != nil {
+			return err
+		}
+		if membership.isEmpty() {
+			return membershipNotFoundError
+		}
+
+		if expired, err := tx.expireMembership(membership.ID); err != nil {
+			return err
+		} else if !expired {
+			return membershipNotFoundError
+		}
+
+		removed, err := tx.selectMembershipByID(membership.ID)
+		if err != nil {
+			return err
+		}
+
+		return recordEntry(ctx, tx, audit.ActionRemoveMember, clientID, membership, removed)
+	})
+}
+
+func (cs clientService) listMembers(ctx context.Context, clientID int64, filter MembershipFilter) (MembershipPage, error) {
+	if _, err := cs.getByID(ctx, clientID); err != nil {
+		return MembershipPage{}, err
+	}
+
+	return cs.clientRepository.selectClientMemberships(clientID, filter)
+}
+
+// listUserClients returns the memberships of the user. When ctx is scoped to a tenant, the user must be an active
+// member of it and only the memberships of the tenant are returned.
+func (cs clientService) listUserClients(ctx context.Context, userID int64, filter MembershipFilter) (MembershipPage, error) {
+	if clientID, scoped := auth.TenantFrom(ctx); scoped {
+		membership, err := cs.clientRepository.selectActiveMembership(userID, clientID)
+		if err != nil {
+			return MembershipPage{}, err
+		}
+		if membership.isEmpty() {
+			return MembershipPage{}, userNotFoundError
+		}
+		filter.clientID = clientID
+	} else {
+		user, err := cs.clientRepository.selectMember(userID)
+		if err != nil {
+			return MembershipPage{}, err
+		}
+		if user.isEmpty() {
+			return MembershipPage{}, userNotFoundError
+		}
+	}
+
+	return cs.clientRepository.selectUserMemberships(userID, filter)
+}
+
+// checkTenant returns clientNotFoundError when ctx is scoped to another client, so its existence is not leaked.
+func checkTenant(ctx context.Context, clientID int64) error {
+	if tenant, scoped := auth.TenantFrom(ctx); scoped && tenant != clientID {
+		return clientNotFoundError
+	}
+	return nil
+}
+
+// checkNameIsFree returns clientWithSameNameError when another client than clientID has the same name.
+func checkNameIsFree(tx Transactioner, clientName string, clientID int64) error {
+	client, err := tx.selectByName(clientName)
+	if err != nil {
+		return err
+	}
+	if !client.isEmptyClient() && client.ID != clientID {
+		return clientWithSameNameError
+	}
+	return nil
+}
+
+func record(ctx context.Context, tx Transactioner, action string, before, after *Client) error {
+	var (
+		clientID                  int64
+		beforeEntity, afterEntity any
+	)
+
+	if before != nil {
+		clientID, beforeEntity = before.ID, *before
+	}
+	if after != nil {
+		clientID, afterEntity = after.ID, *after
+	}
+
+	return recordEntry(ctx, tx, action, clientID, beforeEntity, afterEntity)
+}
+
+// recordEntry records a change of the client clientID, before and after can be any entity related to it.
+func recordEntry(ctx context.Context, tx Transactioner, action string, clientID int64, before, after any) error {
+	entry, err := audit.NewEntry(auth.CallerFrom(ctx), action, auditEntity, clientID, before, after)
+	if err != nil {
+		return err
+	}
+
+	return tx.record(entry)
+}
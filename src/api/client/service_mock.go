@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type serviceMock struct {
+	mock.Mock
+}
+
+func newServiceMock() *serviceMock {
+	return &serviceMock{}
+}
+
+func mockClient(args mock.Arguments, index int) Client {
+	obj := args.Get(index)
+	var s Client
+	var ok bool
+	if s, ok = obj.(Client); !ok {
+		panic(fmt.Sprintf("assert: arguments: Client(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockClients(args mock.Arguments, index int) []Client {
+	obj := args.Get(index)
+	var s []Client
+	var ok bool
+	if s, ok = obj.([]Client); !ok {
+		panic(fmt.Sprintf("assert: arguments: Client(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockInt64(args mock.Arguments, index int) int64 {
+	obj := args.Get(index)
+	var s int64
+	var ok bool
+	if s, ok = obj.(int64); !ok {
+		panic(fmt.Sprintf("assert: arguments: Int64(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockMember(args mock.Arguments, index int) member {
+	obj := args.Get(index)
+	var s member
+	var ok bool
+	if s, ok = obj.(member); !ok {
+		panic(fmt.Sprintf("assert: arguments: member(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockMembership(args mock.Arguments, index int) Membership {
+	obj := args.Get(index)
+	var s Membership
+	var ok bool
+	if s, ok = obj.(Membership); !ok {
+		panic(fmt.Sprintf("assert: arguments: Membership(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockMembershipPage(args mock.Arguments, index int) MembershipPage {
+	obj := args.Get(index)
+	var s MembershipPage
+	var ok bool
+	if s, ok = obj.(MembershipPage); !ok {
+		panic(fmt.Sprintf("assert: arguments: MembershipPage(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func (m *serviceMock) getByID(ctx context.Context, clientID int64) (Client, error) {
+	args := m.Called(ctx, clientID)
+	return mockClient(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) createClient(ctx context.Context, request NewClientRequest) (Client, error) {
+	args := m.Called(ctx, request)
+	return mockClient(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) listClients(ctx context.Context, filter ClientFilter) ([]Client, error) {
+	args := m.Called(ctx, filter)
+	return mockClients(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) renameClient(ctx context.Context, request RenameClientRequest, clientID int64) (Client, error) {
+	args := m.Called(ctx, request, clientID)
+	return mockClient(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) deactivateClient(ctx context.Context, clientID int64) (Client, error) {
+	args := m.Called(ctx, clientID)
+	return mockClient(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) addMember(ctx context.Context, clientID int64, request AddMemberRequest) (Membership, error) {
+	args := m.Called(ctx, clientID, request)
+	return mockMembership(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) removeMember(ctx context.Context, clientID, userID int64) error {
+	args := m.Called(ctx, clientID, userID)
+	return args.Error(0)
+}
+
+func (m *serviceMock) listMembers(ctx context.Context, clientID int64, filter MembershipFilter) (MembershipPage, error) {
+	args := m.Called(ctx, clientID, filter)
+	return mockMembershipPage(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) listUserClients(ctx context.Context, userID int64, filter MembershipFilter) (MembershipPage, error) {
+	args := m.Called(ctx, userID, filter)
+	return mockMembershipPage(args, 0), args.Error(1)
+}
@@ -0,0 +1,1222 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
+	"maria/src/api/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const testCaller = "admin"
+
+var (
+	testNow = time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+	testCtx = auth.WithCaller(context.Background(), testCaller)
+)
+
+type ClientServiceSuite struct {
+	suite.Suite
+}
+
+func TestClientServiceSuite(t *testing.T) {
+	suite.Run(t, new(ClientServiceSuite))
+}
+
+func (s *ClientServiceSuite) BeforeTest(suiteName, testName string) {
+}
+
+func (s *ClientServiceSuite) AfterTest(suiteName, testName string) {
+}
+
+func (s *ClientServiceSuite) TestGetByID() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		ctx            context.Context
+		mockCalls      mockPersisterApplier
+		expectedError  error
+		expectedClient Client
+	}
+
+	tests := []test{
+		{
+			name:           "client not found",
+			mockCalls:      mockPersisterApplier{setPersiterSelectByIDMock(Client{}, nil, clientID)},
+			expectedError:  clientNotFoundError,
+			expectedClient: Client{},
+		},
+		{
+			name:           "repository return error",
+			mockCalls:      mockPersisterApplier{setPersiterSelectByIDMock(Client{}, customError, clientID)},
+			expectedError:  customError,
+			expectedClient: Client{},
+		},
+		{
+			name:           "happy case",
+			mockCalls:      mockPersisterApplier{setPersiterSelectByIDMock(Client{ID: clientID}, nil, clientID)},
+			expectedError:  nil,
+			expectedClient: Client{ID: clientID},
+		},
+		{
+			name:           "client is not the tenant",
+			ctx:            auth.WithTenant(testCtx, clientID+1),
+			mockCalls:      mockPersisterApplier{},
+			expectedError:  clientNotFoundError,
+			expectedClient: Client{},
+		},
+		{
+			name:           "client is the tenant",
+			ctx:            auth.WithTenant(testCtx, clientID),
+			mockCalls:      mockPersisterApplier{setPersiterSelectByIDMock(Client{ID: clientID}, nil, clientID)},
+			expectedError:  nil,
+			expectedClient: Client{ID: clientID},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			ctx := testCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			client, err := serv.getByID(ctx, clientID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedClient, client)
+		})
+	}
+}
+
+func (s *ClientServiceSuite) TestCreateClient() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+		request     = NewClientRequest{ClientName: "acme"}
+		newClient   = Client{
+			ID:          clientID,
+			ClientName:  request.ClientName,
+			Active:      true,
+			DateCreated: time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC),
+		}
+	)
+
+	type test struct {
+		name           string
+		mockCalls      mockPersisterApplier
+		expectedError  error
+		expectedClient Client
+	}
+
+	tests := []test{
+		{
+			name: "transaction cannot be started",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(customError),
+			},
+			expectedError:  customError,
+			expectedClient: Client{},
+		},
+		{
+			name: "client name is taken",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByNameMock(Client{ID: 1}, nil, request.ClientName),
+			},
+			expectedError:  clientWithSameNameError,
+			expectedClient: Client{},
+		},
+		{
+			name: "create client return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByNameMock(Client{}, nil, request.ClientName),
+				setPersiterCreateClientMock(0, customError, request),
+			},
+			expectedError:  customError,
+			expectedClient: Client{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByNameMock(Client{}, nil, request.ClientName),
+				setPersiterCreateClientMock(clientID, nil, request),
+				setPersiterSelectByIDMock(newClient, nil, clientID),
+				setPersiterRecordMock(nil, audit.ActionCreate, nil, &newClient),
+			},
+			expectedError:  nil,
+			expectedClient: newClient,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			client, err := serv.createClient(testCtx, request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedClient, client)
+		})
+	}
+}
+
+func (s *ClientServiceSuite) TestListClients() {
+	var (
+		active      = true
+		customError = errors.New("custom error")
+		filter      = ClientFilter{Active: &active}
+	)
+
+	type test struct {
+		name            string
+		ctx             context.Context
+		mockCalls       mockPersisterApplier
+		expectedError   error
+		expectedClients []Client
+	}
+
+	tests := []test{
+		{
+			name:            "repository return error",
+			mockCalls:       mockPersisterApplier{setPersiterSelectByFilterMock(nil, customError, filter)},
+			expectedError:   customError,
+			expectedClients: nil,
+		},
+		{
+			name:            "there are no clients",
+			mockCalls:       mockPersisterApplier{setPersiterSelectByFilterMock(nil, nil, filter)},
+			expectedError:   nil,
+			expectedClients: []Client{},
+		},
+		{
+			name:            "happy case",
+			mockCalls:       mockPersisterApplier{setPersiterSelectByFilterMock([]Client{{ID: 1}}, nil, filter)},
+			expectedError:   nil,
+			expectedClients: []Client{{ID: 1}},
+		},
+		{
+			name:            "clients are scoped to the tenant",
+			ctx:             auth.WithTenant(testCtx, 1),
+			mockCalls:       mockPersisterApplier{setPersiterSelectByFilterMock([]Client{{ID: 1}}, nil, ClientFilter{Active: &active, id: 1})},
+			expectedError:   nil,
+			expectedClients: []Client{{ID: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			ctx := testCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			clients, err := serv.listClients(ctx, filter)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedClients, clients)
+		})
+	}
+}
+
+func (s *ClientServiceSuite) TestRenameClient() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+		request     = RenameClientRequest{ClientName: "globex"}
+		client      = Client{ID: clientID, ClientName: "acme", Active: true}
+		renamed     = Client{ID: clientID, ClientName: request.ClientName, Active: true}
+	)
+
+	type test struct {
+		name           string
+		mockCalls      mockPersisterApplier
+		expectedError  error
+		expectedClient Client
+	}
+
+	tests := []test{
+		{
+			name:           "client not found",
+			mockCalls:      mockPersisterApplier{setPersiterSelectByIDMock(Client{}, nil, clientID)},
+			expectedError:  clientNotFoundError,
+			expectedClient: Client{},
+		},
+		{
+			name: "client name is taken by another client",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(client, nil, clientID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByNameMock(Client{ID: 2}, nil, request.ClientName),
+			},
+			expectedError:  clientWithSameNameError,
+			expectedClient: Client{},
+		},
+		{
+			name: "rename client return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(client, nil, clientID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByNameMock(Client{}, nil, request.ClientName),
+				setPersiterRenameClientMock(false, customError, clientID, request.ClientName),
+			},
+			expectedError:  customError,
+			expectedClient: Client{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(client, nil, clientID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByNameMock(Client{}, nil, request.ClientName),
+				setPersiterRenameClientMock(true, nil, clientID, request.ClientName),
+				setPersiterSelectByIDMock(renamed, nil, clientID),
+				setPersiterRecordMock(nil, audit.ActionModify, &client, &renamed),
+			},
+			expectedError:  nil,
+			expectedClient: renamed,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			client, err := serv.renameClient(testCtx, request, clientID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedClient, client)
+		})
+	}
+}
+
+func (s *ClientServiceSuite) TestDeactivateClient() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+		client      = Client{ID: clientID, ClientName: "acme", Active: true}
+		deactivated = Client{ID: clientID, ClientName: "acme", Active: false}
+	)
+
+	type test struct {
+		name           string
+		mockCalls      mockPersisterApplier
+		expectedError  error
+		expectedClient Client
+	}
+
+	tests := []test{
+		{
+			name:           "client not found",
+			mockCalls:      mockPersisterApplier{setPersiterSelectByIDMock(Client{}, nil, clientID)},
+			expectedError:  clientNotFoundError,
+			expectedClient: Client{},
+		},
+		{
+			name: "expire links return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(client, nil, clientID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterDeactivateClientMock(true, nil, clientID),
+				setPersiterExpireLinksMock(0, customError, clientID),
+			},
+			expectedError:  customError,
+			expectedClient: Client{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(client, nil, clientID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterDeactivateClientMock(true, nil, clientID),
+				setPersiterExpireLinksMock(2, nil, clientID),
+				setPersiterSelectByIDMock(deactivated, nil, clientID),
+				setPersiterRecordMock(nil, audit.ActionDeactivate, &client, &deactivated),
+			},
+			expectedError:  nil,
+			expectedClient: deactivated,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			client, err := serv.deactivateClient(testCtx, clientID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedClient, client)
+		})
+	}
+}
+
+func (s *ClientServiceSuite) TestAddMember() {
+	var (
+		clientID    = int64(10)
+		userID      = int64(20)
+		customError = errors.New("custom error")
+		past        = testNow.Add(-time.Hour)
+		future      = testNow.Add(time.Hour)
+		request     = AddMemberRequest{UserID: userID, DateExpired: &future}
+		client      = Client{ID: clientID, ClientName: "acme", Active: true}
+		user        = member{ID: userID, Active: true}
+		membership  = Membership{
+			ID:          1,
+			UserID:      userID,
+			UserName:    "jdoe",
+			ClientID:    clientID,
+			ClientName:  client.ClientName,
+			DateExpired: &future,
+			DateCreated: testNow,
+		}
+	)
+
+	type test struct {
+		name               string
+		ctx                context.Context
+		request            AddMemberRequest
+		mockCalls          mockPersisterApplier
+		expectedError      error
+		expectedMembership Membership
+	}
+
+	tests := []test{
+		{
+			name:               "date expired is not in the future",
+			request:            AddMemberRequest{UserID: userID, DateExpired: &past},
+			mockCalls:          mockPersisterApplier{},
+			expectedError:      invalidMembershipExpirationError,
+			expectedMembership: Membership{},
+		},
+		{
+			name:    "client not found",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(Client{}, nil, clientID),
+			},
+			expectedError:      clientNotFoundError,
+			expectedMembership: Membership{},
+		},
+		{
+			name:    "client is not active",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(Client{ID: clientID}, nil, clientID),
+			},
+			expectedError:      inactiveClientError,
+			expectedMembership: Membership{},
+		},
+		{
+			name:    "user not found",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(client, nil, clientID),
+				setPersiterSelectMemberMock(member{}, nil, userID),
+			},
+			expectedError:      userNotFoundError,
+			expectedMembership: Membership{},
+		},
+		{
+			name:    "user belongs to another tenant",
+			ctx:     auth.WithTenant(testCtx, clientID),
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(client, nil, clientID),
+				setPersiterSelectMemberMock(user, nil, userID),
+				setPersiterHasOtherMembershipsMock(true, nil, userID, clientID),
+			},
+			expectedError:      userNotFoundError,
+			expectedMembership: Membership{},
+		},
+		{
+			name:    "has other memberships return error",
+			ctx:     auth.WithTenant(testCtx, clientID),
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(client, nil, clientID),
+				setPersiterSelectMemberMock(member{ID: userID}, nil, userID),
+				setPersiterHasOtherMembershipsMock(false, customError, userID, clientID),
+			},
+			expectedError:      customError,
+			expectedMembership: Membership{},
+		},
+		{
+			name:    "user is not active",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(client, nil, clientID),
+				setPersiterSelectMemberMock(member{ID: userID}, nil, userID),
+			},
+			expectedError:      inactiveUserError,
+			expectedMembership: Membership{},
+		},
+		{
+			name:    "membership is already active",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(client, nil, clientID),
+				setPersiterSelectMemberMock(user, nil, userID),
+				setPersiterSelectActiveMembershipMock(membership, nil, userID, clientID),
+			},
+			expectedError:      membershipAlreadyActiveError,
+			expectedMembership: Membership{},
+		},
+		{
+			name:    "create membership return error",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(client, nil, clientID),
+				setPersiterSelectMemberMock(user, nil, userID),
+				setPersiterSelectActiveMembershipMock(Membership{}, nil, userID, clientID),
+				setPersiterCreateMembershipMock(0, customError, userID, clientID, &future),
+			},
+			expectedError:      customError,
+			expectedMembership: Membership{},
+		},
+		{
+			name:    "happy case",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(client, nil, clientID),
+				setPersiterSelectMemberMock(user, nil, userID),
+				setPersiterSelectActiveMembershipMock(Membership{}, nil, userID, clientID),
+				setPersiterCreateMembershipMock(membership.ID, nil, userID, clientID, &future),
+				setPersiterSelectMembershipByIDMock(membership, nil, membership.ID),
+				setPersiterRecordEntryMock(nil, audit.ActionAddMember, clientID, nil, membership),
+			},
+			expectedError:      nil,
+			expectedMembership: membership,
+		},
+		{
+			name:    "user without other tenants is added in a scoped context",
+			ctx:     auth.WithTenant(testCtx, clientID),
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDForUpdateMock(client, nil, clientID),
+				setPersiterSelectMemberMock(user, nil, userID),
+				setPersiterHasOtherMembershipsMock(false, nil, userID, clientID),
+				setPersiterSelectActiveMembershipMock(Membership{}, nil, userID, clientID),
+				setPersiterCreateMembershipMock(membership.ID, nil, userID, clientID, &future),
+				setPersiterSelectMembershipByIDMock(membership, nil, membership.ID),
+				setPersiterRecordEntryMock(nil, audit.ActionAddMember, clientID, nil, membership),
+			},
+			expectedError:      nil,
+			expectedMembership: membership,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			ctx := testCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			membership, err := serv.addMember(ctx, clientID, test.request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedMembership, membership)
+		})
+	}
+}
+
+func (s *ClientServiceSuite) TestRemoveMember() {
+	var (
+		clientID    = int64(10)
+		userID      = int64(20)
+		customError = errors.New("custom error")
+		client      = Client{ID: clientID, ClientName: "acme", Active: true}
+		membership  = Membership{ID: 1, UserID: userID, ClientID: clientID}
+		removed     = Membership{ID: 1, UserID: userID, ClientID: clientID, DateExpired: &testNow}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+	}
+
+	tests := []test{
+		{
+			name:          "client not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(Client{}, nil, clientID)},
+			expectedError: clientNotFoundError,
+		},
+		{
+			name: "membership not found",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(client, nil, clientID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectActiveMembershipMock(Membership{}, nil, userID, clientID),
+			},
+			expectedError: membershipNotFoundError,
+		},
+		{
+			name: "membership expired concurrently",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(client, nil, clientID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectActiveMembershipMock(membership, nil, userID, clientID),
+				setPersiterExpireMembershipMock(false, nil, membership.ID),
+			},
+			expectedError: membershipNotFoundError,
+		},
+		{
+			name: "expire membership return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(client, nil, clientID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectActiveMembershipMock(membership, nil, userID, clientID),
+				setPersiterExpireMembershipMock(false, customError, membership.ID),
+			},
+			expectedError: customError,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(client, nil, clientID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectActiveMembershipMock(membership, nil, userID, clientID),
+				setPersiterExpireMembershipMock(true, nil, membership.ID),
+				setPersiterSelectMembershipByIDMock(removed, nil, membership.ID),
+				setPersiterRecordEntryMock(nil, audit.ActionRemoveMember, clientID, membership, removed),
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			err := serv.removeMember(testCtx, clientID, userID)
+
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+}
+
+func (s *ClientServiceSuite) TestListMembers() {
+	var (
+		clientID    = int64(10)
+		customError = errors.New("custom error")
+		filter      = MembershipFilter{Status: MembershipActive}
+		page        = MembershipPage{Results: []Membership{{ID: 1, ClientID: clientID}}, Paging: Paging{Limit: 20}}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedPage  MembershipPage
+	}
+
+	tests := []test{
+		{
+			name:          "client not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(Client{}, nil, clientID)},
+			expectedError: clientNotFoundError,
+			expectedPage:  MembershipPage{},
+		},
+		{
+			name: "repository return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Client{ID: clientID}, nil, clientID),
+				setPersiterSelectClientMembershipsMock(MembershipPage{}, customError, clientID, filter),
+			},
+			expectedError: customError,
+			expectedPage:  MembershipPage{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Client{ID: clientID}, nil, clientID),
+				setPersiterSelectClientMembershipsMock(page, nil, clientID, filter),
+			},
+			expectedError: nil,
+			expectedPage:  page,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			page, err := serv.listMembers(testCtx, clientID, filter)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedPage, page)
+		})
+	}
+}
+
+func (s *ClientServiceSuite) TestListUserClients() {
+	var (
+		userID      = int64(20)
+		customError = errors.New("custom error")
+		filter      = MembershipFilter{Status: MembershipAll}
+		page        = MembershipPage{Results: []Membership{{ID: 1, UserID: userID}}, Paging: Paging{Limit: 20}}
+	)
+
+	type test struct {
+		name          string
+		ctx           context.Context
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedPage  MembershipPage
+	}
+
+	tests := []test{
+		{
+			name:          "user not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectMemberMock(member{}, nil, userID)},
+			expectedError: userNotFoundError,
+			expectedPage:  MembershipPage{},
+		},
+		{
+			name:          "select user return error",
+			mockCalls:     mockPersisterApplier{setPersiterSelectMemberMock(member{}, customError, userID)},
+			expectedError: customError,
+			expectedPage:  MembershipPage{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectMemberMock(member{ID: userID}, nil, userID),
+				setPersiterSelectUserMembershipsMock(page, nil, userID, filter),
+			},
+			expectedError: nil,
+			expectedPage:  page,
+		},
+		{
+			name: "user is not member of the tenant",
+			ctx:  auth.WithTenant(testCtx, 10),
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectActiveMembershipMock(Membership{}, nil, userID, 10),
+			},
+			expectedError: userNotFoundError,
+			expectedPage:  MembershipPage{},
+		},
+		{
+			name: "memberships are scoped to the tenant",
+			ctx:  auth.WithTenant(testCtx, 10),
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectActiveMembershipMock(Membership{ID: 1}, nil, userID, 10),
+				setPersiterSelectUserMembershipsMock(page, nil, userID, MembershipFilter{Status: MembershipAll, clientID: 10}),
+			},
+			expectedError: nil,
+			expectedPage:  page,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			ctx := testCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			page, err := serv.listUserClients(ctx, userID, filter)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedPage, page)
+		})
+	}
+}
+
+// newServiceForTest returns a service with a mocked repository and a fixed clock.
+func newServiceForTest() clientService {
+	serv := NewService(newDBMock()).(clientService)
+	serv.now = func() time.Time {
+		return testNow
+	}
+	return serv
+}
+
+type mockPersisterApplier []func(cs *clientService) (func(t *testing.T), error)
+
+func (appliers mockPersisterApplier) apply(cs *clientService) (func(t *testing.T), error) {
+	var assertCalls []func(t *testing.T)
+	for i := range appliers {
+		if assertCall, err := appliers[i](cs); err != nil {
+			return func(t *testing.T) {}, err
+		} else {
+			assertCalls = append(assertCalls, assertCall)
+		}
+	}
+	return func(t *testing.T) {
+		for i := range assertCalls {
+			assertCalls[i](t)
+		}
+	}, nil
+}
+
+func setPersiterWithTransactionMock(
+	errorResponse error,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.withTransaction), mock.Anything).
+			Return(errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByIDMock(
+	clientResponse Client,
+	errorResponse error,
+	clientID int64,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectByID), clientID).
+			Return(clientResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByNameMock(
+	clientResponse Client,
+	errorResponse error,
+	clientName string,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectByName), clientName).
+			Return(clientResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByFilterMock(
+	clientsResponse []Client,
+	errorResponse error,
+	filter ClientFilter,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectByFilter), filter).
+			Return(clientsResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterCreateClientMock(
+	clientID int64,
+	errorResponse error,
+	request NewClientRequest,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.createClient), request).
+			Return(clientID, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterRenameClientMock(
+	renamed bool,
+	errorResponse error,
+	clientID int64,
+	clientName string,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.renameClient), clientID, clientName).
+			Return(renamed, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterDeactivateClientMock(
+	deactivated bool,
+	errorResponse error,
+	clientID int64,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.deactivateClient), clientID).
+			Return(deactivated, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterExpireLinksMock(
+	expired int64,
+	errorResponse error,
+	clientID int64,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.expireLinks), clientID).
+			Return(expired, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterRecordMock(
+	err error,
+	action string,
+	before, after *Client,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+
+		var (
+			clientID                  int64
+			beforeEntity, afterEntity any
+		)
+		if before != nil {
+			clientID, beforeEntity = before.ID, *before
+		}
+		if after != nil {
+			clientID, afterEntity = after.ID, *after
+		}
+		entry, entryErr := audit.NewEntry(testCaller, action, auditEntity, clientID, beforeEntity, afterEntity)
+		if entryErr != nil {
+			return nil, entryErr
+		}
+
+		r.On(util.GetFunctionName(r.record), entry).
+			Return(err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByIDForUpdateMock(
+	clientResponse Client,
+	errorResponse error,
+	clientID int64,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectByIDForUpdate), clientID).
+			Return(clientResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectMemberMock(
+	memberResponse member,
+	errorResponse error,
+	userID int64,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectMember), userID).
+			Return(memberResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterHasOtherMembershipsMock(
+	otherResponse bool,
+	errorResponse error,
+	userID, clientID int64,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.hasOtherMemberships), userID, clientID).
+			Return(otherResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectMembershipByIDMock(
+	membershipResponse Membership,
+	errorResponse error,
+	membershipID int64,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectMembershipByID), membershipID).
+			Return(membershipResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectActiveMembershipMock(
+	membershipResponse Membership,
+	errorResponse error,
+	userID, clientID int64,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectActiveMembership), userID, clientID).
+			Return(membershipResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectClientMembershipsMock(
+	pageResponse MembershipPage,
+	errorResponse error,
+	clientID int64,
+	filter MembershipFilter,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectClientMemberships), clientID, filter).
+			Return(pageResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectUserMembershipsMock(
+	pageResponse MembershipPage,
+	errorResponse error,
+	userID int64,
+	filter MembershipFilter,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectUserMemberships), userID, filter).
+			Return(pageResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterCreateMembershipMock(
+	membershipID int64,
+	errorResponse error,
+	userID, clientID int64,
+	dateExpired *time.Time,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.createMembership), userID, clientID, dateExpired).
+			Return(membershipID, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterExpireMembershipMock(
+	expired bool,
+	errorResponse error,
+	membershipID int64,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.expireMembership), membershipID).
+			Return(expired, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterRecordEntryMock(
+	err error,
+	action string,
+	clientID int64,
+	before, after any,
+) func(cs *clientService) (func(t *testing.T), error) {
+	return func(cs *clientService) (func(t *testing.T), error) {
+		r, ok := cs.clientRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+
+		entry, entryErr := audit.NewEntry(testCaller, action, auditEntity, clientID, before, after)
+		if entryErr != nil {
+			return nil, entryErr
+		}
+
+		r.On(util.GetFunctionName(r.record), entry).
+			Return(err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
@@ -2,9 +2,16 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
 )
 
+// duplicateEntryErrorNumber is the MySQL error raised when an insert or an update violates a unique index.
+const duplicateEntryErrorNumber = 1062
+
 // Next functions are wrapper of sql package's errors, they are used for keeping query
 var (
 	QueryError = func(err error, query string) error {
@@ -35,3 +42,26 @@ var (
 		return fmt.Errorf("it could not rollback it due to: %w", err)
 	}
 )
+
+// DuplicateEntryKey returns the name of the unique index violated by err, which can be wrapped, as ExecError does.
+// It returns false when err is not a MySQL duplicate entry error.
+func DuplicateEntryKey(err error) (string, bool) {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != duplicateEntryErrorNumber {
+		return "", false
+	}
+
+	// message looks like "Duplicate entry 'value' for key 'index'", newer MySQL versions prefix the index with its table
+	const keyPrefix = " for key '"
+	i := strings.LastIndex(mysqlErr.Message, keyPrefix)
+	if i < 0 {
+		return "", true
+	}
+
+	key := strings.TrimSuffix(mysqlErr.Message[i+len(keyPrefix):], "'")
+	if dot := strings.LastIndex(key, "."); dot >= 0 {
+		key = key[dot+1:]
+	}
+
+	return key, true
+}
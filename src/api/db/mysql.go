@@ -28,6 +28,7 @@ func (cfg Config) toMySQLConfig() *mysql.Config {
 	mycfg.DBName = cfg.DBName
 	mycfg.Net = cfg.Net
 	mycfg.Addr = cfg.Host + ":" + cfg.Port
+	mycfg.ParseTime = true
 
 	return mycfg
 }
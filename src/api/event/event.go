@@ -0,0 +1,92 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	TypeRoleExpired         = "role.expired"
+	TypeClientAccessExpired = "client_access.expired"
+)
+
+// Event tells that something happened to an entity, Payload keeps the entity as it was then.
+type Event struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	Entity      string          `json:"entity"`
+	EntityID    int64           `json:"entity_id"`
+	Payload     json.RawMessage `json:"payload"`
+	DateCreated time.Time       `json:"date_created"`
+}
+
+// NewEvent generates an event whose payload is v marshalled as JSON.
+func NewEvent(eventType, entity string, entityID int64, v any) (Event, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return Event{}, fmt.Errorf("event cannot marshal payload: %w", err)
+	}
+
+	return Event{
+		Type:     eventType,
+		Entity:   entity,
+		EntityID: entityID,
+		Payload:  payload,
+	}, nil
+}
+
+// Emitter publishes events to whoever is interested in them. Implementations must be safe for concurrent use.
+type Emitter interface {
+	Emit(Event) error
+}
+
+// Recorder is an in-memory emitter, useful for local development and testing.
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Emit(e Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, e)
+	return nil
+}
+
+// Events returns a copy of every event emitted so far.
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Event(nil), r.events...)
+}
+
+// logEmitter writes every event to w as a JSON line.
+type logEmitter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLogEmitter(w io.Writer) Emitter {
+	return &logEmitter{
+		w: w,
+	}
+}
+
+func (l *logEmitter) Emit(e Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := json.NewEncoder(l.w).Encode(e); err != nil {
+		return fmt.Errorf("it could not write event due to: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package event
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maria/src/api/db"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+type grant struct {
+	ID     int64 `json:"grant_id"`
+	RoleID int64 `json:"role_id"`
+}
+
+func TestNewEvent(t *testing.T) {
+	type test struct {
+		name          string
+		payload       any
+		expectedEvent Event
+		expectedError bool
+	}
+
+	tests := []test{
+		{
+			name:    "happy case",
+			payload: grant{ID: 1, RoleID: 2},
+			expectedEvent: Event{
+				Type:     TypeRoleExpired,
+				Entity:   "user_role",
+				EntityID: 1,
+				Payload:  json.RawMessage(`{"grant_id":1,"role_id":2}`),
+			},
+		},
+		{
+			name:          "payload cannot be marshalled",
+			payload:       make(chan int),
+			expectedEvent: Event{},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := NewEvent(TypeRoleExpired, "user_role", 1, test.payload)
+
+			assert.Equal(t, test.expectedError, err != nil)
+			assert.Equal(t, test.expectedEvent, e)
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	recorder := NewRecorder()
+	e := Event{Type: TypeRoleExpired, Entity: "user_role", EntityID: 1}
+
+	assert.Nil(t, recorder.Emit(e))
+	assert.Nil(t, recorder.Emit(e))
+
+	assert.Equal(t, []Event{e, e}, recorder.Events())
+}
+
+func TestLogEmitter(t *testing.T) {
+	var (
+		b bytes.Buffer
+		e = Event{
+			Type:        TypeClientAccessExpired,
+			Entity:      "user_client",
+			EntityID:    1,
+			Payload:     json.RawMessage(`{}`),
+			DateCreated: time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC),
+		}
+	)
+
+	assert.Nil(t, NewLogEmitter(&b).Emit(e))
+
+	assert.Equal(t,
+		`{"id":0,"type":"client_access.expired","entity":"user_client","entity_id":1,"payload":{},`+
+			`"date_created":"2022-01-01T10:00:00Z"}`+"\n",
+		b.String())
+}
+
+func TestRecord(t *testing.T) {
+	var (
+		customError = errors.New("custom error")
+		e           = Event{
+			Type:     TypeRoleExpired,
+			Entity:   "user_role",
+			EntityID: 1,
+			Payload:  json.RawMessage(`{}`),
+		}
+	)
+
+	type test struct {
+		name          string
+		mockCall      func(m sqlmock.Sqlmock) func() error
+		expectedError error
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCall: db.SetClientExecMock(
+				nil, insertEventQuery, customError, TypeRoleExpired, "user_role", int64(1), "{}"),
+			expectedError: db.ExecError(customError, insertEventQuery),
+		},
+		{
+			name: "happy case",
+			mockCall: db.SetClientExecMock(
+				sqlmock.NewResult(1, 1), insertEventQuery, nil, TypeRoleExpired, "user_role", int64(1), "{}"),
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCall(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			err = NewRelationalDB(client).Record(e)
+
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+}
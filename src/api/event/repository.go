@@ -0,0 +1,32 @@
+package event
+
+import (
+	"maria/src/api/db"
+)
+
+const (
+	insertEventQuery = `INSERT INTO domain_event (type, entity, entity_id, payload) VALUES (?, ?, ?, ?)`
+)
+
+// Persister writes events. It can be built over a transaction, so events are written together with the changes
+// they tell about.
+type Persister interface {
+	Record(Event) error
+}
+
+func NewRelationalDB(client db.Client) Persister {
+	return &relationalDB{
+		client: client,
+	}
+}
+
+type relationalDB struct {
+	client db.Client
+}
+
+func (r *relationalDB) Record(e Event) error {
+	if _, err := r.client.Exec(insertEventQuery, e.Type, e.Entity, e.EntityID, string(e.Payload)); err != nil {
+		return db.ExecError(err, insertEventQuery)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends messages to users. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(Message) error
+}
+
+// Outbox is an in-memory mailer, useful for local development and testing.
+type Outbox struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewOutbox() *Outbox {
+	return &Outbox{}
+}
+
+func (o *Outbox) Send(message Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.messages = append(o.messages, message)
+	return nil
+}
+
+// Messages returns a copy of every message sent so far.
+func (o *Outbox) Messages() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return append([]Message(nil), o.messages...)
+}
+
+// fileMailer appends every message to a file instead of sending it.
+type fileMailer struct {
+	mu   sync.Mutex
+	path string
+	now  func() time.Time
+}
+
+func NewFileMailer(path string) Mailer {
+	return &fileMailer{
+		path: path,
+		now:  time.Now,
+	}
+}
+
+func (f *fileMailer) Send(message Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("it could not open mail file due to: %w", err)
+	}
+
+	_, err = fmt.Fprintf(file, "Date: %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		f.now().Format(time.RFC1123Z), message.To, message.Subject, message.Body)
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("it could not write mail file due to: %w", err)
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,77 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type MailerSuite struct {
+	suite.Suite
+}
+
+func TestMailerSuite(t *testing.T) {
+	suite.Run(t, new(MailerSuite))
+}
+
+func (s *MailerSuite) TestOutbox() {
+	outbox := NewOutbox()
+	message := Message{To: "user@email.com", Subject: "subject", Body: "body"}
+
+	assert.Nil(s.T(), outbox.Send(message))
+	assert.Nil(s.T(), outbox.Send(message))
+
+	assert.Equal(s.T(), []Message{message, message}, outbox.Messages())
+}
+
+func (s *MailerSuite) TestFileMailer() {
+	var (
+		date    = time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+		path    = filepath.Join(s.T().TempDir(), "outbox.txt")
+		message = Message{To: "user@email.com", Subject: "subject", Body: "body"}
+	)
+
+	type test struct {
+		name          string
+		path          string
+		expectedError bool
+		expectedFile  string
+	}
+
+	tests := []test{
+		{
+			name:          "directory does not exist",
+			path:          filepath.Join(s.T().TempDir(), "missing", "outbox.txt"),
+			expectedError: true,
+		},
+		{
+			name: "happy case",
+			path: path,
+			expectedFile: "" +
+				"Date: Sat, 01 Jan 2022 10:00:00 +0000\nTo: user@email.com\nSubject: subject\n\nbody\n\n" +
+				"Date: Sat, 01 Jan 2022 10:00:00 +0000\nTo: user@email.com\nSubject: subject\n\nbody\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			mailer := &fileMailer{path: test.path, now: func() time.Time { return date }}
+
+			if test.expectedError {
+				assert.Error(t, mailer.Send(message))
+				return
+			}
+
+			assert.Nil(t, mailer.Send(message))
+			assert.Nil(t, mailer.Send(message))
+
+			b, err := os.ReadFile(test.path)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedFile, string(b))
+		})
+	}
+}
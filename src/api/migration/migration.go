@@ -0,0 +1,120 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migrations are kept in migrations folder, every version has an up and a down script named
+// <version>_<name>.up.sql and <version>_<name>.down.sql. Versions are applied in ascending order.
+//
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// load reads every migration kept in dir of fsys sorted by version.
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	files, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrations cannot be read due to: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, file := range files {
+		matches := migrationFileName.FindStringSubmatch(file.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("%w: %s", invalidMigrationError, file.Name())
+		}
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%w: %s", invalidMigrationError, file.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("migration %s cannot be read due to: %w", file.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		}
+		if m.Name != matches[2] {
+			return nil, fmt.Errorf("%w: version %d has two names", invalidMigrationError, version)
+		}
+
+		if matches[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("%w: version %d needs both up and down scripts", invalidMigrationError, m.Version)
+		}
+		m.Checksum = checksum(m.Up)
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func checksum(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitStatements splits a script into its statements. A statement ends in the line finishing with a semicolon and
+// lines starting with "--" are comments.
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		current    []string
+	)
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current = append(current, strings.TrimRight(line, " \t\r"))
+		if strings.HasSuffix(trimmed, ";") {
+			statement := strings.TrimSuffix(strings.Join(current, "\n"), ";")
+			statements = append(statements, strings.TrimSpace(statement))
+			current = nil
+		}
+	}
+
+	if len(current) > 0 {
+		statements = append(statements, strings.TrimSpace(strings.Join(current, "\n")))
+	}
+
+	return statements
+}
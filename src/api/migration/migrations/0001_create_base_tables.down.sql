@@ -0,0 +1,7 @@
+drop table user_task;
+drop table user_client;
+drop table user_role;
+drop table client;
+drop table task;
+drop table role;
+drop table user;
@@ -1,4 +1,5 @@
-create table user
+-- base schema as it was first created by hand, existing databases keep their tables
+create table if not exists user
 (
     id           int                                  not null,
     user_name    varchar(100)                         not null,
@@ -10,11 +11,11 @@ create table user
         primary key (id)
 );
 
-create table role
+create table if not exists role
 (
     id           int                                  not null,
     role_name    varchar(100)                         not null,
-    type        varchar(100)                          not null,
+    type         varchar(100)                         not null,
     active       tinyint(1)                           not null,
     date_created datetime default current_timestamp() not null,
 
@@ -22,11 +23,11 @@ create table role
         primary key (id)
 );
 
-create table task
+create table if not exists task
 (
     id           int                                  not null,
     task_name    varchar(100)                         not null,
-    type        varchar(100)                          not null,
+    type         varchar(100)                         not null,
     active       tinyint(1)                           not null,
     date_created datetime default current_timestamp() not null,
 
@@ -34,10 +35,10 @@ create table task
         primary key (id)
 );
 
-create table client
+create table if not exists client
 (
     id           int                                  not null,
-    client_name    varchar(100)                       not null,
+    client_name  varchar(100)                         not null,
     active       tinyint(1)                           not null,
     date_created datetime default current_timestamp() not null,
 
@@ -45,12 +46,12 @@ create table client
         primary key (id)
 );
 
-create table user_role
+create table if not exists user_role
 (
     id           int                                  not null,
-    user_id    int                       not null,
-    role_id       int                           not null,
-    date_expired datetime  null,
+    user_id      int                                  not null,
+    role_id      int                                  not null,
+    date_expired datetime                             null,
     date_created datetime default current_timestamp() not null,
 
     constraint user_role_pk
@@ -61,12 +62,12 @@ create table user_role
         foreign key (role_id) references role (id)
 );
 
-create table user_client
+create table if not exists user_client
 (
     id           int                                  not null,
-    user_id    int                       not null,
-    client_id       int                           not null,
-    date_expired datetime  null,
+    user_id      int                                  not null,
+    client_id    int                                  not null,
+    date_expired datetime                             null,
     date_created datetime default current_timestamp() not null,
 
     constraint user_client_pk
@@ -77,13 +78,13 @@ create table user_client
         foreign key (client_id) references client (id)
 );
 
-create table user_task
+create table if not exists user_task
 (
     id           int                                  not null,
-    user_id    int                       not null,
-    task_id    int                       not null,
-    client_id       int                           not null,
-    status       varchar(100)                           not null,
+    user_id      int                                  not null,
+    task_id      int                                  not null,
+    client_id    int                                  not null,
+    status       varchar(100)                         not null,
     date_created datetime default current_timestamp() not null,
 
     constraint user_task_pk
@@ -94,4 +95,4 @@ create table user_task
         foreign key (task_id) references task (id),
     constraint user_task_client_id_fk
         foreign key (client_id) references client (id)
-);
\ No newline at end of file
+);
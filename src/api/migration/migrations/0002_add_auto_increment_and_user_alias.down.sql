@@ -0,0 +1,11 @@
+alter table user drop column alias;
+
+set foreign_key_checks = 0;
+alter table user_task modify id int not null;
+alter table user_client modify id int not null;
+alter table user_role modify id int not null;
+alter table client modify id int not null;
+alter table task modify id int not null;
+alter table role modify id int not null;
+alter table user modify id int not null;
+set foreign_key_checks = 1;
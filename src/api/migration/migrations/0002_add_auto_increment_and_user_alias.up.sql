@@ -0,0 +1,14 @@
+-- referenced ids cannot be modified while foreign keys are checked, the migrator runs every statement in one session
+set foreign_key_checks = 0;
+alter table user modify id int not null auto_increment;
+alter table role modify id int not null auto_increment;
+alter table task modify id int not null auto_increment;
+alter table client modify id int not null auto_increment;
+alter table user_role modify id int not null auto_increment;
+alter table user_client modify id int not null auto_increment;
+alter table user_task modify id int not null auto_increment;
+set foreign_key_checks = 1;
+
+alter table user add column alias varchar(100) null after user_name;
+update user set alias = user_name where alias is null;
+alter table user modify alias varchar(100) not null;
@@ -0,0 +1 @@
+alter table user add column version int default 1 not null;
@@ -0,0 +1,13 @@
+create table user_verification_token
+(
+    token        varchar(64)                          not null,
+    user_id      int                                  not null,
+    date_expired datetime                             not null,
+    date_used    datetime                             null,
+    date_created datetime default current_timestamp() not null,
+
+    constraint user_verification_token_pk
+        primary key (token),
+    constraint user_verification_token_user_id_fk
+        foreign key (user_id) references user (id)
+);
@@ -0,0 +1,16 @@
+create table audit_log
+(
+    id           bigint                               not null auto_increment,
+    actor        varchar(100)                         not null,
+    action       varchar(50)                          not null,
+    entity       varchar(50)                          not null,
+    entity_id    int                                  not null,
+    changes      json                                 not null,
+    date_created datetime default current_timestamp() not null,
+
+    constraint audit_log_pk
+        primary key (id)
+);
+
+create index audit_log_entity_index
+    on audit_log (entity, entity_id, id);
@@ -0,0 +1,3 @@
+drop index user_email_uindex on user;
+drop index user_alias_uindex on user;
+drop index user_user_name_uindex on user;
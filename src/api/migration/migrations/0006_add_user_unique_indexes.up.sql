@@ -0,0 +1,8 @@
+create unique index user_user_name_uindex
+    on user (user_name);
+
+create unique index user_alias_uindex
+    on user (alias);
+
+create unique index user_email_uindex
+    on user (email);
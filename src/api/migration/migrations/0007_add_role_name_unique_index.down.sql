@@ -0,0 +1 @@
+drop index role_type_role_name_uindex on role;
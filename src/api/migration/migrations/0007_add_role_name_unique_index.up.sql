@@ -0,0 +1,2 @@
+create unique index role_type_role_name_uindex
+    on role (type, role_name);
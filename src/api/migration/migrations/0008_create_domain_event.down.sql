@@ -0,0 +1,3 @@
+drop index user_client_date_expired_index on user_client;
+drop index user_role_date_expired_index on user_role;
+drop table domain_event;
@@ -0,0 +1,21 @@
+create table domain_event
+(
+    id           bigint                               not null auto_increment,
+    type         varchar(50)                          not null,
+    entity       varchar(50)                          not null,
+    entity_id    int                                  not null,
+    payload      json                                 not null,
+    date_created datetime default current_timestamp() not null,
+
+    constraint domain_event_pk
+        primary key (id)
+);
+
+create unique index domain_event_type_entity_uindex
+    on domain_event (type, entity, entity_id);
+
+create index user_role_date_expired_index
+    on user_role (date_expired);
+
+create index user_client_date_expired_index
+    on user_client (date_expired);
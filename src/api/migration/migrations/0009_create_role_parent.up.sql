@@ -0,0 +1,13 @@
+create table role_parent
+(
+    role_id      int                                  not null,
+    parent_id    int                                  not null,
+    date_created datetime default current_timestamp() not null,
+
+    constraint role_parent_pk
+        primary key (role_id, parent_id),
+    constraint role_parent_role_id_fk
+        foreign key (role_id) references role (id),
+    constraint role_parent_parent_id_fk
+        foreign key (parent_id) references role (id)
+);
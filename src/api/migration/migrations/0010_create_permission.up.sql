@@ -0,0 +1,34 @@
+create table permission
+(
+    id           int                                  not null auto_increment,
+    name         varchar(100)                         not null,
+    description  varchar(255)                         not null,
+    date_created datetime default current_timestamp() not null,
+
+    constraint permission_pk
+        primary key (id)
+);
+
+create unique index permission_name_uindex
+    on permission (name);
+
+create table role_permission
+(
+    role_id       int                                  not null,
+    permission_id int                                  not null,
+    date_created  datetime default current_timestamp() not null,
+
+    constraint role_permission_pk
+        primary key (role_id, permission_id),
+    constraint role_permission_role_id_fk
+        foreign key (role_id) references role (id),
+    constraint role_permission_permission_id_fk
+        foreign key (permission_id) references permission (id)
+);
+
+insert into permission (name, description)
+values ('user:read', 'Read users, their history and their roles'),
+       ('user:write', 'Modify and delete users, grant and revoke their roles'),
+       ('role:read', 'Read roles, their parents and their permissions'),
+       ('role:write', 'Modify roles, their parents and their permissions'),
+       ('task:assign', 'Assign tasks to users');
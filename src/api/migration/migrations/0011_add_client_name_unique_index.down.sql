@@ -0,0 +1 @@
+drop index client_client_name_uindex on client;
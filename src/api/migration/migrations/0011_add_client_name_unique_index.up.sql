@@ -0,0 +1,2 @@
+create unique index client_client_name_uindex
+    on client (client_name);
@@ -0,0 +1,3 @@
+delete rp from role_permission rp join permission p on p.id = rp.permission_id
+where p.name in ('client:read', 'client:write');
+delete from permission where name in ('client:read', 'client:write');
@@ -0,0 +1,3 @@
+insert into permission (name, description)
+values ('client:read', 'Read clients'),
+       ('client:write', 'Create, rename and deactivate clients');
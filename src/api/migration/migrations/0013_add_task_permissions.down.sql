@@ -0,0 +1,3 @@
+delete rp from role_permission rp join permission p on p.id = rp.permission_id
+where p.name in ('task:read', 'task:write');
+delete from permission where name in ('task:read', 'task:write');
@@ -0,0 +1,3 @@
+insert into permission (name, description)
+values ('task:read', 'Read tasks'),
+       ('task:write', 'Create, modify and deactivate tasks');
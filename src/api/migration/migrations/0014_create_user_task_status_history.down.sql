@@ -0,0 +1 @@
+drop table user_task_status_history;
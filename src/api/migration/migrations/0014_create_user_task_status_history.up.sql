@@ -0,0 +1,23 @@
+create table user_task_status_history
+(
+    id           bigint                               not null auto_increment,
+    user_task_id int                                  not null,
+    actor        varchar(100)                         not null,
+    from_status  varchar(100)                         null,
+    to_status    varchar(100)                         not null,
+    comment      varchar(500)                         null,
+    date_created datetime default current_timestamp() not null,
+
+    constraint user_task_status_history_pk
+        primary key (id),
+    constraint user_task_status_history_user_task_id_fk
+        foreign key (user_task_id) references user_task (id)
+);
+
+create index user_task_status_history_user_task_index
+    on user_task_status_history (user_task_id, id);
+
+-- tasks assigned before the history existed keep their current status as set when they were assigned
+insert into user_task_status_history (user_task_id, actor, from_status, to_status, date_created)
+select id, 'system', null, status, date_created
+from user_task;
@@ -0,0 +1,4 @@
+create unique index domain_event_type_entity_uindex
+    on domain_event (type, entity, entity_id);
+
+drop index domain_event_type_entity_index on domain_event;
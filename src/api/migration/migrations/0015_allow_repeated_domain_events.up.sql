@@ -0,0 +1,5 @@
+-- an entity can have several events of a type, as a grant which expires again after being extended
+create index domain_event_type_entity_index
+    on domain_event (type, entity, entity_id, date_created);
+
+drop index domain_event_type_entity_uindex on domain_event;
@@ -0,0 +1,290 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maria/src/api/db"
+)
+
+const (
+	// ModeApply applies pending migrations at startup.
+	ModeApply = "apply"
+	// ModeCheck refuses to start when there are pending migrations, they must be applied by someone else.
+	ModeCheck = "check"
+
+	migrationsDir      = "migrations"
+	lockName           = "maria_schema_migrations"
+	lockTimeoutSeconds = 60
+
+	createMigrationsTableQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL, ` +
+		`name varchar(255) NOT NULL, checksum char(64) NOT NULL, dirty tinyint(1) NOT NULL, ` +
+		`date_applied datetime DEFAULT current_timestamp() NOT NULL, PRIMARY KEY (version))`
+	getAppliedMigrationsQuery = `SELECT version, name, checksum, dirty FROM schema_migrations ORDER BY version`
+	insertMigrationQuery      = `INSERT INTO schema_migrations (version, name, checksum, dirty) VALUES (?, ?, ?, true)`
+	cleanMigrationQuery       = `UPDATE schema_migrations SET dirty = false WHERE version = ?`
+	dirtyMigrationQuery       = `UPDATE schema_migrations SET dirty = true WHERE version = ?`
+	deleteMigrationQuery      = `DELETE FROM schema_migrations WHERE version = ?`
+	getLockQuery              = `SELECT GET_LOCK(?, ?)`
+	releaseLockQuery          = `SELECT RELEASE_LOCK(?)`
+)
+
+var (
+	invalidMigrationError = errors.New("invalid migration")
+	invalidModeError      = errors.New("invalid migration mode")
+	driftError            = errors.New("database schema does not match migrations")
+	dirtyMigrationError   = errors.New("migration failed halfway and it must be fixed by hand")
+	lockNotAcquiredError  = errors.New("migrations lock could not be acquired")
+)
+
+type Migrator interface {
+	Run(mode string) error
+	Apply() error
+	Check() error
+	Down(steps int) error
+}
+
+func NewMigrator(client db.Client) Migrator {
+	return &migrator{
+		client:      client,
+		fsys:        embeddedMigrations,
+		dir:         migrationsDir,
+		lockTimeout: lockTimeoutSeconds,
+	}
+}
+
+type migrator struct {
+	client      db.Client
+	fsys        fs.FS
+	dir         string
+	lockTimeout int
+}
+
+type appliedMigration struct {
+	Version  int64
+	Name     string
+	Checksum string
+	Dirty    bool
+}
+
+func (m *migrator) Run(mode string) error {
+	switch mode {
+	case ModeApply:
+		return m.Apply()
+	case ModeCheck:
+		return m.Check()
+	default:
+		return fmt.Errorf("%w: %q", invalidModeError, mode)
+	}
+}
+
+// Apply runs every pending migration in version order. Each one is recorded as dirty before running it and as clean
+// after it, since MySQL cannot roll back schema changes a failure leaves it dirty and blocks later starts.
+func (m *migrator) Apply() error {
+	return m.withLock(func(ctx context.Context, conn *sql.Conn, migrations []Migration, applied []appliedMigration) error {
+		for _, migration := range pending(migrations, applied) {
+			if _, err := conn.ExecContext(
+				ctx,
+				insertMigrationQuery,
+				migration.Version,
+				migration.Name,
+				migration.Checksum,
+			); err != nil {
+				return db.ExecError(err, insertMigrationQuery)
+			}
+
+			if err := runScript(ctx, conn, migration, migration.Up); err != nil {
+				return err
+			}
+
+			if _, err := conn.ExecContext(ctx, cleanMigrationQuery, migration.Version); err != nil {
+				return db.ExecError(err, cleanMigrationQuery)
+			}
+
+			fmt.Printf("migration %d_%s applied\n", migration.Version, migration.Name)
+		}
+		return nil
+	})
+}
+
+// Check returns a driftError when there are pending migrations or the applied ones do not match the known ones.
+func (m *migrator) Check() error {
+	return m.withLock(func(ctx context.Context, conn *sql.Conn, migrations []Migration, applied []appliedMigration) error {
+		if pendings := pending(migrations, applied); len(pendings) > 0 {
+			return fmt.Errorf(
+				"%w: there are %d pending migrations starting by %d_%s",
+				driftError,
+				len(pendings),
+				pendings[0].Version,
+				pendings[0].Name)
+		}
+		return nil
+	})
+}
+
+// Down reverts the last steps applied migrations, from the newest to the oldest one.
+func (m *migrator) Down(steps int) error {
+	return m.withLock(func(ctx context.Context, conn *sql.Conn, migrations []Migration, applied []appliedMigration) error {
+		byVersion := map[int64]Migration{}
+		for _, migration := range migrations {
+			byVersion[migration.Version] = migration
+		}
+
+		for i := len(applied) - 1; i >= 0 && i >= len(applied)-steps; i-- {
+			migration := byVersion[applied[i].Version]
+
+			if _, err := conn.ExecContext(ctx, dirtyMigrationQuery, migration.Version); err != nil {
+				return db.ExecError(err, dirtyMigrationQuery)
+			}
+
+			if err := runScript(ctx, conn, migration, migration.Down); err != nil {
+				return err
+			}
+
+			if _, err := conn.ExecContext(ctx, deleteMigrationQuery, migration.Version); err != nil {
+				return db.ExecError(err, deleteMigrationQuery)
+			}
+
+			fmt.Printf("migration %d_%s reverted\n", migration.Version, migration.Name)
+		}
+		return nil
+	})
+}
+
+// withLock runs fn in a single connection holding a named lock, so only one of the instances starting at the same
+// time migrates the schema and the others wait for it. Applied migrations are verified before calling fn.
+func (m *migrator) withLock(
+	fn func(ctx context.Context, conn *sql.Conn, migrations []Migration, applied []appliedMigration) error,
+) error {
+	migrations, err := load(m.fsys, m.dir)
+	if err != nil {
+		return err
+	}
+
+	client, ok := m.client.(*sql.DB)
+	if !ok {
+		return errors.New("migrator cannot get a connection from client")
+	}
+
+	ctx := context.Background()
+	conn, err := client.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migrator cannot get a connection due to: %w", err)
+	}
+
+	defer func() {
+		if err := conn.Close(); err != nil {
+			println(fmt.Sprintf("error closing migrations connection cause: %s", err.Error()))
+		}
+	}()
+
+	var acquired sql.NullInt64
+	if err = conn.QueryRowContext(ctx, getLockQuery, lockName, m.lockTimeout).Scan(&acquired); err != nil {
+		return db.QueryError(err, getLockQuery)
+	}
+	if acquired.Int64 != 1 {
+		return lockNotAcquiredError
+	}
+
+	defer func() {
+		var released sql.NullInt64
+		if err := conn.QueryRowContext(ctx, releaseLockQuery, lockName).Scan(&released); err != nil {
+			println(fmt.Sprintf("error releasing migrations lock cause: %s", err.Error()))
+		}
+	}()
+
+	if _, err = conn.ExecContext(ctx, createMigrationsTableQuery); err != nil {
+		return db.ExecError(err, createMigrationsTableQuery)
+	}
+
+	applied, err := selectApplied(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if err = verify(migrations, applied); err != nil {
+		return err
+	}
+
+	return fn(ctx, conn, migrations, applied)
+}
+
+func selectApplied(ctx context.Context, conn *sql.Conn) ([]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, getAppliedMigrationsQuery)
+	if err != nil {
+		return nil, db.QueryError(err, getAppliedMigrationsQuery)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	var applied []appliedMigration
+	for rows.Next() {
+		var a appliedMigration
+		if err = rows.Scan(&a.Version, &a.Name, &a.Checksum, &a.Dirty); err != nil {
+			return nil, db.ScanError(err, getAppliedMigrationsQuery)
+		}
+		applied = append(applied, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, getAppliedMigrationsQuery)
+	}
+
+	return applied, nil
+}
+
+// verify returns an error when an applied migration is dirty, unknown or it was modified after being applied.
+func verify(migrations []Migration, applied []appliedMigration) error {
+	checksums := map[int64]string{}
+	for _, migration := range migrations {
+		checksums[migration.Version] = migration.Checksum
+	}
+
+	for _, a := range applied {
+		if a.Dirty {
+			return fmt.Errorf("%w: %d_%s", dirtyMigrationError, a.Version, a.Name)
+		}
+
+		checksum, ok := checksums[a.Version]
+		if !ok {
+			return fmt.Errorf("%w: migration %d_%s is applied but it is unknown", driftError, a.Version, a.Name)
+		}
+		if checksum != a.Checksum {
+			return fmt.Errorf("%w: migration %d_%s was modified after being applied", driftError, a.Version, a.Name)
+		}
+	}
+
+	return nil
+}
+
+func pending(migrations []Migration, applied []appliedMigration) []Migration {
+	isApplied := map[int64]bool{}
+	for _, a := range applied {
+		isApplied[a.Version] = true
+	}
+
+	var result []Migration
+	for _, migration := range migrations {
+		if !isApplied[migration.Version] {
+			result = append(result, migration)
+		}
+	}
+	return result
+}
+
+// runScript executes the statements of script one by one in conn, so session settings changed by the script apply
+// to its next statements.
+func runScript(ctx context.Context, conn *sql.Conn, migration Migration, script string) error {
+	for _, statement := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, db.ExecError(err, statement))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package migration
+
+import (
+	"errors"
+	"maria/src/api/db"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	firstUp    = "create table first\n(\n    id int not null\n);\n"
+	firstDown  = "drop table first;\n"
+	secondUp   = "-- two statements\nset foreign_key_checks = 0;\nalter table first add column name varchar(10);\n"
+	secondDown = "alter table first drop column name;\n"
+)
+
+var testMigrations = fstest.MapFS{
+	"migrations/0001_create_first.up.sql":     {Data: []byte(firstUp)},
+	"migrations/0001_create_first.down.sql":   {Data: []byte(firstDown)},
+	"migrations/0002_add_first_name.up.sql":   {Data: []byte(secondUp)},
+	"migrations/0002_add_first_name.down.sql": {Data: []byte(secondDown)},
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := load(embeddedMigrations, migrationsDir)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "migration versions must be consecutive")
+		assert.NotEmpty(t, splitStatements(migration.Up))
+		assert.NotEmpty(t, splitStatements(migration.Down))
+	}
+}
+
+func TestLoad(t *testing.T) {
+	type test struct {
+		name          string
+		fsys          fstest.MapFS
+		expectedError error
+		expected      []Migration
+	}
+
+	tests := []test{
+		{
+			name:          "invalid file name",
+			fsys:          fstest.MapFS{"migrations/first.sql": {Data: []byte(firstUp)}},
+			expectedError: invalidMigrationError,
+		},
+		{
+			name:          "down script missed",
+			fsys:          fstest.MapFS{"migrations/0001_create_first.up.sql": {Data: []byte(firstUp)}},
+			expectedError: invalidMigrationError,
+		},
+		{
+			name: "version with two names",
+			fsys: fstest.MapFS{
+				"migrations/0001_create_first.up.sql":   {Data: []byte(firstUp)},
+				"migrations/0001_create_other.down.sql": {Data: []byte(firstDown)},
+			},
+			expectedError: invalidMigrationError,
+		},
+		{
+			name: "happy case",
+			fsys: testMigrations,
+			expected: []Migration{
+				{Version: 1, Name: "create_first", Up: firstUp, Down: firstDown, Checksum: checksum(firstUp)},
+				{Version: 2, Name: "add_first_name", Up: secondUp, Down: secondDown, Checksum: checksum(secondUp)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			migrations, err := load(test.fsys, migrationsDir)
+
+			assert.ErrorIs(t, err, test.expectedError)
+			assert.Equal(t, test.expected, migrations)
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	statements := splitStatements(secondUp + "\nupdate first set name = 'a'")
+
+	assert.Equal(t, []string{
+		"set foreign_key_checks = 0",
+		"alter table first add column name varchar(10)",
+		"update first set name = 'a'",
+	}, statements)
+}
+
+func TestApply(t *testing.T) {
+	customError := errors.New("custom error")
+
+	type test struct {
+		name          string
+		mockCalls     func(m sqlmock.Sqlmock)
+		expectedError error
+	}
+
+	tests := []test{
+		{
+			name: "lock not acquired",
+			mockCalls: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(getLockQuery).WithArgs(lockName, 5).
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(0))
+			},
+			expectedError: lockNotAcquiredError,
+		},
+		{
+			name: "applied migration is dirty",
+			mockCalls: func(m sqlmock.Sqlmock) {
+				expectLock(m)
+				expectApplied(m, sqlmock.NewRows(appliedColumns).AddRow(1, "create_first", checksum(firstUp), true))
+				expectRelease(m)
+			},
+			expectedError: dirtyMigrationError,
+		},
+		{
+			name: "applied migration was modified",
+			mockCalls: func(m sqlmock.Sqlmock) {
+				expectLock(m)
+				expectApplied(m, sqlmock.NewRows(appliedColumns).AddRow(1, "create_first", "other", false))
+				expectRelease(m)
+			},
+			expectedError: driftError,
+		},
+		{
+			name: "applied migration is unknown",
+			mockCalls: func(m sqlmock.Sqlmock) {
+				expectLock(m)
+				expectApplied(m, sqlmock.NewRows(appliedColumns).
+					AddRow(1, "create_first", checksum(firstUp), false).
+					AddRow(3, "unknown", "other", false))
+				expectRelease(m)
+			},
+			expectedError: driftError,
+		},
+		{
+			name: "migration fails",
+			mockCalls: func(m sqlmock.Sqlmock) {
+				expectLock(m)
+				expectApplied(m, sqlmock.NewRows(appliedColumns).AddRow(1, "create_first", checksum(firstUp), false))
+				m.ExpectExec(insertMigrationQuery).WithArgs(2, "add_first_name", checksum(secondUp)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec("set foreign_key_checks = 0").WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectExec("alter table first add column name varchar(10)").WillReturnError(customError)
+				expectRelease(m)
+			},
+			expectedError: customError,
+		},
+		{
+			name: "pending migrations applied",
+			mockCalls: func(m sqlmock.Sqlmock) {
+				expectLock(m)
+				expectApplied(m, sqlmock.NewRows(appliedColumns))
+				m.ExpectExec(insertMigrationQuery).WithArgs(1, "create_first", checksum(firstUp)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec("create table first\n(\n    id int not null\n)").WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectExec(cleanMigrationQuery).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec(insertMigrationQuery).WithArgs(2, "add_first_name", checksum(secondUp)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				m.ExpectExec("set foreign_key_checks = 0").WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectExec("alter table first add column name varchar(10)").WillReturnResult(sqlmock.NewResult(0, 0))
+				m.ExpectExec(cleanMigrationQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRelease(m)
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			migrator, mock, err := newMigratorForTest()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+			test.mockCalls(mock)
+
+			err = migrator.Run(ModeApply)
+
+			assert.ErrorIs(t, err, test.expectedError)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	type test struct {
+		name          string
+		mockCalls     func(m sqlmock.Sqlmock)
+		expectedError error
+	}
+
+	tests := []test{
+		{
+			name: "there are pending migrations",
+			mockCalls: func(m sqlmock.Sqlmock) {
+				expectLock(m)
+				expectApplied(m, sqlmock.NewRows(appliedColumns).AddRow(1, "create_first", checksum(firstUp), false))
+				expectRelease(m)
+			},
+			expectedError: driftError,
+		},
+		{
+			name: "schema is up to date",
+			mockCalls: func(m sqlmock.Sqlmock) {
+				expectLock(m)
+				expectApplied(m, sqlmock.NewRows(appliedColumns).
+					AddRow(1, "create_first", checksum(firstUp), false).
+					AddRow(2, "add_first_name", checksum(secondUp), false))
+				expectRelease(m)
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			migrator, mock, err := newMigratorForTest()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+			test.mockCalls(mock)
+
+			err = migrator.Run(ModeCheck)
+
+			assert.ErrorIs(t, err, test.expectedError)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDown(t *testing.T) {
+	migrator, mock, err := newMigratorForTest()
+	if err != nil {
+		assert.Fail(t, err.Error())
+		return
+	}
+
+	expectLock(mock)
+	expectApplied(mock, sqlmock.NewRows(appliedColumns).
+		AddRow(1, "create_first", checksum(firstUp), false).
+		AddRow(2, "add_first_name", checksum(secondUp), false))
+	mock.ExpectExec(dirtyMigrationQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("alter table first drop column name").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(deleteMigrationQuery).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRelease(mock)
+
+	err = migrator.Down(1)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunInvalidMode(t *testing.T) {
+	err := NewMigrator(nil).Run("skip")
+
+	assert.ErrorIs(t, err, invalidModeError)
+}
+
+var appliedColumns = []string{"version", "name", "checksum", "dirty"}
+
+func newMigratorForTest() (Migrator, sqlmock.Sqlmock, error) {
+	client, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &migrator{
+		client:      db.Client(client),
+		fsys:        testMigrations,
+		dir:         migrationsDir,
+		lockTimeout: 5,
+	}, mock, nil
+}
+
+func expectLock(m sqlmock.Sqlmock) {
+	m.ExpectQuery(getLockQuery).WithArgs(lockName, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+	m.ExpectExec(createMigrationsTableQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectApplied(m sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	m.ExpectQuery(getAppliedMigrationsQuery).WillReturnRows(rows)
+}
+
+func expectRelease(m sqlmock.Sqlmock) {
+	m.ExpectQuery(releaseLockQuery).WithArgs(lockName).
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+}
@@ -0,0 +1,415 @@
+package role
+
+import (
+	"errors"
+	"maria/src/api/auth"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	roleIDMissedError     = newBadRequestResponse("role_id param is missed")
+	parentIDMissedError   = newBadRequestResponse("parent_id param is missed")
+	permissionMissedError = newBadRequestResponse("permission param is missed")
+)
+
+type Controller struct {
+	service       Service
+	integerParser func(s string, base int, bitSize int) (i int64, err error)
+}
+
+func NewController(service Service) Controller {
+	return Controller{
+		service:       service,
+		integerParser: strconv.ParseInt,
+	}
+}
+
+func (c Controller) GetByID(ctx *gin.Context) {
+	roleID, ok := c.parseRoleID(ctx)
+	if !ok {
+		return
+	}
+
+	role, err := c.service.getByID(roleID)
+	if err != nil {
+		if errors.Is(err, roleNotFoundError) {
+			ctx.JSON(http.StatusNotFound, newNotFoundError("role_id", roleID))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, role)
+}
+
+func (c Controller) Post(ctx *gin.Context) {
+	var request NewRoleRequest
+
+	if err := ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	role, err := c.service.createRole(auth.Context(ctx), request)
+	if err != nil {
+		if errors.Is(err, roleWithSameNameError) {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, role)
+}
+
+func (c Controller) List(ctx *gin.Context) {
+	filter := RoleFilter{Type: ctx.Query("type")}
+
+	if value, ok := ctx.GetQuery("active"); ok {
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse("active must be a boolean"))
+			return
+		}
+		filter.Active = &active
+	}
+
+	roles, err := c.service.listRoles(filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, roles)
+}
+
+func (c Controller) Put(ctx *gin.Context) {
+	var request ModifyRoleRequest
+
+	roleID, ok := c.parseRoleID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	if request.isEmpty() {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse("request does not specify a change to be applied"))
+		return
+	}
+
+	if err := request.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	role, err := c.service.modifyRole(auth.Context(ctx), request, roleID)
+	respondModified(ctx, roleID, role, err)
+}
+
+// Delete deactivates the role. Roles are never removed because grants keep referencing them.
+func (c Controller) Delete(ctx *gin.Context) {
+	roleID, ok := c.parseRoleID(ctx)
+	if !ok {
+		return
+	}
+
+	role, err := c.service.deactivateRole(auth.Context(ctx), roleID)
+	respondModified(ctx, roleID, role, err)
+}
+
+func (c Controller) GetParents(ctx *gin.Context) {
+	roleID, ok := c.parseRoleID(ctx)
+	if !ok {
+		return
+	}
+
+	parents, err := c.service.listParents(roleID)
+	if err != nil {
+		respondHierarchyError(ctx, roleID, 0, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, parents)
+}
+
+func (c Controller) PostParent(ctx *gin.Context) {
+	var request AddParentRequest
+
+	roleID, ok := c.parseRoleID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	parents, err := c.service.addParent(auth.Context(ctx), roleID, request)
+	if err != nil {
+		respondHierarchyError(ctx, roleID, request.ParentID, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, parents)
+}
+
+func (c Controller) DeleteParent(ctx *gin.Context) {
+	roleID, ok := c.parseRoleID(ctx)
+	if !ok {
+		return
+	}
+
+	parentID, ok := c.parseID(ctx, "parent_id", parentIDMissedError)
+	if !ok {
+		return
+	}
+
+	if err := c.service.removeParent(auth.Context(ctx), roleID, parentID); err != nil {
+		respondHierarchyError(ctx, roleID, parentID, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c Controller) ListPermissions(ctx *gin.Context) {
+	permissions, err := c.service.listPermissions()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, permissions)
+}
+
+func (c Controller) PostPermission(ctx *gin.Context) {
+	var request NewPermissionRequest
+
+	if err := ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	if err := request.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	permission, err := c.service.createPermission(auth.Context(ctx), request)
+	if err != nil {
+		if errors.Is(err, permissionWithSameNameError) {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, permission)
+}
+
+func (c Controller) GetRolePermissions(ctx *gin.Context) {
+	roleID, ok := c.parseRoleID(ctx)
+	if !ok {
+		return
+	}
+
+	permissions, err := c.service.listRolePermissions(roleID)
+	if err != nil {
+		respondPermissionError(ctx, roleID, "", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, permissions)
+}
+
+func (c Controller) PostRolePermission(ctx *gin.Context) {
+	var request AddPermissionRequest
+
+	roleID, ok := c.parseRoleID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	permissions, err := c.service.addRolePermission(auth.Context(ctx), roleID, request)
+	if err != nil {
+		respondPermissionError(ctx, roleID, request.Permission, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, permissions)
+}
+
+func (c Controller) DeleteRolePermission(ctx *gin.Context) {
+	roleID, ok := c.parseRoleID(ctx)
+	if !ok {
+		return
+	}
+
+	permission := ctx.Param("permission")
+	if permission == "" {
+		ctx.JSON(http.StatusBadRequest, permissionMissedError)
+		return
+	}
+
+	if err := c.service.removeRolePermission(auth.Context(ctx), roleID, permission); err != nil {
+		respondPermissionError(ctx, roleID, permission, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// respondPermissionError writes the response of a service call which failed reading or changing the permissions of
+// roleID.
+func respondPermissionError(ctx *gin.Context, roleID int64, permission string, err error) {
+	switch {
+	case errors.Is(err, roleNotFoundError):
+		ctx.JSON(http.StatusNotFound, newNotFoundError("role_id", roleID))
+	case errors.Is(err, permissionNotFoundError), errors.Is(err, rolePermissionNotFoundError):
+		ctx.JSON(http.StatusNotFound, newNotFoundError("permission", permission))
+	case errors.Is(err, permissionAlreadyAddedError):
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+	default:
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+	}
+}
+
+// respondHierarchyError writes the response of a service call which failed reading or changing the parents of roleID.
+func respondHierarchyError(ctx *gin.Context, roleID, parentID int64, err error) {
+	switch {
+	case errors.Is(err, roleNotFoundError):
+		ctx.JSON(http.StatusNotFound, newNotFoundError("role_id", roleID))
+	case errors.Is(err, parentNotFoundError), errors.Is(err, parentLinkNotFoundError):
+		ctx.JSON(http.StatusNotFound, newNotFoundError("parent_id", parentID))
+	case errors.Is(err, selfParentError), errors.Is(err, hierarchyCycleError), errors.Is(err, parentAlreadyAddedError):
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+	default:
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+	}
+}
+
+// respondModified writes the response of a service call which modified the role.
+func respondModified(ctx *gin.Context, roleID int64, role Role, err error) {
+	if err != nil {
+		if errors.Is(err, roleNotFoundError) {
+			ctx.JSON(http.StatusNotFound, newNotFoundError("role_id", roleID))
+			return
+		}
+		if errors.Is(err, roleWithSameNameError) {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, role)
+}
+
+// parseRoleID returns role_id param. When it is not valid the bad request response is written and false is returned.
+func (c Controller) parseRoleID(ctx *gin.Context) (int64, bool) {
+	return c.parseID(ctx, "role_id", roleIDMissedError)
+}
+
+// parseID returns the name param, missedError is written when it is missed.
+func (c Controller) parseID(ctx *gin.Context, name string, missedError map[string]interface{}) (int64, bool) {
+	param := ctx.Param(name)
+	if param == "" {
+		ctx.JSON(http.StatusBadRequest, missedError)
+		return 0, false
+	}
+
+	id, err := c.integerParser(param, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (c Controller) SetURLMapping(router *gin.Engine) {
+	router.GET("/role", c.List)
+	router.GET("/role/:role_id", c.GetByID)
+	router.POST("/role", c.Post)
+	router.PUT("/role/:role_id", c.Put)
+	router.DELETE("/role/:role_id", c.Delete)
+	router.GET("/role/:role_id/parents", c.GetParents)
+	router.POST("/role/:role_id/parents", c.PostParent)
+	router.DELETE("/role/:role_id/parents/:parent_id", c.DeleteParent)
+	router.GET("/role/:role_id/permissions", c.GetRolePermissions)
+	router.POST("/role/:role_id/permissions", c.PostRolePermission)
+	router.DELETE("/role/:role_id/permissions/:permission", c.DeleteRolePermission)
+	router.GET("/permission", c.ListPermissions)
+	router.POST("/permission", c.PostPermission)
+}
+
+// RequiredRoles declares the role types or permissions required by the routes set in SetURLMapping.
+func (c Controller) RequiredRoles() auth.Routes {
+	var (
+		readers = auth.Requirement{
+			RoleTypes:   []string{auth.AdminRoleType, auth.ViewerRoleType},
+			Permissions: []string{auth.PermissionRoleRead},
+		}
+		writers = auth.Requirement{
+			RoleTypes:   []string{auth.AdminRoleType},
+			Permissions: []string{auth.PermissionRoleWrite},
+		}
+	)
+
+	return auth.Routes{
+		auth.Route(http.MethodGet, "/role"):                                     readers,
+		auth.Route(http.MethodGet, "/role/:role_id"):                            readers,
+		auth.Route(http.MethodPost, "/role"):                                    writers,
+		auth.Route(http.MethodPut, "/role/:role_id"):                            writers,
+		auth.Route(http.MethodDelete, "/role/:role_id"):                         writers,
+		auth.Route(http.MethodGet, "/role/:role_id/parents"):                    readers,
+		auth.Route(http.MethodPost, "/role/:role_id/parents"):                   writers,
+		auth.Route(http.MethodDelete, "/role/:role_id/parents/:parent_id"):      writers,
+		auth.Route(http.MethodGet, "/role/:role_id/permissions"):                readers,
+		auth.Route(http.MethodPost, "/role/:role_id/permissions"):               writers,
+		auth.Route(http.MethodDelete, "/role/:role_id/permissions/:permission"): writers,
+		auth.Route(http.MethodGet, "/permission"):                               readers,
+		auth.Route(http.MethodPost, "/permission"):                              writers,
+	}
+}
+
+func newBadRequestResponse(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     message,
+		"status_code": http.StatusBadRequest,
+	}
+}
+
+func newNotFoundError(by string, id any) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     "element not found",
+		"by":          by,
+		"id":          id,
+		"status_code": http.StatusNotFound,
+	}
+}
+
+func newInternalServerError(cause error) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     "internal server error",
+		"cause":       cause,
+		"status_code": http.StatusInternalServerError,
+	}
+}
@@ -0,0 +1,1370 @@
+package role
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maria/src/api/auth"
+	"maria/src/api/util"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ControllerSuite struct {
+	suite.Suite
+}
+
+func TestControllerSuite(t *testing.T) {
+	suite.Run(t, new(ControllerSuite))
+}
+
+func (c *ControllerSuite) BeforeTest(suiteName, testName string) {
+}
+
+func (c *ControllerSuite) AfterTest(suiteName, testName string) {
+}
+
+func (c *ControllerSuite) TestGetRoleByID() {
+	var (
+		roleID      = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		param          string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "role_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("role_id param is missed")),
+		},
+		{
+			name:  "it cannot parse role_id param",
+			param: "word",
+			controller: Controller{
+				integerParser: func(s string, base int, bitSize int) (i int64, err error) {
+					return 0, customError
+				},
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(customError.Error())),
+		},
+		{
+			name:           "role not found",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetByIDMock(Role{}, roleNotFoundError, roleID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("role_id", roleID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetByIDMock(Role{}, customError, roleID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetByIDMock(Role{ID: roleID}, nil, roleID),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(Role{ID: roleID}),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"role_id": test.param}, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.GetByID(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestPost() {
+	const (
+		bindMSGError = "" +
+			"Key: 'NewRoleRequest.RoleName' Error:Field validation for 'RoleName' failed on the 'required' tag\n" +
+			"Key: 'NewRoleRequest.Type' Error:Field validation for 'Type' failed on the 'required' tag"
+	)
+	var (
+		roleID      = int64(10)
+		dateCreated = time.Now()
+		customError = errors.New("custom error")
+		roleRequest = NewRoleRequest{RoleName: "admin", Type: "user"}
+	)
+
+	type test struct {
+		name           string
+		body           NewRoleRequest
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "body fields missed",
+			body:         NewRoleRequest{},
+			controller:   Controller{},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(bindMSGError)),
+		},
+		{
+			name:           "role name is taken",
+			body:           roleRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMock(Role{}, roleWithSameNameError, roleRequest),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   util.RenderToJSON(newBadRequestResponse(roleWithSameNameError.Error())),
+		},
+		{
+			name:           "service return internal error",
+			body:           roleRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMock(Role{}, customError, roleRequest),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			body:           roleRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMock(roleRequest.toRole(roleID, dateCreated, true), nil, roleRequest),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(roleRequest.toRole(roleID, dateCreated, true)),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(nil, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Post(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestList() {
+	var (
+		active      = true
+		customError = errors.New("custom error")
+		roles       = []Role{{ID: 1, RoleName: "admin", Type: "user", Active: true}}
+	)
+
+	type test struct {
+		name           string
+		queryString    string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "active is not a boolean",
+			queryString:  "active=yes",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("active must be a boolean")),
+		},
+		{
+			name:           "service return internal error",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListMock(nil, customError, RoleFilter{}),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			queryString:    "type=user&active=true",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListMock(roles, nil, RoleFilter{Type: "user", Active: &active}),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(roles),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(nil, test.queryString, nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.List(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestPut() {
+	var (
+		roleID      = int64(10)
+		roleName    = "viewer"
+		blank       = " "
+		customError = errors.New("custom error")
+		roleRequest = ModifyRoleRequest{RoleName: &roleName}
+	)
+
+	type test struct {
+		name           string
+		param          string
+		body           any
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "role_id param missed",
+			param:        "",
+			body:         roleRequest,
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("role_id param is missed")),
+		},
+		{
+			name:         "request is empty",
+			param:        "10",
+			body:         ModifyRoleRequest{},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("request does not specify a change to be applied")),
+		},
+		{
+			name:         "role name is blank",
+			param:        "10",
+			body:         ModifyRoleRequest{RoleName: &blank},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("role_name cannot be empty")),
+		},
+		{
+			name:           "role not found",
+			param:          "10",
+			body:           roleRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Role{}, roleNotFoundError, roleRequest, roleID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("role_id", roleID)),
+		},
+		{
+			name:           "role name is taken",
+			param:          "10",
+			body:           roleRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Role{}, roleWithSameNameError, roleRequest, roleID),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   util.RenderToJSON(newBadRequestResponse(roleWithSameNameError.Error())),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			body:           roleRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Role{}, customError, roleRequest, roleID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			body:           roleRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Role{ID: roleID, RoleName: roleName}, nil, roleRequest, roleID),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(Role{ID: roleID, RoleName: roleName}),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"role_id": test.param}, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Put(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestDelete() {
+	var (
+		roleID      = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		param          string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "role_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("role_id param is missed")),
+		},
+		{
+			name:           "role not found",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(Role{}, roleNotFoundError, roleID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("role_id", roleID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(Role{}, customError, roleID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "role deactivated",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(Role{ID: roleID}, nil, roleID),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(Role{ID: roleID}),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"role_id": test.param}, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Delete(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestGetParents() {
+	var (
+		roleID      = int64(10)
+		parents     = []Role{{ID: 20, RoleName: "viewer", Type: "client"}}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		param          string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "role_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("role_id param is missed")),
+		},
+		{
+			name:           "role not found",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListParentsMock([]Role{}, roleNotFoundError, roleID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("role_id", roleID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListParentsMock([]Role{}, customError, roleID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListParentsMock(parents, nil, roleID),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(parents),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"role_id": test.param}, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.GetParents(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestPostParent() {
+	var (
+		roleID      = int64(10)
+		request     = AddParentRequest{ParentID: 20}
+		parents     = []Role{{ID: 20, RoleName: "viewer", Type: "client"}}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		param          string
+		body           any
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "role_id param missed",
+			param:        "",
+			body:         request,
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("role_id param is missed")),
+		},
+		{
+			name:         "parent_id is missed",
+			param:        "10",
+			body:         map[string]any{},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(
+				"Key: 'AddParentRequest.ParentID' Error:Field validation for 'ParentID' failed on the 'required' tag")),
+		},
+		{
+			name:           "parent not found",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAddParentMock(nil, parentNotFoundError, roleID, request),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("parent_id", request.ParentID)),
+		},
+		{
+			name:           "edge makes a cycle",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAddParentMock(nil, hierarchyCycleError, roleID, request),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   util.RenderToJSON(newBadRequestResponse(hierarchyCycleError.Error())),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAddParentMock(nil, customError, roleID, request),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAddParentMock(parents, nil, roleID, request),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(parents),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"role_id": test.param}, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.PostParent(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestDeleteParent() {
+	var (
+		roleID      = int64(10)
+		parentID    = int64(20)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		params         map[string]string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "parent_id param missed",
+			params:       map[string]string{"role_id": "10"},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("parent_id param is missed")),
+		},
+		{
+			name:           "role does not inherit parent",
+			params:         map[string]string{"role_id": "10", "parent_id": "20"},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceRemoveParentMock(parentLinkNotFoundError, roleID, parentID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("parent_id", parentID)),
+		},
+		{
+			name:           "service return internal error",
+			params:         map[string]string{"role_id": "10", "parent_id": "20"},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceRemoveParentMock(customError, roleID, parentID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			params:         map[string]string{"role_id": "10", "parent_id": "20"},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceRemoveParentMock(nil, roleID, parentID),
+			expectedCode:   http.StatusNoContent,
+			expectedBody:   "",
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(test.params, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.DeleteParent(ctx)
+
+			assert.Equal(t, test.expectedCode, ctx.Writer.Status())
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestPostPermission() {
+	var (
+		request     = NewPermissionRequest{Name: "task:read", Description: "Read tasks"}
+		permission  = Permission{ID: 6, Name: request.Name, Description: request.Description}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		body           any
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "name is missed",
+			body:         map[string]any{},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(
+				"Key: 'NewPermissionRequest.Name' Error:Field validation for 'Name' failed on the 'required' tag")),
+		},
+		{
+			name:         "name is not valid",
+			body:         NewPermissionRequest{Name: "Task Read"},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(
+				"name must be written as resource:action using lowercase letters and underscores")),
+		},
+		{
+			name:           "permission with same name",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceCreatePermissionMock(Permission{}, permissionWithSameNameError, request),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   util.RenderToJSON(newBadRequestResponse(permissionWithSameNameError.Error())),
+		},
+		{
+			name:           "service return internal error",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceCreatePermissionMock(Permission{}, customError, request),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceCreatePermissionMock(permission, nil, request),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(permission),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(nil, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.PostPermission(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestPostRolePermission() {
+	var (
+		roleID      = int64(10)
+		request     = AddPermissionRequest{Permission: "user:read"}
+		permissions = []Permission{{ID: 1, Name: request.Permission}}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		param          string
+		body           any
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "role_id param missed",
+			param:        "",
+			body:         request,
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("role_id param is missed")),
+		},
+		{
+			name:         "permission is missed",
+			param:        "10",
+			body:         map[string]any{},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(
+				"Key: 'AddPermissionRequest.Permission' Error:Field validation for 'Permission' failed on the 'required' tag")),
+		},
+		{
+			name:           "role not found",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAddRolePermissionMock(nil, roleNotFoundError, roleID, request),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("role_id", roleID)),
+		},
+		{
+			name:           "permission not found",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAddRolePermissionMock(nil, permissionNotFoundError, roleID, request),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("permission", request.Permission)),
+		},
+		{
+			name:           "permission already added",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAddRolePermissionMock(nil, permissionAlreadyAddedError, roleID, request),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   util.RenderToJSON(newBadRequestResponse(permissionAlreadyAddedError.Error())),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAddRolePermissionMock(nil, customError, roleID, request),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAddRolePermissionMock(permissions, nil, roleID, request),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(permissions),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"role_id": test.param}, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.PostRolePermission(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestDeleteRolePermission() {
+	var (
+		roleID      = int64(10)
+		permission  = "user:read"
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		params         map[string]string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "permission param missed",
+			params:       map[string]string{"role_id": "10"},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("permission param is missed")),
+		},
+		{
+			name:           "role does not hold the permission",
+			params:         map[string]string{"role_id": "10", "permission": permission},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceRemoveRolePermissionMock(rolePermissionNotFoundError, roleID, permission),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("permission", permission)),
+		},
+		{
+			name:           "service return internal error",
+			params:         map[string]string{"role_id": "10", "permission": permission},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceRemoveRolePermissionMock(customError, roleID, permission),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			params:         map[string]string{"role_id": "10", "permission": permission},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceRemoveRolePermissionMock(nil, roleID, permission),
+			expectedCode:   http.StatusNoContent,
+			expectedBody:   "",
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(test.params, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.DeleteRolePermission(ctx)
+
+			assert.Equal(t, test.expectedCode, ctx.Writer.Status())
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestRequiredRoles() {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := NewController(newServiceMock())
+	controller.SetURLMapping(router)
+
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		registered[auth.Route(route.Method, route.Path)] = true
+	}
+
+	for route, requirement := range controller.RequiredRoles() {
+		assert.True(c.T(), registered[route], "%s is not registered", route)
+		assert.NotEmpty(c.T(), requirement.RoleTypes, "%s does not require any role type", route)
+		assert.NotEmpty(c.T(), requirement.Permissions, "%s does not require any permission", route)
+	}
+}
+
+func (c *ControllerSuite) TestSetURLMapping() {
+	var (
+		roleID      = int64(10)
+		roleName    = "viewer"
+		roleRequest = NewRoleRequest{RoleName: "admin", Type: "user"}
+		putRequest  = ModifyRoleRequest{RoleName: &roleName}
+
+		permissionRequest = AddPermissionRequest{Permission: "user:read"}
+	)
+
+	type test struct {
+		name           string
+		path           string
+		method         string
+		body           any
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+	}
+
+	tests := []test{
+		{
+			name:           "list roles",
+			path:           "/role?type=user",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListMock([]Role{}, nil, RoleFilter{Type: "user"}),
+		},
+		{
+			name:           "get role by id",
+			path:           "/role/10",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetByIDMock(Role{ID: roleID}, nil, roleID),
+		},
+		{
+			name:           "post role",
+			path:           "/role",
+			method:         http.MethodPost,
+			body:           roleRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMock(roleRequest.toRole(roleID, time.Time{}, true), nil, roleRequest),
+		},
+		{
+			name:           "put role",
+			path:           "/role/10",
+			method:         http.MethodPut,
+			body:           putRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Role{ID: roleID}, nil, putRequest, roleID),
+		},
+		{
+			name:           "delete role",
+			path:           "/role/10",
+			method:         http.MethodDelete,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(Role{ID: roleID}, nil, roleID),
+		},
+		{
+			name:           "get role parents",
+			path:           "/role/10/parents",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListParentsMock([]Role{}, nil, roleID),
+		},
+		{
+			name:           "post role parent",
+			path:           "/role/10/parents",
+			method:         http.MethodPost,
+			body:           AddParentRequest{ParentID: 20},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAddParentMock([]Role{}, nil, roleID, AddParentRequest{ParentID: 20}),
+		},
+		{
+			name:           "get role permissions",
+			path:           "/role/10/permissions",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListRolePermissionsMock([]Permission{}, nil, roleID),
+		},
+		{
+			name:           "post role permission",
+			path:           "/role/10/permissions",
+			method:         http.MethodPost,
+			body:           permissionRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceAddRolePermissionMock([]Permission{}, nil, roleID, permissionRequest),
+		},
+		{
+			name:           "list permissions",
+			path:           "/permission",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListPermissionsMock([]Permission{}, nil),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			test.controller.SetURLMapping(router)
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			var b bytes.Buffer
+			if test.body != nil {
+				if err := json.NewEncoder(&b).Encode(test.body); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				}
+			}
+
+			req := httptest.NewRequest(test.method, test.path, &b)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(c.T(), http.StatusOK, w.Code)
+		})
+	}
+}
+
+func setServiceGetByIDMock(
+	roleResponse Role,
+	errorResponse error,
+	roleID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.getByID), roleID).
+			Return(roleResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServicePostMock(
+	roleResponse Role,
+	errorResponse error,
+	request NewRoleRequest,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.createRole), mock.Anything, request).
+			Return(roleResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceListMock(
+	rolesResponse []Role,
+	errorResponse error,
+	filter RoleFilter,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.listRoles), filter).
+			Return(rolesResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServicePutMock(
+	roleResponse Role,
+	errorResponse error,
+	request ModifyRoleRequest,
+	roleID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.modifyRole), mock.Anything, request, roleID).
+			Return(roleResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceDeleteMock(
+	roleResponse Role,
+	errorResponse error,
+	roleID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.deactivateRole), mock.Anything, roleID).
+			Return(roleResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceListParentsMock(
+	parentsResponse []Role,
+	errorResponse error,
+	roleID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.listParents), roleID).
+			Return(parentsResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceAddParentMock(
+	parentsResponse []Role,
+	errorResponse error,
+	roleID int64,
+	request AddParentRequest,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.addParent), mock.Anything, roleID, request).
+			Return(parentsResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceRemoveParentMock(
+	errorResponse error,
+	roleID int64,
+	parentID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.removeParent), mock.Anything, roleID, parentID).
+			Return(errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceListPermissionsMock(
+	permissionsResponse []Permission,
+	errorResponse error,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.listPermissions)).
+			Return(permissionsResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceCreatePermissionMock(
+	permissionResponse Permission,
+	errorResponse error,
+	request NewPermissionRequest,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.createPermission), mock.Anything, request).
+			Return(permissionResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceListRolePermissionsMock(
+	permissionsResponse []Permission,
+	errorResponse error,
+	roleID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.listRolePermissions), roleID).
+			Return(permissionsResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceAddRolePermissionMock(
+	permissionsResponse []Permission,
+	errorResponse error,
+	roleID int64,
+	request AddPermissionRequest,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.addRolePermission), mock.Anything, roleID, request).
+			Return(permissionsResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceRemoveRolePermissionMock(
+	errorResponse error,
+	roleID int64,
+	permission string,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.removeRolePermission), mock.Anything, roleID, permission).
+			Return(errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
@@ -0,0 +1,432 @@
+package role
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maria/src/api/audit"
+	"maria/src/api/db"
+	"strings"
+)
+
+const (
+	getRoleByIDQuery          = `SELECT id, role_name, type, active, date_created FROM role WHERE id = ?`
+	getRoleByNameAndTypeQuery = `SELECT id, role_name, type, active, date_created FROM role WHERE role_name = ? AND type = ?`
+	listRolesQuery            = `SELECT id, role_name, type, active, date_created FROM role`
+	insertRoleQuery           = `INSERT INTO role (role_name, type, active) VALUES (?, ?, true)`
+	updateRoleByIDQuery       = `UPDATE role SET role_name = ?, type = ?, active = ? WHERE id = ?`
+	getParentsQuery           = `SELECT r.id, r.role_name, r.type, r.active, r.date_created FROM role_parent rp ` +
+		`JOIN role r ON r.id = rp.parent_id WHERE rp.role_id = ? ORDER BY r.id`
+	// edges are locked, so concurrent additions cannot close a cycle between them
+	getEdgesForUpdateQuery = `SELECT role_id, parent_id FROM role_parent FOR UPDATE`
+	insertParentQuery      = `INSERT INTO role_parent (role_id, parent_id) VALUES (?, ?)`
+	deleteParentQuery      = `DELETE FROM role_parent WHERE role_id = ? AND parent_id = ?`
+
+	listPermissionsQuery     = `SELECT id, name, description, date_created FROM permission ORDER BY name`
+	getPermissionByIDQuery   = `SELECT id, name, description, date_created FROM permission WHERE id = ?`
+	getPermissionByNameQuery = `SELECT id, name, description, date_created FROM permission WHERE name = ?`
+	insertPermissionQuery    = `INSERT INTO permission (name, description) VALUES (?, ?)`
+	getRolePermissionsQuery  = `SELECT p.id, p.name, p.description, p.date_created FROM role_permission rp ` +
+		`JOIN permission p ON p.id = rp.permission_id WHERE rp.role_id = ? ORDER BY p.name`
+	insertRolePermissionQuery = `INSERT INTO role_permission (role_id, permission_id) VALUES (?, ?)`
+	deleteRolePermissionQuery = `DELETE FROM role_permission WHERE role_id = ? AND permission_id = ?`
+
+	// roleNameUniqueKey keeps role_name unique by type.
+	roleNameUniqueKey = "role_type_role_name_uindex"
+	// permissionNameUniqueKey keeps permission names unique.
+	permissionNameUniqueKey = "permission_name_uindex"
+)
+
+type Querier interface {
+	selectByID(int64) (Role, error)
+	selectByNameAndType(string, string) (Role, error)
+	selectByFilter(RoleFilter) ([]Role, error)
+	createRole(NewRoleRequest) (int64, error)
+	modifyRole(ModifyRoleRequest, Role) (bool, error)
+	selectParents(int64) ([]Role, error)
+	selectEdges() ([]Edge, error)
+	createParent(Edge) error
+	deleteParent(Edge) (bool, error)
+	selectPermissions() ([]Permission, error)
+	selectPermissionByID(int64) (Permission, error)
+	selectPermissionByName(string) (Permission, error)
+	createPermission(NewPermissionRequest) (int64, error)
+	selectRolePermissions(int64) ([]Permission, error)
+	addRolePermission(int64, int64) error
+	removeRolePermission(int64, int64) (bool, error)
+	record(audit.Entry) error
+}
+
+type Persister interface {
+	Querier
+	withTransaction(fn func(tx Transactioner) error) error
+}
+
+type Transactioner interface {
+	Querier
+	commit() error
+	rollback() error
+}
+
+func NewRelationalDB(client db.Client) Persister {
+	return &relationalDB{
+		client: client,
+	}
+}
+
+type relationalDB struct {
+	client db.Client
+}
+
+func (r *relationalDB) selectByID(roleID int64) (Role, error) {
+	return r.selectOne(getRoleByIDQuery, roleID)
+}
+
+func (r *relationalDB) selectByNameAndType(roleName, roleType string) (Role, error) {
+	return r.selectOne(getRoleByNameAndTypeQuery, roleName, roleType)
+}
+
+func (r *relationalDB) selectOne(query string, args ...any) (Role, error) {
+	var role Role
+
+	if err := r.client.QueryRow(query, args...).Scan(
+		&role.ID,
+		&role.RoleName,
+		&role.Type,
+		&role.Active,
+		&role.DateCreated,
+	); err != nil {
+		return role, db.ScanError(err, query)
+	}
+
+	return role, nil
+}
+
+func (r *relationalDB) selectByFilter(filter RoleFilter) ([]Role, error) {
+	query, args := buildListQuery(filter)
+	return r.selectMany(query, args...)
+}
+
+// selectParents returns the roles directly inherited by roleID.
+func (r *relationalDB) selectParents(roleID int64) ([]Role, error) {
+	return r.selectMany(getParentsQuery, roleID)
+}
+
+func (r *relationalDB) selectMany(query string, args ...any) ([]Role, error) {
+	var (
+		rows  *sql.Rows
+		err   error
+		roles []Role
+	)
+
+	if rows, err = r.client.Query(query, args...); err != nil {
+		return nil, db.QueryError(err, query)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var role Role
+		if err = rows.Scan(
+			&role.ID,
+			&role.RoleName,
+			&role.Type,
+			&role.Active,
+			&role.DateCreated,
+		); err != nil {
+			return nil, db.ScanError(err, query)
+		}
+		roles = append(roles, role)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, query)
+	}
+
+	return roles, nil
+}
+
+// buildListQuery returns the query listing the roles matching filter sorted by type and name.
+func buildListQuery(filter RoleFilter) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if filter.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, filter.Type)
+	}
+	if filter.Active != nil {
+		conditions = append(conditions, "active = ?")
+		args = append(args, *filter.Active)
+	}
+
+	query := listRolesQuery
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return query + " ORDER BY type, role_name, id", args
+}
+
+func (r *relationalDB) createRole(request NewRoleRequest) (int64, error) {
+	result, err := r.client.Exec(insertRoleQuery, request.RoleName, request.Type)
+	if err != nil {
+		return 0, roleExecError(err, insertRoleQuery)
+	}
+
+	roleID, err := result.LastInsertId()
+	if err != nil {
+		return 0, db.LastInsertedError(err, insertRoleQuery)
+	}
+
+	return roleID, nil
+}
+
+func (r *relationalDB) modifyRole(request ModifyRoleRequest, role Role) (bool, error) {
+	role = request.apply(role)
+
+	result, err := r.client.Exec(updateRoleByIDQuery, role.RoleName, role.Type, role.Active, role.ID)
+	if err != nil {
+		return false, roleExecError(err, updateRoleByIDQuery)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, db.RowsAffectedError(err, updateRoleByIDQuery)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *relationalDB) selectEdges() ([]Edge, error) {
+	var (
+		rows  *sql.Rows
+		err   error
+		edges []Edge
+	)
+
+	if rows, err = r.client.Query(getEdgesForUpdateQuery); err != nil {
+		return nil, db.QueryError(err, getEdgesForUpdateQuery)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var edge Edge
+		if err = rows.Scan(&edge.RoleID, &edge.ParentID); err != nil {
+			return nil, db.ScanError(err, getEdgesForUpdateQuery)
+		}
+		edges = append(edges, edge)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, getEdgesForUpdateQuery)
+	}
+
+	return edges, nil
+}
+
+func (r *relationalDB) createParent(edge Edge) error {
+	if _, err := r.client.Exec(insertParentQuery, edge.RoleID, edge.ParentID); err != nil {
+		if _, ok := db.DuplicateEntryKey(err); ok {
+			return parentAlreadyAddedError
+		}
+		return db.ExecError(err, insertParentQuery)
+	}
+	return nil
+}
+
+func (r *relationalDB) deleteParent(edge Edge) (bool, error) {
+	result, err := r.client.Exec(deleteParentQuery, edge.RoleID, edge.ParentID)
+	if err != nil {
+		return false, db.ExecError(err, deleteParentQuery)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, db.RowsAffectedError(err, deleteParentQuery)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *relationalDB) selectPermissions() ([]Permission, error) {
+	return r.selectManyPermissions(listPermissionsQuery)
+}
+
+func (r *relationalDB) selectPermissionByID(permissionID int64) (Permission, error) {
+	return r.selectOnePermission(getPermissionByIDQuery, permissionID)
+}
+
+func (r *relationalDB) selectPermissionByName(name string) (Permission, error) {
+	return r.selectOnePermission(getPermissionByNameQuery, name)
+}
+
+func (r *relationalDB) selectOnePermission(query string, args ...any) (Permission, error) {
+	var permission Permission
+
+	if err := r.client.QueryRow(query, args...).Scan(
+		&permission.ID,
+		&permission.Name,
+		&permission.Description,
+		&permission.DateCreated,
+	); err != nil {
+		return permission, db.ScanError(err, query)
+	}
+
+	return permission, nil
+}
+
+// selectRolePermissions returns the permissions directly attached to roleID, the inherited ones are not included.
+func (r *relationalDB) selectRolePermissions(roleID int64) ([]Permission, error) {
+	return r.selectManyPermissions(getRolePermissionsQuery, roleID)
+}
+
+func (r *relationalDB) selectManyPermissions(query string, args ...any) ([]Permission, error) {
+	var (
+		rows        *sql.Rows
+		err         error
+		permissions []Permission
+	)
+
+	if rows, err = r.client.Query(query, args...); err != nil {
+		return nil, db.QueryError(err, query)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var permission Permission
+		if err = rows.Scan(
+			&permission.ID,
+			&permission.Name,
+			&permission.Description,
+			&permission.DateCreated,
+		); err != nil {
+			return nil, db.ScanError(err, query)
+		}
+		permissions = append(permissions, permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, query)
+	}
+
+	return permissions, nil
+}
+
+func (r *relationalDB) createPermission(request NewPermissionRequest) (int64, error) {
+	result, err := r.client.Exec(insertPermissionQuery, request.Name, request.Description)
+	if err != nil {
+		if key, ok := db.DuplicateEntryKey(err); ok && key == permissionNameUniqueKey {
+			return 0, permissionWithSameNameError
+		}
+		return 0, db.ExecError(err, insertPermissionQuery)
+	}
+
+	permissionID, err := result.LastInsertId()
+	if err != nil {
+		return 0, db.LastInsertedError(err, insertPermissionQuery)
+	}
+
+	return permissionID, nil
+}
+
+func (r *relationalDB) addRolePermission(roleID, permissionID int64) error {
+	if _, err := r.client.Exec(insertRolePermissionQuery, roleID, permissionID); err != nil {
+		if _, ok := db.DuplicateEntryKey(err); ok {
+			return permissionAlreadyAddedError
+		}
+		return db.ExecError(err, insertRolePermissionQuery)
+	}
+	return nil
+}
+
+func (r *relationalDB) removeRolePermission(roleID, permissionID int64) (bool, error) {
+	result, err := r.client.Exec(deleteRolePermissionQuery, roleID, permissionID)
+	if err != nil {
+		return false, db.ExecError(err, deleteRolePermissionQuery)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, db.RowsAffectedError(err, deleteRolePermissionQuery)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// roleExecError translates the violation of the role name unique index into a roleWithSameNameError.
+func roleExecError(err error, query string) error {
+	if key, ok := db.DuplicateEntryKey(err); ok && key == roleNameUniqueKey {
+		return roleWithSameNameError
+	}
+	return db.ExecError(err, query)
+}
+
+func (r *relationalDB) record(entry audit.Entry) error {
+	return audit.NewRelationalDB(r.client).Record(entry)
+}
+
+func (r *relationalDB) getTransactioner() (Transactioner, error) {
+	client, ok := r.client.(*sql.DB)
+	if !ok {
+		return nil, errors.New("persister cannot generate transactional db")
+	}
+
+	tx, err := client.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("persister cannot generate transactional due to: %w", err)
+	}
+
+	return &transactionalDB{relationalDB: relationalDB{client: tx}, tx: tx}, nil
+}
+
+func (r *relationalDB) withTransaction(fn func(tx Transactioner) error) error {
+	tx, err := r.getTransactioner()
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		if err := tx.rollback(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	return tx.commit()
+}
+
+type transactionalDB struct {
+	relationalDB
+	tx *sql.Tx
+}
+
+func (tx *transactionalDB) commit() error {
+	if err := tx.tx.Commit(); err != nil {
+		return db.CommitError(err)
+	}
+	return nil
+}
+
+func (tx *transactionalDB) rollback() error {
+	if err := tx.tx.Rollback(); err != nil {
+		return db.RollbackError(err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package role
+
+import (
+	"fmt"
+	"maria/src/api/audit"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type dbMock struct {
+	mock.Mock
+}
+
+func newDBMock() *dbMock {
+	return &dbMock{}
+}
+
+func (m *dbMock) selectByID(roleID int64) (Role, error) {
+	args := m.Called(roleID)
+	return mockRole(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectByNameAndType(roleName, roleType string) (Role, error) {
+	args := m.Called(roleName, roleType)
+	return mockRole(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectByFilter(filter RoleFilter) ([]Role, error) {
+	args := m.Called(filter)
+	return mockRoles(args, 0), args.Error(1)
+}
+
+func (m *dbMock) createRole(request NewRoleRequest) (int64, error) {
+	args := m.Called(request)
+	return mockInt64(args, 0), args.Error(1)
+}
+
+func (m *dbMock) modifyRole(request ModifyRoleRequest, role Role) (bool, error) {
+	args := m.Called(request, role)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) selectParents(roleID int64) ([]Role, error) {
+	args := m.Called(roleID)
+	return mockRoles(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectEdges() ([]Edge, error) {
+	args := m.Called()
+	return mockEdges(args, 0), args.Error(1)
+}
+
+func (m *dbMock) createParent(edge Edge) error {
+	args := m.Called(edge)
+	return args.Error(0)
+}
+
+func (m *dbMock) deleteParent(edge Edge) (bool, error) {
+	args := m.Called(edge)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) selectPermissions() ([]Permission, error) {
+	args := m.Called()
+	return mockPermissions(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectPermissionByID(permissionID int64) (Permission, error) {
+	args := m.Called(permissionID)
+	return mockPermission(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectPermissionByName(name string) (Permission, error) {
+	args := m.Called(name)
+	return mockPermission(args, 0), args.Error(1)
+}
+
+func (m *dbMock) createPermission(request NewPermissionRequest) (int64, error) {
+	args := m.Called(request)
+	return mockInt64(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectRolePermissions(roleID int64) ([]Permission, error) {
+	args := m.Called(roleID)
+	return mockPermissions(args, 0), args.Error(1)
+}
+
+func (m *dbMock) addRolePermission(roleID, permissionID int64) error {
+	args := m.Called(roleID, permissionID)
+	return args.Error(0)
+}
+
+func (m *dbMock) removeRolePermission(roleID, permissionID int64) (bool, error) {
+	args := m.Called(roleID, permissionID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) record(entry audit.Entry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *dbMock) withTransaction(fn func(tx Transactioner) error) error {
+	args := m.Called(fn)
+
+	if err := args.Error(0); err != nil {
+		return err
+	}
+
+	return fn(m)
+}
+
+func (m *dbMock) commit() error {
+	args := m.Called()
+	return args.Error(1)
+}
+
+func (m *dbMock) rollback() error {
+	args := m.Called()
+	return args.Error(1)
+}
+
+func mockEdges(args mock.Arguments, index int) []Edge {
+	obj := args.Get(index)
+	var s []Edge
+	var ok bool
+	if s, ok = obj.([]Edge); !ok {
+		panic(fmt.Sprintf("assert: arguments: Edge(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
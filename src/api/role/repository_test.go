@@ -0,0 +1,749 @@
+package role
+
+import (
+	"errors"
+	"maria/src/api/db"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type relationalDBSuite struct {
+	suite.Suite
+}
+
+func TestRelationalDBSuite(t *testing.T) {
+	suite.Run(t, new(relationalDBSuite))
+}
+
+func (s *relationalDBSuite) BeforeTest(suiteName, testName string) {
+}
+
+func (s *relationalDBSuite) AfterTest(suiteName, testName string) {
+}
+
+func (s *relationalDBSuite) TestSelectByNameAndType() {
+	var (
+		role        = Role{ID: 10, RoleName: "admin", Type: "user", Active: true, DateCreated: time.Now()}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedRole  Role
+	}
+
+	tests := []test{
+		{
+			name: "scan error",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getRoleMockRows([]Role{role}), getRoleByNameAndTypeQuery, customError, role.RoleName, role.Type)},
+			expectedError: db.ScanError(customError, getRoleByNameAndTypeQuery),
+			expectedRole:  Role{},
+		},
+		{
+			name: "role not found",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getRoleMockRows(nil), getRoleByNameAndTypeQuery, nil, role.RoleName, role.Type)},
+			expectedError: nil,
+			expectedRole:  Role{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getRoleMockRows([]Role{role}), getRoleByNameAndTypeQuery, nil, role.RoleName, role.Type)},
+			expectedError: nil,
+			expectedRole:  role,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectByNameAndType(role.RoleName, role.Type)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRole, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectByFilter() {
+	var (
+		active      = true
+		roles       = []Role{{ID: 10, RoleName: "admin", Type: "user", Active: true, DateCreated: time.Now()}}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		filter        RoleFilter
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedRoles []Role
+	}
+
+	tests := []test{
+		{
+			name:   "query error",
+			filter: RoleFilter{},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				nil, listRolesQuery+" ORDER BY type, role_name, id", customError, nil)},
+			expectedError: db.QueryError(customError, listRolesQuery+" ORDER BY type, role_name, id"),
+			expectedRoles: nil,
+		},
+		{
+			name:   "filtered by type and active",
+			filter: RoleFilter{Type: "user", Active: &active},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getRoleMockRows(roles),
+				listRolesQuery+" WHERE type = ? AND active = ? ORDER BY type, role_name, id",
+				nil,
+				nil,
+				"user", true)},
+			expectedError: nil,
+			expectedRoles: roles,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectByFilter(test.filter)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRoles, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestCreateRole() {
+	var (
+		request     = NewRoleRequest{RoleName: "admin", Type: "user"}
+		customError = errors.New("custom error")
+		duplicated  = &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'user-admin' for key 'role_type_role_name_uindex'"}
+	)
+
+	type test struct {
+		name           string
+		mockCalls      mockDBApplier
+		expectedError  error
+		expectedRoleID int64
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertRoleQuery, customError, request.RoleName, request.Type)},
+			expectedError:  db.ExecError(customError, insertRoleQuery),
+			expectedRoleID: 0,
+		},
+		{
+			name: "role name is duplicated",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertRoleQuery, duplicated, request.RoleName, request.Type)},
+			expectedError:  roleWithSameNameError,
+			expectedRoleID: 0,
+		},
+		{
+			name: "last inserted error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewErrorResult(customError), insertRoleQuery, nil, request.RoleName, request.Type)},
+			expectedError:  db.LastInsertedError(customError, insertRoleQuery),
+			expectedRoleID: 0,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(10, 1), insertRoleQuery, nil, request.RoleName, request.Type)},
+			expectedError:  nil,
+			expectedRoleID: 10,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			roleID, err := rDB.createRole(request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRoleID, roleID)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestModifyRole() {
+	var (
+		role        = Role{ID: 10, RoleName: "admin", Type: "user", Active: true}
+		inactive    = false
+		request     = ModifyRoleRequest{Active: &inactive}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, updateRoleByIDQuery, customError, role.RoleName, role.Type, false, role.ID)},
+			expectedError: db.ExecError(customError, updateRoleByIDQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "rows affected error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewErrorResult(customError), updateRoleByIDQuery, nil, role.RoleName, role.Type, false, role.ID)},
+			expectedError: db.RowsAffectedError(customError, updateRoleByIDQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), updateRoleByIDQuery, nil, role.RoleName, role.Type, false, role.ID)},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			updated, err := rDB.modifyRole(request, role)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, updated)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectEdges() {
+	var (
+		edges       = []Edge{{RoleID: 10, ParentID: 20}, {RoleID: 20, ParentID: 30}}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedEdges []Edge
+	}
+
+	tests := []test{
+		{
+			name:          "query error",
+			mockCalls:     mockDBApplier{db.SetClientQueryMock(nil, getEdgesForUpdateQuery, customError, nil)},
+			expectedError: db.QueryError(customError, getEdgesForUpdateQuery),
+			expectedEdges: nil,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				sqlmock.NewRows([]string{"role_id", "parent_id"}).AddRow(10, 20).AddRow(20, 30),
+				getEdgesForUpdateQuery, nil, nil)},
+			expectedError: nil,
+			expectedEdges: edges,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectEdges()
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedEdges, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestCreateParent() {
+	var (
+		edge        = Edge{RoleID: 10, ParentID: 20}
+		customError = errors.New("custom error")
+		duplicated  = &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '10-20' for key 'PRIMARY'"}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertParentQuery, customError, edge.RoleID, edge.ParentID)},
+			expectedError: db.ExecError(customError, insertParentQuery),
+		},
+		{
+			name: "edge is duplicated",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertParentQuery, duplicated, edge.RoleID, edge.ParentID)},
+			expectedError: parentAlreadyAddedError,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), insertParentQuery, nil, edge.RoleID, edge.ParentID)},
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			err = rDB.createParent(edge)
+
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestDeleteParent() {
+	var (
+		edge        = Edge{RoleID: 10, ParentID: 20}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, deleteParentQuery, customError, edge.RoleID, edge.ParentID)},
+			expectedError: db.ExecError(customError, deleteParentQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "edge does not exist",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 0), deleteParentQuery, nil, edge.RoleID, edge.ParentID)},
+			expectedError: nil,
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), deleteParentQuery, nil, edge.RoleID, edge.ParentID)},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			deleted, err := rDB.deleteParent(edge)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, deleted)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestCreatePermission() {
+	var (
+		request     = NewPermissionRequest{Name: "task:read", Description: "Read tasks"}
+		customError = errors.New("custom error")
+		duplicated  = &mysql.MySQLError{
+			Number:  1062,
+			Message: "Duplicate entry 'task:read' for key 'permission_name_uindex'",
+		}
+	)
+
+	type test struct {
+		name                 string
+		mockCalls            mockDBApplier
+		expectedError        error
+		expectedPermissionID int64
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertPermissionQuery, customError, request.Name, request.Description)},
+			expectedError:        db.ExecError(customError, insertPermissionQuery),
+			expectedPermissionID: 0,
+		},
+		{
+			name: "name is duplicated",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertPermissionQuery, duplicated, request.Name, request.Description)},
+			expectedError:        permissionWithSameNameError,
+			expectedPermissionID: 0,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(6, 1), insertPermissionQuery, nil, request.Name, request.Description)},
+			expectedError:        nil,
+			expectedPermissionID: 6,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			permissionID, err := rDB.createPermission(request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedPermissionID, permissionID)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectRolePermissions() {
+	var (
+		roleID      = int64(10)
+		now         = time.Now()
+		permissions = []Permission{
+			{ID: 1, Name: "user:read", Description: "Read users", DateCreated: now},
+			{ID: 2, Name: "user:write", Description: "Modify users", DateCreated: now},
+		}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name                string
+		mockCalls           mockDBApplier
+		expectedError       error
+		expectedPermissions []Permission
+	}
+
+	tests := []test{
+		{
+			name:                "query error",
+			mockCalls:           mockDBApplier{db.SetClientQueryMock(nil, getRolePermissionsQuery, customError, nil, roleID)},
+			expectedError:       db.QueryError(customError, getRolePermissionsQuery),
+			expectedPermissions: nil,
+		},
+		{
+			name: "rows error",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getPermissionMockRows(permissions), getRolePermissionsQuery, nil, customError, roleID)},
+			expectedError:       db.RowsError(customError, getRolePermissionsQuery),
+			expectedPermissions: nil,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getPermissionMockRows(permissions), getRolePermissionsQuery, nil, nil, roleID)},
+			expectedError:       nil,
+			expectedPermissions: permissions,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectRolePermissions(roleID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedPermissions, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestAddRolePermission() {
+	var (
+		roleID       = int64(10)
+		permissionID = int64(2)
+		customError  = errors.New("custom error")
+		duplicated   = &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '10-2' for key 'PRIMARY'"}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertRolePermissionQuery, customError, roleID, permissionID)},
+			expectedError: db.ExecError(customError, insertRolePermissionQuery),
+		},
+		{
+			name: "permission is already added",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertRolePermissionQuery, duplicated, roleID, permissionID)},
+			expectedError: permissionAlreadyAddedError,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), insertRolePermissionQuery, nil, roleID, permissionID)},
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			err = rDB.addRolePermission(roleID, permissionID)
+
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestRemoveRolePermission() {
+	var (
+		roleID       = int64(10)
+		permissionID = int64(2)
+		customError  = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, deleteRolePermissionQuery, customError, roleID, permissionID)},
+			expectedError: db.ExecError(customError, deleteRolePermissionQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "role does not hold the permission",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 0), deleteRolePermissionQuery, nil, roleID, permissionID)},
+			expectedError: nil,
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), deleteRolePermissionQuery, nil, roleID, permissionID)},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			deleted, err := rDB.removeRolePermission(roleID, permissionID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, deleted)
+		})
+	}
+}
+
+func getRoleMockRows(roles []Role) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "role_name", "type", "active", "date_created"})
+	for _, r := range roles {
+		rows.AddRow(r.ID, r.RoleName, r.Type, r.Active, r.DateCreated)
+	}
+	return rows
+}
+
+func getPermissionMockRows(permissions []Permission) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "name", "description", "date_created"})
+	for _, p := range permissions {
+		rows.AddRow(p.ID, p.Name, p.Description, p.DateCreated)
+	}
+	return rows
+}
+
+type mockDBApplier []func(m sqlmock.Sqlmock) func() error
+
+func (appliers mockDBApplier) apply(m sqlmock.Sqlmock) func() error {
+	var assertCalls []func() error
+	for i := range appliers {
+		assertCall := appliers[i](m)
+		assertCalls = append(assertCalls, assertCall)
+	}
+	return func() error {
+		for i := range assertCalls {
+			if err := assertCalls[i](); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
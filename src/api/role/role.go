@@ -0,0 +1,150 @@
+package role
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// permissionNamePattern accepts names like user:read, made of the resource and the action separated by a colon.
+var permissionNamePattern = regexp.MustCompile(`^[a-z_]+:[a-z_]+$`)
+
+type Role struct {
+	ID          int64     `json:"role_id"`
+	RoleName    string    `json:"role_name"`
+	Type        string    `json:"type"`
+	Active      bool      `json:"active"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+func (r Role) isEmptyRole() bool {
+	return r.ID == 0
+}
+
+type NewRoleRequest struct {
+	RoleName string `json:"role_name" binding:"required"`
+	Type     string `json:"type" binding:"required"`
+}
+
+func (r NewRoleRequest) toRole(roleID int64, dateCreated time.Time, active bool) Role {
+	return Role{
+		ID:          roleID,
+		RoleName:    r.RoleName,
+		Type:        r.Type,
+		Active:      active,
+		DateCreated: dateCreated,
+	}
+}
+
+type ModifyRoleRequest struct {
+	RoleName *string `json:"role_name"`
+	Type     *string `json:"type"`
+	Active   *bool   `json:"active"`
+}
+
+func (r ModifyRoleRequest) isEmpty() bool {
+	return r.RoleName == nil && r.Type == nil && r.Active == nil
+}
+
+// validate checks that given fields are not blank.
+func (r ModifyRoleRequest) validate() error {
+	fields := []string{"role_name", "type"}
+	for i, value := range []*string{r.RoleName, r.Type} {
+		if value != nil && strings.TrimSpace(*value) == "" {
+			return fmt.Errorf("%s cannot be empty", fields[i])
+		}
+	}
+	return nil
+}
+
+// changesName tells whether the request modifies the role name or its type, which must be unique together.
+func (r ModifyRoleRequest) changesName() bool {
+	return r.RoleName != nil || r.Type != nil
+}
+
+// apply returns the role with the request changes applied.
+func (r ModifyRoleRequest) apply(role Role) Role {
+	if r.RoleName != nil {
+		role.RoleName = *r.RoleName
+	}
+	if r.Type != nil {
+		role.Type = *r.Type
+	}
+	if r.Active != nil {
+		role.Active = *r.Active
+	}
+	return role
+}
+
+// RoleFilter keeps the conditions used for listing roles. Empty fields are ignored.
+type RoleFilter struct {
+	Type   string
+	Active *bool
+}
+
+// Edge tells that RoleID inherits ParentID, so granting RoleID grants ParentID as well.
+type Edge struct {
+	RoleID   int64 `json:"role_id"`
+	ParentID int64 `json:"parent_id"`
+}
+
+type AddParentRequest struct {
+	ParentID int64 `json:"parent_id" binding:"required"`
+}
+
+// createsCycle tells whether adding the edge from roleID to parentID closes a cycle, which happens when parentID
+// already inherits roleID, directly or through other roles.
+func createsCycle(edges []Edge, roleID, parentID int64) bool {
+	parents := map[int64][]int64{}
+	for _, edge := range edges {
+		parents[edge.RoleID] = append(parents[edge.RoleID], edge.ParentID)
+	}
+
+	visited := map[int64]bool{}
+	pending := []int64{parentID}
+	for len(pending) > 0 {
+		current := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if current == roleID {
+			return true
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		pending = append(pending, parents[current]...)
+	}
+
+	return false
+}
+
+// Permission allows an action, roles holding it give access to the routes requiring it.
+type Permission struct {
+	ID          int64     `json:"permission_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+func (p Permission) isEmptyPermission() bool {
+	return p.ID == 0
+}
+
+type NewPermissionRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+func (r NewPermissionRequest) validate() error {
+	if !permissionNamePattern.MatchString(r.Name) {
+		return errors.New("name must be written as resource:action using lowercase letters and underscores")
+	}
+	return nil
+}
+
+type AddPermissionRequest struct {
+	Permission string `json:"permission" binding:"required"`
+}
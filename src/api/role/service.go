@@ -0,0 +1,385 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
+)
+
+var (
+	roleNotFoundError     = errors.New("role not found")
+	roleWithSameNameError = errors.New("there is already a role with same role_name and type")
+
+	parentNotFoundError     = errors.New("parent role not found")
+	selfParentError         = errors.New("a role cannot inherit itself")
+	hierarchyCycleError     = errors.New("parent role already inherits the role, it would make a cycle")
+	parentAlreadyAddedError = errors.New("role already inherits the parent role")
+	parentLinkNotFoundError = errors.New("role does not inherit the parent role")
+
+	permissionNotFoundError     = errors.New("permission not found")
+	permissionWithSameNameError = errors.New("there is already a permission with same name")
+	permissionAlreadyAddedError = errors.New("role already holds the permission")
+	rolePermissionNotFoundError = errors.New("role does not hold the permission")
+)
+
+const (
+	auditEntity           = "role"
+	permissionAuditEntity = "permission"
+)
+
+// Service methods receiving a context use it for knowing the caller, who is recorded in the audit log.
+type Service interface {
+	getByID(int64) (Role, error)
+	createRole(context.Context, NewRoleRequest) (Role, error)
+	listRoles(RoleFilter) ([]Role, error)
+	modifyRole(context.Context, ModifyRoleRequest, int64) (Role, error)
+	deactivateRole(context.Context, int64) (Role, error)
+	listParents(int64) ([]Role, error)
+	addParent(context.Context, int64, AddParentRequest) ([]Role, error)
+	removeParent(context.Context, int64, int64) error
+	listPermissions() ([]Permission, error)
+	createPermission(context.Context, NewPermissionRequest) (Permission, error)
+	listRolePermissions(int64) ([]Permission, error)
+	addRolePermission(context.Context, int64, AddPermissionRequest) ([]Permission, error)
+	removeRolePermission(context.Context, int64, string) error
+}
+
+type roleService struct {
+	roleRepository Persister
+}
+
+func NewService(roleRepository Persister) Service {
+	return roleService{
+		roleRepository: roleRepository,
+	}
+}
+
+func (rs roleService) getByID(roleID int64) (Role, error) {
+	role, err := rs.roleRepository.selectByID(roleID)
+	if err == nil && role.isEmptyRole() {
+		return role, roleNotFoundError
+	}
+	return role, err
+}
+
+func (rs roleService) createRole(ctx context.Context, request NewRoleRequest) (Role, error) {
+	var role Role
+
+	if err := rs.roleRepository.withTransaction(func(tx Transactioner) error {
+		if err := checkNameIsFree(tx, request.RoleName, request.Type, 0); err != nil {
+			return err
+		}
+
+		roleID, err := tx.createRole(request)
+		if err != nil {
+			return err
+		}
+
+		if role, err = tx.selectByID(roleID); err != nil {
+			return err
+		}
+
+		return record(ctx, tx, audit.ActionCreate, nil, &role)
+	}); err != nil {
+		return Role{}, err
+	}
+
+	return role, nil
+}
+
+func (rs roleService) listRoles(filter RoleFilter) ([]Role, error) {
+	roles, err := rs.roleRepository.selectByFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if roles == nil {
+		roles = []Role{}
+	}
+	return roles, nil
+}
+
+func (rs roleService) modifyRole(ctx context.Context, request ModifyRoleRequest, roleID int64) (Role, error) {
+	return rs.update(ctx, audit.ActionModify, request, roleID)
+}
+
+// deactivateRole marks the role as inactive. The role is kept, so grants referencing it keep their history.
+func (rs roleService) deactivateRole(ctx context.Context, roleID int64) (Role, error) {
+	inactive := false
+	return rs.update(ctx, audit.ActionDeactivate, ModifyRoleRequest{Active: &inactive}, roleID)
+}
+
+func (rs roleService) update(ctx context.Context, action string, request ModifyRoleRequest, roleID int64) (Role, error) {
+	role, err := rs.getByID(roleID)
+	if err != nil {
+		return Role{}, err
+	}
+
+	if err = rs.roleRepository.withTransaction(func(tx Transactioner) error {
+		if request.changesName() {
+			modified := request.apply(role)
+			if err := checkNameIsFree(tx, modified.RoleName, modified.Type, role.ID); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.modifyRole(request, role); err != nil {
+			return err
+		}
+
+		before := role
+		if role, err = tx.selectByID(roleID); err != nil {
+			return err
+		}
+
+		return record(ctx, tx, action, &before, &role)
+	}); err != nil {
+		return Role{}, err
+	}
+
+	return role, nil
+}
+
+func (rs roleService) listParents(roleID int64) ([]Role, error) {
+	if _, err := rs.getByID(roleID); err != nil {
+		return nil, err
+	}
+
+	parents, err := rs.roleRepository.selectParents(roleID)
+	if err != nil {
+		return nil, err
+	}
+	if parents == nil {
+		parents = []Role{}
+	}
+	return parents, nil
+}
+
+// addParent makes roleID inherit the parent role, the edge is rejected when it would close a cycle. It returns the
+// parents of roleID after adding it.
+func (rs roleService) addParent(ctx context.Context, roleID int64, request AddParentRequest) ([]Role, error) {
+	edge := Edge{RoleID: roleID, ParentID: request.ParentID}
+	if edge.RoleID == edge.ParentID {
+		return nil, selfParentError
+	}
+
+	if _, err := rs.getByID(roleID); err != nil {
+		return nil, err
+	}
+
+	var parents []Role
+	if err := rs.roleRepository.withTransaction(func(tx Transactioner) error {
+		parent, err := tx.selectByID(edge.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent.isEmptyRole() {
+			return parentNotFoundError
+		}
+
+		edges, err := tx.selectEdges()
+		if err != nil {
+			return err
+		}
+		for _, e := range edges {
+			if e == edge {
+				return parentAlreadyAddedError
+			}
+		}
+		if createsCycle(edges, edge.RoleID, edge.ParentID) {
+			return hierarchyCycleError
+		}
+
+		if err = tx.createParent(edge); err != nil {
+			return err
+		}
+
+		if parents, err = tx.selectParents(roleID); err != nil {
+			return err
+		}
+
+		return recordEntry(ctx, tx, audit.ActionAddParent, roleID, nil, edge)
+	}); err != nil {
+		return nil, err
+	}
+
+	return parents, nil
+}
+
+func (rs roleService) removeParent(ctx context.Context, roleID, parentID int64) error {
+	if _, err := rs.getByID(roleID); err != nil {
+		return err
+	}
+
+	edge := Edge{RoleID: roleID, ParentID: parentID}
+	return rs.roleRepository.withTransaction(func(tx Transactioner) error {
+		deleted, err := tx.deleteParent(edge)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return parentLinkNotFoundError
+		}
+
+		return recordEntry(ctx, tx, audit.ActionRemoveParent, roleID, edge, nil)
+	})
+}
+
+func (rs roleService) listPermissions() ([]Permission, error) {
+	permissions, err := rs.roleRepository.selectPermissions()
+	if err != nil {
+		return nil, err
+	}
+	if permissions == nil {
+		permissions = []Permission{}
+	}
+	return permissions, nil
+}
+
+func (rs roleService) createPermission(ctx context.Context, request NewPermissionRequest) (Permission, error) {
+	var permission Permission
+
+	if err := rs.roleRepository.withTransaction(func(tx Transactioner) error {
+		permissionID, err := tx.createPermission(request)
+		if err != nil {
+			return err
+		}
+
+		if permission, err = tx.selectPermissionByID(permissionID); err != nil {
+			return err
+		}
+
+		entry, err := audit.NewEntry(
+			auth.CallerFrom(ctx), audit.ActionCreate, permissionAuditEntity, permission.ID, nil, permission)
+		if err != nil {
+			return err
+		}
+
+		return tx.record(entry)
+	}); err != nil {
+		return Permission{}, err
+	}
+
+	return permission, nil
+}
+
+func (rs roleService) listRolePermissions(roleID int64) ([]Permission, error) {
+	if _, err := rs.getByID(roleID); err != nil {
+		return nil, err
+	}
+
+	permissions, err := rs.roleRepository.selectRolePermissions(roleID)
+	if err != nil {
+		return nil, err
+	}
+	if permissions == nil {
+		permissions = []Permission{}
+	}
+	return permissions, nil
+}
+
+// addRolePermission attaches the permission to roleID. It returns the permissions of roleID after attaching it.
+func (rs roleService) addRolePermission(
+	ctx context.Context,
+	roleID int64,
+	request AddPermissionRequest,
+) ([]Permission, error) {
+	if _, err := rs.getByID(roleID); err != nil {
+		return nil, err
+	}
+
+	var permissions []Permission
+	if err := rs.roleRepository.withTransaction(func(tx Transactioner) error {
+		permission, err := selectPermission(tx, request.Permission)
+		if err != nil {
+			return err
+		}
+
+		if err = tx.addRolePermission(roleID, permission.ID); err != nil {
+			return err
+		}
+
+		if permissions, err = tx.selectRolePermissions(roleID); err != nil {
+			return err
+		}
+
+		return recordEntry(ctx, tx, audit.ActionAddPermission, roleID, nil, permission)
+	}); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (rs roleService) removeRolePermission(ctx context.Context, roleID int64, name string) error {
+	if _, err := rs.getByID(roleID); err != nil {
+		return err
+	}
+
+	return rs.roleRepository.withTransaction(func(tx Transactioner) error {
+		permission, err := selectPermission(tx, name)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := tx.removeRolePermission(roleID, permission.ID)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return rolePermissionNotFoundError
+		}
+
+		return recordEntry(ctx, tx, audit.ActionRemovePermission, roleID, permission, nil)
+	})
+}
+
+// selectPermission returns permissionNotFoundError when there is no permission with the given name.
+func selectPermission(tx Transactioner, name string) (Permission, error) {
+	permission, err := tx.selectPermissionByName(name)
+	if err != nil {
+		return Permission{}, err
+	}
+	if permission.isEmptyPermission() {
+		return Permission{}, permissionNotFoundError
+	}
+	return permission, nil
+}
+
+// checkNameIsFree returns roleWithSameNameError when another role than roleID has the same name and type.
+func checkNameIsFree(tx Transactioner, roleName, roleType string, roleID int64) error {
+	role, err := tx.selectByNameAndType(roleName, roleType)
+	if err != nil {
+		return err
+	}
+	if !role.isEmptyRole() && role.ID != roleID {
+		return roleWithSameNameError
+	}
+	return nil
+}
+
+func record(ctx context.Context, tx Transactioner, action string, before, after *Role) error {
+	var (
+		roleID                    int64
+		beforeEntity, afterEntity any
+	)
+
+	if before != nil {
+		roleID, beforeEntity = before.ID, *before
+	}
+	if after != nil {
+		roleID, afterEntity = after.ID, *after
+	}
+
+	return recordEntry(ctx, tx, action, roleID, beforeEntity, afterEntity)
+}
+
+// recordEntry records a change of the role roleID, before and after can be any entity related to it.
+func recordEntry(ctx context.Context, tx Transactioner, action string, roleID int64, before, after any) error {
+	entry, err := audit.NewEntry(auth.CallerFrom(ctx), action, auditEntity, roleID, before, after)
+	if err != nil {
+		return err
+	}
+
+	return tx.record(entry)
+}
@@ -0,0 +1,135 @@
+package role
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type serviceMock struct {
+	mock.Mock
+}
+
+func newServiceMock() *serviceMock {
+	return &serviceMock{}
+}
+
+func mockRole(args mock.Arguments, index int) Role {
+	obj := args.Get(index)
+	var s Role
+	var ok bool
+	if s, ok = obj.(Role); !ok {
+		panic(fmt.Sprintf("assert: arguments: Role(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockRoles(args mock.Arguments, index int) []Role {
+	obj := args.Get(index)
+	var s []Role
+	var ok bool
+	if s, ok = obj.([]Role); !ok {
+		panic(fmt.Sprintf("assert: arguments: Role(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockInt64(args mock.Arguments, index int) int64 {
+	obj := args.Get(index)
+	var s int64
+	var ok bool
+	if s, ok = obj.(int64); !ok {
+		panic(fmt.Sprintf("assert: arguments: Int64(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockPermission(args mock.Arguments, index int) Permission {
+	obj := args.Get(index)
+	var s Permission
+	var ok bool
+	if s, ok = obj.(Permission); !ok {
+		panic(fmt.Sprintf("assert: arguments: Permission(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockPermissions(args mock.Arguments, index int) []Permission {
+	obj := args.Get(index)
+	var s []Permission
+	var ok bool
+	if s, ok = obj.([]Permission); !ok {
+		panic(fmt.Sprintf("assert: arguments: Permission(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func (m *serviceMock) getByID(roleID int64) (Role, error) {
+	args := m.Called(roleID)
+	return mockRole(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) createRole(ctx context.Context, request NewRoleRequest) (Role, error) {
+	args := m.Called(ctx, request)
+	return mockRole(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) listRoles(filter RoleFilter) ([]Role, error) {
+	args := m.Called(filter)
+	return mockRoles(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) modifyRole(ctx context.Context, request ModifyRoleRequest, roleID int64) (Role, error) {
+	args := m.Called(ctx, request, roleID)
+	return mockRole(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) deactivateRole(ctx context.Context, roleID int64) (Role, error) {
+	args := m.Called(ctx, roleID)
+	return mockRole(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) listParents(roleID int64) ([]Role, error) {
+	args := m.Called(roleID)
+	return mockRoles(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) addParent(ctx context.Context, roleID int64, request AddParentRequest) ([]Role, error) {
+	args := m.Called(ctx, roleID, request)
+	return mockRoles(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) removeParent(ctx context.Context, roleID, parentID int64) error {
+	args := m.Called(ctx, roleID, parentID)
+	return args.Error(0)
+}
+
+func (m *serviceMock) listPermissions() ([]Permission, error) {
+	args := m.Called()
+	return mockPermissions(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) createPermission(ctx context.Context, request NewPermissionRequest) (Permission, error) {
+	args := m.Called(ctx, request)
+	return mockPermission(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) listRolePermissions(roleID int64) ([]Permission, error) {
+	args := m.Called(roleID)
+	return mockPermissions(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) addRolePermission(
+	ctx context.Context,
+	roleID int64,
+	request AddPermissionRequest,
+) ([]Permission, error) {
+	args := m.Called(ctx, roleID, request)
+	return mockPermissions(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) removeRolePermission(ctx context.Context, roleID int64, name string) error {
+	args := m.Called(ctx, roleID, name)
+	return args.Error(0)
+}
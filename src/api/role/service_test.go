@@ -0,0 +1,1260 @@
+package role
+
+import (
+	"context"
+	"errors"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
+	"maria/src/api/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const testCaller = "admin"
+
+var testCtx = auth.WithCaller(context.Background(), testCaller)
+
+type RoleServiceSuite struct {
+	suite.Suite
+}
+
+func TestRoleServiceSuite(t *testing.T) {
+	suite.Run(t, new(RoleServiceSuite))
+}
+
+func (s *RoleServiceSuite) BeforeTest(suiteName, testName string) {
+}
+
+func (s *RoleServiceSuite) AfterTest(suiteName, testName string) {
+}
+
+func (s *RoleServiceSuite) TestGetByID() {
+	var (
+		roleID      = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedRole  Role
+	}
+
+	tests := []test{
+		{
+			name:          "role not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(Role{}, nil, roleID)},
+			expectedError: roleNotFoundError,
+			expectedRole:  Role{},
+		},
+		{
+			name:          "repository return error",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(Role{}, customError, roleID)},
+			expectedError: customError,
+			expectedRole:  Role{},
+		},
+		{
+			name:          "happy case",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID)},
+			expectedError: nil,
+			expectedRole:  Role{ID: roleID},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			role, err := serv.getByID(roleID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRole, role)
+		})
+	}
+}
+
+func (s *RoleServiceSuite) TestCreateRole() {
+	var (
+		roleID      = int64(10)
+		customError = errors.New("custom error")
+		request     = NewRoleRequest{RoleName: "admin", Type: "user"}
+		newRole     = request.toRole(roleID, time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC), true)
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedRole  Role
+	}
+
+	tests := []test{
+		{
+			name: "transaction cannot be started",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(customError),
+			},
+			expectedError: customError,
+			expectedRole:  Role{},
+		},
+		{
+			name: "role name is taken",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByNameAndTypeMock(Role{ID: 1}, nil, request.RoleName, request.Type),
+			},
+			expectedError: roleWithSameNameError,
+			expectedRole:  Role{},
+		},
+		{
+			name: "create role return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByNameAndTypeMock(Role{}, nil, request.RoleName, request.Type),
+				setPersiterCreateRoleMock(0, customError, request),
+			},
+			expectedError: customError,
+			expectedRole:  Role{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByNameAndTypeMock(Role{}, nil, request.RoleName, request.Type),
+				setPersiterCreateRoleMock(roleID, nil, request),
+				setPersiterSelectByIDMock(newRole, nil, roleID),
+				setPersiterRecordMock(nil, audit.ActionCreate, nil, &newRole),
+			},
+			expectedError: nil,
+			expectedRole:  newRole,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			role, err := serv.createRole(testCtx, request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRole, role)
+		})
+	}
+}
+
+func (s *RoleServiceSuite) TestListRoles() {
+	var (
+		customError = errors.New("custom error")
+		filter      = RoleFilter{Type: "user"}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedRoles []Role
+	}
+
+	tests := []test{
+		{
+			name:          "repository return error",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByFilterMock(nil, customError, filter)},
+			expectedError: customError,
+			expectedRoles: nil,
+		},
+		{
+			name:          "there are no roles",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByFilterMock(nil, nil, filter)},
+			expectedError: nil,
+			expectedRoles: []Role{},
+		},
+		{
+			name:          "happy case",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByFilterMock([]Role{{ID: 1}}, nil, filter)},
+			expectedError: nil,
+			expectedRoles: []Role{{ID: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			roles, err := serv.listRoles(filter)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRoles, roles)
+		})
+	}
+}
+
+func (s *RoleServiceSuite) TestModifyRole() {
+	var (
+		roleID      = int64(10)
+		roleName    = "viewer"
+		customError = errors.New("custom error")
+		request     = ModifyRoleRequest{RoleName: &roleName}
+		role        = Role{ID: roleID, RoleName: "admin", Type: "user", Active: true}
+		modified    = Role{ID: roleID, RoleName: roleName, Type: "user", Active: true}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedRole  Role
+	}
+
+	tests := []test{
+		{
+			name:          "role not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(Role{}, nil, roleID)},
+			expectedError: roleNotFoundError,
+			expectedRole:  Role{},
+		},
+		{
+			name: "role name is taken by another role",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(role, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByNameAndTypeMock(Role{ID: 2}, nil, roleName, "user"),
+			},
+			expectedError: roleWithSameNameError,
+			expectedRole:  Role{},
+		},
+		{
+			name: "modify role return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(role, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByNameAndTypeMock(Role{}, nil, roleName, "user"),
+				setPersiterModifyRoleMock(false, customError, request, role),
+			},
+			expectedError: customError,
+			expectedRole:  Role{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(role, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByNameAndTypeMock(Role{}, nil, roleName, "user"),
+				setPersiterModifyRoleMock(true, nil, request, role),
+				setPersiterSelectByIDMock(modified, nil, roleID),
+				setPersiterRecordMock(nil, audit.ActionModify, &role, &modified),
+			},
+			expectedError: nil,
+			expectedRole:  modified,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			role, err := serv.modifyRole(testCtx, request, roleID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRole, role)
+		})
+	}
+}
+
+func (s *RoleServiceSuite) TestDeactivateRole() {
+	var (
+		roleID      = int64(10)
+		inactive    = false
+		role        = Role{ID: roleID, RoleName: "admin", Type: "user", Active: true}
+		deactivated = Role{ID: roleID, RoleName: "admin", Type: "user", Active: false}
+	)
+
+	serv := newServiceForTest()
+	assertsCalls, err := mockPersisterApplier{
+		setPersiterSelectByIDMock(role, nil, roleID),
+		setPersiterWithTransactionMock(nil),
+		setPersiterModifyRoleMock(true, nil, ModifyRoleRequest{Active: &inactive}, role),
+		setPersiterSelectByIDMock(deactivated, nil, roleID),
+		setPersiterRecordMock(nil, audit.ActionDeactivate, &role, &deactivated),
+	}.apply(&serv)
+	if err != nil {
+		assert.Fail(s.T(), err.Error())
+		return
+	}
+	defer assertsCalls(s.T())
+
+	result, err := serv.deactivateRole(testCtx, roleID)
+
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), deactivated, result)
+}
+
+func (s *RoleServiceSuite) TestListParents() {
+	var (
+		roleID  = int64(10)
+		parents = []Role{{ID: 20, RoleName: "viewer", Type: "client"}}
+	)
+
+	type test struct {
+		name            string
+		mockCalls       mockPersisterApplier
+		expectedError   error
+		expectedParents []Role
+	}
+
+	tests := []test{
+		{
+			name:            "role not found",
+			mockCalls:       mockPersisterApplier{setPersiterSelectByIDMock(Role{}, nil, roleID)},
+			expectedError:   roleNotFoundError,
+			expectedParents: nil,
+		},
+		{
+			name: "role without parents",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID),
+				setPersiterSelectParentsMock(nil, nil, roleID),
+			},
+			expectedError:   nil,
+			expectedParents: []Role{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID),
+				setPersiterSelectParentsMock(parents, nil, roleID),
+			},
+			expectedError:   nil,
+			expectedParents: parents,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			parents, err := serv.listParents(roleID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedParents, parents)
+		})
+	}
+}
+
+func (s *RoleServiceSuite) TestAddParent() {
+	var (
+		roleID      = int64(10)
+		request     = AddParentRequest{ParentID: 20}
+		edge        = Edge{RoleID: roleID, ParentID: request.ParentID}
+		role        = Role{ID: roleID, RoleName: "admin", Type: "client"}
+		parent      = Role{ID: request.ParentID, RoleName: "viewer", Type: "client"}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name            string
+		request         AddParentRequest
+		mockCalls       mockPersisterApplier
+		expectedError   error
+		expectedParents []Role
+	}
+
+	tests := []test{
+		{
+			name:            "role cannot inherit itself",
+			request:         AddParentRequest{ParentID: roleID},
+			mockCalls:       mockPersisterApplier{},
+			expectedError:   selfParentError,
+			expectedParents: nil,
+		},
+		{
+			name:            "role not found",
+			request:         request,
+			mockCalls:       mockPersisterApplier{setPersiterSelectByIDMock(Role{}, nil, roleID)},
+			expectedError:   roleNotFoundError,
+			expectedParents: nil,
+		},
+		{
+			name:    "parent not found",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(role, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDMock(Role{}, nil, request.ParentID),
+			},
+			expectedError:   parentNotFoundError,
+			expectedParents: nil,
+		},
+		{
+			name:    "parent already added",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(role, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDMock(parent, nil, request.ParentID),
+				setPersiterSelectEdgesMock([]Edge{edge}, nil),
+			},
+			expectedError:   parentAlreadyAddedError,
+			expectedParents: nil,
+		},
+		{
+			name:    "parent inherits the role",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(role, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDMock(parent, nil, request.ParentID),
+				setPersiterSelectEdgesMock([]Edge{{RoleID: 20, ParentID: 30}, {RoleID: 30, ParentID: roleID}}, nil),
+			},
+			expectedError:   hierarchyCycleError,
+			expectedParents: nil,
+		},
+		{
+			name:    "create parent error",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(role, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDMock(parent, nil, request.ParentID),
+				setPersiterSelectEdgesMock([]Edge{}, nil),
+				setPersiterCreateParentMock(customError, edge),
+			},
+			expectedError:   customError,
+			expectedParents: nil,
+		},
+		{
+			name:    "happy case",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(role, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByIDMock(parent, nil, request.ParentID),
+				setPersiterSelectEdgesMock([]Edge{{RoleID: 30, ParentID: roleID}}, nil),
+				setPersiterCreateParentMock(nil, edge),
+				setPersiterSelectParentsMock([]Role{parent}, nil, roleID),
+				setPersiterRecordEntryMock(nil, audit.ActionAddParent, roleID, nil, edge),
+			},
+			expectedError:   nil,
+			expectedParents: []Role{parent},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			parents, err := serv.addParent(testCtx, roleID, test.request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedParents, parents)
+		})
+	}
+}
+
+func (s *RoleServiceSuite) TestRemoveParent() {
+	var (
+		roleID   = int64(10)
+		parentID = int64(20)
+		edge     = Edge{RoleID: roleID, ParentID: parentID}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+	}
+
+	tests := []test{
+		{
+			name:          "role not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(Role{}, nil, roleID)},
+			expectedError: roleNotFoundError,
+		},
+		{
+			name: "role does not inherit parent",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterDeleteParentMock(false, nil, edge),
+			},
+			expectedError: parentLinkNotFoundError,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterDeleteParentMock(true, nil, edge),
+				setPersiterRecordEntryMock(nil, audit.ActionRemoveParent, roleID, edge, nil),
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			err := serv.removeParent(testCtx, roleID, parentID)
+
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+}
+
+func (s *RoleServiceSuite) TestCreatePermission() {
+	var (
+		request     = NewPermissionRequest{Name: "task:read", Description: "Read tasks"}
+		permission  = Permission{ID: 6, Name: request.Name, Description: request.Description}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name               string
+		mockCalls          mockPersisterApplier
+		expectedError      error
+		expectedPermission Permission
+	}
+
+	tests := []test{
+		{
+			name: "permission with same name",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterCreatePermissionMock(0, permissionWithSameNameError, request),
+			},
+			expectedError:      permissionWithSameNameError,
+			expectedPermission: Permission{},
+		},
+		{
+			name: "record error",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterCreatePermissionMock(permission.ID, nil, request),
+				setPersiterSelectPermissionByIDMock(permission, nil, permission.ID),
+				setPersiterRecordPermissionMock(customError, permission),
+			},
+			expectedError:      customError,
+			expectedPermission: Permission{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterCreatePermissionMock(permission.ID, nil, request),
+				setPersiterSelectPermissionByIDMock(permission, nil, permission.ID),
+				setPersiterRecordPermissionMock(nil, permission),
+			},
+			expectedError:      nil,
+			expectedPermission: permission,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			permission, err := serv.createPermission(testCtx, request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedPermission, permission)
+		})
+	}
+}
+
+func (s *RoleServiceSuite) TestListRolePermissions() {
+	var (
+		roleID      = int64(10)
+		permissions = []Permission{{ID: 1, Name: "user:read"}}
+	)
+
+	type test struct {
+		name                string
+		mockCalls           mockPersisterApplier
+		expectedError       error
+		expectedPermissions []Permission
+	}
+
+	tests := []test{
+		{
+			name:                "role not found",
+			mockCalls:           mockPersisterApplier{setPersiterSelectByIDMock(Role{}, nil, roleID)},
+			expectedError:       roleNotFoundError,
+			expectedPermissions: nil,
+		},
+		{
+			name: "role without permissions",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID),
+				setPersiterSelectRolePermissionsMock(nil, nil, roleID),
+			},
+			expectedError:       nil,
+			expectedPermissions: []Permission{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID),
+				setPersiterSelectRolePermissionsMock(permissions, nil, roleID),
+			},
+			expectedError:       nil,
+			expectedPermissions: permissions,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			permissions, err := serv.listRolePermissions(roleID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedPermissions, permissions)
+		})
+	}
+}
+
+func (s *RoleServiceSuite) TestAddRolePermission() {
+	var (
+		roleID      = int64(10)
+		request     = AddPermissionRequest{Permission: "user:read"}
+		permission  = Permission{ID: 1, Name: request.Permission}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name                string
+		mockCalls           mockPersisterApplier
+		expectedError       error
+		expectedPermissions []Permission
+	}
+
+	tests := []test{
+		{
+			name:                "role not found",
+			mockCalls:           mockPersisterApplier{setPersiterSelectByIDMock(Role{}, nil, roleID)},
+			expectedError:       roleNotFoundError,
+			expectedPermissions: nil,
+		},
+		{
+			name: "permission not found",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectPermissionByNameMock(Permission{}, nil, request.Permission),
+			},
+			expectedError:       permissionNotFoundError,
+			expectedPermissions: nil,
+		},
+		{
+			name: "permission already added",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectPermissionByNameMock(permission, nil, request.Permission),
+				setPersiterAddRolePermissionMock(permissionAlreadyAddedError, roleID, permission.ID),
+			},
+			expectedError:       permissionAlreadyAddedError,
+			expectedPermissions: nil,
+		},
+		{
+			name: "select permissions error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectPermissionByNameMock(permission, nil, request.Permission),
+				setPersiterAddRolePermissionMock(nil, roleID, permission.ID),
+				setPersiterSelectRolePermissionsMock(nil, customError, roleID),
+			},
+			expectedError:       customError,
+			expectedPermissions: nil,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectPermissionByNameMock(permission, nil, request.Permission),
+				setPersiterAddRolePermissionMock(nil, roleID, permission.ID),
+				setPersiterSelectRolePermissionsMock([]Permission{permission}, nil, roleID),
+				setPersiterRecordEntryMock(nil, audit.ActionAddPermission, roleID, nil, permission),
+			},
+			expectedError:       nil,
+			expectedPermissions: []Permission{permission},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			permissions, err := serv.addRolePermission(testCtx, roleID, request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedPermissions, permissions)
+		})
+	}
+}
+
+func (s *RoleServiceSuite) TestRemoveRolePermission() {
+	var (
+		roleID     = int64(10)
+		permission = Permission{ID: 1, Name: "user:read"}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+	}
+
+	tests := []test{
+		{
+			name:          "role not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(Role{}, nil, roleID)},
+			expectedError: roleNotFoundError,
+		},
+		{
+			name: "permission not found",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectPermissionByNameMock(Permission{}, nil, permission.Name),
+			},
+			expectedError: permissionNotFoundError,
+		},
+		{
+			name: "role does not hold the permission",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectPermissionByNameMock(permission, nil, permission.Name),
+				setPersiterRemoveRolePermissionMock(false, nil, roleID, permission.ID),
+			},
+			expectedError: rolePermissionNotFoundError,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(Role{ID: roleID}, nil, roleID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectPermissionByNameMock(permission, nil, permission.Name),
+				setPersiterRemoveRolePermissionMock(true, nil, roleID, permission.ID),
+				setPersiterRecordEntryMock(nil, audit.ActionRemovePermission, roleID, permission, nil),
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			err := serv.removeRolePermission(testCtx, roleID, permission.Name)
+
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+}
+
+func (s *RoleServiceSuite) TestCreatesCycle() {
+	edges := []Edge{{RoleID: 1, ParentID: 2}, {RoleID: 2, ParentID: 3}, {RoleID: 4, ParentID: 3}}
+
+	type test struct {
+		name     string
+		roleID   int64
+		parentID int64
+		expected bool
+	}
+
+	tests := []test{
+		{name: "unrelated roles", roleID: 4, parentID: 2, expected: false},
+		{name: "parent is already inherited", roleID: 1, parentID: 3, expected: false},
+		{name: "parent inherits the role directly", roleID: 2, parentID: 1, expected: true},
+		{name: "parent inherits the role through other roles", roleID: 3, parentID: 1, expected: true},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, createsCycle(edges, test.roleID, test.parentID))
+		})
+	}
+}
+
+func newServiceForTest() roleService {
+	return NewService(newDBMock()).(roleService)
+}
+
+type mockPersisterApplier []func(rs *roleService) (func(t *testing.T), error)
+
+func (appliers mockPersisterApplier) apply(rs *roleService) (func(t *testing.T), error) {
+	var assertCalls []func(t *testing.T)
+	for i := range appliers {
+		if assertCall, err := appliers[i](rs); err != nil {
+			return func(t *testing.T) {}, err
+		} else {
+			assertCalls = append(assertCalls, assertCall)
+		}
+	}
+	return func(t *testing.T) {
+		for i := range assertCalls {
+			assertCalls[i](t)
+		}
+	}, nil
+}
+
+func setPersiterWithTransactionMock(
+	errorResponse error,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.withTransaction), mock.Anything).
+			Return(errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByIDMock(
+	roleResponse Role,
+	errorResponse error,
+	roleID int64,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectByID), roleID).
+			Return(roleResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByNameAndTypeMock(
+	roleResponse Role,
+	errorResponse error,
+	roleName, roleType string,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectByNameAndType), roleName, roleType).
+			Return(roleResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByFilterMock(
+	rolesResponse []Role,
+	errorResponse error,
+	filter RoleFilter,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectByFilter), filter).
+			Return(rolesResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterCreateRoleMock(
+	roleID int64,
+	errorResponse error,
+	request NewRoleRequest,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.createRole), request).
+			Return(roleID, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterModifyRoleMock(
+	updated bool,
+	errorResponse error,
+	request ModifyRoleRequest,
+	role Role,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.modifyRole), request, role).
+			Return(updated, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterRecordMock(
+	err error,
+	action string,
+	before, after *Role,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+
+		var (
+			roleID                    int64
+			beforeEntity, afterEntity any
+		)
+		if before != nil {
+			roleID, beforeEntity = before.ID, *before
+		}
+		if after != nil {
+			roleID, afterEntity = after.ID, *after
+		}
+		entry, entryErr := audit.NewEntry(testCaller, action, auditEntity, roleID, beforeEntity, afterEntity)
+		if entryErr != nil {
+			return nil, entryErr
+		}
+
+		r.On(util.GetFunctionName(r.record), entry).
+			Return(err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectParentsMock(
+	parentsResponse []Role,
+	errorResponse error,
+	roleID int64,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectParents), roleID).
+			Return(parentsResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectEdgesMock(
+	edgesResponse []Edge,
+	errorResponse error,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectEdges)).
+			Return(edgesResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterCreateParentMock(
+	errorResponse error,
+	edge Edge,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.createParent), edge).
+			Return(errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterDeleteParentMock(
+	deleted bool,
+	errorResponse error,
+	edge Edge,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.deleteParent), edge).
+			Return(deleted, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterRecordEntryMock(
+	err error,
+	action string,
+	roleID int64,
+	before, after any,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+
+		entry, entryErr := audit.NewEntry(testCaller, action, auditEntity, roleID, before, after)
+		if entryErr != nil {
+			return nil, entryErr
+		}
+
+		r.On(util.GetFunctionName(r.record), entry).
+			Return(err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectPermissionByIDMock(
+	permissionResponse Permission,
+	errorResponse error,
+	permissionID int64,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectPermissionByID), permissionID).
+			Return(permissionResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectPermissionByNameMock(
+	permissionResponse Permission,
+	errorResponse error,
+	name string,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectPermissionByName), name).
+			Return(permissionResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterCreatePermissionMock(
+	permissionID int64,
+	errorResponse error,
+	request NewPermissionRequest,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.createPermission), request).
+			Return(permissionID, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectRolePermissionsMock(
+	permissionsResponse []Permission,
+	errorResponse error,
+	roleID int64,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectRolePermissions), roleID).
+			Return(permissionsResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterAddRolePermissionMock(
+	errorResponse error,
+	roleID, permissionID int64,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.addRolePermission), roleID, permissionID).
+			Return(errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterRemoveRolePermissionMock(
+	deleted bool,
+	errorResponse error,
+	roleID, permissionID int64,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.removeRolePermission), roleID, permissionID).
+			Return(deleted, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterRecordPermissionMock(
+	err error,
+	permission Permission,
+) func(rs *roleService) (func(t *testing.T), error) {
+	return func(rs *roleService) (func(t *testing.T), error) {
+		r, ok := rs.roleRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+
+		entry, entryErr := audit.NewEntry(
+			testCaller, audit.ActionCreate, permissionAuditEntity, permission.ID, nil, permission)
+		if entryErr != nil {
+			return nil, entryErr
+		}
+
+		r.On(util.GetFunctionName(r.record), entry).
+			Return(err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
@@ -0,0 +1,207 @@
+package sweeper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maria/src/api/audit"
+	"maria/src/api/db"
+	"maria/src/api/event"
+)
+
+const (
+	// Grants are selected once by expiry, when no expiry event is recorded since their date_expired. An event recorded
+	// before it belongs to a previous expiry, so a grant extended after expiring is selected again when it expires.
+	getExpiredRoleGrantsQuery = `SELECT ur.id, ur.user_id, ur.role_id, ur.date_expired FROM user_role ur ` +
+		`LEFT JOIN domain_event e ON e.type = '` + event.TypeRoleExpired + `' AND e.entity = 'user_role' ` +
+		`AND e.entity_id = ur.id AND e.date_created >= ur.date_expired ` +
+		`WHERE ur.date_expired <= NOW() AND e.id IS NULL ORDER BY ur.date_expired, ur.id LIMIT ?`
+	getExpiredClientLinksQuery = `SELECT uc.id, uc.user_id, uc.client_id, uc.date_expired FROM user_client uc ` +
+		`LEFT JOIN domain_event e ON e.type = '` + event.TypeClientAccessExpired + `' AND e.entity = 'user_client' ` +
+		`AND e.entity_id = uc.id AND e.date_created >= uc.date_expired ` +
+		`WHERE uc.date_expired <= NOW() AND e.id IS NULL ORDER BY uc.date_expired, uc.id LIMIT ?`
+	getLockQuery     = `SELECT GET_LOCK(?, 0)`
+	releaseLockQuery = `SELECT RELEASE_LOCK(?)`
+)
+
+type Querier interface {
+	selectExpiredRoleGrants(limit int) ([]ExpiredRoleGrant, error)
+	selectExpiredClientLinks(limit int) ([]ExpiredClientLink, error)
+	recordEvent(event.Event) error
+	record(audit.Entry) error
+}
+
+type Persister interface {
+	Querier
+	withTransaction(fn func(tx Transactioner) error) error
+	// withLock runs fn only when the named lock is acquired, it returns false when another session holds it.
+	withLock(ctx context.Context, name string, fn func() error) (bool, error)
+}
+
+type Transactioner interface {
+	Querier
+	commit() error
+	rollback() error
+}
+
+func NewRelationalDB(client db.Client) Persister {
+	return &relationalDB{
+		client: client,
+	}
+}
+
+type relationalDB struct {
+	client db.Client
+}
+
+func (r *relationalDB) selectExpiredRoleGrants(limit int) ([]ExpiredRoleGrant, error) {
+	rows, err := r.client.Query(getExpiredRoleGrantsQuery, limit)
+	if err != nil {
+		return nil, db.QueryError(err, getExpiredRoleGrantsQuery)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	var grants []ExpiredRoleGrant
+	for rows.Next() {
+		var grant ExpiredRoleGrant
+		if err = rows.Scan(&grant.ID, &grant.UserID, &grant.RoleID, &grant.DateExpired); err != nil {
+			return nil, db.ScanError(err, getExpiredRoleGrantsQuery)
+		}
+		grants = append(grants, grant)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, getExpiredRoleGrantsQuery)
+	}
+
+	return grants, nil
+}
+
+func (r *relationalDB) selectExpiredClientLinks(limit int) ([]ExpiredClientLink, error) {
+	rows, err := r.client.Query(getExpiredClientLinksQuery, limit)
+	if err != nil {
+		return nil, db.QueryError(err, getExpiredClientLinksQuery)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	var links []ExpiredClientLink
+	for rows.Next() {
+		var link ExpiredClientLink
+		if err = rows.Scan(&link.ID, &link.UserID, &link.ClientID, &link.DateExpired); err != nil {
+			return nil, db.ScanError(err, getExpiredClientLinksQuery)
+		}
+		links = append(links, link)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, getExpiredClientLinksQuery)
+	}
+
+	return links, nil
+}
+
+func (r *relationalDB) recordEvent(e event.Event) error {
+	return event.NewRelationalDB(r.client).Record(e)
+}
+
+func (r *relationalDB) record(entry audit.Entry) error {
+	return audit.NewRelationalDB(r.client).Record(entry)
+}
+
+// withLock holds the lock in a single connection while fn runs, GET_LOCK does not wait so replicas which do not
+// acquire it skip the work instead of repeating it.
+func (r *relationalDB) withLock(ctx context.Context, name string, fn func() error) (bool, error) {
+	client, ok := r.client.(*sql.DB)
+	if !ok {
+		return false, errors.New("persister cannot get a connection from client")
+	}
+
+	conn, err := client.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("persister cannot get a connection due to: %w", err)
+	}
+
+	defer func() {
+		if err := conn.Close(); err != nil {
+			println(fmt.Sprintf("error closing lock connection cause: %s", err.Error()))
+		}
+	}()
+
+	var acquired sql.NullInt64
+	if err = conn.QueryRowContext(ctx, getLockQuery, name).Scan(&acquired); err != nil {
+		return false, db.QueryError(err, getLockQuery)
+	}
+	if acquired.Int64 != 1 {
+		return false, nil
+	}
+
+	defer func() {
+		// the lock is released even when ctx is cancelled, otherwise it is kept until the connection is closed
+		var released sql.NullInt64
+		if err := conn.QueryRowContext(context.Background(), releaseLockQuery, name).Scan(&released); err != nil {
+			println(fmt.Sprintf("error releasing sweeper lock cause: %s", err.Error()))
+		}
+	}()
+
+	return true, fn()
+}
+
+func (r *relationalDB) getTransactioner() (Transactioner, error) {
+	client, ok := r.client.(*sql.DB)
+	if !ok {
+		return nil, errors.New("persister cannot generate transactional db")
+	}
+
+	tx, err := client.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("persister cannot generate transactional due to: %w", err)
+	}
+
+	return &transactionalDB{relationalDB: relationalDB{client: tx}, tx: tx}, nil
+}
+
+func (r *relationalDB) withTransaction(fn func(tx Transactioner) error) error {
+	tx, err := r.getTransactioner()
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		if err := tx.rollback(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	return tx.commit()
+}
+
+type transactionalDB struct {
+	relationalDB
+	tx *sql.Tx
+}
+
+func (tx *transactionalDB) commit() error {
+	if err := tx.tx.Commit(); err != nil {
+		return db.CommitError(err)
+	}
+	return nil
+}
+
+func (tx *transactionalDB) rollback() error {
+	if err := tx.tx.Rollback(); err != nil {
+		return db.RollbackError(err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package sweeper
+
+import (
+	"context"
+	"fmt"
+	"maria/src/api/audit"
+	"maria/src/api/event"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type dbMock struct {
+	mock.Mock
+}
+
+func newDBMock() *dbMock {
+	return &dbMock{}
+}
+
+func (m *dbMock) selectExpiredRoleGrants(limit int) ([]ExpiredRoleGrant, error) {
+	args := m.Called(limit)
+	return mockExpiredRoleGrants(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectExpiredClientLinks(limit int) ([]ExpiredClientLink, error) {
+	args := m.Called(limit)
+	return mockExpiredClientLinks(args, 0), args.Error(1)
+}
+
+func (m *dbMock) recordEvent(e event.Event) error {
+	args := m.Called(e)
+	return args.Error(0)
+}
+
+func (m *dbMock) record(entry audit.Entry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *dbMock) withTransaction(fn func(tx Transactioner) error) error {
+	args := m.Called(fn)
+
+	if err := args.Error(0); err != nil {
+		return err
+	}
+
+	return fn(m)
+}
+
+func (m *dbMock) withLock(ctx context.Context, name string, fn func() error) (bool, error) {
+	args := m.Called(ctx, name, fn)
+
+	if acquired, err := args.Bool(0), args.Error(1); !acquired || err != nil {
+		return acquired, err
+	}
+
+	return true, fn()
+}
+
+func (m *dbMock) commit() error {
+	args := m.Called()
+	return args.Error(1)
+}
+
+func (m *dbMock) rollback() error {
+	args := m.Called()
+	return args.Error(1)
+}
+
+func mockExpiredRoleGrants(args mock.Arguments, index int) []ExpiredRoleGrant {
+	obj := args.Get(index)
+	if obj == nil {
+		return nil
+	}
+	var s []ExpiredRoleGrant
+	var ok bool
+	if s, ok = obj.([]ExpiredRoleGrant); !ok {
+		panic(fmt.Sprintf("assert: arguments: ExpiredRoleGrant(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockExpiredClientLinks(args mock.Arguments, index int) []ExpiredClientLink {
+	obj := args.Get(index)
+	if obj == nil {
+		return nil
+	}
+	var s []ExpiredClientLink
+	var ok bool
+	if s, ok = obj.([]ExpiredClientLink); !ok {
+		panic(fmt.Sprintf("assert: arguments: ExpiredClientLink(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
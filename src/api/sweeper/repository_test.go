@@ -0,0 +1,236 @@
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"maria/src/api/db"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type relationalDBSuite struct {
+	suite.Suite
+}
+
+func TestRelationalDBSuite(t *testing.T) {
+	suite.Run(t, new(relationalDBSuite))
+}
+
+func (s *relationalDBSuite) TestSelectExpiredRoleGrants() {
+	var (
+		grants      = []ExpiredRoleGrant{{ID: 1, UserID: 10, RoleID: 2, DateExpired: time.Now()}}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		mockCalls      mockDBApplier
+		expectedError  error
+		expectedGrants []ExpiredRoleGrant
+	}
+
+	tests := []test{
+		{
+			name: "query error",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				nil, getExpiredRoleGrantsQuery, customError, nil, defaultBatchSize)},
+			expectedError:  db.QueryError(customError, getExpiredRoleGrantsQuery),
+			expectedGrants: nil,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getExpiredMockRows("role_id", grants[0].ID, grants[0].UserID, grants[0].RoleID, grants[0].DateExpired),
+				getExpiredRoleGrantsQuery, nil, nil, defaultBatchSize)},
+			expectedError:  nil,
+			expectedGrants: grants,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			result, err := NewRelationalDB(client).selectExpiredRoleGrants(defaultBatchSize)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedGrants, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectExpiredClientLinks() {
+	var (
+		links       = []ExpiredClientLink{{ID: 3, UserID: 10, ClientID: 4, DateExpired: time.Now()}}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedLinks []ExpiredClientLink
+	}
+
+	tests := []test{
+		{
+			name: "rows error",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getExpiredMockRows("client_id", links[0].ID, links[0].UserID, links[0].ClientID, links[0].DateExpired),
+				getExpiredClientLinksQuery, nil, customError, defaultBatchSize)},
+			expectedError: db.RowsError(customError, getExpiredClientLinksQuery),
+			expectedLinks: nil,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getExpiredMockRows("client_id", links[0].ID, links[0].UserID, links[0].ClientID, links[0].DateExpired),
+				getExpiredClientLinksQuery, nil, nil, defaultBatchSize)},
+			expectedError: nil,
+			expectedLinks: links,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			result, err := NewRelationalDB(client).selectExpiredClientLinks(defaultBatchSize)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedLinks, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestWithLock() {
+	customError := errors.New("custom error")
+
+	type test struct {
+		name             string
+		mockCalls        func(m sqlmock.Sqlmock)
+		fnError          error
+		expectedAcquired bool
+		expectedError    error
+		expectedFnCalled bool
+	}
+
+	tests := []test{
+		{
+			name: "lock is held by another session",
+			mockCalls: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(regexp.QuoteMeta(getLockQuery)).WithArgs(lockName).
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(0))
+			},
+			expectedAcquired: false,
+			expectedError:    nil,
+			expectedFnCalled: false,
+		},
+		{
+			name: "lock query error",
+			mockCalls: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(regexp.QuoteMeta(getLockQuery)).WithArgs(lockName).WillReturnError(customError)
+			},
+			expectedAcquired: false,
+			expectedError:    db.QueryError(customError, getLockQuery),
+			expectedFnCalled: false,
+		},
+		{
+			name: "lock is released after fn fails",
+			mockCalls: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(regexp.QuoteMeta(getLockQuery)).WithArgs(lockName).
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+				m.ExpectQuery(regexp.QuoteMeta(releaseLockQuery)).WithArgs(lockName).
+					WillReturnRows(sqlmock.NewRows([]string{"release"}).AddRow(1))
+			},
+			fnError:          customError,
+			expectedAcquired: true,
+			expectedError:    customError,
+			expectedFnCalled: true,
+		},
+		{
+			name: "happy case",
+			mockCalls: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(regexp.QuoteMeta(getLockQuery)).WithArgs(lockName).
+					WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+				m.ExpectQuery(regexp.QuoteMeta(releaseLockQuery)).WithArgs(lockName).
+					WillReturnRows(sqlmock.NewRows([]string{"release"}).AddRow(1))
+			},
+			expectedAcquired: true,
+			expectedError:    nil,
+			expectedFnCalled: true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+			test.mockCalls(mock)
+
+			called := false
+			acquired, err := NewRelationalDB(client).withLock(context.Background(), lockName, func() error {
+				called = true
+				return test.fnError
+			})
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedAcquired, acquired)
+			assert.Equal(t, test.expectedFnCalled, called)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func getExpiredMockRows(targetColumn string, id, userID, targetID int64, dateExpired time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", targetColumn, "date_expired"}).
+		AddRow(id, userID, targetID, dateExpired)
+}
+
+type mockDBApplier []func(m sqlmock.Sqlmock) func() error
+
+func (appliers mockDBApplier) apply(m sqlmock.Sqlmock) func() error {
+	var assertCalls []func() error
+	for i := range appliers {
+		assertCall := appliers[i](m)
+		assertCalls = append(assertCalls, assertCall)
+	}
+	return func() error {
+		for i := range assertCalls {
+			if err := assertCalls[i](); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
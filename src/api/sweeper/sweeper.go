@@ -0,0 +1,185 @@
+package sweeper
+
+import (
+	"context"
+	"fmt"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
+	"maria/src/api/event"
+	"time"
+)
+
+const (
+	lockName         = "maria_expiry_sweeper"
+	defaultBatchSize = 100
+
+	// expirations are recorded in the history of the user who loses the grant
+	auditEntity      = "user"
+	roleGrantEntity  = "user_role"
+	clientLinkEntity = "user_client"
+)
+
+// ExpiredRoleGrant is a grant whose date_expired is reached. Expired is false until the sweeper processes it.
+type ExpiredRoleGrant struct {
+	ID          int64     `json:"grant_id"`
+	UserID      int64     `json:"user_id"`
+	RoleID      int64     `json:"role_id"`
+	DateExpired time.Time `json:"date_expired"`
+	Expired     bool      `json:"expired"`
+}
+
+func (g ExpiredRoleGrant) asExpired() ExpiredRoleGrant {
+	g.Expired = true
+	return g
+}
+
+// ExpiredClientLink is a client link whose date_expired is reached. Expired is false until the sweeper processes it.
+type ExpiredClientLink struct {
+	ID          int64     `json:"link_id"`
+	UserID      int64     `json:"user_id"`
+	ClientID    int64     `json:"client_id"`
+	DateExpired time.Time `json:"date_expired"`
+	Expired     bool      `json:"expired"`
+}
+
+func (l ExpiredClientLink) asExpired() ExpiredClientLink {
+	l.Expired = true
+	return l
+}
+
+// Sweeper acts on user_role and user_client rows once their date_expired is reached. Every expiration is recorded
+// as a domain event and an audit entry, and the event is emitted after being committed. Revoked grants are swept
+// as well, since revoking a grant expires it.
+type Sweeper struct {
+	repository Persister
+	emitter    event.Emitter
+	interval   time.Duration
+	batchSize  int
+	now        func() time.Time
+}
+
+func NewSweeper(repository Persister, emitter event.Emitter, interval time.Duration) Sweeper {
+	return Sweeper{
+		repository: repository,
+		emitter:    emitter,
+		interval:   interval,
+		batchSize:  defaultBatchSize,
+		now:        time.Now,
+	}
+}
+
+// Run sweeps right away and then every interval, until ctx is cancelled.
+func (s Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Sweep(ctx); err != nil {
+			println(fmt.Sprintf("error sweeping expired grants cause: %s", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep processes expired grants batch by batch while this instance is the leader. When another instance holds
+// the lock nothing is done, it is processing them.
+func (s Sweeper) Sweep(ctx context.Context) error {
+	_, err := s.repository.withLock(ctx, lockName, func() error {
+		for ctx.Err() == nil {
+			processed, err := s.sweepBatch()
+			if err != nil {
+				return err
+			}
+			if processed < s.batchSize {
+				return nil
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// sweepBatch processes up to batchSize grants of each kind in a transaction. It returns the greatest number of
+// grants processed of a kind, so a full batch means that there can be more of them.
+func (s Sweeper) sweepBatch() (int, error) {
+	var (
+		events    []event.Event
+		processed int
+	)
+
+	if err := s.repository.withTransaction(func(tx Transactioner) error {
+		events = nil
+
+		grants, err := tx.selectExpiredRoleGrants(s.batchSize)
+		if err != nil {
+			return err
+		}
+		for _, grant := range grants {
+			e, err := expire(tx, event.TypeRoleExpired, roleGrantEntity, grant.ID, grant.UserID, grant, grant.asExpired())
+			if err != nil {
+				return err
+			}
+			events = append(events, e)
+		}
+
+		links, err := tx.selectExpiredClientLinks(s.batchSize)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			e, err := expire(tx, event.TypeClientAccessExpired, clientLinkEntity, link.ID, link.UserID, link, link.asExpired())
+			if err != nil {
+				return err
+			}
+			events = append(events, e)
+		}
+
+		processed = len(grants)
+		if len(links) > processed {
+			processed = len(links)
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	// events are already recorded, a failing emitter does not make them to be processed again
+	now := s.now()
+	for _, e := range events {
+		e.DateCreated = now
+		if err := s.emitter.Emit(e); err != nil {
+			println(fmt.Sprintf("error emitting %s event of %s %d cause: %s", e.Type, e.Entity, e.EntityID, err.Error()))
+		}
+	}
+
+	return processed, nil
+}
+
+// expire records the event and the audit entry of an expired grant. The grant is not deleted, so the entry keeps
+// every field of the grant before and after expiring, which identifies it, and the event carries the expired grant.
+func expire(tx Transactioner, eventType, entity string, entityID, userID int64, before, after any) (event.Event, error) {
+	e, err := event.NewEvent(eventType, entity, entityID, after)
+	if err != nil {
+		return event.Event{}, err
+	}
+
+	if err = tx.recordEvent(e); err != nil {
+		return event.Event{}, err
+	}
+
+	entry, err := audit.NewSnapshotEntry(auth.SystemCaller, audit.ActionExpire, auditEntity, userID, before, after)
+	if err != nil {
+		return event.Event{}, err
+	}
+
+	if err = tx.record(entry); err != nil {
+		return event.Event{}, err
+	}
+
+	return e, nil
+}
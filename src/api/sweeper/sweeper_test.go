@@ -0,0 +1,428 @@
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
+	"maria/src/api/event"
+	"maria/src/api/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var testNow = time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+
+type SweeperSuite struct {
+	suite.Suite
+}
+
+func TestSweeperSuite(t *testing.T) {
+	suite.Run(t, new(SweeperSuite))
+}
+
+func (s *SweeperSuite) TestSweep() {
+	var (
+		grant = ExpiredRoleGrant{ID: 1, UserID: 10, RoleID: 2, DateExpired: testNow.Add(-time.Minute)}
+		link  = ExpiredClientLink{ID: 3, UserID: 10, ClientID: 4, DateExpired: testNow.Add(-time.Minute)}
+
+		expiredGrant = ExpiredRoleGrant{ID: 1, UserID: 10, RoleID: 2, DateExpired: testNow.Add(-time.Minute), Expired: true}
+		expiredLink  = ExpiredClientLink{ID: 3, UserID: 10, ClientID: 4, DateExpired: testNow.Add(-time.Minute), Expired: true}
+
+		grantEvent  = newTestEvent(event.TypeRoleExpired, roleGrantEntity, grant.ID, expiredGrant)
+		linkEvent   = newTestEvent(event.TypeClientAccessExpired, clientLinkEntity, link.ID, expiredLink)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		batchSize      int
+		emitter        event.Emitter
+		mockCalls      mockPersisterApplier
+		expectedError  error
+		expectedEvents []event.Event
+	}
+
+	tests := []test{
+		{
+			name:      "lock is held by another instance",
+			batchSize: defaultBatchSize,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithLockMock(false, nil),
+			},
+			expectedError:  nil,
+			expectedEvents: nil,
+		},
+		{
+			name:      "lock error",
+			batchSize: defaultBatchSize,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithLockMock(false, customError),
+			},
+			expectedError:  customError,
+			expectedEvents: nil,
+		},
+		{
+			name:      "selecting expired grants fails",
+			batchSize: defaultBatchSize,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithLockMock(true, nil),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectExpiredRoleGrantsMock(nil, customError, defaultBatchSize),
+			},
+			expectedError:  customError,
+			expectedEvents: nil,
+		},
+		{
+			name:      "recording event fails",
+			batchSize: defaultBatchSize,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithLockMock(true, nil),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectExpiredRoleGrantsMock([]ExpiredRoleGrant{grant}, nil, defaultBatchSize),
+				setPersiterRecordEventMock(customError, grantEvent),
+			},
+			expectedError:  customError,
+			expectedEvents: nil,
+		},
+		{
+			name:      "recording audit entry fails",
+			batchSize: defaultBatchSize,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithLockMock(true, nil),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectExpiredRoleGrantsMock([]ExpiredRoleGrant{grant}, nil, defaultBatchSize),
+				setPersiterRecordEventMock(nil, grantEvent),
+				setPersiterRecordMock(customError, grant.UserID, grant, expiredGrant),
+			},
+			expectedError:  customError,
+			expectedEvents: nil,
+		},
+		{
+			name:      "happy case",
+			batchSize: defaultBatchSize,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithLockMock(true, nil),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectExpiredRoleGrantsMock([]ExpiredRoleGrant{grant}, nil, defaultBatchSize),
+				setPersiterRecordEventMock(nil, grantEvent),
+				setPersiterRecordMock(nil, grant.UserID, grant, expiredGrant),
+				setPersiterSelectExpiredClientLinksMock([]ExpiredClientLink{link}, nil, defaultBatchSize),
+				setPersiterRecordEventMock(nil, linkEvent),
+				setPersiterRecordMock(nil, link.UserID, link, expiredLink),
+			},
+			expectedError:  nil,
+			expectedEvents: []event.Event{emitted(grantEvent), emitted(linkEvent)},
+		},
+		{
+			name:      "full batch is followed by another one",
+			batchSize: 1,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithLockMock(true, nil),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectExpiredRoleGrantsMock([]ExpiredRoleGrant{grant}, nil, 1),
+				setPersiterRecordEventMock(nil, grantEvent),
+				setPersiterRecordMock(nil, grant.UserID, grant, expiredGrant),
+				setPersiterSelectExpiredClientLinksMock(nil, nil, 1),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectExpiredRoleGrantsMock(nil, nil, 1),
+				setPersiterSelectExpiredClientLinksMock(nil, nil, 1),
+			},
+			expectedError:  nil,
+			expectedEvents: []event.Event{emitted(grantEvent)},
+		},
+		{
+			name:      "emitter error does not fail the sweep",
+			batchSize: defaultBatchSize,
+			emitter:   failingEmitter{},
+			mockCalls: mockPersisterApplier{
+				setPersiterWithLockMock(true, nil),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectExpiredRoleGrantsMock([]ExpiredRoleGrant{grant}, nil, defaultBatchSize),
+				setPersiterRecordEventMock(nil, grantEvent),
+				setPersiterRecordMock(nil, grant.UserID, grant, expiredGrant),
+				setPersiterSelectExpiredClientLinksMock(nil, nil, defaultBatchSize),
+			},
+			expectedError:  nil,
+			expectedEvents: nil,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			recorder := event.NewRecorder()
+			sweeper := NewSweeper(newDBMock(), recorder, time.Minute)
+			if test.emitter != nil {
+				sweeper.emitter = test.emitter
+			}
+			sweeper.batchSize = test.batchSize
+			sweeper.now = func() time.Time { return testNow }
+
+			if assertsCalls, err := test.mockCalls.apply(&sweeper); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			err := sweeper.Sweep(context.Background())
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedEvents, recorder.Events())
+		})
+	}
+}
+
+func (s *SweeperSuite) TestExpireRecordsTheExpiredGrant() {
+	var (
+		grant = ExpiredRoleGrant{ID: 1, UserID: 10, RoleID: 2, DateExpired: testNow.Add(-time.Minute)}
+		link  = ExpiredClientLink{ID: 3, UserID: 10, ClientID: 4, DateExpired: testNow.Add(-time.Minute)}
+	)
+
+	type test struct {
+		name            string
+		eventType       string
+		entity          string
+		entityID        int64
+		before          any
+		after           any
+		expectedChanges string
+		expectedPayload string
+	}
+
+	tests := []test{
+		{
+			name:      "role grant",
+			eventType: event.TypeRoleExpired,
+			entity:    roleGrantEntity,
+			entityID:  grant.ID,
+			before:    grant,
+			after:     grant.asExpired(),
+			expectedChanges: `{"grant_id":{"before":1,"after":1},"user_id":{"before":10,"after":10},` +
+				`"role_id":{"before":2,"after":2},` +
+				`"date_expired":{"before":"2022-01-01T09:59:00Z","after":"2022-01-01T09:59:00Z"},` +
+				`"expired":{"before":false,"after":true}}`,
+			expectedPayload: `{"grant_id":1,"user_id":10,"role_id":2,"date_expired":"2022-01-01T09:59:00Z",` +
+				`"expired":true}`,
+		},
+		{
+			name:      "client link",
+			eventType: event.TypeClientAccessExpired,
+			entity:    clientLinkEntity,
+			entityID:  link.ID,
+			before:    link,
+			after:     link.asExpired(),
+			expectedChanges: `{"link_id":{"before":3,"after":3},"user_id":{"before":10,"after":10},` +
+				`"client_id":{"before":4,"after":4},` +
+				`"date_expired":{"before":"2022-01-01T09:59:00Z","after":"2022-01-01T09:59:00Z"},` +
+				`"expired":{"before":false,"after":true}}`,
+			expectedPayload: `{"link_id":3,"user_id":10,"client_id":4,"date_expired":"2022-01-01T09:59:00Z",` +
+				`"expired":true}`,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			var entry audit.Entry
+
+			repository := newDBMock()
+			repository.On(util.GetFunctionName(repository.recordEvent), mock.Anything).Return(nil).Once()
+			repository.On(util.GetFunctionName(repository.record), mock.Anything).
+				Run(func(args mock.Arguments) { entry = args.Get(0).(audit.Entry) }).
+				Return(nil).
+				Once()
+			defer repository.AssertExpectations(t)
+
+			e, err := expire(repository, test.eventType, test.entity, test.entityID, 10, test.before, test.after)
+
+			assert.Nil(t, err)
+			assert.Equal(t, auditEntity, entry.Entity)
+			assert.Equal(t, int64(10), entry.EntityID)
+			assert.JSONEq(t, test.expectedChanges, string(entry.Changes))
+			assert.Equal(t, test.entity, e.Entity)
+			assert.JSONEq(t, test.expectedPayload, string(e.Payload))
+		})
+	}
+}
+
+func (s *SweeperSuite) TestRunStopsWhenContextIsCancelled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sweeper := NewSweeper(newDBMock(), event.NewRecorder(), time.Hour)
+	assertsCalls, err := mockPersisterApplier{setPersiterWithLockMock(false, nil)}.apply(&sweeper)
+	if err != nil {
+		assert.Fail(s.T(), err.Error())
+		return
+	}
+	defer assertsCalls(s.T())
+
+	done := make(chan struct{})
+	go func() {
+		sweeper.Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		assert.Fail(s.T(), "sweeper did not stop after cancelling its context")
+	}
+}
+
+type failingEmitter struct{}
+
+func (failingEmitter) Emit(event.Event) error {
+	return errors.New("emitter is down")
+}
+
+func newTestEvent(eventType, entity string, entityID int64, grant any) event.Event {
+	e, err := event.NewEvent(eventType, entity, entityID, grant)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+func emitted(e event.Event) event.Event {
+	e.DateCreated = testNow
+	return e
+}
+
+type mockPersisterApplier []func(s *Sweeper) (func(t *testing.T), error)
+
+func (appliers mockPersisterApplier) apply(s *Sweeper) (func(t *testing.T), error) {
+	var assertCalls []func(t *testing.T)
+	for i := range appliers {
+		if assertCall, err := appliers[i](s); err != nil {
+			return func(t *testing.T) {}, err
+		} else {
+			assertCalls = append(assertCalls, assertCall)
+		}
+	}
+	return func(t *testing.T) {
+		for i := range assertCalls {
+			assertCalls[i](t)
+		}
+	}, nil
+}
+
+func setPersiterWithLockMock(
+	acquired bool,
+	errorResponse error,
+) func(s *Sweeper) (func(t *testing.T), error) {
+	return func(s *Sweeper) (func(t *testing.T), error) {
+		r, ok := s.repository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.withLock), mock.Anything, lockName, mock.Anything).
+			Return(acquired, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterWithTransactionMock(
+	errorResponse error,
+) func(s *Sweeper) (func(t *testing.T), error) {
+	return func(s *Sweeper) (func(t *testing.T), error) {
+		r, ok := s.repository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.withTransaction), mock.Anything).
+			Return(errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectExpiredRoleGrantsMock(
+	grantsResponse []ExpiredRoleGrant,
+	errorResponse error,
+	limit int,
+) func(s *Sweeper) (func(t *testing.T), error) {
+	return func(s *Sweeper) (func(t *testing.T), error) {
+		r, ok := s.repository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectExpiredRoleGrants), limit).
+			Return(grantsResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectExpiredClientLinksMock(
+	linksResponse []ExpiredClientLink,
+	errorResponse error,
+	limit int,
+) func(s *Sweeper) (func(t *testing.T), error) {
+	return func(s *Sweeper) (func(t *testing.T), error) {
+		r, ok := s.repository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectExpiredClientLinks), limit).
+			Return(linksResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterRecordEventMock(
+	errorResponse error,
+	e event.Event,
+) func(s *Sweeper) (func(t *testing.T), error) {
+	return func(s *Sweeper) (func(t *testing.T), error) {
+		r, ok := s.repository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.recordEvent), e).
+			Return(errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterRecordMock(
+	errorResponse error,
+	userID int64,
+	before, after any,
+) func(s *Sweeper) (func(t *testing.T), error) {
+	return func(s *Sweeper) (func(t *testing.T), error) {
+		r, ok := s.repository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		entry, err := audit.NewSnapshotEntry(auth.SystemCaller, audit.ActionExpire, auditEntity, userID, before, after)
+		if err != nil {
+			return nil, err
+		}
+		r.On(util.GetFunctionName(r.record), entry).
+			Return(errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
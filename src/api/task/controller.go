@@ -0,0 +1,221 @@
+package task
+
+import (
+	"errors"
+	"maria/src/api/auth"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	taskIDMissedError = newBadRequestResponse("task_id param is missed")
+)
+
+type Controller struct {
+	service       Service
+	integerParser func(s string, base int, bitSize int) (i int64, err error)
+}
+
+func NewController(service Service) Controller {
+	return Controller{
+		service:       service,
+		integerParser: strconv.ParseInt,
+	}
+}
+
+func (c Controller) GetByID(ctx *gin.Context) {
+	taskID, ok := c.parseTaskID(ctx)
+	if !ok {
+		return
+	}
+
+	task, err := c.service.getByID(taskID)
+	if err != nil {
+		if errors.Is(err, taskNotFoundError) {
+			ctx.JSON(http.StatusNotFound, newNotFoundError("task_id", taskID))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, task)
+}
+
+func (c Controller) Post(ctx *gin.Context) {
+	var request NewTaskRequest
+
+	if err := ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	if err := request.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	task, err := c.service.createTask(auth.Context(ctx), request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, task)
+}
+
+// List returns the tasks, filtered by type and active query params when they are given.
+func (c Controller) List(ctx *gin.Context) {
+	filter := TaskFilter{Type: ctx.Query("type")}
+
+	if filter.Type != "" {
+		if err := ValidateType(filter.Type); err != nil {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+			return
+		}
+	}
+
+	if value, ok := ctx.GetQuery("active"); ok {
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse("active must be a boolean"))
+			return
+		}
+		filter.Active = &active
+	}
+
+	tasks, err := c.service.listTasks(filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tasks)
+}
+
+// Put modifies the fields given in the body, the others are kept.
+func (c Controller) Put(ctx *gin.Context) {
+	var request ModifyTaskRequest
+
+	taskID, ok := c.parseTaskID(ctx)
+	if !ok {
+		return
+	}
+
+	if err := ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	if request.isEmpty() {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse("request does not specify a change to be applied"))
+		return
+	}
+
+	if err := request.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	task, err := c.service.modifyTask(auth.Context(ctx), request, taskID)
+	respondModified(ctx, taskID, task, err)
+}
+
+// Delete deactivates the task. Tasks are never removed because assignments keep referencing them.
+func (c Controller) Delete(ctx *gin.Context) {
+	taskID, ok := c.parseTaskID(ctx)
+	if !ok {
+		return
+	}
+
+	task, err := c.service.deactivateTask(auth.Context(ctx), taskID)
+	respondModified(ctx, taskID, task, err)
+}
+
+// respondModified writes the response of a service call which modified the task.
+func respondModified(ctx *gin.Context, taskID int64, task Task, err error) {
+	if err != nil {
+		if errors.Is(err, taskNotFoundError) {
+			ctx.JSON(http.StatusNotFound, newNotFoundError("task_id", taskID))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, task)
+}
+
+// parseTaskID returns task_id param. When it is not valid the bad request response is written and false is
+// returned.
+func (c Controller) parseTaskID(ctx *gin.Context) (int64, bool) {
+	param := ctx.Param("task_id")
+	if param == "" {
+		ctx.JSON(http.StatusBadRequest, taskIDMissedError)
+		return 0, false
+	}
+
+	taskID, err := c.integerParser(param, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return 0, false
+	}
+
+	return taskID, true
+}
+
+func (c Controller) SetURLMapping(router *gin.Engine) {
+	router.GET("/task", c.List)
+	router.GET("/task/:task_id", c.GetByID)
+	router.POST("/task", c.Post)
+	router.PUT("/task/:task_id", c.Put)
+	router.DELETE("/task/:task_id", c.Delete)
+}
+
+// RequiredRoles declares the role types or permissions required by the routes set in SetURLMapping.
+func (c Controller) RequiredRoles() auth.Routes {
+	var (
+		readers = auth.Requirement{
+			RoleTypes:   []string{auth.AdminRoleType, auth.ViewerRoleType},
+			Permissions: []string{auth.PermissionTaskRead},
+		}
+		writers = auth.Requirement{
+			RoleTypes:   []string{auth.AdminRoleType},
+			Permissions: []string{auth.PermissionTaskWrite},
+		}
+	)
+
+	return auth.Routes{
+		auth.Route(http.MethodGet, "/task"):             readers,
+		auth.Route(http.MethodGet, "/task/:task_id"):    readers,
+		auth.Route(http.MethodPost, "/task"):            writers,
+		auth.Route(http.MethodPut, "/task/:task_id"):    writers,
+		auth.Route(http.MethodDelete, "/task/:task_id"): writers,
+	}
+}
+
+func newBadRequestResponse(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     message,
+		"status_code": http.StatusBadRequest,
+	}
+}
+
+func newNotFoundError(by string, id any) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     "element not found",
+		"by":          by,
+		"id":          id,
+		"status_code": http.StatusNotFound,
+	}
+}
+
+func newInternalServerError(cause error) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     "internal server error",
+		"cause":       cause,
+		"status_code": http.StatusInternalServerError,
+	}
+}
@@ -0,0 +1,657 @@
+package task
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"maria/src/api/auth"
+	"maria/src/api/util"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ControllerSuite struct {
+	suite.Suite
+}
+
+func TestControllerSuite(t *testing.T) {
+	suite.Run(t, new(ControllerSuite))
+}
+
+func (c *ControllerSuite) BeforeTest(suiteName, testName string) {
+}
+
+func (c *ControllerSuite) AfterTest(suiteName, testName string) {
+}
+
+func (c *ControllerSuite) TestGetTaskByID() {
+	var (
+		taskID      = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		param          string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "task_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("task_id param is missed")),
+		},
+		{
+			name:  "it cannot parse task_id param",
+			param: "word",
+			controller: Controller{
+				integerParser: func(s string, base int, bitSize int) (i int64, err error) {
+					return 0, customError
+				},
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(customError.Error())),
+		},
+		{
+			name:           "task not found",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetByIDMock(Task{}, taskNotFoundError, taskID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("task_id", taskID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetByIDMock(Task{}, customError, taskID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetByIDMock(Task{ID: taskID}, nil, taskID),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(Task{ID: taskID}),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"task_id": test.param}, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.GetByID(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestPost() {
+	const (
+		bindMSGError = "Key: 'NewTaskRequest.Type' Error:Field validation for 'Type' failed on the 'required' tag"
+	)
+	var (
+		customError = errors.New("custom error")
+		request     = NewTaskRequest{TaskName: "onboarding", Type: "hr"}
+		task        = Task{ID: 10, TaskName: request.TaskName, Type: request.Type, Active: true, DateCreated: time.Now()}
+	)
+
+	type test struct {
+		name           string
+		body           NewTaskRequest
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "body fields missed",
+			body:         NewTaskRequest{TaskName: "onboarding"},
+			controller:   Controller{},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(bindMSGError)),
+		},
+		{
+			name:         "task name is blank",
+			body:         NewTaskRequest{TaskName: " ", Type: "hr"},
+			controller:   Controller{},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("task_name cannot be empty")),
+		},
+		{
+			name:         "type is not valid",
+			body:         NewTaskRequest{TaskName: "onboarding", Type: "Human Resources"},
+			controller:   Controller{},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(ValidateType("Human Resources").Error())),
+		},
+		{
+			name:           "service return internal error",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMock(Task{}, customError, request),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMock(task, nil, request),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(task),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(nil, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Post(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestList() {
+	var (
+		active      = false
+		customError = errors.New("custom error")
+		tasks       = []Task{{ID: 1, TaskName: "onboarding", Type: "hr", Active: false}}
+	)
+
+	type test struct {
+		name           string
+		queryString    string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "active is not a boolean",
+			queryString:  "active=yes",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("active must be a boolean")),
+		},
+		{
+			name:         "type is not valid",
+			queryString:  "type=HR",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(ValidateType("HR").Error())),
+		},
+		{
+			name:           "service return internal error",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListMock(nil, customError, TaskFilter{}),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			queryString:    "type=hr&active=false",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListMock(tasks, nil, TaskFilter{Type: "hr", Active: &active}),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(tasks),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(nil, test.queryString, nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.List(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestPut() {
+	var (
+		taskID      = int64(10)
+		taskName    = "induction"
+		blank       = " "
+		customError = errors.New("custom error")
+		request     = ModifyTaskRequest{TaskName: &taskName}
+	)
+
+	type test struct {
+		name           string
+		param          string
+		body           any
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "task_id param missed",
+			param:        "",
+			body:         request,
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("task_id param is missed")),
+		},
+		{
+			name:         "request is empty",
+			param:        "10",
+			body:         ModifyTaskRequest{},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(
+				newBadRequestResponse("request does not specify a change to be applied")),
+		},
+		{
+			name:         "task name is blank",
+			param:        "10",
+			body:         ModifyTaskRequest{TaskName: &blank},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("task_name cannot be empty")),
+		},
+		{
+			name:           "task not found",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Task{}, taskNotFoundError, request, taskID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("task_id", taskID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Task{}, customError, request, taskID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Task{ID: taskID, TaskName: taskName}, nil, request, taskID),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(Task{ID: taskID, TaskName: taskName}),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"task_id": test.param}, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Put(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestDelete() {
+	var (
+		taskID      = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		param          string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "task_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("task_id param is missed")),
+		},
+		{
+			name:           "task not found",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(Task{}, taskNotFoundError, taskID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("task_id", taskID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(Task{}, customError, taskID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "task deactivated",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(Task{ID: taskID}, nil, taskID),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(Task{ID: taskID}),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"task_id": test.param}, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Delete(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestRequiredRoles() {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := NewController(newServiceMock())
+	controller.SetURLMapping(router)
+
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		registered[auth.Route(route.Method, route.Path)] = true
+	}
+
+	for route, requirement := range controller.RequiredRoles() {
+		assert.True(c.T(), registered[route], "%s is not registered", route)
+		assert.NotEmpty(c.T(), requirement.RoleTypes, "%s does not require any role type", route)
+		assert.NotEmpty(c.T(), requirement.Permissions, "%s does not require any permission", route)
+	}
+}
+
+func (c *ControllerSuite) TestSetURLMapping() {
+	var (
+		taskID        = int64(10)
+		active        = true
+		taskName      = "induction"
+		taskRequest   = NewTaskRequest{TaskName: "onboarding", Type: "hr"}
+		modifyRequest = ModifyTaskRequest{TaskName: &taskName}
+	)
+
+	type test struct {
+		name           string
+		path           string
+		method         string
+		body           any
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+	}
+
+	tests := []test{
+		{
+			name:           "list tasks",
+			path:           "/task?type=hr&active=true",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListMock([]Task{}, nil, TaskFilter{Type: "hr", Active: &active}),
+		},
+		{
+			name:           "get task by id",
+			path:           "/task/10",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetByIDMock(Task{ID: taskID}, nil, taskID),
+		},
+		{
+			name:           "post task",
+			path:           "/task",
+			method:         http.MethodPost,
+			body:           taskRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePostMock(Task{ID: taskID}, nil, taskRequest),
+		},
+		{
+			name:           "put task",
+			path:           "/task/10",
+			method:         http.MethodPut,
+			body:           modifyRequest,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(Task{ID: taskID}, nil, modifyRequest, taskID),
+		},
+		{
+			name:           "delete task",
+			path:           "/task/10",
+			method:         http.MethodDelete,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(Task{ID: taskID}, nil, taskID),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			test.controller.SetURLMapping(router)
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			var b bytes.Buffer
+			if test.body != nil {
+				if err := json.NewEncoder(&b).Encode(test.body); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				}
+			}
+
+			req := httptest.NewRequest(test.method, test.path, &b)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(c.T(), http.StatusOK, w.Code)
+		})
+	}
+}
+
+func setServiceGetByIDMock(
+	taskResponse Task,
+	errorResponse error,
+	taskID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.getByID), taskID).
+			Return(taskResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServicePostMock(
+	taskResponse Task,
+	errorResponse error,
+	request NewTaskRequest,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.createTask), mock.Anything, request).
+			Return(taskResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceListMock(
+	tasksResponse []Task,
+	errorResponse error,
+	filter TaskFilter,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.listTasks), filter).
+			Return(tasksResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServicePutMock(
+	taskResponse Task,
+	errorResponse error,
+	request ModifyTaskRequest,
+	taskID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.modifyTask), mock.Anything, request, taskID).
+			Return(taskResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceDeleteMock(
+	taskResponse Task,
+	errorResponse error,
+	taskID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.deactivateTask), mock.Anything, taskID).
+			Return(taskResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
@@ -0,0 +1,217 @@
+package task
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maria/src/api/audit"
+	"maria/src/api/db"
+	"strings"
+)
+
+const (
+	getTaskByIDQuery    = `SELECT id, task_name, type, active, date_created FROM task WHERE id = ?`
+	listTasksQuery      = `SELECT id, task_name, type, active, date_created FROM task`
+	insertTaskQuery     = `INSERT INTO task (task_name, type, active) VALUES (?, ?, true)`
+	updateTaskQuery     = `UPDATE task SET task_name = ?, type = ?, active = ? WHERE id = ?`
+	deactivateTaskQuery = `UPDATE task SET active = false WHERE id = ?`
+)
+
+type Querier interface {
+	selectByID(int64) (Task, error)
+	selectByFilter(TaskFilter) ([]Task, error)
+	createTask(NewTaskRequest) (int64, error)
+	modifyTask(Task) (bool, error)
+	deactivateTask(int64) (bool, error)
+	record(audit.Entry) error
+}
+
+type Persister interface {
+	Querier
+	withTransaction(fn func(tx Transactioner) error) error
+}
+
+type Transactioner interface {
+	Querier
+	commit() error
+	rollback() error
+}
+
+func NewRelationalDB(client db.Client) Persister {
+	return &relationalDB{
+		client: client,
+	}
+}
+
+type relationalDB struct {
+	client db.Client
+}
+
+func (r *relationalDB) selectByID(taskID int64) (Task, error) {
+	var task Task
+
+	if err := r.client.QueryRow(getTaskByIDQuery, taskID).Scan(
+		&task.ID,
+		&task.TaskName,
+		&task.Type,
+		&task.Active,
+		&task.DateCreated,
+	); err != nil {
+		return task, db.ScanError(err, getTaskByIDQuery)
+	}
+
+	return task, nil
+}
+
+func (r *relationalDB) selectByFilter(filter TaskFilter) ([]Task, error) {
+	var (
+		rows  *sql.Rows
+		err   error
+		tasks []Task
+	)
+
+	query, args := buildListQuery(filter)
+	if rows, err = r.client.Query(query, args...); err != nil {
+		return nil, db.QueryError(err, query)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var task Task
+		if err = rows.Scan(
+			&task.ID,
+			&task.TaskName,
+			&task.Type,
+			&task.Active,
+			&task.DateCreated,
+		); err != nil {
+			return nil, db.ScanError(err, query)
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, query)
+	}
+
+	return tasks, nil
+}
+
+func buildListQuery(filter TaskFilter) (string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if filter.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, filter.Type)
+	}
+	if filter.Active != nil {
+		conditions = append(conditions, "active = ?")
+		args = append(args, *filter.Active)
+	}
+
+	query := listTasksQuery
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return query + " ORDER BY task_name, id", args
+}
+
+func (r *relationalDB) createTask(request NewTaskRequest) (int64, error) {
+	result, err := r.client.Exec(insertTaskQuery, request.TaskName, request.Type)
+	if err != nil {
+		return 0, db.ExecError(err, insertTaskQuery)
+	}
+
+	taskID, err := result.LastInsertId()
+	if err != nil {
+		return 0, db.LastInsertedError(err, insertTaskQuery)
+	}
+
+	return taskID, nil
+}
+
+// modifyTask stores every field of the task.
+func (r *relationalDB) modifyTask(task Task) (bool, error) {
+	return r.execByTask(updateTaskQuery, task.TaskName, task.Type, task.Active, task.ID)
+}
+
+func (r *relationalDB) deactivateTask(taskID int64) (bool, error) {
+	return r.execByTask(deactivateTaskQuery, taskID)
+}
+
+// execByTask runs a query changing a single task, it returns whether the task was changed.
+func (r *relationalDB) execByTask(query string, args ...any) (bool, error) {
+	result, err := r.client.Exec(query, args...)
+	if err != nil {
+		return false, db.ExecError(err, query)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, db.RowsAffectedError(err, query)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *relationalDB) record(entry audit.Entry) error {
+	return audit.NewRelationalDB(r.client).Record(entry)
+}
+
+func (r *relationalDB) getTransactioner() (Transactioner, error) {
+	client, ok := r.client.(*sql.DB)
+	if !ok {
+		return nil, errors.New("persister cannot generate transactional db")
+	}
+
+	tx, err := client.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("persister cannot generate transactional due to: %w", err)
+	}
+
+	return &transactionalDB{relationalDB: relationalDB{client: tx}, tx: tx}, nil
+}
+
+func (r *relationalDB) withTransaction(fn func(tx Transactioner) error) error {
+	tx, err := r.getTransactioner()
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		if err := tx.rollback(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	return tx.commit()
+}
+
+type transactionalDB struct {
+	relationalDB
+	tx *sql.Tx
+}
+
+func (tx *transactionalDB) commit() error {
+	if err := tx.tx.Commit(); err != nil {
+		return db.CommitError(err)
+	}
+	return nil
+}
+
+func (tx *transactionalDB) rollback() error {
+	if err := tx.tx.Rollback(); err != nil {
+		return db.RollbackError(err)
+	}
+	return nil
+}
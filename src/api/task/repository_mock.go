@@ -0,0 +1,65 @@
+package task
+
+import (
+	"maria/src/api/audit"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type dbMock struct {
+	mock.Mock
+}
+
+func newDBMock() *dbMock {
+	return &dbMock{}
+}
+
+func (m *dbMock) selectByID(taskID int64) (Task, error) {
+	args := m.Called(taskID)
+	return mockTask(args, 0), args.Error(1)
+}
+
+func (m *dbMock) selectByFilter(filter TaskFilter) ([]Task, error) {
+	args := m.Called(filter)
+	return mockTasks(args, 0), args.Error(1)
+}
+
+func (m *dbMock) createTask(request NewTaskRequest) (int64, error) {
+	args := m.Called(request)
+	return mockInt64(args, 0), args.Error(1)
+}
+
+func (m *dbMock) modifyTask(task Task) (bool, error) {
+	args := m.Called(task)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) deactivateTask(taskID int64) (bool, error) {
+	args := m.Called(taskID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) record(entry audit.Entry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *dbMock) withTransaction(fn func(tx Transactioner) error) error {
+	args := m.Called(fn)
+
+	if err := args.Error(0); err != nil {
+		return err
+	}
+
+	return fn(m)
+}
+
+func (m *dbMock) commit() error {
+	args := m.Called()
+	return args.Error(1)
+}
+
+func (m *dbMock) rollback() error {
+	args := m.Called()
+	return args.Error(1)
+}
@@ -0,0 +1,391 @@
+package task
+
+import (
+	"errors"
+	"maria/src/api/db"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type relationalDBSuite struct {
+	suite.Suite
+}
+
+func TestRelationalDBSuite(t *testing.T) {
+	suite.Run(t, new(relationalDBSuite))
+}
+
+func (s *relationalDBSuite) BeforeTest(suiteName, testName string) {
+}
+
+func (s *relationalDBSuite) AfterTest(suiteName, testName string) {
+}
+
+func (s *relationalDBSuite) TestSelectByID() {
+	var (
+		onboarding  = Task{ID: 10, TaskName: "onboarding", Type: "hr", Active: true, DateCreated: time.Now()}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTask  Task
+	}
+
+	tests := []test{
+		{
+			name: "scan error",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getTaskMockRows([]Task{onboarding}), getTaskByIDQuery, customError, onboarding.ID)},
+			expectedError: db.ScanError(customError, getTaskByIDQuery),
+			expectedTask:  Task{},
+		},
+		{
+			name: "task not found",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getTaskMockRows(nil), getTaskByIDQuery, nil, onboarding.ID)},
+			expectedError: nil,
+			expectedTask:  Task{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryRowMock(
+				getTaskMockRows([]Task{onboarding}), getTaskByIDQuery, nil, onboarding.ID)},
+			expectedError: nil,
+			expectedTask:  onboarding,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectByID(onboarding.ID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTask, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectByFilter() {
+	var (
+		active      = true
+		tasks       = []Task{{ID: 10, TaskName: "onboarding", Type: "hr", Active: true, DateCreated: time.Now()}}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		filter        TaskFilter
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTasks []Task
+	}
+
+	tests := []test{
+		{
+			name:   "query error",
+			filter: TaskFilter{},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				nil, listTasksQuery+" ORDER BY task_name, id", customError, nil)},
+			expectedError: db.QueryError(customError, listTasksQuery+" ORDER BY task_name, id"),
+			expectedTasks: nil,
+		},
+		{
+			name:   "rows error",
+			filter: TaskFilter{},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getTaskMockRows(tasks), listTasksQuery+" ORDER BY task_name, id", nil, customError)},
+			expectedError: db.RowsError(customError, listTasksQuery+" ORDER BY task_name, id"),
+			expectedTasks: nil,
+		},
+		{
+			name:   "filtered by type",
+			filter: TaskFilter{Type: "hr"},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getTaskMockRows(tasks),
+				listTasksQuery+" WHERE type = ? ORDER BY task_name, id",
+				nil,
+				nil,
+				"hr")},
+			expectedError: nil,
+			expectedTasks: tasks,
+		},
+		{
+			name:   "filtered by type and active",
+			filter: TaskFilter{Type: "hr", Active: &active},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getTaskMockRows(tasks),
+				listTasksQuery+" WHERE type = ? AND active = ? ORDER BY task_name, id",
+				nil,
+				nil,
+				"hr",
+				true)},
+			expectedError: nil,
+			expectedTasks: tasks,
+		},
+		{
+			name:   "happy case",
+			filter: TaskFilter{},
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getTaskMockRows(tasks), listTasksQuery+" ORDER BY task_name, id", nil, nil)},
+			expectedError: nil,
+			expectedTasks: tasks,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectByFilter(test.filter)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTasks, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestCreateTask() {
+	var (
+		request     = NewTaskRequest{TaskName: "onboarding", Type: "hr"}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		mockCalls      mockDBApplier
+		expectedError  error
+		expectedTaskID int64
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertTaskQuery, customError, request.TaskName, request.Type)},
+			expectedError:  db.ExecError(customError, insertTaskQuery),
+			expectedTaskID: 0,
+		},
+		{
+			name: "last inserted error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewErrorResult(customError), insertTaskQuery, nil, request.TaskName, request.Type)},
+			expectedError:  db.LastInsertedError(customError, insertTaskQuery),
+			expectedTaskID: 0,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(10, 1), insertTaskQuery, nil, request.TaskName, request.Type)},
+			expectedError:  nil,
+			expectedTaskID: 10,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.createTask(request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTaskID, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestModifyTask() {
+	var (
+		task        = Task{ID: 10, TaskName: "onboarding", Type: "hr", Active: true}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, updateTaskQuery, customError, task.TaskName, task.Type, task.Active, task.ID)},
+			expectedError: db.ExecError(customError, updateTaskQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "rows affected error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewErrorResult(customError), updateTaskQuery, nil, task.TaskName, task.Type, task.Active, task.ID)},
+			expectedError: db.RowsAffectedError(customError, updateTaskQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "task not changed",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 0), updateTaskQuery, nil, task.TaskName, task.Type, task.Active, task.ID)},
+			expectedError: nil,
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), updateTaskQuery, nil, task.TaskName, task.Type, task.Active, task.ID)},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.modifyTask(task)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestDeactivateTask() {
+	var (
+		taskID      = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name:          "exec error",
+			mockCalls:     mockDBApplier{db.SetClientExecMock(nil, deactivateTaskQuery, customError, taskID)},
+			expectedError: db.ExecError(customError, deactivateTaskQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), deactivateTaskQuery, nil, taskID)},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.deactivateTask(taskID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, result)
+		})
+	}
+}
+
+func getTaskMockRows(tasks []Task) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "task_name", "type", "active", "date_created"})
+	for _, t := range tasks {
+		rows.AddRow(t.ID, t.TaskName, t.Type, t.Active, t.DateCreated)
+	}
+	return rows
+}
+
+type mockDBApplier []func(m sqlmock.Sqlmock) func() error
+
+func (appliers mockDBApplier) apply(m sqlmock.Sqlmock) func() error {
+	var assertCalls []func() error
+	for i := range appliers {
+		assertCall := appliers[i](m)
+		assertCalls = append(assertCalls, assertCall)
+	}
+	return func() error {
+		for i := range assertCalls {
+			if err := assertCalls[i](); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,143 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
+)
+
+var (
+	taskNotFoundError = errors.New("task not found")
+)
+
+const auditEntity = "task"
+
+// Service methods receiving a context use it for knowing the caller, who is recorded in the audit log.
+type Service interface {
+	getByID(int64) (Task, error)
+	createTask(context.Context, NewTaskRequest) (Task, error)
+	listTasks(TaskFilter) ([]Task, error)
+	modifyTask(context.Context, ModifyTaskRequest, int64) (Task, error)
+	deactivateTask(context.Context, int64) (Task, error)
+}
+
+type taskService struct {
+	taskRepository Persister
+}
+
+func NewService(taskRepository Persister) Service {
+	return taskService{
+		taskRepository: taskRepository,
+	}
+}
+
+func (ts taskService) getByID(taskID int64) (Task, error) {
+	task, err := ts.taskRepository.selectByID(taskID)
+	if err == nil && task.isEmptyTask() {
+		return task, taskNotFoundError
+	}
+	return task, err
+}
+
+func (ts taskService) createTask(ctx context.Context, request NewTaskRequest) (Task, error) {
+	var task Task
+
+	if err := ts.taskRepository.withTransaction(func(tx Transactioner) error {
+		taskID, err := tx.createTask(request)
+		if err != nil {
+			return err
+		}
+
+		if task, err = tx.selectByID(taskID); err != nil {
+			return err
+		}
+
+		return record(ctx, tx, audit.ActionCreate, nil, &task)
+	}); err != nil {
+		return Task{}, err
+	}
+
+	return task, nil
+}
+
+func (ts taskService) listTasks(filter TaskFilter) ([]Task, error) {
+	tasks, err := ts.taskRepository.selectByFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if tasks == nil {
+		tasks = []Task{}
+	}
+	return tasks, nil
+}
+
+func (ts taskService) modifyTask(ctx context.Context, request ModifyTaskRequest, taskID int64) (Task, error) {
+	task, err := ts.getByID(taskID)
+	if err != nil {
+		return Task{}, err
+	}
+
+	if err = ts.taskRepository.withTransaction(func(tx Transactioner) error {
+		if _, err := tx.modifyTask(request.apply(task)); err != nil {
+			return err
+		}
+
+		before := task
+		if task, err = tx.selectByID(taskID); err != nil {
+			return err
+		}
+
+		return record(ctx, tx, audit.ActionModify, &before, &task)
+	}); err != nil {
+		return Task{}, err
+	}
+
+	return task, nil
+}
+
+// deactivateTask marks the task as inactive, so it cannot be assigned anymore. Assignments already made are kept.
+func (ts taskService) deactivateTask(ctx context.Context, taskID int64) (Task, error) {
+	task, err := ts.getByID(taskID)
+	if err != nil {
+		return Task{}, err
+	}
+
+	if err = ts.taskRepository.withTransaction(func(tx Transactioner) error {
+		if _, err := tx.deactivateTask(taskID); err != nil {
+			return err
+		}
+
+		before := task
+		if task, err = tx.selectByID(taskID); err != nil {
+			return err
+		}
+
+		return record(ctx, tx, audit.ActionDeactivate, &before, &task)
+	}); err != nil {
+		return Task{}, err
+	}
+
+	return task, nil
+}
+
+func record(ctx context.Context, tx Transactioner, action string, before, after *Task) error {
+	var (
+		taskID                    int64
+		beforeEntity, afterEntity any
+	)
+
+	if before != nil {
+		taskID, beforeEntity = before.ID, *before
+	}
+	if after != nil {
+		taskID, afterEntity = after.ID, *after
+	}
+
+	entry, err := audit.NewEntry(auth.CallerFrom(ctx), action, auditEntity, taskID, beforeEntity, afterEntity)
+	if err != nil {
+		return err
+	}
+
+	return tx.record(entry)
+}
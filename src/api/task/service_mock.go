@@ -0,0 +1,71 @@
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type serviceMock struct {
+	mock.Mock
+}
+
+func newServiceMock() *serviceMock {
+	return &serviceMock{}
+}
+
+func mockTask(args mock.Arguments, index int) Task {
+	obj := args.Get(index)
+	var s Task
+	var ok bool
+	if s, ok = obj.(Task); !ok {
+		panic(fmt.Sprintf("assert: arguments: Task(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockTasks(args mock.Arguments, index int) []Task {
+	obj := args.Get(index)
+	var s []Task
+	var ok bool
+	if s, ok = obj.([]Task); !ok {
+		panic(fmt.Sprintf("assert: arguments: Task(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func mockInt64(args mock.Arguments, index int) int64 {
+	obj := args.Get(index)
+	var s int64
+	var ok bool
+	if s, ok = obj.(int64); !ok {
+		panic(fmt.Sprintf("assert: arguments: Int64(%d) failed because object wasn't correct type: %v", index, args.Get(index)))
+	}
+	return s
+}
+
+func (m *serviceMock) getByID(taskID int64) (Task, error) {
+	args := m.Called(taskID)
+	return mockTask(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) createTask(ctx context.Context, request NewTaskRequest) (Task, error) {
+	args := m.Called(ctx, request)
+	return mockTask(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) listTasks(filter TaskFilter) ([]Task, error) {
+	args := m.Called(filter)
+	return mockTasks(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) modifyTask(ctx context.Context, request ModifyTaskRequest, taskID int64) (Task, error) {
+	args := m.Called(ctx, request, taskID)
+	return mockTask(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) deactivateTask(ctx context.Context, taskID int64) (Task, error) {
+	args := m.Called(ctx, taskID)
+	return mockTask(args, 0), args.Error(1)
+}
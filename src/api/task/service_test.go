@@ -0,0 +1,515 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
+	"maria/src/api/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+const testCaller = "admin"
+
+var testCtx = auth.WithCaller(context.Background(), testCaller)
+
+type TaskServiceSuite struct {
+	suite.Suite
+}
+
+func TestTaskServiceSuite(t *testing.T) {
+	suite.Run(t, new(TaskServiceSuite))
+}
+
+func (s *TaskServiceSuite) BeforeTest(suiteName, testName string) {
+}
+
+func (s *TaskServiceSuite) AfterTest(suiteName, testName string) {
+}
+
+func (s *TaskServiceSuite) TestGetByID() {
+	var (
+		taskID      = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedTask  Task
+	}
+
+	tests := []test{
+		{
+			name:          "task not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(Task{}, nil, taskID)},
+			expectedError: taskNotFoundError,
+			expectedTask:  Task{},
+		},
+		{
+			name:          "repository return error",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(Task{}, customError, taskID)},
+			expectedError: customError,
+			expectedTask:  Task{},
+		},
+		{
+			name:          "happy case",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(Task{ID: taskID}, nil, taskID)},
+			expectedError: nil,
+			expectedTask:  Task{ID: taskID},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := NewService(newDBMock()).(taskService)
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			task, err := serv.getByID(taskID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTask, task)
+		})
+	}
+}
+
+func (s *TaskServiceSuite) TestCreateTask() {
+	var (
+		taskID      = int64(10)
+		customError = errors.New("custom error")
+		request     = NewTaskRequest{TaskName: "onboarding", Type: "hr"}
+		newTask     = Task{
+			ID:          taskID,
+			TaskName:    request.TaskName,
+			Type:        request.Type,
+			Active:      true,
+			DateCreated: time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC),
+		}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedTask  Task
+	}
+
+	tests := []test{
+		{
+			name: "transaction cannot be started",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(customError),
+			},
+			expectedError: customError,
+			expectedTask:  Task{},
+		},
+		{
+			name: "create task return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterCreateTaskMock(0, customError, request),
+			},
+			expectedError: customError,
+			expectedTask:  Task{},
+		},
+		{
+			name: "record return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterCreateTaskMock(taskID, nil, request),
+				setPersiterSelectByIDMock(newTask, nil, taskID),
+				setPersiterRecordMock(customError, audit.ActionCreate, nil, &newTask),
+			},
+			expectedError: customError,
+			expectedTask:  Task{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterCreateTaskMock(taskID, nil, request),
+				setPersiterSelectByIDMock(newTask, nil, taskID),
+				setPersiterRecordMock(nil, audit.ActionCreate, nil, &newTask),
+			},
+			expectedError: nil,
+			expectedTask:  newTask,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := NewService(newDBMock()).(taskService)
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			task, err := serv.createTask(testCtx, request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTask, task)
+		})
+	}
+}
+
+func (s *TaskServiceSuite) TestListTasks() {
+	var (
+		active      = true
+		filter      = TaskFilter{Type: "hr", Active: &active}
+		tasks       = []Task{{ID: 10, TaskName: "onboarding", Type: "hr", Active: true}}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedTasks []Task
+	}
+
+	tests := []test{
+		{
+			name:          "repository return error",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByFilterMock(nil, customError, filter)},
+			expectedError: customError,
+			expectedTasks: nil,
+		},
+		{
+			name:          "no tasks found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByFilterMock(nil, nil, filter)},
+			expectedError: nil,
+			expectedTasks: []Task{},
+		},
+		{
+			name:          "happy case",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByFilterMock(tasks, nil, filter)},
+			expectedError: nil,
+			expectedTasks: tasks,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := NewService(newDBMock()).(taskService)
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			result, err := serv.listTasks(filter)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTasks, result)
+		})
+	}
+}
+
+func (s *TaskServiceSuite) TestModifyTask() {
+	var (
+		taskID      = int64(10)
+		taskType    = "onboarding_hr"
+		customError = errors.New("custom error")
+		request     = ModifyTaskRequest{Type: &taskType}
+		task        = Task{ID: taskID, TaskName: "onboarding", Type: "hr", Active: true}
+		modified    = Task{ID: taskID, TaskName: "onboarding", Type: taskType, Active: true}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedTask  Task
+	}
+
+	tests := []test{
+		{
+			name:          "task not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(Task{}, nil, taskID)},
+			expectedError: taskNotFoundError,
+			expectedTask:  Task{},
+		},
+		{
+			name: "modify task return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(task, nil, taskID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterModifyTaskMock(false, customError, modified),
+			},
+			expectedError: customError,
+			expectedTask:  Task{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(task, nil, taskID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterModifyTaskMock(true, nil, modified),
+				setPersiterSelectByIDMock(modified, nil, taskID),
+				setPersiterRecordMock(nil, audit.ActionModify, &task, &modified),
+			},
+			expectedError: nil,
+			expectedTask:  modified,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := NewService(newDBMock()).(taskService)
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			result, err := serv.modifyTask(testCtx, request, taskID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTask, result)
+		})
+	}
+}
+
+func (s *TaskServiceSuite) TestDeactivateTask() {
+	var (
+		taskID      = int64(10)
+		customError = errors.New("custom error")
+		task        = Task{ID: taskID, TaskName: "onboarding", Type: "hr", Active: true}
+		deactivated = Task{ID: taskID, TaskName: "onboarding", Type: "hr", Active: false}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedTask  Task
+	}
+
+	tests := []test{
+		{
+			name:          "task not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(Task{}, nil, taskID)},
+			expectedError: taskNotFoundError,
+			expectedTask:  Task{},
+		},
+		{
+			name: "deactivate task return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(task, nil, taskID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterDeactivateTaskMock(false, customError, taskID),
+			},
+			expectedError: customError,
+			expectedTask:  Task{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(task, nil, taskID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterDeactivateTaskMock(true, nil, taskID),
+				setPersiterSelectByIDMock(deactivated, nil, taskID),
+				setPersiterRecordMock(nil, audit.ActionDeactivate, &task, &deactivated),
+			},
+			expectedError: nil,
+			expectedTask:  deactivated,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := NewService(newDBMock()).(taskService)
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			result, err := serv.deactivateTask(testCtx, taskID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTask, result)
+		})
+	}
+}
+
+type mockPersisterApplier []func(ts *taskService) (func(t *testing.T), error)
+
+func (appliers mockPersisterApplier) apply(ts *taskService) (func(t *testing.T), error) {
+	var assertCalls []func(t *testing.T)
+	for i := range appliers {
+		if assertCall, err := appliers[i](ts); err != nil {
+			return func(t *testing.T) {}, err
+		} else {
+			assertCalls = append(assertCalls, assertCall)
+		}
+	}
+	return func(t *testing.T) {
+		for i := range assertCalls {
+			assertCalls[i](t)
+		}
+	}, nil
+}
+
+func setPersiterWithTransactionMock(
+	errorResponse error,
+) func(ts *taskService) (func(t *testing.T), error) {
+	return func(ts *taskService) (func(t *testing.T), error) {
+		r, ok := ts.taskRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.withTransaction), mock.Anything).
+			Return(errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByIDMock(
+	taskResponse Task,
+	errorResponse error,
+	taskID int64,
+) func(ts *taskService) (func(t *testing.T), error) {
+	return func(ts *taskService) (func(t *testing.T), error) {
+		r, ok := ts.taskRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectByID), taskID).
+			Return(taskResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByFilterMock(
+	tasksResponse []Task,
+	errorResponse error,
+	filter TaskFilter,
+) func(ts *taskService) (func(t *testing.T), error) {
+	return func(ts *taskService) (func(t *testing.T), error) {
+		r, ok := ts.taskRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectByFilter), filter).
+			Return(tasksResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterCreateTaskMock(
+	taskID int64,
+	errorResponse error,
+	request NewTaskRequest,
+) func(ts *taskService) (func(t *testing.T), error) {
+	return func(ts *taskService) (func(t *testing.T), error) {
+		r, ok := ts.taskRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.createTask), request).
+			Return(taskID, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterModifyTaskMock(
+	modified bool,
+	errorResponse error,
+	task Task,
+) func(ts *taskService) (func(t *testing.T), error) {
+	return func(ts *taskService) (func(t *testing.T), error) {
+		r, ok := ts.taskRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.modifyTask), task).
+			Return(modified, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterDeactivateTaskMock(
+	deactivated bool,
+	errorResponse error,
+	taskID int64,
+) func(ts *taskService) (func(t *testing.T), error) {
+	return func(ts *taskService) (func(t *testing.T), error) {
+		r, ok := ts.taskRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.deactivateTask), taskID).
+			Return(deactivated, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterRecordMock(
+	err error,
+	action string,
+	before, after *Task,
+) func(ts *taskService) (func(t *testing.T), error) {
+	return func(ts *taskService) (func(t *testing.T), error) {
+		r, ok := ts.taskRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+
+		var (
+			taskID                    int64
+			beforeEntity, afterEntity any
+		)
+		if before != nil {
+			taskID, beforeEntity = before.ID, *before
+		}
+		if after != nil {
+			taskID, afterEntity = after.ID, *after
+		}
+		entry, entryErr := audit.NewEntry(testCaller, action, auditEntity, taskID, beforeEntity, afterEntity)
+		if entryErr != nil {
+			return nil, entryErr
+		}
+
+		r.On(util.GetFunctionName(r.record), entry).
+			Return(err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
@@ -0,0 +1,87 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// taskTypePattern keeps task types usable as configuration keys, as "bug" or "code_review".
+var taskTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+type Task struct {
+	ID          int64     `json:"task_id"`
+	TaskName    string    `json:"task_name"`
+	Type        string    `json:"type"`
+	Active      bool      `json:"active"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+func (t Task) isEmptyTask() bool {
+	return t.ID == 0
+}
+
+type NewTaskRequest struct {
+	TaskName string `json:"task_name" binding:"required"`
+	Type     string `json:"type" binding:"required"`
+}
+
+// validate checks that the name is not blank and the type is well formed.
+func (r NewTaskRequest) validate() error {
+	if strings.TrimSpace(r.TaskName) == "" {
+		return errors.New("task_name cannot be empty")
+	}
+	return ValidateType(r.Type)
+}
+
+type ModifyTaskRequest struct {
+	TaskName *string `json:"task_name"`
+	Type     *string `json:"type"`
+	Active   *bool   `json:"active"`
+}
+
+func (r ModifyTaskRequest) isEmpty() bool {
+	return r.TaskName == nil && r.Type == nil && r.Active == nil
+}
+
+// validate checks that given fields are not blank and the type is well formed.
+func (r ModifyTaskRequest) validate() error {
+	if r.TaskName != nil && strings.TrimSpace(*r.TaskName) == "" {
+		return errors.New("task_name cannot be empty")
+	}
+	if r.Type != nil {
+		return ValidateType(*r.Type)
+	}
+	return nil
+}
+
+// apply returns the task with the request changes applied.
+func (r ModifyTaskRequest) apply(task Task) Task {
+	if r.TaskName != nil {
+		task.TaskName = *r.TaskName
+	}
+	if r.Type != nil {
+		task.Type = *r.Type
+	}
+	if r.Active != nil {
+		task.Active = *r.Active
+	}
+	return task
+}
+
+// ValidateType checks that the task type follows the rule of taskTypePattern.
+func ValidateType(taskType string) error {
+	if !taskTypePattern.MatchString(taskType) {
+		return fmt.Errorf("type %q must start with a lower case letter followed by lower case letters, "+
+			"digits or underscores", taskType)
+	}
+	return nil
+}
+
+// TaskFilter keeps the conditions used for listing tasks. Empty fields are ignored.
+type TaskFilter struct {
+	Type   string
+	Active *bool
+}
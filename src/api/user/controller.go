@@ -1,11 +1,22 @@
 package user
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 var (
@@ -37,7 +48,7 @@ func (c Controller) GetByID(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.service.getByID(userID)
+	user, err := c.service.getByID(auth.Context(ctx), userID)
 	if err != nil {
 		if errors.Is(err, userNotFoundError) {
 			ctx.JSON(http.StatusNotFound, newNotFoundError("user_id", userID))
@@ -47,6 +58,7 @@ func (c Controller) GetByID(ctx *gin.Context) {
 		return
 	}
 
+	ctx.Header("ETag", newETag(user.Version))
 	ctx.JSON(http.StatusOK, user)
 }
 
@@ -61,9 +73,34 @@ func (c Controller) Post(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.service.createUser(userRequest)
+	user, err := c.service.createUser(auth.Context(ctx), userRequest)
 	if err != nil {
 		if errors.Is(err, userWithSameValueError) {
+			ctx.JSON(http.StatusBadRequest, newSameValueResponse(err))
+			return
+		}
+		if errors.Is(err, inactiveTenantError) {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, user)
+}
+
+func (c Controller) Verify(ctx *gin.Context) {
+	var request VerifyUserRequest
+
+	if err := ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	user, err := c.service.verifyUser(auth.Context(ctx), request)
+	if err != nil {
+		if errors.Is(err, invalidVerificationTokenError) {
 			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
 			return
 		}
@@ -74,6 +111,46 @@ func (c Controller) Post(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, user)
 }
 
+func (c Controller) Import(ctx *gin.Context) {
+	atomic, err := strconv.ParseBool(ctx.DefaultQuery("atomic", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse("atomic must be a boolean"))
+		return
+	}
+
+	var rows []ImportRow
+	switch importFormat(ctx) {
+	case "csv":
+		rows, err = parseCSVImport(ctx.Request.Body)
+	case "ndjson":
+		rows, err = parseNDJSONImport(ctx.Request.Body)
+	default:
+		err = errors.New("format must be csv or ndjson, specify it by format param or Content-Type header")
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	if len(rows) == 0 {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse("file does not have any user"))
+		return
+	}
+
+	report, err := c.service.importUsers(auth.Context(ctx), rows, atomic)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	if atomic && report.Failed > 0 {
+		ctx.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
 func (c Controller) Put(ctx *gin.Context) {
 	var (
 		userID      int64
@@ -114,28 +191,714 @@ func (c Controller) Put(ctx *gin.Context) {
 		return
 	}
 
+	if err = userRequest.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	if userRequest.Version, err = parseIfMatch(ctx.GetHeader("If-Match")); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
 	user := User{
 		ID:       userID,
 		UserName: userName,
 		Alias:    userAlias,
 	}
 
-	if user, err = c.service.modifyUser(userRequest, user); err != nil {
+	if user, err = c.service.modifyUser(auth.Context(ctx), userRequest, user); err != nil {
 		if errors.Is(err, userNotFoundError) {
 			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
 			return
 		}
+		if errors.Is(err, userWithSameValueError) {
+			ctx.JSON(http.StatusBadRequest, newSameValueResponse(err))
+			return
+		}
+		if errors.Is(err, versionMismatchError) {
+			ctx.JSON(http.StatusPreconditionFailed, newPreconditionFailedResponse(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.Header("ETag", newETag(user.Version))
+	ctx.JSON(http.StatusOK, user)
+}
+
+func (c Controller) Delete(ctx *gin.Context) {
+	param := ctx.Param("user_id")
+	if param == "" {
+		ctx.JSON(http.StatusBadRequest, userIDMissedError)
+		return
+	}
+
+	userID, err := c.integerParser(param, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	hard, err := strconv.ParseBool(ctx.DefaultQuery("hard", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse("hard must be a boolean"))
+		return
+	}
+
+	user, err := c.service.deleteUser(auth.Context(ctx), userID, hard)
+	if err != nil {
+		if errors.Is(err, userNotFoundError) {
+			ctx.JSON(http.StatusNotFound, newNotFoundError("user_id", userID))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
 		return
 	}
 
+	if hard {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+
 	ctx.JSON(http.StatusOK, user)
 }
 
+func (c Controller) GetHistory(ctx *gin.Context) {
+	param := ctx.Param("user_id")
+	if param == "" {
+		ctx.JSON(http.StatusBadRequest, userIDMissedError)
+		return
+	}
+
+	userID, err := c.integerParser(param, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	var page audit.Page
+	if value, ok := ctx.GetQuery("limit"); ok {
+		if page.Limit, err = strconv.Atoi(value); err != nil || page.Limit <= 0 {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse("limit must be a positive integer"))
+			return
+		}
+	}
+	if value, ok := ctx.GetQuery("cursor"); ok {
+		if page.BeforeID, err = strconv.ParseInt(value, 10, 64); err != nil || page.BeforeID <= 0 {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(invalidCursorError.Error()))
+			return
+		}
+	}
+
+	history, err := c.service.getHistory(auth.Context(ctx), userID, page)
+	if err != nil {
+		if errors.Is(err, userNotFoundError) {
+			ctx.JSON(http.StatusNotFound, newNotFoundError("user_id", userID))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, history)
+}
+
+func (c Controller) GetRoles(ctx *gin.Context) {
+	param := ctx.Param("user_id")
+	if param == "" {
+		ctx.JSON(http.StatusBadRequest, userIDMissedError)
+		return
+	}
+
+	userID, err := c.integerParser(param, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	includeExpired, err := strconv.ParseBool(ctx.DefaultQuery("include_expired", "false"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse("include_expired must be a boolean"))
+		return
+	}
+
+	grants, err := c.service.listRoleGrants(auth.Context(ctx), userID, includeExpired)
+	if err != nil {
+		if errors.Is(err, userNotFoundError) {
+			ctx.JSON(http.StatusNotFound, newNotFoundError("user_id", userID))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, grants)
+}
+
+// GetEffectiveRoles returns the roles the user holds, granted or inherited, and where each one comes from.
+func (c Controller) GetEffectiveRoles(ctx *gin.Context) {
+	param := ctx.Param("user_id")
+	if param == "" {
+		ctx.JSON(http.StatusBadRequest, userIDMissedError)
+		return
+	}
+
+	userID, err := c.integerParser(param, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	roles, err := c.service.listEffectiveRoles(auth.Context(ctx), userID)
+	if err != nil {
+		if errors.Is(err, userNotFoundError) {
+			ctx.JSON(http.StatusNotFound, newNotFoundError("user_id", userID))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, roles)
+}
+
+// GetPermissions returns the permissions the user holds through their effective roles, for debugging access problems.
+func (c Controller) GetPermissions(ctx *gin.Context) {
+	param := ctx.Param("user_id")
+	if param == "" {
+		ctx.JSON(http.StatusBadRequest, userIDMissedError)
+		return
+	}
+
+	userID, err := c.integerParser(param, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	permissions, err := c.service.listPermissions(auth.Context(ctx), userID)
+	if err != nil {
+		if errors.Is(err, userNotFoundError) {
+			ctx.JSON(http.StatusNotFound, newNotFoundError("user_id", userID))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, permissions)
+}
+
+func (c Controller) PostRole(ctx *gin.Context) {
+	var request GrantRoleRequest
+
+	param := ctx.Param("user_id")
+	if param == "" {
+		ctx.JSON(http.StatusBadRequest, userIDMissedError)
+		return
+	}
+
+	userID, err := c.integerParser(param, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	if err = ctx.BindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	grant, err := c.service.grantRole(auth.Context(ctx), userID, request)
+	if err != nil {
+		switch {
+		case errors.Is(err, userNotFoundError):
+			ctx.JSON(http.StatusNotFound, newNotFoundError("user_id", userID))
+		case errors.Is(err, roleNotFoundError):
+			ctx.JSON(http.StatusNotFound, newNotFoundError("role_id", request.RoleID))
+		case errors.Is(err, inactiveUserError),
+			errors.Is(err, inactiveRoleError),
+			errors.Is(err, roleAlreadyGrantedError),
+			errors.Is(err, invalidGrantExpirationError):
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		case errors.Is(err, roleNotGrantableError):
+			ctx.JSON(http.StatusForbidden, newForbiddenResponse(err.Error()))
+		default:
+			ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, grant)
+}
+
+func (c Controller) DeleteRole(ctx *gin.Context) {
+	userParam, roleParam := ctx.Param("user_id"), ctx.Param("role_id")
+	if userParam == "" {
+		ctx.JSON(http.StatusBadRequest, userIDMissedError)
+		return
+	}
+	if roleParam == "" {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse("role_id param is missed"))
+		return
+	}
+
+	userID, err := c.integerParser(userParam, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	roleID, err := c.integerParser(roleParam, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	if err = c.service.revokeRole(auth.Context(ctx), userID, roleID); err != nil {
+		switch {
+		case errors.Is(err, userNotFoundError):
+			ctx.JSON(http.StatusNotFound, newNotFoundError("user_id", userID))
+		case errors.Is(err, roleGrantNotFoundError):
+			ctx.JSON(http.StatusNotFound, newNotFoundError("role_id", roleID))
+		default:
+			ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c Controller) Search(ctx *gin.Context) {
+	request, err := parseSearchRequest(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	response, err := c.service.searchUsers(auth.Context(ctx), request)
+	if err != nil {
+		if errors.Is(err, invalidCursorError) {
+			ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Export streams every user matching the filter as CSV or NDJSON. Users are written while they are read from
+// the database, so exporting does not keep them in memory.
+func (c Controller) Export(ctx *gin.Context) {
+	filter, err := parseUserFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse(err.Error()))
+		return
+	}
+
+	var exporter userExporter
+	switch ctx.DefaultQuery("format", "csv") {
+	case "csv":
+		exporter = newCSVExporter(ctx.Writer)
+	case "ndjson":
+		exporter = newNDJSONExporter(ctx.Writer)
+	default:
+		ctx.JSON(http.StatusBadRequest, newBadRequestResponse("format must be csv or ndjson"))
+		return
+	}
+
+	ctx.Header("Content-Type", exporter.contentType())
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, exporter.fileName()))
+	ctx.Status(http.StatusOK)
+
+	var exported int
+	err = c.service.exportUsers(auth.Context(ctx), filter, func(u User) error {
+		if err := exporter.write(u); err != nil {
+			return err
+		}
+		if exported++; exported%exportFlushEvery == 0 {
+			return exporter.flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = exporter.flush()
+	}
+
+	if err != nil {
+		if ctx.Writer.Written() {
+			// the response is already being streamed, so it can only be left incomplete
+			_ = ctx.Error(err)
+			return
+		}
+		ctx.Writer.Header().Del("Content-Type")
+		ctx.Writer.Header().Del("Content-Disposition")
+		ctx.JSON(http.StatusInternalServerError, newInternalServerError(err))
+	}
+}
+
 func (c Controller) SetURLMapping(router *gin.Engine) {
+	router.GET("/user", c.Search)
+	router.GET("/user/export", c.Export)
 	router.GET("/user/:user_id", c.GetByID)
 	router.POST("/user", c.Post)
+	router.POST("/user/verify", c.Verify)
+	router.POST("/user/import", c.Import)
 	router.PUT("/user/:user_id", c.Put)
+	router.DELETE("/user/:user_id", c.Delete)
+	router.GET("/user/:user_id/history", c.GetHistory)
+	router.GET("/user/:user_id/roles", c.GetRoles)
+	router.POST("/user/:user_id/roles", c.PostRole)
+	router.DELETE("/user/:user_id/roles/:role_id", c.DeleteRole)
+	router.GET("/user/:user_id/effective-roles", c.GetEffectiveRoles)
+	router.GET("/user/:user_id/permissions", c.GetPermissions)
+}
+
+// RequiredRoles declares the role types or permissions required by the routes set in SetURLMapping. Sign up and
+// verification are public.
+func (c Controller) RequiredRoles() auth.Routes {
+	var (
+		readers = auth.Requirement{
+			RoleTypes:   []string{auth.AdminRoleType, auth.ViewerRoleType},
+			Permissions: []string{auth.PermissionUserRead},
+		}
+		writers = auth.Requirement{
+			RoleTypes:   []string{auth.AdminRoleType},
+			Permissions: []string{auth.PermissionUserWrite},
+		}
+	)
+
+	return auth.Routes{
+		auth.Route(http.MethodGet, "/user"):                            readers,
+		auth.Route(http.MethodGet, "/user/export"):                     readers,
+		auth.Route(http.MethodGet, "/user/:user_id"):                   readers,
+		auth.Route(http.MethodPost, "/user/import"):                    writers,
+		auth.Route(http.MethodPut, "/user/:user_id"):                   writers,
+		auth.Route(http.MethodDelete, "/user/:user_id"):                writers,
+		auth.Route(http.MethodGet, "/user/:user_id/history"):           readers,
+		auth.Route(http.MethodGet, "/user/:user_id/roles"):             readers,
+		auth.Route(http.MethodPost, "/user/:user_id/roles"):            writers,
+		auth.Route(http.MethodDelete, "/user/:user_id/roles/:role_id"): writers,
+		auth.Route(http.MethodGet, "/user/:user_id/effective-roles"):   readers,
+		auth.Route(http.MethodGet, "/user/:user_id/permissions"):       readers,
+	}
+}
+
+// parseUserFilter reads the user filter from query string. Dates can be sent as RFC 3339 timestamps or as plain dates.
+func parseUserFilter(ctx *gin.Context) (UserFilter, error) {
+	filter := UserFilter{
+		UserName: ctx.Query("user_name"),
+		Alias:    ctx.Query("alias"),
+		Email:    ctx.Query("email"),
+	}
+
+	if value, ok := ctx.GetQuery("active"); ok {
+		active, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("active must be a boolean")
+		}
+		filter.Active = &active
+	}
+
+	for _, dateParam := range []struct {
+		name  string
+		field **time.Time
+	}{
+		{name: "date_created_from", field: &filter.DateCreatedFrom},
+		{name: "date_created_to", field: &filter.DateCreatedTo},
+	} {
+		value, ok := ctx.GetQuery(dateParam.name)
+		if !ok {
+			continue
+		}
+		date, err := parseDate(value)
+		if err != nil {
+			return filter, fmt.Errorf("%s must be a date (YYYY-MM-DD) or a RFC 3339 timestamp", dateParam.name)
+		}
+		*dateParam.field = &date
+	}
+
+	if filter.DateCreatedFrom != nil && filter.DateCreatedTo != nil && !filter.DateCreatedFrom.Before(*filter.DateCreatedTo) {
+		return filter, errors.New("date_created_from must be before date_created_to")
+	}
+
+	return filter, nil
+}
+
+func parseSearchRequest(ctx *gin.Context) (SearchRequest, error) {
+	var (
+		request SearchRequest
+		err     error
+	)
+
+	if request.Filter, err = parseUserFilter(ctx); err != nil {
+		return request, err
+	}
+
+	request.SortBy = ctx.DefaultQuery("sort_by", "id")
+	if !searchSortColumns[request.SortBy] {
+		return request, errors.New("sort_by must be one of id, user_name, alias, email or date_created")
+	}
+
+	switch ctx.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		request.Desc = true
+	default:
+		return request, errors.New("order must be asc or desc")
+	}
+
+	if value, ok := ctx.GetQuery("limit"); ok {
+		if request.Limit, err = strconv.Atoi(value); err != nil || request.Limit <= 0 || request.Limit > maxSearchLimit {
+			return request, fmt.Errorf("limit must be an integer between 1 and %d", maxSearchLimit)
+		}
+	}
+
+	if value, ok := ctx.GetQuery("cursor"); ok {
+		cursor, err := decodeSearchCursor(value)
+		if err != nil {
+			return request, err
+		}
+		request.After = &cursor
+	}
+
+	return request, nil
+}
+
+// importFormat returns the format of an imported file. Format param takes precedence over Content-Type header.
+func importFormat(ctx *gin.Context) string {
+	if format, ok := ctx.GetQuery("format"); ok {
+		return format
+	}
+
+	switch ctx.ContentType() {
+	case "text/csv":
+		return "csv"
+	case "application/x-ndjson", "application/ndjson":
+		return "ndjson"
+	}
+
+	return ""
+}
+
+// parseCSVImport reads users from a CSV file. Its first record must be a header naming user_name, alias and email
+// columns, which can be in any order.
+func parseCSVImport(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("csv header cannot be read: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.TrimSpace(column)] = i
+	}
+
+	for _, column := range []string{"user_name", "alias", "email"} {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("csv header must have %s column", column)
+		}
+	}
+
+	field := func(record []string, column string) string {
+		if i := columns[column]; i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv cannot be read: %w", err)
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("file cannot have more than %d users", maxImportRows)
+		}
+
+		row := ImportRow{
+			Row: len(rows) + 1,
+			Request: NewUserRequest{
+				UserName: field(record, "user_name"),
+				Alias:    field(record, "alias"),
+				Email:    field(record, "email"),
+			},
+		}
+		row.Err = binding.Validator.ValidateStruct(row.Request)
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseNDJSONImport reads users from a file with a JSON object by line. Blank lines are ignored.
+func parseNDJSONImport(r io.Reader) ([]ImportRow, error) {
+	var (
+		rows    []ImportRow
+		scanner = bufio.NewScanner(r)
+	)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("file cannot have more than %d users", maxImportRows)
+		}
+
+		row := ImportRow{Row: len(rows) + 1}
+		if err := json.Unmarshal(line, &row.Request); err != nil {
+			row.Err = fmt.Errorf("invalid json: %w", err)
+		} else {
+			row.Err = binding.Validator.ValidateStruct(row.Request)
+		}
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ndjson cannot be read: %w", err)
+	}
+
+	return rows, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+const exportFlushEvery = 100
+
+// userExporter writes users in a file format. Written users are buffered until flush is called.
+type userExporter interface {
+	contentType() string
+	fileName() string
+	write(User) error
+	flush() error
+}
+
+type csvExporter struct {
+	writer        *csv.Writer
+	flusher       http.Flusher
+	headerWritten bool
+}
+
+func newCSVExporter(w gin.ResponseWriter) *csvExporter {
+	return &csvExporter{writer: csv.NewWriter(w), flusher: w}
+}
+
+func (e *csvExporter) contentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (e *csvExporter) fileName() string {
+	return "users.csv"
+}
+
+func (e *csvExporter) writeHeader() error {
+	if e.headerWritten {
+		return nil
+	}
+	e.headerWritten = true
+	return e.writer.Write([]string{"user_id", "user_name", "alias", "email", "active", "date_created", "version"})
+}
+
+func (e *csvExporter) write(u User) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	return e.writer.Write([]string{
+		strconv.FormatInt(u.ID, 10),
+		u.UserName,
+		u.Alias,
+		u.Email,
+		strconv.FormatBool(u.Active),
+		u.DateCreated.Format(time.RFC3339),
+		strconv.FormatInt(u.Version, 10),
+	})
+}
+
+func (e *csvExporter) flush() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.writer.Flush()
+	if err := e.writer.Error(); err != nil {
+		return err
+	}
+	e.flusher.Flush()
+	return nil
+}
+
+type ndjsonExporter struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+	flusher http.Flusher
+}
+
+func newNDJSONExporter(w gin.ResponseWriter) *ndjsonExporter {
+	writer := bufio.NewWriter(w)
+	return &ndjsonExporter{writer: writer, encoder: json.NewEncoder(writer), flusher: w}
+}
+
+func (e *ndjsonExporter) contentType() string {
+	return "application/x-ndjson"
+}
+
+func (e *ndjsonExporter) fileName() string {
+	return "users.ndjson"
+}
+
+func (e *ndjsonExporter) write(u User) error {
+	return e.encoder.Encode(u)
+}
+
+func (e *ndjsonExporter) flush() error {
+	if err := e.writer.Flush(); err != nil {
+		return err
+	}
+	e.flusher.Flush()
+	return nil
+}
+
+func newETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// parseIfMatch returns the user version expected by If-Match header. Empty header and "*" do not expect a version.
+func parseIfMatch(header string) (*int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(header, "W/"), `"`), 10, 64)
+	if err != nil {
+		return nil, errors.New("If-Match header must be an ETag returned by this API")
+	}
+
+	return &version, nil
 }
 
 func newBadRequestResponse(message string) map[string]interface{} {
@@ -145,6 +908,32 @@ func newBadRequestResponse(message string) map[string]interface{} {
 	}
 }
 
+// newSameValueResponse generates a bad request response which also says the conflicting field when it is known.
+func newSameValueResponse(err error) map[string]interface{} {
+	response := newBadRequestResponse(err.Error())
+
+	var sameValueErr sameValueError
+	if errors.As(err, &sameValueErr) {
+		response["field"] = sameValueErr.field
+	}
+
+	return response
+}
+
+func newForbiddenResponse(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     message,
+		"status_code": http.StatusForbidden,
+	}
+}
+
+func newPreconditionFailedResponse(message string) map[string]interface{} {
+	return map[string]interface{}{
+		"message":     message,
+		"status_code": http.StatusPreconditionFailed,
+	}
+}
+
 func newNotFoundError(by string, id any) map[string]interface{} {
 	return map[string]interface{}{
 		"message":     "element not found",
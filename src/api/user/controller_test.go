@@ -5,14 +5,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
 	"maria/src/api/util"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -42,6 +49,7 @@ func (c *ControllerSuite) TestGetUserByID() {
 			Email:       "user@email.com",
 			DateCreated: time.Now(),
 			Active:      true,
+			Version:     4,
 		}
 	)
 
@@ -52,6 +60,7 @@ func (c *ControllerSuite) TestGetUserByID() {
 		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
 		expectedCode   int
 		expectedBody   string
+		expectedETag   string
 	}
 
 	tests := []test{
@@ -96,6 +105,7 @@ func (c *ControllerSuite) TestGetUserByID() {
 			applyMockCalls: setServiceGetByIDMock(user, nil, userID),
 			expectedCode:   http.StatusOK,
 			expectedBody:   util.RenderToJSON(user),
+			expectedETag:   `"4"`,
 		},
 	}
 
@@ -124,6 +134,7 @@ func (c *ControllerSuite) TestGetUserByID() {
 
 			assert.Equal(t, test.expectedCode, r.Code)
 			assert.Equal(t, test.expectedBody, r.Body.String())
+			assert.Equal(t, test.expectedETag, r.Header().Get("ETag"))
 		})
 	}
 }
@@ -228,10 +239,271 @@ func (c *ControllerSuite) TestPost() {
 	}
 }
 
+func (c *ControllerSuite) TestPostIgnoresTenantOfAnonymousCallers() {
+	var (
+		userID      = int64(10)
+		userRequest = NewUserRequest{UserName: "name", Alias: "alias", Email: "email@email.com"}
+		newUser     = userRequest.toUser(userID, time.Time{}, false)
+	)
+
+	serv := newServiceForTest()
+	assertsCalls, err := mockPersisterApplier{
+		setPersiterSelectByAnyMock(nil, nil, userRequest.UserName, userRequest.Alias, userRequest.Email),
+		setPersiterWithTransactionMock(nil),
+		setPersiterCreateUserMock(userID, nil, userRequest),
+		setPersiterCreateVerificationTokenMock(nil, testToken, userID, testNow.Add(verificationTokenTTL)),
+		setPersiterSelectByIDMock(newUser, nil, userID),
+	}.apply(&serv)
+	if err != nil {
+		assert.Fail(c.T(), err.Error())
+		return
+	}
+	defer assertsCalls(c.T())
+
+	repository := serv.userRepository.(*dbMock)
+	repository.On(util.GetFunctionName(repository.record), mock.Anything).Return(nil).Once()
+
+	// no grants are loaded for a public route, so any query made by the authorizer fails the test
+	client, sqlMock, err := sqlmock.New()
+	if err != nil {
+		assert.Fail(c.T(), err.Error())
+		return
+	}
+
+	controller := NewController(serv)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(auth.NewAuthorizer(auth.NewRelationalDB(client), controller.RequiredRoles()).Authorize)
+	router.Use(auth.Tenant)
+	controller.SetURLMapping(router)
+
+	body, err := json.Marshal(userRequest)
+	if err != nil {
+		assert.Fail(c.T(), err.Error())
+		return
+	}
+	req := httptest.NewRequest(http.MethodPost, "/user", bytes.NewBuffer(body))
+	req.Header.Set(auth.TenantHeader, "7")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(c.T(), http.StatusOK, w.Code)
+	repository.AssertNotCalled(c.T(), util.GetFunctionName(repository.linkClient), mock.Anything, mock.Anything)
+	assert.NoError(c.T(), sqlMock.ExpectationsWereMet())
+}
+
+func (c *ControllerSuite) TestVerify() {
+	var (
+		customError = errors.New("custom error")
+		request     = VerifyUserRequest{Token: "token"}
+		user        = User{ID: 10, Active: true}
+	)
+
+	type test struct {
+		name           string
+		body           VerifyUserRequest
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "token missed",
+			body:         VerifyUserRequest{},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(
+				"Key: 'VerifyUserRequest.Token' Error:Field validation for 'Token' failed on the 'required' tag")),
+		},
+		{
+			name:           "token expired",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceVerifyMock(User{}, verificationTokenExpiredError, request),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   util.RenderToJSON(newBadRequestResponse(verificationTokenExpiredError.Error())),
+		},
+		{
+			name:           "service return internal error",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceVerifyMock(User{}, customError, request),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceVerifyMock(user, nil, request),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(user),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(nil, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Verify(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestImport() {
+	const (
+		csvFile = "email,user_name,alias\n" +
+			"first@email.com, first, first\n" +
+			"second@email.com,second\n"
+		ndjsonFile = `{"user_name":"first","alias":"first","email":"first@email.com"}` + "\n\n" +
+			`{"user_name":` + "\n"
+	)
+	var (
+		customError = errors.New("custom error")
+		first       = NewUserRequest{UserName: "first", Alias: "first", Email: "first@email.com"}
+		csvRows     = []ImportRow{
+			{Row: 1, Request: first},
+			{
+				Row:     2,
+				Request: NewUserRequest{UserName: "second", Email: "second@email.com"},
+				Err: binding.Validator.ValidateStruct(
+					NewUserRequest{UserName: "second", Email: "second@email.com"}),
+			},
+		}
+		ndjsonRows = []ImportRow{
+			{Row: 1, Request: first},
+			{Row: 2, Err: fmt.Errorf("invalid json: %w", json.Unmarshal([]byte(`{"user_name":`), &NewUserRequest{}))},
+		}
+		report = ImportReport{Total: 2, Created: 1, Failed: 1}
+	)
+
+	type test struct {
+		name           string
+		queryString    string
+		contentType    string
+		body           string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "atomic is not a boolean",
+			queryString:  "atomic=yes",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("atomic must be a boolean")),
+		},
+		{
+			name:         "format is not supported",
+			contentType:  "application/json",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(
+				"format must be csv or ndjson, specify it by format param or Content-Type header")),
+		},
+		{
+			name:         "csv header misses a column",
+			contentType:  "text/csv",
+			body:         "user_name,email\nname,email@email.com\n",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("csv header must have alias column")),
+		},
+		{
+			name:         "file is empty",
+			queryString:  "format=ndjson",
+			body:         "\n",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("file does not have any user")),
+		},
+		{
+			name:           "service return internal error",
+			contentType:    "text/csv",
+			body:           csvFile,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceImportMock(ImportReport{}, customError, csvRows, false),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "atomic import is rejected",
+			queryString:    "atomic=true",
+			contentType:    "text/csv; charset=utf-8",
+			body:           csvFile,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceImportMock(ImportReport{Atomic: true, Failed: 1}, nil, csvRows, true),
+			expectedCode:   http.StatusUnprocessableEntity,
+			expectedBody:   util.RenderToJSON(ImportReport{Atomic: true, Failed: 1}),
+		},
+		{
+			name:           "ndjson imported",
+			contentType:    "application/x-ndjson",
+			body:           ndjsonFile,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceImportMock(report, nil, ndjsonRows, false),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(report),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(nil, test.queryString, nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+			ctx.Request.Header = http.Header{"Content-Type": []string{test.contentType}}
+			ctx.Request.Body = io.NopCloser(strings.NewReader(test.body))
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Import(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
 func (c *ControllerSuite) TestPut() {
 	var (
 		active          = true
 		requestToActive = ModifyUserRequest{Active: &active}
+		blank           = " "
+		email           = "new@email.com"
+		requestEmail    = ModifyUserRequest{Email: &email}
+		version         = int64(3)
 		userID          = int64(10)
 		customError     = errors.New("custom error")
 	)
@@ -239,6 +511,7 @@ func (c *ControllerSuite) TestPut() {
 	type test struct {
 		name           string
 		queryString    string
+		ifMatch        string
 		body           ModifyUserRequest
 		controller     Controller
 		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
@@ -277,52 +550,998 @@ func (c *ControllerSuite) TestPut() {
 				"user_id must be a positive integer")),
 		},
 		{
-			name:           "request is empty",
-			body:           ModifyUserRequest{},
-			queryString:    "user_id=10",
-			controller:     NewController(newServiceMock()),
-			applyMockCalls: nil,
-			expectedCode:   http.StatusBadRequest,
-			expectedBody: util.RenderToJSON(newBadRequestResponse(
-				"request does not specify a change to be applied")),
+			name:           "request is empty",
+			body:           ModifyUserRequest{},
+			queryString:    "user_id=10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: nil,
+			expectedCode:   http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(
+				"request does not specify a change to be applied")),
+		},
+		{
+			name:        "user not found",
+			body:        requestToActive,
+			queryString: "user_id=10",
+			controller:  NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(
+				User{},
+				fmt.Errorf("error: %w", userNotFoundError),
+				requestToActive,
+				User{ID: userID}),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(
+				fmt.Errorf("error: %w", userNotFoundError).Error())),
+		},
+		{
+			name:         "user name is blank",
+			body:         ModifyUserRequest{UserName: &blank},
+			queryString:  "user_id=10",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_name cannot be empty")),
+		},
+		{
+			name:        "another user has the same email",
+			body:        requestEmail,
+			queryString: "user_id=10",
+			controller:  NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(
+				User{},
+				userWithSameValueErrorFunc("email"),
+				requestEmail,
+				User{ID: userID}),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(map[string]interface{}{
+				"message":     userWithSameValueErrorFunc("email").Error(),
+				"field":       "email",
+				"status_code": http.StatusBadRequest,
+			}),
+		},
+		{
+			name:         "if match header is not valid",
+			body:         requestToActive,
+			queryString:  "user_id=10",
+			ifMatch:      "abc",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(
+				"If-Match header must be an ETag returned by this API")),
+		},
+		{
+			name:        "version is stale",
+			body:        requestToActive,
+			queryString: "user_id=10",
+			ifMatch:     `"3"`,
+			controller:  NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(
+				User{},
+				versionMismatchError,
+				ModifyUserRequest{Active: &active, Version: &version},
+				User{ID: userID}),
+			expectedCode: http.StatusPreconditionFailed,
+			expectedBody: util.RenderToJSON(newPreconditionFailedResponse(versionMismatchError.Error())),
+		},
+		{
+			name:        "version matches",
+			body:        requestToActive,
+			queryString: "user_id=10",
+			ifMatch:     `W/"3"`,
+			controller:  NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(
+				User{ID: userID, Version: version + 1},
+				nil,
+				ModifyUserRequest{Active: &active, Version: &version},
+				User{ID: userID}),
+			expectedCode: http.StatusOK,
+			expectedBody: util.RenderToJSON(User{ID: userID, Version: version + 1}),
+		},
+		{
+			name:           "internal error",
+			body:           requestToActive,
+			queryString:    "user_name=name",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(User{}, customError, requestToActive, User{UserName: "name"}),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "status ok",
+			body:           requestToActive,
+			queryString:    "alias=alias",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServicePutMock(User{Alias: "alias"}, nil, requestToActive, User{Alias: "alias"}),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(User{Alias: "alias"}),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(nil, test.queryString, test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+			ctx.Request.Header = http.Header{"If-Match": []string{test.ifMatch}}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Put(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestDelete() {
+	var (
+		userID      = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		param          string
+		queryString    string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "user_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_id param is missed")),
+		},
+		{
+			name:  "it cannot parse user_id param",
+			param: "word",
+			controller: Controller{
+				integerParser: func(s string, base int, bitSize int) (i int64, err error) {
+					return 0, customError
+				},
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(customError.Error())),
+		},
+		{
+			name:         "hard is not a boolean",
+			param:        "10",
+			queryString:  "hard=yes",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("hard must be a boolean")),
+		},
+		{
+			name:           "user not found",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(User{}, userNotFoundError, userID, false),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("user_id", userID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(User{}, customError, userID, false),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "user deactivated",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(User{ID: userID}, nil, userID, false),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(User{ID: userID}),
+		},
+		{
+			name:           "user deleted",
+			param:          "10",
+			queryString:    "hard=true",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(User{ID: userID}, nil, userID, true),
+			expectedCode:   http.StatusNoContent,
+			expectedBody:   "",
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"user_id": test.param}, test.queryString, nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Delete(ctx)
+
+			assert.Equal(t, test.expectedCode, ctx.Writer.Status())
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestGetHistory() {
+	var (
+		userID      = int64(10)
+		customError = errors.New("custom error")
+		history     = audit.History{
+			Results: []audit.Entry{{ID: 19, Action: audit.ActionModify, EntityID: userID}},
+			Paging:  audit.Paging{Limit: 5, NextCursor: "19"},
+		}
+	)
+
+	type test struct {
+		name           string
+		param          string
+		queryString    string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "user_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_id param is missed")),
+		},
+		{
+			name:  "it cannot parse user_id param",
+			param: "word",
+			controller: Controller{
+				integerParser: func(s string, base int, bitSize int) (i int64, err error) {
+					return 0, customError
+				},
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(customError.Error())),
+		},
+		{
+			name:         "limit is not a positive integer",
+			param:        "10",
+			queryString:  "limit=-1",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("limit must be a positive integer")),
+		},
+		{
+			name:         "invalid cursor",
+			param:        "10",
+			queryString:  "cursor=abc",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(invalidCursorError.Error())),
+		},
+		{
+			name:           "service return error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetHistoryMock(audit.History{}, customError, userID, audit.Page{}),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			queryString:    "limit=5&cursor=20",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetHistoryMock(history, nil, userID, audit.Page{Limit: 5, BeforeID: 20}),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(history),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"user_id": test.param}, test.queryString, nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.GetHistory(ctx)
+
+			assert.Equal(t, test.expectedCode, ctx.Writer.Status())
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestGetRoles() {
+	var (
+		userID      = int64(10)
+		customError = errors.New("custom error")
+		grants      = []RoleGrant{{ID: 1, UserID: userID, RoleID: 2, RoleName: "admin", RoleType: "user"}}
+	)
+
+	type test struct {
+		name           string
+		param          string
+		queryString    string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "user_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_id param is missed")),
+		},
+		{
+			name:         "include_expired is not a boolean",
+			param:        "10",
+			queryString:  "include_expired=yes",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("include_expired must be a boolean")),
+		},
+		{
+			name:           "user not found",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListRoleGrantsMock(nil, userNotFoundError, userID, false),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("user_id", userID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListRoleGrantsMock(nil, customError, userID, false),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			queryString:    "include_expired=true",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListRoleGrantsMock(grants, nil, userID, true),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(grants),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"user_id": test.param}, test.queryString, nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.GetRoles(ctx)
+
+			assert.Equal(t, test.expectedCode, ctx.Writer.Status())
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestGetEffectiveRoles() {
+	var (
+		userID      = int64(10)
+		customError = errors.New("custom error")
+		roles       = []EffectiveRole{
+			{RoleID: 2, RoleName: "client-admin", RoleType: "client", Source: sourceGranted, GrantID: 1},
+			{RoleID: 3, RoleName: "client-viewer", RoleType: "client", Source: sourceInherited, GrantID: 1, InheritedThrough: []int64{2}},
+		}
+	)
+
+	type test struct {
+		name           string
+		param          string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "user_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_id param is missed")),
+		},
+		{
+			name:           "user not found",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListEffectiveRolesMock(nil, userNotFoundError, userID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("user_id", userID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListEffectiveRolesMock(nil, customError, userID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListEffectiveRolesMock(roles, nil, userID),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(roles),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"user_id": test.param}, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.GetEffectiveRoles(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestGetPermissions() {
+	var (
+		userID      = int64(10)
+		customError = errors.New("custom error")
+		permissions = []UserPermission{{Permission: "user:read", GrantedBy: []int64{2, 3}}}
+	)
+
+	type test struct {
+		name           string
+		param          string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "user_id param missed",
+			param:        "",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_id param is missed")),
+		},
+		{
+			name:           "user not found",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListPermissionsMock(nil, userNotFoundError, userID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("user_id", userID)),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListPermissionsMock(nil, customError, userID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListPermissionsMock(permissions, nil, userID),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(permissions),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"user_id": test.param}, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.GetPermissions(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestPostRole() {
+	const bindMSGError = "Key: 'GrantRoleRequest.RoleID' Error:Field validation for 'RoleID' failed on the 'required' tag"
+	var (
+		userID      = int64(10)
+		roleID      = int64(2)
+		customError = errors.New("custom error")
+		request     = GrantRoleRequest{RoleID: roleID}
+		grant       = RoleGrant{ID: 1, UserID: userID, RoleID: roleID}
+	)
+
+	type test struct {
+		name           string
+		param          string
+		body           GrantRoleRequest
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "user_id param missed",
+			param:        "",
+			body:         request,
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("user_id param is missed")),
+		},
+		{
+			name:         "role_id missed",
+			param:        "10",
+			body:         GrantRoleRequest{},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(bindMSGError)),
+		},
+		{
+			name:           "user not found",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGrantRoleMock(RoleGrant{}, userNotFoundError, userID, request),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("user_id", userID)),
+		},
+		{
+			name:           "role not found",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGrantRoleMock(RoleGrant{}, roleNotFoundError, userID, request),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("role_id", roleID)),
+		},
+		{
+			name:           "role is not active",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGrantRoleMock(RoleGrant{}, inactiveRoleError, userID, request),
+			expectedCode:   http.StatusBadRequest,
+			expectedBody:   util.RenderToJSON(newBadRequestResponse(inactiveRoleError.Error())),
+		},
+		{
+			name:           "role gives access the caller does not have",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGrantRoleMock(RoleGrant{}, roleNotGrantableError, userID, request),
+			expectedCode:   http.StatusForbidden,
+			expectedBody:   util.RenderToJSON(newForbiddenResponse(roleNotGrantableError.Error())),
+		},
+		{
+			name:           "service return internal error",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGrantRoleMock(RoleGrant{}, customError, userID, request),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "happy case",
+			param:          "10",
+			body:           request,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGrantRoleMock(grant, nil, userID, request),
+			expectedCode:   http.StatusOK,
+			expectedBody:   util.RenderToJSON(grant),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(map[string]string{"user_id": test.param}, "", test.body)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.PostRole(ctx)
+
+			assert.Equal(t, test.expectedCode, ctx.Writer.Status())
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestDeleteRole() {
+	var (
+		userID      = int64(10)
+		roleID      = int64(2)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		params         map[string]string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "role_id param missed",
+			params:       map[string]string{"user_id": "10"},
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("role_id param is missed")),
+		},
+		{
+			name:           "role is not granted",
+			params:         map[string]string{"user_id": "10", "role_id": "2"},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceRevokeRoleMock(roleGrantNotFoundError, userID, roleID),
+			expectedCode:   http.StatusNotFound,
+			expectedBody:   util.RenderToJSON(newNotFoundError("role_id", roleID)),
+		},
+		{
+			name:           "service return internal error",
+			params:         map[string]string{"user_id": "10", "role_id": "2"},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceRevokeRoleMock(customError, userID, roleID),
+			expectedCode:   http.StatusInternalServerError,
+			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name:           "role revoked",
+			params:         map[string]string{"user_id": "10", "role_id": "2"},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceRevokeRoleMock(nil, userID, roleID),
+			expectedCode:   http.StatusNoContent,
+			expectedBody:   "",
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(test.params, "", nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.DeleteRole(ctx)
+
+			assert.Equal(t, test.expectedCode, ctx.Writer.Status())
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestSearch() {
+	var (
+		active      = true
+		from        = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		customError = errors.New("custom error")
+		cursor      = searchCursor{SortBy: "user_name", Value: "name", ID: 10}
+		response    = SearchResponse{
+			Results: []User{{ID: 11, UserName: "other"}},
+			Paging:  Paging{Limit: 1, NextCursor: newSearchCursor("user_name", User{ID: 11, UserName: "other"}).encode()},
+		}
+	)
+
+	type test struct {
+		name           string
+		queryString    string
+		controller     Controller
+		applyMockCalls func(controller *Controller) (func(t *testing.T), error)
+		expectedCode   int
+		expectedBody   string
+	}
+
+	tests := []test{
+		{
+			name:         "active is not a boolean",
+			queryString:  "active=yes",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("active must be a boolean")),
+		},
+		{
+			name:         "date is not valid",
+			queryString:  "date_created_to=yesterday",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(
+				"date_created_to must be a date (YYYY-MM-DD) or a RFC 3339 timestamp")),
+		},
+		{
+			name:         "date range is not valid",
+			queryString:  "date_created_from=2022-01-02&date_created_to=2022-01-01",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("date_created_from must be before date_created_to")),
+		},
+		{
+			name:         "sort column is not valid",
+			queryString:  "sort_by=active",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(
+				"sort_by must be one of id, user_name, alias, email or date_created")),
+		},
+		{
+			name:         "order is not valid",
+			queryString:  "order=random",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("order must be asc or desc")),
+		},
+		{
+			name:         "limit is not valid",
+			queryString:  "limit=0",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse("limit must be an integer between 1 and 200")),
+		},
+		{
+			name:         "cursor is not valid",
+			queryString:  "cursor=invalid",
+			controller:   NewController(newServiceMock()),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(invalidCursorError.Error())),
+		},
+		{
+			name:        "service return invalid cursor error",
+			queryString: "cursor=" + cursor.encode(),
+			controller:  NewController(newServiceMock()),
+			applyMockCalls: setServiceSearchUsersMock(
+				SearchResponse{},
+				invalidCursorError,
+				SearchRequest{SortBy: "id", After: &cursor}),
+			expectedCode: http.StatusBadRequest,
+			expectedBody: util.RenderToJSON(newBadRequestResponse(invalidCursorError.Error())),
+		},
+		{
+			name:        "service return internal error",
+			queryString: "",
+			controller:  NewController(newServiceMock()),
+			applyMockCalls: setServiceSearchUsersMock(
+				SearchResponse{},
+				customError,
+				SearchRequest{SortBy: "id"}),
+			expectedCode: http.StatusInternalServerError,
+			expectedBody: util.RenderToJSON(newInternalServerError(customError)),
+		},
+		{
+			name: "happy case",
+			queryString: "active=true&date_created_from=2022-01-01&user_name=na&alias=al&email=em" +
+				"&sort_by=user_name&order=desc&limit=1&cursor=" + cursor.encode(),
+			controller: NewController(newServiceMock()),
+			applyMockCalls: setServiceSearchUsersMock(
+				response,
+				nil,
+				SearchRequest{
+					Filter: UserFilter{
+						Active:          &active,
+						DateCreatedFrom: &from,
+						UserName:        "na",
+						Alias:           "al",
+						Email:           "em",
+					},
+					SortBy: "user_name",
+					Desc:   true,
+					Limit:  1,
+					After:  &cursor,
+				}),
+			expectedCode: http.StatusOK,
+			expectedBody: util.RenderToJSON(response),
+		},
+	}
+
+	for _, test := range tests {
+		c.T().Run(test.name, func(t *testing.T) {
+			ctx, r, err := util.GetTestContext(nil, test.queryString, nil)
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				if assertsCalls, err := test.applyMockCalls(&test.controller); err != nil {
+					assert.Fail(t, err.Error())
+					return
+				} else {
+					defer assertsCalls(t)
+				}
+			}
+
+			test.controller.Search(ctx)
+
+			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedBody, r.Body.String())
+		})
+	}
+}
+
+func (c *ControllerSuite) TestExport() {
+	var (
+		active      = true
+		customError = errors.New("custom error")
+		dateCreated = time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+		users       = []User{
+			{ID: 1, UserName: "first", Alias: "first", Email: "first@email.com", Active: true, DateCreated: dateCreated, Version: 1},
+			{ID: 2, UserName: "second, jr", Alias: "second", Email: "second@email.com", DateCreated: dateCreated, Version: 3},
+		}
+	)
+
+	type test struct {
+		name                string
+		queryString         string
+		controller          Controller
+		applyMockCalls      func(controller *Controller) (func(t *testing.T), error)
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}
+
+	tests := []test{
+		{
+			name:                "filter is not valid",
+			queryString:         "active=yes",
+			controller:          NewController(newServiceMock()),
+			expectedCode:        http.StatusBadRequest,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        util.RenderToJSON(newBadRequestResponse("active must be a boolean")),
+		},
+		{
+			name:                "format is not valid",
+			queryString:         "format=xml",
+			controller:          NewController(newServiceMock()),
+			expectedCode:        http.StatusBadRequest,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        util.RenderToJSON(newBadRequestResponse("format must be csv or ndjson")),
 		},
 		{
-			name:        "user not found",
-			body:        requestToActive,
-			queryString: "user_id=10",
-			controller:  NewController(newServiceMock()),
-			applyMockCalls: setServicePutMock(
-				User{},
-				fmt.Errorf("error: %w", userNotFoundError),
-				requestToActive,
-				User{ID: userID}),
-			expectedCode: http.StatusBadRequest,
-			expectedBody: util.RenderToJSON(newBadRequestResponse(
-				fmt.Errorf("error: %w", userNotFoundError).Error())),
+			name:                "service return error before writing",
+			queryString:         "format=ndjson",
+			controller:          NewController(newServiceMock()),
+			applyMockCalls:      setServiceExportMock(nil, customError, UserFilter{}),
+			expectedCode:        http.StatusInternalServerError,
+			expectedContentType: "application/json; charset=utf-8",
+			expectedBody:        util.RenderToJSON(newInternalServerError(customError)),
 		},
 		{
-			name:           "internal error",
-			body:           requestToActive,
-			queryString:    "user_name=name",
-			controller:     NewController(newServiceMock()),
-			applyMockCalls: setServicePutMock(User{}, customError, requestToActive, User{UserName: "name"}),
-			expectedCode:   http.StatusInternalServerError,
-			expectedBody:   util.RenderToJSON(newInternalServerError(customError)),
+			name:                "csv without users",
+			queryString:         "",
+			controller:          NewController(newServiceMock()),
+			applyMockCalls:      setServiceExportMock(nil, nil, UserFilter{}),
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "user_id,user_name,alias,email,active,date_created,version\n",
 		},
 		{
-			name:           "status ok",
-			body:           requestToActive,
-			queryString:    "alias=alias",
-			controller:     NewController(newServiceMock()),
-			applyMockCalls: setServicePutMock(User{Alias: "alias"}, nil, requestToActive, User{Alias: "alias"}),
-			expectedCode:   http.StatusOK,
-			expectedBody:   util.RenderToJSON(User{Alias: "alias"}),
+			name:                "csv",
+			queryString:         "active=true&format=csv",
+			controller:          NewController(newServiceMock()),
+			applyMockCalls:      setServiceExportMock(users, nil, UserFilter{Active: &active}),
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody: "user_id,user_name,alias,email,active,date_created,version\n" +
+				"1,first,first,first@email.com,true,2022-01-01T10:00:00Z,1\n" +
+				"2,\"second, jr\",second,second@email.com,false,2022-01-01T10:00:00Z,3\n",
+		},
+		{
+			name:                "ndjson",
+			queryString:         "format=ndjson",
+			controller:          NewController(newServiceMock()),
+			applyMockCalls:      setServiceExportMock(users, nil, UserFilter{}),
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedBody:        util.RenderToJSON(users[0]) + "\n" + util.RenderToJSON(users[1]) + "\n",
 		},
 	}
 
 	for _, test := range tests {
 		c.T().Run(test.name, func(t *testing.T) {
-			ctx, r, err := util.GetTestContext(nil, test.queryString, test.body)
+			ctx, r, err := util.GetTestContext(nil, test.queryString, nil)
 			if err != nil {
 				assert.Fail(t, err.Error())
 				return
@@ -337,14 +1556,33 @@ func (c *ControllerSuite) TestPut() {
 				}
 			}
 
-			test.controller.Put(ctx)
+			test.controller.Export(ctx)
 
 			assert.Equal(t, test.expectedCode, r.Code)
+			assert.Equal(t, test.expectedContentType, r.Header().Get("Content-Type"))
 			assert.Equal(t, test.expectedBody, r.Body.String())
 		})
 	}
 }
 
+func (c *ControllerSuite) TestRequiredRoles() {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	controller := NewController(newServiceMock())
+	controller.SetURLMapping(router)
+
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		registered[auth.Route(route.Method, route.Path)] = true
+	}
+
+	for route, requirement := range controller.RequiredRoles() {
+		assert.True(c.T(), registered[route], "%s is not registered", route)
+		assert.NotEmpty(c.T(), requirement.RoleTypes, "%s does not require any role type", route)
+		assert.NotEmpty(c.T(), requirement.Permissions, "%s does not require any permission", route)
+	}
+}
+
 func (c *ControllerSuite) TestSetURLMapping() {
 	var (
 		userID      = int64(10)
@@ -367,6 +1605,20 @@ func (c *ControllerSuite) TestSetURLMapping() {
 	}
 
 	tests := []test{
+		{
+			name:           "search users",
+			path:           "/user?limit=10",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceSearchUsersMock(SearchResponse{}, nil, SearchRequest{SortBy: "id", Limit: 10}),
+		},
+		{
+			name:           "export users",
+			path:           "/user/export",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceExportMock([]User{{ID: userID}}, nil, UserFilter{}),
+		},
 		{
 			name:           "get user by id",
 			path:           "/user/10",
@@ -386,6 +1638,21 @@ func (c *ControllerSuite) TestSetURLMapping() {
 				userRequest,
 			),
 		},
+		{
+			name:           "verify user",
+			path:           "/user/verify",
+			method:         http.MethodPost,
+			body:           VerifyUserRequest{Token: "token"},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceVerifyMock(User{ID: userID}, nil, VerifyUserRequest{Token: "token"}),
+		},
+		{
+			name:           "delete user",
+			path:           "/user/10",
+			method:         http.MethodDelete,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceDeleteMock(User{ID: userID}, nil, userID, false),
+		},
 		{
 			name:           "put user",
 			path:           "/user/10?alias=alias",
@@ -394,6 +1661,42 @@ func (c *ControllerSuite) TestSetURLMapping() {
 			controller:     NewController(newServiceMock()),
 			applyMockCalls: setServicePutMock(User{Alias: "alias"}, nil, userRequestPut, User{Alias: "alias"}),
 		},
+		{
+			name:           "get user history",
+			path:           "/user/10/history",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGetHistoryMock(audit.History{}, nil, userID, audit.Page{}),
+		},
+		{
+			name:           "get user roles",
+			path:           "/user/10/roles",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListRoleGrantsMock([]RoleGrant{}, nil, userID, false),
+		},
+		{
+			name:           "get user effective roles",
+			path:           "/user/10/effective-roles",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListEffectiveRolesMock([]EffectiveRole{}, nil, userID),
+		},
+		{
+			name:           "get user permissions",
+			path:           "/user/10/permissions",
+			method:         http.MethodGet,
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceListPermissionsMock([]UserPermission{}, nil, userID),
+		},
+		{
+			name:           "grant user role",
+			path:           "/user/10/roles",
+			method:         http.MethodPost,
+			body:           GrantRoleRequest{RoleID: 2},
+			controller:     NewController(newServiceMock()),
+			applyMockCalls: setServiceGrantRoleMock(RoleGrant{ID: 1}, nil, userID, GrantRoleRequest{RoleID: 2}),
+		},
 	}
 
 	for _, test := range tests {
@@ -439,7 +1742,7 @@ func setServiceGetByIDMock(
 		if !ok {
 			return nil, errors.New("it could not cast to mock service")
 		}
-		s.On(util.GetFunctionName(s.getByID), userID).
+		s.On(util.GetFunctionName(s.getByID), mock.Anything, userID).
 			Return(userResponse, errorResponse).
 			Once()
 
@@ -459,7 +1762,7 @@ func setServicePostMock(
 		if !ok {
 			return nil, errors.New("it could not cast to mock service")
 		}
-		s.On(util.GetFunctionName(s.createUser), userRequest).
+		s.On(util.GetFunctionName(s.createUser), mock.Anything, userRequest).
 			Return(userResponse, errorResponse).
 			Once()
 
@@ -480,7 +1783,68 @@ func setServicePutMock(
 		if !ok {
 			return nil, errors.New("it could not cast to mock service")
 		}
-		s.On(util.GetFunctionName(s.modifyUser), request, userRequest).
+		s.On(util.GetFunctionName(s.modifyUser), mock.Anything, request, userRequest).
+			Return(userResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceSearchUsersMock(
+	response SearchResponse,
+	errorResponse error,
+	request SearchRequest,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.searchUsers), mock.Anything, request).
+			Return(response, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceDeleteMock(
+	userResponse User,
+	errorResponse error,
+	userID int64,
+	hard bool,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.deleteUser), mock.Anything, userID, hard).
+			Return(userResponse, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceVerifyMock(
+	userResponse User,
+	errorResponse error,
+	request VerifyUserRequest,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.verifyUser), mock.Anything, request).
 			Return(userResponse, errorResponse).
 			Once()
 
@@ -489,3 +1853,167 @@ func setServicePutMock(
 		}, nil
 	}
 }
+
+func setServiceImportMock(
+	response ImportReport,
+	errorResponse error,
+	rows []ImportRow,
+	atomic bool,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.importUsers), mock.Anything, rows, atomic).
+			Return(response, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceExportMock(
+	users []User,
+	errorResponse error,
+	filter UserFilter,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.exportUsers), mock.Anything, filter).
+			Return(users, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceGetHistoryMock(
+	response audit.History,
+	errorResponse error,
+	userID int64,
+	page audit.Page,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.getHistory), mock.Anything, userID, page).
+			Return(response, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceListEffectiveRolesMock(
+	response []EffectiveRole,
+	errorResponse error,
+	userID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.listEffectiveRoles), mock.Anything, userID).
+			Return(response, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceListPermissionsMock(
+	response []UserPermission,
+	errorResponse error,
+	userID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.listPermissions), mock.Anything, userID).
+			Return(response, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceListRoleGrantsMock(
+	response []RoleGrant,
+	errorResponse error,
+	userID int64,
+	includeExpired bool,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.listRoleGrants), mock.Anything, userID, includeExpired).
+			Return(response, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceGrantRoleMock(
+	response RoleGrant,
+	errorResponse error,
+	userID int64,
+	request GrantRoleRequest,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.grantRole), mock.Anything, userID, request).
+			Return(response, errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setServiceRevokeRoleMock(
+	errorResponse error,
+	userID int64,
+	roleID int64,
+) func(*Controller) (func(t *testing.T), error) {
+	return func(c *Controller) (func(t *testing.T), error) {
+		s, ok := c.service.(*serviceMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock service")
+		}
+		s.On(util.GetFunctionName(s.revokeRole), mock.Anything, userID, roleID).
+			Return(errorResponse).
+			Once()
+
+		return func(t *testing.T) {
+			s.AssertExpectations(t)
+		}, nil
+	}
+}
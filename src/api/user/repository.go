@@ -4,21 +4,117 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
 	"maria/src/api/db"
+	"strings"
+	"time"
 )
 
 const (
-	getUserByIDQuery    = `SELECT user_id, user_name, alias, email, active, date_created FROM user WHERE id = ?`
-	getUserByAnyQuery   = `SELECT user_id, user_name, alias, email, active, date_created FROM user WHERE user_name = ? OR alias = ? OR email = ?`
-	insertUserQuery     = `INSERT INTO user (user_name, alias, email, active) VALUES (?, ?, ?, false, NOW())`
-	UpdateUserByIDQuery = `UPDATE user SET active = ? WHERE id = ?`
+	getUserByIDQuery              = `SELECT id, user_name, alias, email, active, date_created, version FROM user WHERE id = ?`
+	getUserByIDInClientQuery      = getUserByIDQuery + ` AND ` + clientMemberCondition
+	getUserByAnyQuery             = `SELECT id, user_name, alias, email, active, date_created, version FROM user WHERE user_name = ? OR alias = ? OR email = ?`
+	insertUserQuery               = `INSERT INTO user (user_name, alias, email, active) VALUES (?, ?, ?, false)`
+	UpdateUserByIDQuery           = `UPDATE user SET user_name = ?, alias = ?, email = ?, active = ?, version = version + 1 WHERE id = ?`
+	updateUserByIDAndVersionQuery = `UPDATE user SET user_name = ?, alias = ?, email = ?, active = ?, version = version + 1 ` +
+		`WHERE id = ? AND version = ?`
+	searchUsersQuery     = `SELECT id, user_name, alias, email, active, date_created, version FROM user`
+	expireUserRolesQuery = `UPDATE user_role SET date_expired = NOW() ` +
+		`WHERE user_id = ? AND (date_expired IS NULL OR date_expired > NOW())`
+	expireUserClientsQuery = `UPDATE user_client SET date_expired = NOW() ` +
+		`WHERE user_id = ? AND (date_expired IS NULL OR date_expired > NOW())`
+	// tokens of a deactivated user cannot be redeemed, since redeeming them would activate the user again
+	deleteUnusedVerificationTokensQuery = `DELETE FROM user_verification_token WHERE user_id = ? AND date_used IS NULL`
+	cancelUserTasksQuery                = `UPDATE user_task SET status = 'cancelled' WHERE user_id = ? AND status NOT IN ('done', 'cancelled')`
+	// the cancellations are written to the history of the tasks before cancelling them, so from_status is kept
+	insertCancellationsQuery = `INSERT INTO user_task_status_history (user_task_id, actor, from_status, to_status, comment) ` +
+		`SELECT id, ?, status, 'cancelled', 'user was deactivated' FROM user_task ` +
+		`WHERE user_id = ? AND status NOT IN ('done', 'cancelled')`
+	deleteUserQuery              = `DELETE FROM user WHERE id = ?`
+	insertVerificationTokenQuery = `INSERT INTO user_verification_token (token, user_id, date_expired) VALUES (?, ?, ?)`
+	getVerificationTokenQuery    = `SELECT token, user_id, date_expired, date_used, date_created ` +
+		`FROM user_verification_token WHERE token = ?`
+	useVerificationTokenQuery = `UPDATE user_verification_token SET date_used = NOW() WHERE token = ? AND date_used IS NULL`
+	getGrantableRoleQuery     = `SELECT id, active FROM role WHERE id = ?`
+	// the access of a role is walked up its active parents as auth.EffectiveRolesCTE does for the grants of a user
+	getRoleAccessQuery = `WITH RECURSIVE reached (role_id, path) AS (` +
+		`SELECT id, CAST(id AS CHAR(1000)) FROM role WHERE id = ? ` +
+		`UNION ALL SELECT rp.parent_id, CONCAT(e.path, ',', rp.parent_id) FROM role_parent rp ` +
+		`JOIN reached e ON e.role_id = rp.role_id JOIN role p ON p.id = rp.parent_id ` +
+		`WHERE p.active = true AND FIND_IN_SET(rp.parent_id, e.path) = 0) ` +
+		`SELECT DISTINCT r.type, p.name FROM reached e JOIN role r ON r.id = e.role_id ` +
+		`LEFT JOIN role_permission rpm ON rpm.role_id = e.role_id LEFT JOIN permission p ON p.id = rpm.permission_id ` +
+		`ORDER BY r.type, p.name`
+	roleGrantsQuery = `SELECT ur.id, ur.user_id, ur.role_id, r.role_name, r.type, ur.date_expired, ur.date_created ` +
+		`FROM user_role ur JOIN role r ON r.id = ur.role_id`
+	getRoleGrantByIDQuery    = roleGrantsQuery + ` WHERE ur.id = ?`
+	getRoleGrantsQuery       = roleGrantsQuery + ` WHERE ur.user_id = ? ORDER BY ur.id`
+	getActiveRoleGrantsQuery = roleGrantsQuery + ` WHERE ur.user_id = ? ` +
+		`AND (ur.date_expired IS NULL OR ur.date_expired > NOW()) ORDER BY ur.id`
+	// each effective role is shown once, reached in the shortest way and, between ways as short, through the first
+	// grant
+	getEffectiveRolesQuery = auth.EffectiveRolesCTE +
+		`SELECT r.id, r.role_name, r.type, w.grant_id, w.depth, w.path FROM (` +
+		`SELECT role_id, grant_id, depth, path, ` +
+		`ROW_NUMBER() OVER (PARTITION BY role_id ORDER BY depth, grant_id, path) AS walk FROM effective) w ` +
+		`JOIN role r ON r.id = w.role_id WHERE w.walk = 1 ORDER BY w.depth, w.grant_id, w.path`
+	getRolePermissionsQuery = `SELECT rp.role_id, p.name FROM role_permission rp ` +
+		`JOIN permission p ON p.id = rp.permission_id ORDER BY p.name, rp.role_id`
+	// the link is only created when the client is active
+	linkClientQuery = `INSERT INTO user_client (user_id, client_id) SELECT ?, id FROM client WHERE id = ? AND active = true`
+	// clientMemberCondition restricts the users to the unexpired members of a client
+	clientMemberCondition = `id IN (SELECT user_id FROM user_client WHERE client_id = ? ` +
+		`AND (date_expired IS NULL OR date_expired > NOW()))`
+	insertRoleGrantQuery = `INSERT INTO user_role (user_id, role_id, date_expired) VALUES (?, ?, ?)`
+	expireRoleGrantQuery = `UPDATE user_role SET date_expired = NOW() ` +
+		`WHERE id = ? AND (date_expired IS NULL OR date_expired > NOW())`
 )
 
+// deleteUserLinksQueries removes every row referencing a user, and the rows referencing those, in the order the foreign
+// keys require. They must run before deleteUserQuery.
+var deleteUserLinksQueries = []string{
+	`DELETE FROM user_verification_token WHERE user_id = ?`,
+	`DELETE h FROM user_task_status_history h JOIN user_task t ON t.id = h.user_task_id WHERE t.user_id = ?`,
+	`DELETE FROM user_task WHERE user_id = ?`,
+	`DELETE FROM user_client WHERE user_id = ?`,
+	`DELETE FROM user_role WHERE user_id = ?`,
+}
+
+// userUniqueKeys maps the unique indexes of user table to the field each one keeps unique.
+var userUniqueKeys = map[string]string{
+	"user_user_name_uindex": "user_name",
+	"user_alias_uindex":     "alias",
+	"user_email_uindex":     "email",
+}
+
 type Querier interface {
 	selectByID(int64) (User, error)
+	selectByIDInClient(int64, int64) (User, error)
 	selectByAny(string, string, string) ([]User, error)
+	selectBySearch(SearchRequest) ([]User, error)
+	streamByFilter(UserFilter, func(User) error) error
 	createUser(NewUserRequest) (int64, error)
 	modifyUser(ModifyUserRequest, User) (bool, error)
+	expireUserRoles(int64) (int64, error)
+	expireUserClients(int64) (int64, error)
+	deleteUnusedVerificationTokens(int64) (int64, error)
+	linkClient(int64, int64) (bool, error)
+	cancelUserTasks(int64, string) (int64, error)
+	deleteUser(int64) (bool, error)
+	createVerificationToken(string, int64, time.Time) error
+	selectVerificationToken(string) (VerificationToken, error)
+	useVerificationToken(string) (bool, error)
+	record(audit.Entry) error
+	selectHistory(int64, audit.Page) (audit.History, error)
+	selectGrantableRole(int64) (grantableRole, error)
+	selectRoleAccess(int64) (roleAccess, error)
+	selectRoleGrantByID(int64) (RoleGrant, error)
+	selectRoleGrants(int64, bool) ([]RoleGrant, error)
+	selectEffectiveRoles(int64) ([]EffectiveRole, error)
+	selectRolePermissions() ([]rolePermission, error)
+	createRoleGrant(int64, int64, *time.Time) (int64, error)
+	expireRoleGrant(int64) (bool, error)
 }
 
 type Persister interface {
@@ -43,13 +139,22 @@ type relationalDB struct {
 }
 
 func (r *relationalDB) selectByID(userID int64) (User, error) {
+	return r.selectOne(getUserByIDQuery, userID)
+}
+
+// selectByIDInClient returns the user when it is an unexpired member of the client.
+func (r *relationalDB) selectByIDInClient(userID, clientID int64) (User, error) {
+	return r.selectOne(getUserByIDInClientQuery, userID, clientID)
+}
+
+func (r *relationalDB) selectOne(query string, args ...any) (User, error) {
 	var (
 		u   User
 		row *sql.Row
 		err error
 	)
 
-	row = r.client.QueryRow(getUserByIDQuery, userID)
+	row = r.client.QueryRow(query, args...)
 
 	if err = row.Scan(
 		&u.ID,
@@ -58,8 +163,9 @@ func (r *relationalDB) selectByID(userID int64) (User, error) {
 		&u.Email,
 		&u.Active,
 		&u.DateCreated,
+		&u.Version,
 	); err != nil {
-		return u, db.ScanError(err, getUserByIDQuery)
+		return u, db.ScanError(err, query)
 	}
 
 	return u, nil
@@ -91,6 +197,7 @@ func (r *relationalDB) selectByAny(name, alias, email string) ([]User, error) {
 			&u.Email,
 			&u.Active,
 			&u.DateCreated,
+			&u.Version,
 		); err != nil {
 			return nil, db.ScanError(err, getUserByAnyQuery)
 		}
@@ -104,6 +211,194 @@ func (r *relationalDB) selectByAny(name, alias, email string) ([]User, error) {
 	return users, nil
 }
 
+func (r *relationalDB) selectBySearch(request SearchRequest) ([]User, error) {
+	var (
+		rows  *sql.Rows
+		err   error
+		users []User
+	)
+
+	query, args := buildSearchQuery(request)
+
+	if rows, err = r.client.Query(query, args...); err != nil {
+		return nil, db.QueryError(err, query)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var u User
+		if err = rows.Scan(
+			&u.ID,
+			&u.UserName,
+			&u.Alias,
+			&u.Email,
+			&u.Active,
+			&u.DateCreated,
+			&u.Version,
+		); err != nil {
+			return nil, db.ScanError(err, query)
+		}
+		users = append(users, u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, query)
+	}
+
+	return users, nil
+}
+
+// streamByFilter calls fn with every user matching the filter, sorted by id. Users are read one by one from the
+// database, so memory does not grow with the amount of users. Iteration stops at the first error returned by fn.
+func (r *relationalDB) streamByFilter(filter UserFilter, fn func(User) error) error {
+	var (
+		rows *sql.Rows
+		err  error
+	)
+
+	query, args := buildExportQuery(filter)
+
+	if rows, err = r.client.Query(query, args...); err != nil {
+		return db.QueryError(err, query)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var u User
+		if err = rows.Scan(
+			&u.ID,
+			&u.UserName,
+			&u.Alias,
+			&u.Email,
+			&u.Active,
+			&u.DateCreated,
+			&u.Version,
+		); err != nil {
+			return db.ScanError(err, query)
+		}
+		if err = fn(u); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return db.RowsError(err, query)
+	}
+
+	return nil
+}
+
+func buildExportQuery(filter UserFilter) (string, []any) {
+	conditions, args := buildFilterConditions(filter)
+
+	query := searchUsersQuery
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	return query + " ORDER BY id ASC", args
+}
+
+// buildFilterConditions returns the where conditions and its arguments for the given filter.
+func buildFilterConditions(filter UserFilter) ([]string, []any) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if filter.Active != nil {
+		conditions = append(conditions, "active = ?")
+		args = append(args, *filter.Active)
+	}
+	if filter.DateCreatedFrom != nil {
+		conditions = append(conditions, "date_created >= ?")
+		args = append(args, *filter.DateCreatedFrom)
+	}
+	if filter.DateCreatedTo != nil {
+		conditions = append(conditions, "date_created < ?")
+		args = append(args, *filter.DateCreatedTo)
+	}
+	if filter.UserName != "" {
+		conditions = append(conditions, "user_name LIKE ?")
+		args = append(args, containsPattern(filter.UserName))
+	}
+	if filter.Alias != "" {
+		conditions = append(conditions, "alias LIKE ?")
+		args = append(args, containsPattern(filter.Alias))
+	}
+	if filter.Email != "" {
+		conditions = append(conditions, "email LIKE ?")
+		args = append(args, containsPattern(filter.Email))
+	}
+	if filter.clientID != 0 {
+		conditions = append(conditions, clientMemberCondition)
+		args = append(args, filter.clientID)
+	}
+
+	return conditions, args
+}
+
+// buildSearchQuery generates a keyset paginated query. Rows are sorted by the requested column and then by id,
+// so the cursor (last sort value, last id) always points to a unique position.
+func buildSearchQuery(request SearchRequest) (string, []any) {
+	var (
+		conditions, args = buildFilterConditions(request.Filter)
+		sortBy           = request.SortBy
+		direction        = "ASC"
+		comparator       = ">"
+	)
+
+	if !searchSortColumns[sortBy] {
+		sortBy = "id"
+	}
+
+	if request.Desc {
+		direction, comparator = "DESC", "<"
+	}
+
+	if request.After != nil {
+		if sortBy == "id" {
+			conditions = append(conditions, fmt.Sprintf("id %s ?", comparator))
+			args = append(args, request.After.ID)
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", sortBy, comparator))
+			args = append(args, request.After.value(), request.After.value(), request.After.ID)
+		}
+	}
+
+	query := searchUsersQuery
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if sortBy == "id" {
+		query += fmt.Sprintf(" ORDER BY id %s", direction)
+	} else {
+		query += fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s", sortBy, direction)
+	}
+
+	query += " LIMIT ?"
+	args = append(args, request.Limit)
+
+	return query, args
+}
+
+// containsPattern generates a LIKE pattern matching any value containing s. Wildcards in s are escaped.
+func containsPattern(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return "%" + replacer.Replace(s) + "%"
+}
+
 func (r *relationalDB) createUser(request NewUserRequest) (int64, error) {
 	var (
 		userID int64
@@ -113,7 +408,7 @@ func (r *relationalDB) createUser(request NewUserRequest) (int64, error) {
 
 	result, err = r.client.Exec(insertUserQuery, request.UserName, request.Alias, request.Email)
 	if err != nil {
-		return 0, db.ExecError(err, insertUserQuery)
+		return 0, userExecError(err, insertUserQuery)
 	}
 
 	if userID, err = result.LastInsertId(); err != nil {
@@ -124,22 +419,408 @@ func (r *relationalDB) createUser(request NewUserRequest) (int64, error) {
 }
 
 func (r *relationalDB) modifyUser(request ModifyUserRequest, user User) (bool, error) {
-	if request.Active != nil {
-		user.Active = *request.Active
+	var (
+		query = UpdateUserByIDQuery
+		args  []any
+	)
+
+	user = request.apply(user)
+	args = append(args, user.UserName, user.Alias, user.Email, user.Active, user.ID)
+
+	// when the request expects a version, the user is only updated if nobody modified it in the meantime
+	if request.Version != nil {
+		query = updateUserByIDAndVersionQuery
+		args = append(args, *request.Version)
+	}
+
+	result, err := r.client.Exec(query, args...)
+	if err != nil {
+		return false, userExecError(err, query)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, db.RowsAffectedError(err, query)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// userExecError translates the violation of a user unique index into a userWithSameValueError, so a concurrent
+// request which passed the same values check is still answered as a conflict.
+func userExecError(err error, query string) error {
+	if key, ok := db.DuplicateEntryKey(err); ok {
+		if field, ok := userUniqueKeys[key]; ok {
+			return userWithSameValueErrorFunc(field)
+		}
+	}
+	return db.ExecError(err, query)
+}
+
+func (r *relationalDB) expireUserRoles(userID int64) (int64, error) {
+	return r.execByUserID(expireUserRolesQuery, userID)
+}
+
+func (r *relationalDB) expireUserClients(userID int64) (int64, error) {
+	return r.execByUserID(expireUserClientsQuery, userID)
+}
+
+func (r *relationalDB) deleteUnusedVerificationTokens(userID int64) (int64, error) {
+	return r.execByUserID(deleteUnusedVerificationTokensQuery, userID)
+}
+
+// linkClient makes the user a member of the client. It returns false when the client does not exist or is not active.
+func (r *relationalDB) linkClient(userID, clientID int64) (bool, error) {
+	result, err := r.client.Exec(linkClientQuery, userID, clientID)
+	if err != nil {
+		return false, db.ExecError(err, linkClientQuery)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, db.RowsAffectedError(err, linkClientQuery)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// cancelUserTasks cancels the open tasks of the user, recording every cancellation in the status history of the task
+// as done by actor. It must run in a transaction, so the history and the statuses are changed together.
+func (r *relationalDB) cancelUserTasks(userID int64, actor string) (int64, error) {
+	if _, err := r.client.Exec(insertCancellationsQuery, actor, userID); err != nil {
+		return 0, db.ExecError(err, insertCancellationsQuery)
+	}
+
+	return r.execByUserID(cancelUserTasksQuery, userID)
+}
+
+// deleteUser removes the user and every row referencing it.
+func (r *relationalDB) deleteUser(userID int64) (bool, error) {
+	for _, query := range deleteUserLinksQueries {
+		if _, err := r.execByUserID(query, userID); err != nil {
+			return false, err
+		}
+	}
+
+	rowsAffected, err := r.execByUserID(deleteUserQuery, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *relationalDB) createVerificationToken(token string, userID int64, dateExpired time.Time) error {
+	if _, err := r.client.Exec(insertVerificationTokenQuery, token, userID, dateExpired); err != nil {
+		return db.ExecError(err, insertVerificationTokenQuery)
+	}
+	return nil
+}
+
+func (r *relationalDB) selectVerificationToken(token string) (VerificationToken, error) {
+	var (
+		t        VerificationToken
+		dateUsed sql.NullTime
+	)
+
+	if err := r.client.QueryRow(getVerificationTokenQuery, token).Scan(
+		&t.Token,
+		&t.UserID,
+		&t.DateExpired,
+		&dateUsed,
+		&t.DateCreated,
+	); err != nil {
+		return VerificationToken{}, db.ScanError(err, getVerificationTokenQuery)
+	}
+
+	if dateUsed.Valid {
+		t.DateUsed = &dateUsed.Time
+	}
+
+	return t, nil
+}
+
+// useVerificationToken marks the token as used. It returns false when the token was already used.
+func (r *relationalDB) useVerificationToken(token string) (bool, error) {
+	result, err := r.client.Exec(useVerificationTokenQuery, token)
+	if err != nil {
+		return false, db.ExecError(err, useVerificationTokenQuery)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, db.RowsAffectedError(err, useVerificationTokenQuery)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *relationalDB) record(entry audit.Entry) error {
+	return audit.NewRelationalDB(r.client).Record(entry)
+}
+
+func (r *relationalDB) selectHistory(userID int64, page audit.Page) (audit.History, error) {
+	return audit.NewRelationalDB(r.client).SelectHistory(auditEntity, userID, page)
+}
+
+func (r *relationalDB) selectGrantableRole(roleID int64) (grantableRole, error) {
+	var role grantableRole
+
+	if err := r.client.QueryRow(getGrantableRoleQuery, roleID).Scan(&role.ID, &role.Active); err != nil {
+		return grantableRole{}, db.ScanError(err, getGrantableRoleQuery)
+	}
+
+	return role, nil
+}
+
+// selectRoleAccess returns the role types and permissions given by the role, itself or through its active parents.
+func (r *relationalDB) selectRoleAccess(roleID int64) (roleAccess, error) {
+	var (
+		rows   *sql.Rows
+		err    error
+		access roleAccess
+	)
+
+	if rows, err = r.client.Query(getRoleAccessQuery, roleID); err != nil {
+		return roleAccess{}, db.QueryError(err, getRoleAccessQuery)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	// a role type is repeated by every permission of the roles of that type
+	seenTypes, seenPermissions := make(map[string]bool), make(map[string]bool)
+	for rows.Next() {
+		var (
+			roleType   string
+			permission sql.NullString
+		)
+		if err = rows.Scan(&roleType, &permission); err != nil {
+			return roleAccess{}, db.ScanError(err, getRoleAccessQuery)
+		}
+
+		if !seenTypes[roleType] {
+			seenTypes[roleType] = true
+			access.RoleTypes = append(access.RoleTypes, roleType)
+		}
+		if permission.Valid && !seenPermissions[permission.String] {
+			seenPermissions[permission.String] = true
+			access.Permissions = append(access.Permissions, permission.String)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return roleAccess{}, db.RowsError(err, getRoleAccessQuery)
+	}
+
+	return access, nil
+}
+
+func (r *relationalDB) selectRoleGrantByID(grantID int64) (RoleGrant, error) {
+	var (
+		g           RoleGrant
+		dateExpired sql.NullTime
+	)
+
+	if err := r.client.QueryRow(getRoleGrantByIDQuery, grantID).Scan(
+		&g.ID,
+		&g.UserID,
+		&g.RoleID,
+		&g.RoleName,
+		&g.RoleType,
+		&dateExpired,
+		&g.DateCreated,
+	); err != nil {
+		return RoleGrant{}, db.ScanError(err, getRoleGrantByIDQuery)
+	}
+
+	if dateExpired.Valid {
+		g.DateExpired = &dateExpired.Time
+	}
+
+	return g, nil
+}
+
+// selectRoleGrants returns the roles granted to the user. Expired grants are only returned when includeExpired is true.
+func (r *relationalDB) selectRoleGrants(userID int64, includeExpired bool) ([]RoleGrant, error) {
+	if includeExpired {
+		return r.selectGrants(getRoleGrantsQuery, userID)
+	}
+	return r.selectGrants(getActiveRoleGrantsQuery, userID)
+}
+
+func (r *relationalDB) selectGrants(query string, userID int64) ([]RoleGrant, error) {
+	var (
+		rows   *sql.Rows
+		err    error
+		grants []RoleGrant
+	)
+
+	if rows, err = r.client.Query(query, userID); err != nil {
+		return nil, db.QueryError(err, query)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			g           RoleGrant
+			dateExpired sql.NullTime
+		)
+		if err = rows.Scan(
+			&g.ID,
+			&g.UserID,
+			&g.RoleID,
+			&g.RoleName,
+			&g.RoleType,
+			&dateExpired,
+			&g.DateCreated,
+		); err != nil {
+			return nil, db.ScanError(err, query)
+		}
+		if dateExpired.Valid {
+			g.DateExpired = &dateExpired.Time
+		}
+		grants = append(grants, g)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, query)
+	}
+
+	return grants, nil
+}
+
+// selectEffectiveRoles returns the roles giving access to the user, as resolved by auth.EffectiveRolesCTE. Granted
+// roles come first, followed by the roles they inherit.
+func (r *relationalDB) selectEffectiveRoles(userID int64) ([]EffectiveRole, error) {
+	var (
+		rows  *sql.Rows
+		err   error
+		roles []EffectiveRole
+	)
+
+	if rows, err = r.client.Query(getEffectiveRolesQuery, userID); err != nil {
+		return nil, db.QueryError(err, getEffectiveRolesQuery)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var (
+			role  EffectiveRole
+			depth int
+			path  string
+		)
+		if err = rows.Scan(&role.RoleID, &role.RoleName, &role.RoleType, &role.GrantID, &depth, &path); err != nil {
+			return nil, db.ScanError(err, getEffectiveRolesQuery)
+		}
+
+		role.Source = sourceGranted
+		if depth > 0 {
+			role.Source = sourceInherited
+			if role.InheritedThrough, err = parseInheritedThrough(path); err != nil {
+				return nil, db.ScanError(err, getEffectiveRolesQuery)
+			}
+		}
+		roles = append(roles, role)
 	}
-	result, err := r.client.Exec(UpdateUserByIDQuery, user.Active, user.ID)
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, getEffectiveRolesQuery)
+	}
+
+	return roles, nil
+}
+
+// selectRolePermissions returns the permissions held by every role, sorted by permission name.
+func (r *relationalDB) selectRolePermissions() ([]rolePermission, error) {
+	var (
+		rows        *sql.Rows
+		err         error
+		permissions []rolePermission
+	)
+
+	if rows, err = r.client.Query(getRolePermissionsQuery); err != nil {
+		return nil, db.QueryError(err, getRolePermissionsQuery)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			println(fmt.Sprintf("error closing rows cause: %s", err.Error()))
+		}
+	}()
+
+	for rows.Next() {
+		var p rolePermission
+		if err = rows.Scan(&p.RoleID, &p.Permission); err != nil {
+			return nil, db.ScanError(err, getRolePermissionsQuery)
+		}
+		permissions = append(permissions, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, db.RowsError(err, getRolePermissionsQuery)
+	}
+
+	return permissions, nil
+}
+
+func (r *relationalDB) createRoleGrant(userID, roleID int64, dateExpired *time.Time) (int64, error) {
+	result, err := r.client.Exec(insertRoleGrantQuery, userID, roleID, dateExpired)
+	if err != nil {
+		return 0, db.ExecError(err, insertRoleGrantQuery)
+	}
+
+	grantID, err := result.LastInsertId()
+	if err != nil {
+		return 0, db.LastInsertedError(err, insertRoleGrantQuery)
+	}
+
+	return grantID, nil
+}
+
+// expireRoleGrant expires the grant now. It returns false when the grant was already expired.
+func (r *relationalDB) expireRoleGrant(grantID int64) (bool, error) {
+	result, err := r.client.Exec(expireRoleGrantQuery, grantID)
 	if err != nil {
-		return false, db.ExecError(err, UpdateUserByIDQuery)
+		return false, db.ExecError(err, expireRoleGrantQuery)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return false, db.RowsAffectedError(err, UpdateUserByIDQuery)
+		return false, db.RowsAffectedError(err, expireRoleGrantQuery)
 	}
 
 	return rowsAffected == 1, nil
 }
 
+// execByUserID executes a query which only receives the user id and returns the affected rows.
+func (r *relationalDB) execByUserID(query string, userID int64) (int64, error) {
+	result, err := r.client.Exec(query, userID)
+	if err != nil {
+		return 0, db.ExecError(err, query)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, db.RowsAffectedError(err, query)
+	}
+
+	return rowsAffected, nil
+}
+
 func (r *relationalDB) getTransactioner() (Transactioner, error) {
 	client, ok := r.client.(*sql.DB)
 	if !ok {
@@ -1,6 +1,9 @@
 package user
 
 import (
+	"maria/src/api/audit"
+	"time"
+
 	"github.com/stretchr/testify/mock"
 )
 
@@ -22,6 +25,32 @@ func (m *dbMock) selectByAny(name, alias, email string) ([]User, error) {
 	return mockUsers(args, 0), args.Error(1)
 }
 
+func (m *dbMock) selectByIDInClient(userID, clientID int64) (User, error) {
+	args := m.Called(userID, clientID)
+	return mockUser(args, 0), args.Error(1)
+}
+
+func (m *dbMock) linkClient(userID, clientID int64) (bool, error) {
+	args := m.Called(userID, clientID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) selectBySearch(request SearchRequest) ([]User, error) {
+	args := m.Called(request)
+	return mockUsers(args, 0), args.Error(1)
+}
+
+// streamByFilter calls fn with the users given to the mock and then returns the mocked error.
+func (m *dbMock) streamByFilter(filter UserFilter, fn func(User) error) error {
+	args := m.Called(filter)
+	for _, u := range mockUsers(args, 0) {
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
 func (m *dbMock) createUser(request NewUserRequest) (int64, error) {
 	args := m.Called(request)
 	return mockInt64(args, 0), args.Error(1)
@@ -32,6 +61,96 @@ func (m *dbMock) modifyUser(request ModifyUserRequest, user User) (bool, error)
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *dbMock) expireUserRoles(userID int64) (int64, error) {
+	args := m.Called(userID)
+	return mockInt64(args, 0), args.Error(1)
+}
+
+func (m *dbMock) expireUserClients(userID int64) (int64, error) {
+	args := m.Called(userID)
+	return mockInt64(args, 0), args.Error(1)
+}
+
+func (m *dbMock) deleteUnusedVerificationTokens(userID int64) (int64, error) {
+	args := m.Called(userID)
+	return mockInt64(args, 0), args.Error(1)
+}
+
+func (m *dbMock) cancelUserTasks(userID int64, actor string) (int64, error) {
+	args := m.Called(userID, actor)
+	return mockInt64(args, 0), args.Error(1)
+}
+
+func (m *dbMock) deleteUser(userID int64) (bool, error) {
+	args := m.Called(userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) createVerificationToken(token string, userID int64, dateExpired time.Time) error {
+	args := m.Called(token, userID, dateExpired)
+	return args.Error(0)
+}
+
+func (m *dbMock) selectVerificationToken(token string) (VerificationToken, error) {
+	args := m.Called(token)
+	return args.Get(0).(VerificationToken), args.Error(1)
+}
+
+func (m *dbMock) useVerificationToken(token string) (bool, error) {
+	args := m.Called(token)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *dbMock) record(entry audit.Entry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *dbMock) selectHistory(userID int64, page audit.Page) (audit.History, error) {
+	args := m.Called(userID, page)
+	return args.Get(0).(audit.History), args.Error(1)
+}
+
+func (m *dbMock) selectGrantableRole(roleID int64) (grantableRole, error) {
+	args := m.Called(roleID)
+	return args.Get(0).(grantableRole), args.Error(1)
+}
+
+func (m *dbMock) selectRoleAccess(roleID int64) (roleAccess, error) {
+	args := m.Called(roleID)
+	return args.Get(0).(roleAccess), args.Error(1)
+}
+
+func (m *dbMock) selectRoleGrantByID(grantID int64) (RoleGrant, error) {
+	args := m.Called(grantID)
+	return args.Get(0).(RoleGrant), args.Error(1)
+}
+
+func (m *dbMock) selectEffectiveRoles(userID int64) ([]EffectiveRole, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]EffectiveRole), args.Error(1)
+}
+
+func (m *dbMock) selectRolePermissions() ([]rolePermission, error) {
+	args := m.Called()
+	return args.Get(0).([]rolePermission), args.Error(1)
+}
+
+func (m *dbMock) selectRoleGrants(userID int64, includeExpired bool) ([]RoleGrant, error) {
+	args := m.Called(userID, includeExpired)
+	return args.Get(0).([]RoleGrant), args.Error(1)
+}
+
+func (m *dbMock) createRoleGrant(userID, roleID int64, dateExpired *time.Time) (int64, error) {
+	args := m.Called(userID, roleID, dateExpired)
+	return mockInt64(args, 0), args.Error(1)
+}
+
+func (m *dbMock) expireRoleGrant(grantID int64) (bool, error) {
+	args := m.Called(grantID)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *dbMock) withTransaction(fn func(tx Transactioner) error) error {
 	args := m.Called(fn)
 
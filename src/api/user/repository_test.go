@@ -2,11 +2,19 @@ package user
 
 import (
 	"errors"
+	"fmt"
 	"maria/src/api/db"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -192,6 +200,303 @@ func (s *relationalDBSuite) TestSelectByAny() {
 	}
 }
 
+func (s *relationalDBSuite) TestSelectBySearch() {
+	var (
+		active      = true
+		from        = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		customError = errors.New("custom error")
+		user        = User{
+			ID:          10,
+			UserName:    "user",
+			Alias:       "alias",
+			Email:       "user@email.com",
+			DateCreated: time.Now(),
+			Active:      true,
+		}
+	)
+
+	type test struct {
+		name           string
+		request        SearchRequest
+		applyMockCalls func(m sqlmock.Sqlmock) func() error
+		expectedError  error
+		expectedUsers  []User
+	}
+
+	tests := []test{
+		{
+			name:    "query error",
+			request: SearchRequest{Limit: 10},
+			applyMockCalls: db.SetClientQueryMock(
+				getUserMockRows(nil),
+				searchUsersQuery+" ORDER BY id ASC LIMIT ?",
+				customError,
+				nil,
+				10),
+			expectedError: db.QueryError(customError, searchUsersQuery+" ORDER BY id ASC LIMIT ?"),
+			expectedUsers: nil,
+		},
+		{
+			name:    "rows error",
+			request: SearchRequest{Limit: 10, Desc: true, After: &searchCursor{SortBy: "id", ID: 5}},
+			applyMockCalls: db.SetClientQueryMock(
+				getUserMockRows([]User{user}),
+				searchUsersQuery+" WHERE id < ? ORDER BY id DESC LIMIT ?",
+				nil,
+				customError,
+				int64(5), 10),
+			expectedError: db.RowsError(customError, searchUsersQuery+" WHERE id < ? ORDER BY id DESC LIMIT ?"),
+			expectedUsers: nil,
+		},
+		{
+			name: "happy case with every filter",
+			request: SearchRequest{
+				Filter: UserFilter{
+					Active:          &active,
+					DateCreatedFrom: &from,
+					UserName:        "us%",
+					Alias:           "ali",
+					Email:           "email",
+				},
+				SortBy: "user_name",
+				Limit:  10,
+				After:  &searchCursor{SortBy: "user_name", Value: "name", ID: 5},
+			},
+			applyMockCalls: db.SetClientQueryMock(
+				getUserMockRows([]User{user}),
+				searchUsersQuery+" WHERE active = ? AND date_created >= ? AND user_name LIKE ? AND alias LIKE ?"+
+					" AND email LIKE ? AND (user_name > ? OR (user_name = ? AND id > ?))"+
+					" ORDER BY user_name ASC, id ASC LIMIT ?",
+				nil,
+				nil,
+				true, from, `%us\%%`, "%ali%", "%email%", "name", "name", int64(5), 10),
+			expectedError: nil,
+			expectedUsers: []User{user},
+		},
+		{
+			name:    "scoped to a client",
+			request: SearchRequest{Filter: UserFilter{clientID: 7}, Limit: 10},
+			applyMockCalls: db.SetClientQueryMock(
+				getUserMockRows([]User{user}),
+				searchUsersQuery+" WHERE "+clientMemberCondition+" ORDER BY id ASC LIMIT ?",
+				nil,
+				nil,
+				int64(7), 10),
+			expectedError: nil,
+			expectedUsers: []User{user},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			if test.applyMockCalls != nil {
+				assertsCalls := test.applyMockCalls(mock)
+				defer func() {
+					if err = assertsCalls(); err != nil {
+						assert.Fail(t, err.Error())
+					}
+				}()
+			}
+
+			rDB := NewRelationalDB(client)
+
+			users, err := rDB.selectBySearch(test.request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedUsers, users)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectByIDInClient() {
+	var (
+		userID   = int64(10)
+		clientID = int64(7)
+		user     = User{ID: userID, UserName: "user", Alias: "alias", Email: "user@email.com", Active: true}
+	)
+
+	type test struct {
+		name           string
+		applyMockCalls func(m sqlmock.Sqlmock) func() error
+		expectedError  error
+		expectedUser   User
+	}
+
+	tests := []test{
+		{
+			name: "user is not member of the client",
+			applyMockCalls: db.SetClientQueryRowMock(
+				getUserMockRows(nil), getUserByIDInClientQuery, nil, userID, clientID),
+			expectedError: nil,
+			expectedUser:  User{},
+		},
+		{
+			name: "happy case",
+			applyMockCalls: db.SetClientQueryRowMock(
+				getUserMockRows([]User{user}), getUserByIDInClientQuery, nil, userID, clientID),
+			expectedError: nil,
+			expectedUser:  user,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.applyMockCalls(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			user, err := rDB.selectByIDInClient(userID, clientID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedUser, user)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestLinkClient() {
+	var (
+		userID      = int64(10)
+		clientID    = int64(7)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		applyMockCalls func(m sqlmock.Sqlmock) func() error
+		expectedError  error
+		expectedTag    bool
+	}
+
+	tests := []test{
+		{
+			name:           "exec error",
+			applyMockCalls: db.SetClientExecMock(nil, linkClientQuery, customError, userID, clientID),
+			expectedError:  db.ExecError(customError, linkClientQuery),
+			expectedTag:    false,
+		},
+		{
+			name:           "client is not active",
+			applyMockCalls: db.SetClientExecMock(sqlmock.NewResult(0, 0), linkClientQuery, nil, userID, clientID),
+			expectedError:  nil,
+			expectedTag:    false,
+		},
+		{
+			name:           "happy case",
+			applyMockCalls: db.SetClientExecMock(sqlmock.NewResult(1, 1), linkClientQuery, nil, userID, clientID),
+			expectedError:  nil,
+			expectedTag:    true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.applyMockCalls(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			linked, err := rDB.linkClient(userID, clientID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, linked)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestStreamByFilter() {
+	var (
+		active      = true
+		customError = errors.New("custom error")
+		users       = []User{{ID: 1, UserName: "first"}, {ID: 2, UserName: "second"}}
+		query       = searchUsersQuery + " WHERE active = ? ORDER BY id ASC"
+	)
+
+	type test struct {
+		name           string
+		fnError        error
+		applyMockCalls func(m sqlmock.Sqlmock) func() error
+		expectedError  error
+		expectedUsers  []User
+	}
+
+	tests := []test{
+		{
+			name:           "query error",
+			applyMockCalls: db.SetClientQueryMock(getUserMockRows(nil), query, customError, nil, true),
+			expectedError:  db.QueryError(customError, query),
+			expectedUsers:  nil,
+		},
+		{
+			name:           "fn returns error",
+			fnError:        customError,
+			applyMockCalls: db.SetClientQueryMock(getUserMockRows(users), query, nil, nil, true),
+			expectedError:  customError,
+			expectedUsers:  users[:1],
+		},
+		{
+			name:           "happy case",
+			applyMockCalls: db.SetClientQueryMock(getUserMockRows(users), query, nil, nil, true),
+			expectedError:  nil,
+			expectedUsers:  users,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.applyMockCalls(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			var streamed []User
+			err = rDB.streamByFilter(UserFilter{Active: &active}, func(u User) error {
+				streamed = append(streamed, u)
+				return test.fnError
+			})
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedUsers, streamed)
+		})
+	}
+}
+
 func (s *relationalDBSuite) TestCreateUser() {
 	var (
 		userID      = int64(10)
@@ -200,7 +505,8 @@ func (s *relationalDBSuite) TestCreateUser() {
 			Alias:    "alias",
 			Email:    "email@email.com",
 		}
-		customError = errors.New("custom error")
+		customError       = errors.New("custom error")
+		duplicatedPKError = &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '10' for key 'PRIMARY'"}
 	)
 
 	type test struct {
@@ -222,6 +528,28 @@ func (s *relationalDBSuite) TestCreateUser() {
 			expectedError:  db.ExecError(customError, insertUserQuery),
 			expectedUserID: 0,
 		},
+		{
+			name: "email is duplicated",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil,
+				insertUserQuery,
+				&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'email@email.com' for key 'user.user_email_uindex'"},
+				userRequest.UserName, userRequest.Alias, userRequest.Email),
+			},
+			expectedError:  userWithSameValueErrorFunc("email"),
+			expectedUserID: 0,
+		},
+		{
+			name: "duplicated key is not a user field",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil,
+				insertUserQuery,
+				duplicatedPKError,
+				userRequest.UserName, userRequest.Alias, userRequest.Email),
+			},
+			expectedError:  db.ExecError(duplicatedPKError, insertUserQuery),
+			expectedUserID: 0,
+		},
 		{
 			name: "last inserted error",
 			mockCalls: mockDBApplier{db.SetClientExecMock(
@@ -274,14 +602,19 @@ func (s *relationalDBSuite) TestCreateUser() {
 
 func (s *relationalDBSuite) TestModifyUser() {
 	var (
-		user        = User{ID: 10}
+		user        = User{ID: 10, UserName: "name", Alias: "alias", Email: "email@email.com"}
 		active      = true
-		userRequest = ModifyUserRequest{Active: &active}
+		email       = "new@email.com"
+		userRequest = ModifyUserRequest{Active: &active, Email: &email}
+		version     = int64(3)
 		customError = errors.New("custom error")
+
+		versionRequest = ModifyUserRequest{Active: &active, Email: &email, Version: &version}
 	)
 
 	type test struct {
 		name          string
+		request       *ModifyUserRequest
 		mockCalls     mockDBApplier
 		expectedError error
 		expectedTag   bool
@@ -294,18 +627,29 @@ func (s *relationalDBSuite) TestModifyUser() {
 				nil,
 				UpdateUserByIDQuery,
 				customError,
-				active, user.ID),
+				user.UserName, user.Alias, email, active, user.ID),
 			},
 			expectedError: db.ExecError(customError, UpdateUserByIDQuery),
 			expectedTag:   false,
 		},
+		{
+			name: "user name is duplicated",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil,
+				UpdateUserByIDQuery,
+				&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'name' for key 'user_user_name_uindex'"},
+				user.UserName, user.Alias, email, active, user.ID),
+			},
+			expectedError: userWithSameValueErrorFunc("user_name"),
+			expectedTag:   false,
+		},
 		{
 			name: "rows affected error",
 			mockCalls: mockDBApplier{db.SetClientExecMock(
 				sqlmock.NewErrorResult(customError),
 				UpdateUserByIDQuery,
 				nil,
-				active, user.ID),
+				user.UserName, user.Alias, email, active, user.ID),
 			},
 			expectedError: db.RowsAffectedError(customError, UpdateUserByIDQuery),
 			expectedTag:   false,
@@ -317,7 +661,33 @@ func (s *relationalDBSuite) TestModifyUser() {
 					sqlmock.NewResult(0, 1),
 					UpdateUserByIDQuery,
 					nil,
-					active, user.ID),
+					user.UserName, user.Alias, email, active, user.ID),
+			},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+		{
+			name:    "version is stale",
+			request: &versionRequest,
+			mockCalls: mockDBApplier{
+				db.SetClientExecMock(
+					sqlmock.NewResult(0, 0),
+					updateUserByIDAndVersionQuery,
+					nil,
+					user.UserName, user.Alias, email, active, user.ID, version),
+			},
+			expectedError: nil,
+			expectedTag:   false,
+		},
+		{
+			name:    "version matches",
+			request: &versionRequest,
+			mockCalls: mockDBApplier{
+				db.SetClientExecMock(
+					sqlmock.NewResult(0, 1),
+					updateUserByIDAndVersionQuery,
+					nil,
+					user.UserName, user.Alias, email, active, user.ID, version),
 			},
 			expectedError: nil,
 			expectedTag:   true,
@@ -341,7 +711,12 @@ func (s *relationalDBSuite) TestModifyUser() {
 
 			rDB := NewRelationalDB(client)
 
-			user, err := rDB.modifyUser(userRequest, user)
+			request := userRequest
+			if test.request != nil {
+				request = *test.request
+			}
+
+			user, err := rDB.modifyUser(request, user)
 
 			assert.Equal(t, test.expectedError, err)
 			assert.Equal(t, test.expectedTag, user)
@@ -349,11 +724,939 @@ func (s *relationalDBSuite) TestModifyUser() {
 	}
 }
 
+func (s *relationalDBSuite) TestExpireUserLinks() {
+	var (
+		userID      = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name                 string
+		query                string
+		mockCalls            mockDBApplier
+		expectedError        error
+		expectedRowsAffected int64
+	}
+
+	tests := []test{
+		{
+			name:                 "query error",
+			query:                expireUserRolesQuery,
+			mockCalls:            mockDBApplier{db.SetClientExecMock(nil, expireUserRolesQuery, customError, userID)},
+			expectedError:        db.ExecError(customError, expireUserRolesQuery),
+			expectedRowsAffected: 0,
+		},
+		{
+			name:  "rows affected error",
+			query: expireUserClientsQuery,
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewErrorResult(customError), expireUserClientsQuery, nil, userID)},
+			expectedError:        db.RowsAffectedError(customError, expireUserClientsQuery),
+			expectedRowsAffected: 0,
+		},
+		{
+			name:  "unused verification tokens deleted",
+			query: deleteUnusedVerificationTokensQuery,
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), deleteUnusedVerificationTokensQuery, nil, userID)},
+			expectedError:        nil,
+			expectedRowsAffected: 1,
+		},
+		{
+			name:  "happy case",
+			query: expireUserClientsQuery,
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 3), expireUserClientsQuery, nil, userID)},
+			expectedError:        nil,
+			expectedRowsAffected: 3,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client).(*relationalDB)
+			expire := map[string]func(int64) (int64, error){
+				expireUserRolesQuery:                rDB.expireUserRoles,
+				expireUserClientsQuery:              rDB.expireUserClients,
+				deleteUnusedVerificationTokensQuery: rDB.deleteUnusedVerificationTokens,
+			}[test.query]
+
+			rowsAffected, err := expire(userID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRowsAffected, rowsAffected)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestCancelUserTasks() {
+	var (
+		userID      = int64(10)
+		actor       = "admin"
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name                 string
+		mockCalls            mockDBApplier
+		expectedError        error
+		expectedRowsAffected int64
+	}
+
+	tests := []test{
+		{
+			name: "insert cancellations error",
+			mockCalls: mockDBApplier{
+				db.SetClientExecMock(nil, insertCancellationsQuery, customError, actor, userID)},
+			expectedError:        db.ExecError(customError, insertCancellationsQuery),
+			expectedRowsAffected: 0,
+		},
+		{
+			name: "cancel tasks error",
+			mockCalls: mockDBApplier{
+				db.SetClientExecMock(sqlmock.NewResult(0, 3), insertCancellationsQuery, nil, actor, userID),
+				db.SetClientExecMock(nil, cancelUserTasksQuery, customError, userID),
+			},
+			expectedError:        db.ExecError(customError, cancelUserTasksQuery),
+			expectedRowsAffected: 0,
+		},
+		{
+			name: "cancellations are kept in the status history",
+			mockCalls: mockDBApplier{
+				db.SetClientExecMock(sqlmock.NewResult(0, 3), insertCancellationsQuery, nil, actor, userID),
+				db.SetClientExecMock(sqlmock.NewResult(0, 3), cancelUserTasksQuery, nil, userID),
+			},
+			expectedError:        nil,
+			expectedRowsAffected: 3,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			rowsAffected, err := rDB.cancelUserTasks(userID, actor)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRowsAffected, rowsAffected)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestDeleteUser() {
+	var (
+		userID      = int64(10)
+		customError = errors.New("custom error")
+	)
+
+	deleteLinksMocks := func() mockDBApplier {
+		var appliers mockDBApplier
+		for _, query := range deleteUserLinksQueries {
+			appliers = append(appliers, db.SetClientExecMock(sqlmock.NewResult(0, 1), query, nil, userID))
+		}
+		return appliers
+	}
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name: "delete links error",
+			mockCalls: mockDBApplier{
+				db.SetClientExecMock(sqlmock.NewResult(0, 1), deleteUserLinksQueries[0], nil, userID),
+				db.SetClientExecMock(nil, deleteUserLinksQueries[1], customError, userID),
+			},
+			expectedError: db.ExecError(customError, deleteUserLinksQueries[1]),
+			expectedTag:   false,
+		},
+		{
+			name: "status history is deleted before user tasks",
+			mockCalls: mockDBApplier{
+				db.SetClientExecMock(sqlmock.NewResult(0, 1),
+					`DELETE FROM user_verification_token WHERE user_id = ?`, nil, userID),
+				db.SetClientExecMock(sqlmock.NewResult(0, 3),
+					`DELETE h FROM user_task_status_history h JOIN user_task t ON t.id = h.user_task_id WHERE t.user_id = ?`,
+					nil, userID),
+				db.SetClientExecMock(sqlmock.NewResult(0, 1), `DELETE FROM user_task WHERE user_id = ?`, nil, userID),
+				db.SetClientExecMock(sqlmock.NewResult(0, 1), `DELETE FROM user_client WHERE user_id = ?`, nil, userID),
+				db.SetClientExecMock(sqlmock.NewResult(0, 1), `DELETE FROM user_role WHERE user_id = ?`, nil, userID),
+				db.SetClientExecMock(sqlmock.NewResult(0, 1), deleteUserQuery, nil, userID),
+			},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+		{
+			name: "user was not deleted",
+			mockCalls: append(deleteLinksMocks(),
+				db.SetClientExecMock(sqlmock.NewResult(0, 0), deleteUserQuery, nil, userID)),
+			expectedError: nil,
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: append(deleteLinksMocks(),
+				db.SetClientExecMock(sqlmock.NewResult(0, 1), deleteUserQuery, nil, userID)),
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			deleted, err := rDB.deleteUser(userID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, deleted)
+		})
+	}
+}
+
+// TestDeleteUserLinksCoverForeignKeys reads the foreign keys declared by the migrations, so a table referencing user,
+// or referencing a table which does, cannot be added without deleting its rows in deleteUserLinksQueries.
+func (s *relationalDBSuite) TestDeleteUserLinksCoverForeignKeys() {
+	var (
+		tableStatement = regexp.MustCompile(`^(?:create|alter) table (?:if not exists )?(\w+)`)
+		foreignKey     = regexp.MustCompile(`foreign key \(\w+\) references (\w+) \(\w+\)( on delete cascade)?`)
+		deletedTable   = regexp.MustCompile(`(?i)^DELETE .*?FROM (\w+)`)
+	)
+
+	scripts, err := filepath.Glob(filepath.Join("..", "migration", "migrations", "*.up.sql"))
+	if err != nil || len(scripts) == 0 {
+		assert.Fail(s.T(), "migrations cannot be found")
+		return
+	}
+	sort.Strings(scripts)
+
+	// referencing keeps, by table, the tables whose rows must be deleted before its own
+	referencing := make(map[string][]string)
+	for _, script := range scripts {
+		content, err := os.ReadFile(script)
+		if err != nil {
+			assert.Fail(s.T(), err.Error())
+			return
+		}
+
+		for _, statement := range strings.Split(strings.ToLower(string(content)), ";") {
+			var lines []string
+			for _, line := range strings.Split(statement, "\n") {
+				if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+					lines = append(lines, line)
+				}
+			}
+
+			statement = strings.Join(lines, " ")
+			table := tableStatement.FindStringSubmatch(statement)
+			if table == nil {
+				continue
+			}
+			for _, key := range foreignKey.FindAllStringSubmatch(statement, -1) {
+				if key[2] == "" {
+					referencing[key[1]] = append(referencing[key[1]], table[1])
+				}
+			}
+		}
+	}
+
+	position := make(map[string]int)
+	for i, query := range deleteUserLinksQueries {
+		if table := deletedTable.FindStringSubmatch(query); table != nil {
+			position[table[1]] = i
+		}
+	}
+	position["user"] = len(deleteUserLinksQueries)
+
+	pending := []string{"user"}
+	for len(pending) > 0 {
+		parent := pending[0]
+		pending = pending[1:]
+		for _, child := range referencing[parent] {
+			childPosition, ok := position[child]
+			if !assert.True(s.T(), ok, "rows of %s are not deleted with the user", child) {
+				continue
+			}
+			assert.Less(s.T(), childPosition, position[parent], "rows of %s are deleted after %s", child, parent)
+			pending = append(pending, child)
+		}
+	}
+}
+
+func (s *relationalDBSuite) TestCreateVerificationToken() {
+	var (
+		userID      = int64(10)
+		token       = "token"
+		dateExpired = time.Now()
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+	}
+
+	tests := []test{
+		{
+			name: "query error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertVerificationTokenQuery, customError, token, userID, dateExpired)},
+			expectedError: db.ExecError(customError, insertVerificationTokenQuery),
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), insertVerificationTokenQuery, nil, token, userID, dateExpired)},
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			err = rDB.createVerificationToken(token, userID, dateExpired)
+
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectVerificationToken() {
+	var (
+		now   = time.Now()
+		token = VerificationToken{
+			Token:       "token",
+			UserID:      10,
+			DateExpired: now.Add(time.Hour),
+			DateUsed:    &now,
+			DateCreated: now,
+		}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		applyMockCalls func(m sqlmock.Sqlmock) func() error
+		expectedError  error
+		expectedToken  VerificationToken
+	}
+
+	tests := []test{
+		{
+			name: "empty result",
+			applyMockCalls: db.SetClientQueryRowMock(
+				getVerificationTokenMockRows(nil),
+				getVerificationTokenQuery,
+				nil,
+				token.Token),
+			expectedError: nil,
+			expectedToken: VerificationToken{},
+		},
+		{
+			name: "scan error",
+			applyMockCalls: db.SetClientQueryRowMock(
+				getVerificationTokenMockRows([]VerificationToken{token}),
+				getVerificationTokenQuery,
+				customError,
+				token.Token),
+			expectedError: db.ScanError(customError, getVerificationTokenQuery),
+			expectedToken: VerificationToken{},
+		},
+		{
+			name: "happy case",
+			applyMockCalls: db.SetClientQueryRowMock(
+				getVerificationTokenMockRows([]VerificationToken{token}),
+				getVerificationTokenQuery,
+				nil,
+				token.Token),
+			expectedError: nil,
+			expectedToken: token,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.applyMockCalls(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			verificationToken, err := rDB.selectVerificationToken(token.Token)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedToken, verificationToken)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestUseVerificationToken() {
+	var (
+		token       = "token"
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name:          "query error",
+			mockCalls:     mockDBApplier{db.SetClientExecMock(nil, useVerificationTokenQuery, customError, token)},
+			expectedError: db.ExecError(customError, useVerificationTokenQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "token already used",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 0), useVerificationTokenQuery, nil, token)},
+			expectedError: nil,
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), useVerificationTokenQuery, nil, token)},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			used, err := rDB.useVerificationToken(token)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, used)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectRoleGrants() {
+	var (
+		userID      = int64(10)
+		dateExpired = time.Now()
+		grants      = []RoleGrant{
+			{ID: 1, UserID: userID, RoleID: 2, RoleName: "admin", RoleType: "user", DateCreated: time.Now()},
+			{ID: 2, UserID: userID, RoleID: 3, RoleName: "viewer", RoleType: "user", DateExpired: &dateExpired},
+		}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		includeExpired bool
+		mockCalls      mockDBApplier
+		expectedError  error
+		expectedGrants []RoleGrant
+	}
+
+	tests := []test{
+		{
+			name: "query error",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				nil, getActiveRoleGrantsQuery, customError, nil, userID)},
+			expectedError:  db.QueryError(customError, getActiveRoleGrantsQuery),
+			expectedGrants: nil,
+		},
+		{
+			name: "only unexpired grants",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getRoleGrantMockRows(grants[:1]), getActiveRoleGrantsQuery, nil, nil, userID)},
+			expectedError:  nil,
+			expectedGrants: grants[:1],
+		},
+		{
+			name:           "expired grants included",
+			includeExpired: true,
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				getRoleGrantMockRows(grants), getRoleGrantsQuery, nil, nil, userID)},
+			expectedError:  nil,
+			expectedGrants: grants,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectRoleGrants(userID, test.includeExpired)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedGrants, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestCreateRoleGrant() {
+	var (
+		userID      = int64(10)
+		roleID      = int64(2)
+		dateExpired = time.Now()
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name            string
+		mockCalls       mockDBApplier
+		expectedError   error
+		expectedGrantID int64
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, insertRoleGrantQuery, customError, userID, roleID, dateExpired)},
+			expectedError:   db.ExecError(customError, insertRoleGrantQuery),
+			expectedGrantID: 0,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(5, 1), insertRoleGrantQuery, nil, userID, roleID, dateExpired)},
+			expectedError:   nil,
+			expectedGrantID: 5,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			grantID, err := rDB.createRoleGrant(userID, roleID, &dateExpired)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedGrantID, grantID)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestExpireRoleGrant() {
+	var (
+		grantID     = int64(5)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedTag   bool
+	}
+
+	tests := []test{
+		{
+			name: "exec error",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				nil, expireRoleGrantQuery, customError, grantID)},
+			expectedError: db.ExecError(customError, expireRoleGrantQuery),
+			expectedTag:   false,
+		},
+		{
+			name: "grant was already expired",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 0), expireRoleGrantQuery, nil, grantID)},
+			expectedError: nil,
+			expectedTag:   false,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientExecMock(
+				sqlmock.NewResult(0, 1), expireRoleGrantQuery, nil, grantID)},
+			expectedError: nil,
+			expectedTag:   true,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			expired, err := rDB.expireRoleGrant(grantID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedTag, expired)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectRoleAccess() {
+	var (
+		roleID      = int64(2)
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name           string
+		mockCalls      mockDBApplier
+		expectedError  error
+		expectedAccess roleAccess
+	}
+
+	tests := []test{
+		{
+			name: "query error",
+			mockCalls: mockDBApplier{
+				db.SetClientQueryMock(nil, getRoleAccessQuery, customError, nil, roleID)},
+			expectedError:  db.QueryError(customError, getRoleAccessQuery),
+			expectedAccess: roleAccess{},
+		},
+		{
+			name: "role without permissions",
+			mockCalls: mockDBApplier{
+				db.SetClientQueryMock(sqlmock.NewRows([]string{"type", "name"}).AddRow("developer", nil),
+					getRoleAccessQuery, nil, nil, roleID)},
+			expectedError:  nil,
+			expectedAccess: roleAccess{RoleTypes: []string{"developer"}},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{
+				db.SetClientQueryMock(sqlmock.NewRows([]string{"type", "name"}).
+					AddRow("admin", nil).
+					AddRow("client", "user:read").
+					AddRow("client", "user:write").
+					AddRow("developer", "user:read"),
+					getRoleAccessQuery, nil, nil, roleID)},
+			expectedError: nil,
+			expectedAccess: roleAccess{
+				RoleTypes:   []string{"admin", "client", "developer"},
+				Permissions: []string{"user:read", "user:write"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectRoleAccess(roleID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedAccess, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectEffectiveRoles() {
+	var (
+		userID = int64(10)
+		roles  = []EffectiveRole{
+			{RoleID: 1, RoleName: "client-admin", RoleType: "client", Source: sourceGranted, GrantID: 1},
+			{
+				RoleID:           2,
+				RoleName:         "client-editor",
+				RoleType:         "client",
+				Source:           sourceInherited,
+				GrantID:          1,
+				InheritedThrough: []int64{1},
+			},
+			{
+				RoleID:           4,
+				RoleName:         "reporter",
+				RoleType:         "report",
+				Source:           sourceInherited,
+				GrantID:          1,
+				InheritedThrough: []int64{1, 2},
+			},
+		}
+		customError = errors.New("custom error")
+	)
+
+	effectiveRows := func(path string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "role_name", "type", "grant_id", "depth", "path"}).
+			AddRow(1, "client-admin", "client", 1, 0, "1").
+			AddRow(2, "client-editor", "client", 1, 1, "1,2").
+			AddRow(4, "reporter", "report", 1, 2, path)
+	}
+
+	type test struct {
+		name          string
+		mockCalls     mockDBApplier
+		expectedError error
+		expectedRoles []EffectiveRole
+	}
+
+	tests := []test{
+		{
+			name: "query error",
+			mockCalls: mockDBApplier{
+				db.SetClientQueryMock(nil, getEffectiveRolesQuery, customError, nil, userID)},
+			expectedError: db.QueryError(customError, getEffectiveRolesQuery),
+			expectedRoles: nil,
+		},
+		{
+			name: "path is not valid",
+			mockCalls: mockDBApplier{
+				db.SetClientQueryMock(effectiveRows("1,x,4"), getEffectiveRolesQuery, nil, nil, userID)},
+			expectedError: db.ScanError(
+				fmt.Errorf(`role path "1,x,4" is not valid: %w`, &strconv.NumError{
+					Func: "ParseInt", Num: "x", Err: strconv.ErrSyntax}),
+				getEffectiveRolesQuery),
+			expectedRoles: nil,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{
+				db.SetClientQueryMock(effectiveRows("1,2,4"), getEffectiveRolesQuery, nil, nil, userID)},
+			expectedError: nil,
+			expectedRoles: roles,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectEffectiveRoles(userID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRoles, result)
+		})
+	}
+}
+
+func (s *relationalDBSuite) TestSelectRolePermissions() {
+	var (
+		permissions = []rolePermission{{RoleID: 2, Permission: "user:read"}, {RoleID: 3, Permission: "user:read"}}
+		customError = errors.New("custom error")
+	)
+
+	type test struct {
+		name                string
+		mockCalls           mockDBApplier
+		expectedError       error
+		expectedPermissions []rolePermission
+	}
+
+	tests := []test{
+		{
+			name:                "query error",
+			mockCalls:           mockDBApplier{db.SetClientQueryMock(nil, getRolePermissionsQuery, customError, nil)},
+			expectedError:       db.QueryError(customError, getRolePermissionsQuery),
+			expectedPermissions: nil,
+		},
+		{
+			name: "rows error",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				sqlmock.NewRows([]string{"role_id", "name"}).AddRow(2, "user:read"),
+				getRolePermissionsQuery, nil, customError)},
+			expectedError:       db.RowsError(customError, getRolePermissionsQuery),
+			expectedPermissions: nil,
+		},
+		{
+			name: "happy case",
+			mockCalls: mockDBApplier{db.SetClientQueryMock(
+				sqlmock.NewRows([]string{"role_id", "name"}).AddRow(2, "user:read").AddRow(3, "user:read"),
+				getRolePermissionsQuery, nil, nil)},
+			expectedError:       nil,
+			expectedPermissions: permissions,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			client, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Fail(t, err.Error())
+				return
+			}
+
+			assertsCalls := test.mockCalls.apply(mock)
+			defer func() {
+				if err = assertsCalls(); err != nil {
+					assert.Fail(t, err.Error())
+				}
+			}()
+
+			rDB := NewRelationalDB(client)
+
+			result, err := rDB.selectRolePermissions()
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedPermissions, result)
+		})
+	}
+}
+
+func getRoleGrantMockRows(grants []RoleGrant) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "user_id", "role_id", "role_name", "type", "date_expired", "date_created"})
+	for _, g := range grants {
+		var dateExpired any
+		if g.DateExpired != nil {
+			dateExpired = *g.DateExpired
+		}
+		rows.AddRow(g.ID, g.UserID, g.RoleID, g.RoleName, g.RoleType, dateExpired, g.DateCreated)
+	}
+	return rows
+}
+
+func getVerificationTokenMockRows(tokens []VerificationToken) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"token", "user_id", "date_expired", "date_used", "date_created"})
+
+	for _, t := range tokens {
+		rows.AddRow(t.Token, t.UserID, t.DateExpired, t.DateUsed, t.DateCreated)
+	}
+	return rows
+}
+
 func getUserMockRows(users []User) *sqlmock.Rows {
-	rows := sqlmock.NewRows([]string{"user_id", "user_name", "alias", "email", "active", "date_created"})
+	rows := sqlmock.NewRows([]string{"user_id", "user_name", "alias", "email", "active", "date_created", "version"})
 
 	for _, user := range users {
-		rows.AddRow(user.ID, user.UserName, user.Alias, user.Email, user.Active, user.DateCreated)
+		rows.AddRow(user.ID, user.UserName, user.Alias, user.Email, user.Active, user.DateCreated, user.Version)
 	}
 	return rows
 }
@@ -1,54 +1,129 @@
 package user
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
+	"maria/src/api/mail"
+	"strings"
+	"time"
 )
 
 var (
 	userNotFoundError          = errors.New("user not found")
 	conflictError              = errors.New("conflict internal error")
+	invalidCursorError         = errors.New("cursor is not valid")
+	versionMismatchError       = errors.New("user was modified by someone else")
+	importRejectedError        = errors.New("import was rejected")
 	userWithSameValueError     = errors.New("common user feature")
 	userWithSameValueErrorFunc = func(value string) error {
-		return fmt.Errorf("%w: there is already a user with same %s", userWithSameValueError, value)
+		return sameValueError{field: value}
 	}
+	invalidVerificationTokenError = errors.New("verification token is not valid")
+	verificationTokenExpiredError = fmt.Errorf("%w: it has expired", invalidVerificationTokenError)
+	verificationTokenUsedError    = fmt.Errorf("%w: it was already used", invalidVerificationTokenError)
+	roleNotFoundError             = errors.New("role not found")
+	inactiveUserError             = errors.New("user is not active")
+	inactiveRoleError             = errors.New("role is not active")
+	roleAlreadyGrantedError       = errors.New("role is already granted to the user")
+	roleGrantNotFoundError        = errors.New("role is not granted to the user")
+	invalidGrantExpirationError   = errors.New("date_expired must be in the future")
+	roleNotGrantableError         = errors.New("role gives access which the caller does not have")
+	inactiveTenantError           = errors.New("client of " + auth.TenantHeader + " header is not active")
 )
 
+const (
+	auditEntity = "user"
+
+	verificationTokenTTL  = 24 * time.Hour
+	verificationTokenSize = 32
+)
+
+// sameValueError is a userWithSameValueError which keeps the name of the conflicting field.
+type sameValueError struct {
+	field string
+}
+
+func (e sameValueError) Error() string {
+	return fmt.Sprintf("%s: there is already a user with same %s", userWithSameValueError, e.field)
+}
+
+func (e sameValueError) Unwrap() error {
+	return userWithSameValueError
+}
+
+// Service methods receiving a context use it for knowing the caller, who is recorded in the audit log, and the
+// tenant. When the context is scoped to a client, only the users linked to it by an unexpired membership are visible
+// and the created ones are linked to it.
 type Service interface {
-	getByID(int64) (User, error)
-	createUser(NewUserRequest) (User, error)
-	modifyUser(ModifyUserRequest, User) (User, error)
+	getByID(context.Context, int64) (User, error)
+	createUser(context.Context, NewUserRequest) (User, error)
+	modifyUser(context.Context, ModifyUserRequest, User) (User, error)
+	searchUsers(context.Context, SearchRequest) (SearchResponse, error)
+	deleteUser(context.Context, int64, bool) (User, error)
+	verifyUser(context.Context, VerifyUserRequest) (User, error)
+	importUsers(context.Context, []ImportRow, bool) (ImportReport, error)
+	exportUsers(context.Context, UserFilter, func(User) error) error
+	getHistory(context.Context, int64, audit.Page) (audit.History, error)
+	grantRole(context.Context, int64, GrantRoleRequest) (RoleGrant, error)
+	listRoleGrants(context.Context, int64, bool) ([]RoleGrant, error)
+	listEffectiveRoles(context.Context, int64) ([]EffectiveRole, error)
+	listPermissions(context.Context, int64) ([]UserPermission, error)
+	revokeRole(context.Context, int64, int64) error
 }
 
 type userService struct {
 	userRepository Persister
+	mailer         mail.Mailer
+	tokenGenerator func() (string, error)
+	now            func() time.Time
 }
 
-func NewService(userRepository Persister) Service {
-	return userService{userRepository: userRepository}
+func NewService(userRepository Persister, mailer mail.Mailer) Service {
+	return userService{
+		userRepository: userRepository,
+		mailer:         mailer,
+		tokenGenerator: newVerificationToken,
+		now:            time.Now,
+	}
+}
+
+// newVerificationToken generates a random hexadecimal token.
+func newVerificationToken() (string, error) {
+	b := make([]byte, verificationTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("it could not generate verification token due to: %w", err)
+	}
+	return hex.EncodeToString(b), nil
 }
 
-func (us userService) getByID(userID int64) (User, error) {
-	user, err := us.userRepository.selectByID(userID)
+// getByID returns the user, users out of the tenant of ctx are not found.
+func (us userService) getByID(ctx context.Context, userID int64) (User, error) {
+	var (
+		user User
+		err  error
+	)
+
+	if clientID, scoped := auth.TenantFrom(ctx); scoped {
+		user, err = us.userRepository.selectByIDInClient(userID, clientID)
+	} else {
+		user, err = us.userRepository.selectByID(userID)
+	}
 	if err == nil && user.isEmptyUser() {
 		return user, userNotFoundError
 	}
 	return user, err
 }
 
-func (us userService) createUser(user NewUserRequest) (User, error) {
+func (us userService) createUser(ctx context.Context, user NewUserRequest) (User, error) {
 	if users, err := us.userRepository.selectByAny(user.UserName, user.Alias, user.Email); err != nil {
 		return User{}, err
-	} else if len(users) > 0 {
-		switch {
-		case users[0].UserName == user.UserName:
-			return User{}, userWithSameValueErrorFunc("user_name")
-		case users[0].Alias == user.Alias:
-			return User{}, userWithSameValueErrorFunc("alias")
-		case users[0].Email == user.Email:
-			return User{}, userWithSameValueErrorFunc("email")
-		}
-		return User{}, conflictError
+	} else if err = checkSameValues(users, user.toUser(0, time.Time{}, false)); err != nil {
+		return User{}, err
 	}
 
 	var (
@@ -56,23 +131,124 @@ func (us userService) createUser(user NewUserRequest) (User, error) {
 		err     error
 	)
 
+	// the email is sent inside the transaction, so the user is not created when it cannot be sent
 	if err = us.userRepository.withTransaction(func(tx Transactioner) error {
-		userID, err := tx.createUser(user)
+		var message mail.Message
+		if newUser, message, err = us.insertUser(ctx, tx, user); err != nil {
+			return err
+		}
+		return us.sendVerification(message)
+	}); err != nil {
+		return User{}, err
+	}
+	return newUser, nil
+}
+
+// insertUser creates an inactive user together with its verification token and audit entry. The user is linked to the
+// tenant of ctx, if any, which is only set for authorized callers. It returns the verification email which must be sent to the user.
+func (us userService) insertUser(ctx context.Context, tx Transactioner, request NewUserRequest) (User, mail.Message, error) {
+	userID, err := tx.createUser(request)
+	if err != nil {
+		return User{}, mail.Message{}, err
+	}
+
+	if clientID, scoped := auth.TenantFrom(ctx); scoped {
+		if linked, err := tx.linkClient(userID, clientID); err != nil {
+			return User{}, mail.Message{}, err
+		} else if !linked {
+			return User{}, mail.Message{}, inactiveTenantError
+		}
+	}
+
+	token, err := us.tokenGenerator()
+	if err != nil {
+		return User{}, mail.Message{}, err
+	}
+
+	if err = tx.createVerificationToken(token, userID, us.now().Add(verificationTokenTTL)); err != nil {
+		return User{}, mail.Message{}, err
+	}
+
+	user, err := tx.selectByID(userID)
+	if err != nil {
+		return User{}, mail.Message{}, err
+	}
+
+	if err = record(ctx, tx, audit.ActionCreate, nil, &user); err != nil {
+		return User{}, mail.Message{}, err
+	}
+
+	return user, newVerificationMessage(user, token), nil
+}
+
+func (us userService) sendVerification(message mail.Message) error {
+	if err := us.mailer.Send(message); err != nil {
+		return fmt.Errorf("it could not send verification email due to: %w", err)
+	}
+	return nil
+}
+
+func newVerificationMessage(user User, token string) mail.Message {
+	return mail.Message{
+		To:      user.Email,
+		Subject: "Verify your account",
+		Body: fmt.Sprintf("Hi %s, use the next token for verifying your account: %s\n"+
+			"It expires in %s.", user.UserName, token, verificationTokenTTL),
+	}
+}
+
+// verifyUser redeems the verification token and activates its user.
+func (us userService) verifyUser(ctx context.Context, request VerifyUserRequest) (User, error) {
+	var user User
+
+	if err := us.userRepository.withTransaction(func(tx Transactioner) error {
+		token, err := tx.selectVerificationToken(request.Token)
 		if err != nil {
 			return err
 		}
 
-		if newUser, err = tx.selectByID(userID); err != nil {
+		switch {
+		case token.isEmpty():
+			return invalidVerificationTokenError
+		case token.DateUsed != nil:
+			return verificationTokenUsedError
+		case us.now().After(token.DateExpired):
+			return verificationTokenExpiredError
+		}
+
+		// the update is conditional, so two requests cannot redeem the same token
+		if used, err := tx.useVerificationToken(token.Token); err != nil {
 			return err
+		} else if !used {
+			return verificationTokenUsedError
 		}
-		return nil
+
+		if user, err = tx.selectByID(token.UserID); err != nil {
+			return err
+		}
+		if user.isEmptyUser() {
+			return userNotFoundError
+		}
+
+		active := true
+		if _, err = tx.modifyUser(ModifyUserRequest{Active: &active}, user); err != nil {
+			return err
+		}
+
+		before := user
+		if user, err = tx.selectByID(token.UserID); err != nil {
+			return err
+		}
+
+		return record(ctx, tx, audit.ActionVerify, &before, &user)
 	}); err != nil {
 		return User{}, err
 	}
-	return newUser, nil
+
+	return user, nil
 }
 
-func (us userService) modifyUser(request ModifyUserRequest, user User) (User, error) {
+func (us userService) modifyUser(ctx context.Context, request ModifyUserRequest, user User) (User, error) {
 	var (
 		err error
 	)
@@ -88,20 +264,498 @@ func (us userService) modifyUser(request ModifyUserRequest, user User) (User, er
 			return User{}, fmt.Errorf("%w: there is more than one user", conflictError)
 		}
 		user = users[0]
-	} else if user, err = us.getByID(user.ID); err != nil {
+		// users are looked up by their unique values, which are global, so the tenant is checked afterwards
+		if _, scoped := auth.TenantFrom(ctx); scoped {
+			if user, err = us.getByID(ctx, user.ID); err != nil {
+				return User{}, err
+			}
+		}
+	} else if user, err = us.getByID(ctx, user.ID); err != nil {
 		return User{}, err
 	}
 
+	if request.Version != nil && *request.Version != user.Version {
+		return User{}, versionMismatchError
+	}
+
 	if err = us.userRepository.withTransaction(func(tx Transactioner) error {
-		if _, err = tx.modifyUser(request, user); err != nil {
+		if request.changesProfile() {
+			// uniqueness is checked again inside the transaction, so the values are not taken in the meantime
+			users, err := tx.selectByAny(request.uniqueValues())
+			if err != nil {
+				return err
+			}
+			if err = checkSameValues(users, request.apply(user)); err != nil {
+				return err
+			}
+		}
+
+		updated, err := tx.modifyUser(request, user)
+		if err != nil {
 			return err
 		}
+		if !updated && request.Version != nil {
+			return versionMismatchError
+		}
 
-		user, err = tx.selectByID(user.ID)
-		return err
+		before := user
+		if user, err = tx.selectByID(user.ID); err != nil {
+			return err
+		}
+
+		return record(ctx, tx, audit.ActionModify, &before, &user)
+	}); err != nil {
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+// deleteUser deactivates the user, expires its roles and clients and cancels its open tasks.
+// When hard is true the user and every row referencing it are removed instead.
+func (us userService) deleteUser(ctx context.Context, userID int64, hard bool) (User, error) {
+	user, err := us.getByID(ctx, userID)
+	if err != nil {
+		return User{}, err
+	}
+
+	if err = us.userRepository.withTransaction(func(tx Transactioner) error {
+		if hard {
+			deleted, err := tx.deleteUser(userID)
+			if err != nil {
+				return err
+			}
+			if !deleted {
+				return userNotFoundError
+			}
+			return record(ctx, tx, audit.ActionDelete, &user, nil)
+		}
+
+		inactive := false
+		if _, err = tx.modifyUser(ModifyUserRequest{Active: &inactive}, user); err != nil {
+			return err
+		}
+		if _, err = tx.expireUserRoles(userID); err != nil {
+			return err
+		}
+		if _, err = tx.expireUserClients(userID); err != nil {
+			return err
+		}
+		if _, err = tx.deleteUnusedVerificationTokens(userID); err != nil {
+			return err
+		}
+		if _, err = tx.cancelUserTasks(userID, auth.CallerFrom(ctx)); err != nil {
+			return err
+		}
+
+		before := user
+		if user, err = tx.selectByID(userID); err != nil {
+			return err
+		}
+
+		return record(ctx, tx, audit.ActionDeactivate, &before, &user)
 	}); err != nil {
 		return User{}, err
 	}
 
 	return user, nil
 }
+
+// getHistory returns the audit entries of the user. History is kept after deleting the user, so it is only checked
+// that the user exists when ctx is scoped to a tenant.
+func (us userService) getHistory(ctx context.Context, userID int64, page audit.Page) (audit.History, error) {
+	if _, scoped := auth.TenantFrom(ctx); scoped {
+		if _, err := us.getByID(ctx, userID); err != nil {
+			return audit.History{}, err
+		}
+	}
+
+	return us.userRepository.selectHistory(userID, page)
+}
+
+// record writes an audit entry for the user changing from before to after. Nil before or after means that the user
+// did not exist before or does not exist after the change.
+func record(ctx context.Context, tx Transactioner, action string, before, after *User) error {
+	var (
+		userID                    int64
+		beforeEntity, afterEntity any
+	)
+
+	if before != nil {
+		userID, beforeEntity = before.ID, *before
+	}
+	if after != nil {
+		userID, afterEntity = after.ID, *after
+	}
+
+	return recordEntry(ctx, tx, action, userID, beforeEntity, afterEntity)
+}
+
+// recordEntry writes an audit entry of the user. before and after can be any value related to it, as a role grant.
+func recordEntry(ctx context.Context, tx Transactioner, action string, userID int64, before, after any) error {
+	entry, err := audit.NewEntry(auth.CallerFrom(ctx), action, auditEntity, userID, before, after)
+	if err != nil {
+		return err
+	}
+
+	return tx.record(entry)
+}
+
+// grantRole grants an active role to an active user. The grant never expires unless the request has date_expired.
+// Callers who are not admins can only grant roles giving access they have themselves, see auth.Grants.CanGrant.
+func (us userService) grantRole(ctx context.Context, userID int64, request GrantRoleRequest) (RoleGrant, error) {
+	if request.DateExpired != nil && !request.DateExpired.After(us.now()) {
+		return RoleGrant{}, invalidGrantExpirationError
+	}
+
+	user, err := us.getByID(ctx, userID)
+	if err != nil {
+		return RoleGrant{}, err
+	}
+	if !user.Active {
+		return RoleGrant{}, inactiveUserError
+	}
+
+	var grant RoleGrant
+	if err = us.userRepository.withTransaction(func(tx Transactioner) error {
+		role, err := tx.selectGrantableRole(request.RoleID)
+		if err != nil {
+			return err
+		}
+		if role.isEmpty() {
+			return roleNotFoundError
+		}
+		if !role.Active {
+			return inactiveRoleError
+		}
+
+		if caller := auth.GrantsFrom(ctx); !caller.IsAdmin() {
+			access, err := tx.selectRoleAccess(request.RoleID)
+			if err != nil {
+				return err
+			}
+			if !caller.CanGrant(access.RoleTypes, access.Permissions) {
+				return roleNotGrantableError
+			}
+		}
+
+		grants, err := tx.selectRoleGrants(userID, false)
+		if err != nil {
+			return err
+		}
+		for _, g := range grants {
+			if g.RoleID == request.RoleID {
+				return roleAlreadyGrantedError
+			}
+		}
+
+		grantID, err := tx.createRoleGrant(userID, request.RoleID, request.DateExpired)
+		if err != nil {
+			return err
+		}
+
+		if grant, err = tx.selectRoleGrantByID(grantID); err != nil {
+			return err
+		}
+
+		return recordEntry(ctx, tx, audit.ActionGrant, userID, nil, grant)
+	}); err != nil {
+		return RoleGrant{}, err
+	}
+
+	return grant, nil
+}
+
+func (us userService) listRoleGrants(ctx context.Context, userID int64, includeExpired bool) ([]RoleGrant, error) {
+	if _, err := us.getByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	grants, err := us.userRepository.selectRoleGrants(userID, includeExpired)
+	if err != nil {
+		return nil, err
+	}
+	if grants == nil {
+		grants = []RoleGrant{}
+	}
+	return grants, nil
+}
+
+// listEffectiveRoles returns the roles granted to the user together with the ones they inherit.
+func (us userService) listEffectiveRoles(ctx context.Context, userID int64) ([]EffectiveRole, error) {
+	if _, err := us.getByID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	roles, err := us.userRepository.selectEffectiveRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	if roles == nil {
+		roles = []EffectiveRole{}
+	}
+	return roles, nil
+}
+
+// listPermissions returns the permissions held by the effective roles of the user.
+func (us userService) listPermissions(ctx context.Context, userID int64) ([]UserPermission, error) {
+	roles, err := us.listEffectiveRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := us.userRepository.selectRolePermissions()
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := resolvePermissions(roles, permissions)
+	if resolved == nil {
+		resolved = []UserPermission{}
+	}
+	return resolved, nil
+}
+
+// revokeRole expires the unexpired grant of the role, so the grant is kept as history.
+func (us userService) revokeRole(ctx context.Context, userID, roleID int64) error {
+	if _, err := us.getByID(ctx, userID); err != nil {
+		return err
+	}
+
+	return us.userRepository.withTransaction(func(tx Transactioner) error {
+		grants, err := tx.selectRoleGrants(userID, false)
+		if err != nil {
+			return err
+		}
+
+		var grant RoleGrant
+		for _, g := range grants {
+			if g.RoleID == roleID {
+				grant = g
+				break
+			}
+		}
+		if grant.isEmpty() {
+			return roleGrantNotFoundError
+		}
+
+		if expired, err := tx.expireRoleGrant(grant.ID); err != nil {
+			return err
+		} else if !expired {
+			return roleGrantNotFoundError
+		}
+
+		revoked, err := tx.selectRoleGrantByID(grant.ID)
+		if err != nil {
+			return err
+		}
+
+		return recordEntry(ctx, tx, audit.ActionRevoke, userID, grant, revoked)
+	})
+}
+
+func (us userService) exportUsers(ctx context.Context, filter UserFilter, fn func(User) error) error {
+	filter.clientID, _ = auth.TenantFrom(ctx)
+	return us.userRepository.streamByFilter(filter, fn)
+}
+
+// checkSameValues returns a userWithSameValueError naming the first field that user shares with any other of users.
+// Values are compared ignoring case as the database does.
+func checkSameValues(users []User, user User) error {
+	for _, u := range users {
+		if user.ID != 0 && u.ID == user.ID {
+			continue
+		}
+		switch {
+		case strings.EqualFold(u.UserName, user.UserName):
+			return userWithSameValueErrorFunc("user_name")
+		case strings.EqualFold(u.Alias, user.Alias):
+			return userWithSameValueErrorFunc("alias")
+		case strings.EqualFold(u.Email, user.Email):
+			return userWithSameValueErrorFunc("email")
+		}
+		return conflictError
+	}
+	return nil
+}
+
+func (us userService) searchUsers(ctx context.Context, request SearchRequest) (SearchResponse, error) {
+	request.Filter.clientID, _ = auth.TenantFrom(ctx)
+	if request.Limit <= 0 {
+		request.Limit = defaultSearchLimit
+	}
+	if request.Limit > maxSearchLimit {
+		request.Limit = maxSearchLimit
+	}
+	if !searchSortColumns[request.SortBy] {
+		request.SortBy = "id"
+	}
+	if request.After != nil && request.After.SortBy != request.SortBy {
+		return SearchResponse{}, fmt.Errorf("%w: it was generated for sorting by %s", invalidCursorError, request.After.SortBy)
+	}
+
+	limit := request.Limit
+	// one extra user is requested for knowing whether there is a next page
+	request.Limit++
+
+	users, err := us.userRepository.selectBySearch(request)
+	if err != nil {
+		return SearchResponse{}, err
+	}
+
+	response := SearchResponse{
+		Results: make([]User, 0, limit),
+		Paging:  Paging{Limit: limit},
+	}
+
+	if len(users) > limit {
+		users = users[:limit]
+		response.Paging.NextCursor = newSearchCursor(request.SortBy, users[limit-1]).encode()
+	}
+
+	response.Results = append(response.Results, users...)
+
+	return response, nil
+}
+
+// importUsers creates the users of every valid row. Each row is created in its own transaction, unless atomic is true:
+// then every user is created in a single transaction and nothing is created when any row fails.
+func (us userService) importUsers(ctx context.Context, rows []ImportRow, atomic bool) (ImportReport, error) {
+	report := newImportReport(rows, atomic)
+	checkImportDuplicates(rows, &report)
+
+	if atomic {
+		return us.importUsersAtomically(ctx, rows, report)
+	}
+
+	for i := range rows {
+		if report.failed(i) {
+			continue
+		}
+
+		var user User
+		err := us.userRepository.withTransaction(func(tx Transactioner) error {
+			if err := checkUserIsNew(tx, rows[i].Request); err != nil {
+				return err
+			}
+
+			var (
+				message mail.Message
+				err     error
+			)
+			if user, message, err = us.insertUser(ctx, tx, rows[i].Request); err != nil {
+				return err
+			}
+			return us.sendVerification(message)
+		})
+		if err != nil {
+			report.fail(i, err)
+			continue
+		}
+
+		report.create(i, user)
+	}
+
+	return report, nil
+}
+
+func (us userService) importUsersAtomically(ctx context.Context, rows []ImportRow, report ImportReport) (ImportReport, error) {
+	var (
+		users    = make([]User, len(rows))
+		messages = make([]mail.Message, len(rows))
+	)
+
+	err := us.userRepository.withTransaction(func(tx Transactioner) error {
+		for i := range rows {
+			if report.failed(i) {
+				continue
+			}
+			if err := checkUserIsNew(tx, rows[i].Request); err != nil {
+				if !errors.Is(err, userWithSameValueError) && !errors.Is(err, conflictError) {
+					return err
+				}
+				report.fail(i, err)
+			}
+		}
+
+		if report.Failed > 0 {
+			return importRejectedError
+		}
+
+		for i := range rows {
+			var err error
+			if users[i], messages[i], err = us.insertUser(ctx, tx, rows[i].Request); err != nil {
+				report.fail(i, err)
+				return importRejectedError
+			}
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, importRejectedError) {
+		report.skipPending()
+		return report, nil
+	}
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	// emails are sent once users are committed, a failure does not undo the import
+	for i := range rows {
+		report.create(i, users[i])
+		if err = us.sendVerification(messages[i]); err != nil {
+			report.Rows[i].Error = err.Error()
+		}
+	}
+
+	return report, nil
+}
+
+// checkUserIsNew returns an error when there is already a user with the same user_name, alias or email.
+func checkUserIsNew(tx Transactioner, request NewUserRequest) error {
+	users, err := tx.selectByAny(request.UserName, request.Alias, request.Email)
+	if err != nil {
+		return err
+	}
+	return checkSameValues(users, request.toUser(0, time.Time{}, false))
+}
+
+// checkImportDuplicates fails every row sharing user_name, alias or email with a previous row of the same file.
+func checkImportDuplicates(rows []ImportRow, report *ImportReport) {
+	seen := map[string]map[string]int{
+		"user_name": {},
+		"alias":     {},
+		"email":     {},
+	}
+
+	for i := range rows {
+		if report.failed(i) {
+			continue
+		}
+
+		request := rows[i].Request
+		for _, field := range []struct {
+			name  string
+			value string
+		}{
+			{name: "user_name", value: request.UserName},
+			{name: "alias", value: request.Alias},
+			{name: "email", value: request.Email},
+		} {
+			value := strings.ToLower(field.value)
+			if row, ok := seen[field.name][value]; ok {
+				report.fail(i, fmt.Errorf("%w in row %d", userWithSameValueErrorFunc(field.name), row))
+				break
+			}
+		}
+
+		if report.failed(i) {
+			continue
+		}
+
+		seen["user_name"][strings.ToLower(request.UserName)] = rows[i].Row
+		seen["alias"][strings.ToLower(request.Alias)] = rows[i].Row
+		seen["email"][strings.ToLower(request.Email)] = rows[i].Row
+	}
+}
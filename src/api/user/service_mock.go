@@ -1,7 +1,9 @@
 package user
 
 import (
+	"context"
 	"fmt"
+	"maria/src/api/audit"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -44,17 +46,78 @@ func mockUsers(args mock.Arguments, index int) []User {
 	return s
 }
 
-func (m *serviceMock) getByID(userID int64) (User, error) {
-	args := m.Called(userID)
+func (m *serviceMock) getByID(ctx context.Context, userID int64) (User, error) {
+	args := m.Called(ctx, userID)
 	return mockUser(args, 0), args.Error(1)
 }
 
-func (m *serviceMock) createUser(user NewUserRequest) (User, error) {
-	args := m.Called(user)
+func (m *serviceMock) createUser(ctx context.Context, user NewUserRequest) (User, error) {
+	args := m.Called(ctx, user)
 	return mockUser(args, 0), args.Error(1)
 }
 
-func (m *serviceMock) modifyUser(request ModifyUserRequest, user User) (User, error) {
-	args := m.Called(request, user)
+func (m *serviceMock) modifyUser(ctx context.Context, request ModifyUserRequest, user User) (User, error) {
+	args := m.Called(ctx, request, user)
 	return mockUser(args, 0), args.Error(1)
 }
+
+func (m *serviceMock) searchUsers(ctx context.Context, request SearchRequest) (SearchResponse, error) {
+	args := m.Called(ctx, request)
+	return args.Get(0).(SearchResponse), args.Error(1)
+}
+
+func (m *serviceMock) deleteUser(ctx context.Context, userID int64, hard bool) (User, error) {
+	args := m.Called(ctx, userID, hard)
+	return mockUser(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) verifyUser(ctx context.Context, request VerifyUserRequest) (User, error) {
+	args := m.Called(ctx, request)
+	return mockUser(args, 0), args.Error(1)
+}
+
+func (m *serviceMock) importUsers(ctx context.Context, rows []ImportRow, atomic bool) (ImportReport, error) {
+	args := m.Called(ctx, rows, atomic)
+	return args.Get(0).(ImportReport), args.Error(1)
+}
+
+// exportUsers calls fn with the users given to the mock and then returns the mocked error.
+func (m *serviceMock) exportUsers(ctx context.Context, filter UserFilter, fn func(User) error) error {
+	args := m.Called(ctx, filter)
+	for _, u := range mockUsers(args, 0) {
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *serviceMock) getHistory(ctx context.Context, userID int64, page audit.Page) (audit.History, error) {
+	args := m.Called(ctx, userID, page)
+	return args.Get(0).(audit.History), args.Error(1)
+}
+
+func (m *serviceMock) grantRole(ctx context.Context, userID int64, request GrantRoleRequest) (RoleGrant, error) {
+	args := m.Called(ctx, userID, request)
+	return args.Get(0).(RoleGrant), args.Error(1)
+}
+
+func (m *serviceMock) listEffectiveRoles(ctx context.Context, userID int64) ([]EffectiveRole, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]EffectiveRole), args.Error(1)
+}
+
+func (m *serviceMock) listPermissions(ctx context.Context, userID int64) ([]UserPermission, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]UserPermission), args.Error(1)
+}
+
+func (m *serviceMock) listRoleGrants(ctx context.Context, userID int64, includeExpired bool) ([]RoleGrant, error) {
+	args := m.Called(ctx, userID, includeExpired)
+	return args.Get(0).([]RoleGrant), args.Error(1)
+}
+
+func (m *serviceMock) revokeRole(ctx context.Context, userID, roleID int64) error {
+	args := m.Called(ctx, userID, roleID)
+	return args.Error(0)
+}
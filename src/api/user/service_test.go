@@ -1,8 +1,12 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"maria/src/api/audit"
+	"maria/src/api/auth"
+	"maria/src/api/mail"
 	"maria/src/api/util"
 	"testing"
 	"time"
@@ -33,6 +37,7 @@ func (s *UserServiceSuite) TestGetByID() {
 
 	type test struct {
 		name           string
+		ctx            context.Context
 		applyMockCalls func(us *userService) (func(t *testing.T), error)
 		expectedError  error
 		expectedUser   User
@@ -57,11 +62,25 @@ func (s *UserServiceSuite) TestGetByID() {
 			expectedError:  nil,
 			expectedUser:   User{ID: userID},
 		},
+		{
+			name:           "user is not member of the tenant",
+			ctx:            testTenantCtx,
+			applyMockCalls: setPersiterSelectByIDInClientMock(User{}, nil, userID, testTenant),
+			expectedError:  userNotFoundError,
+			expectedUser:   User{},
+		},
+		{
+			name:           "user is member of the tenant",
+			ctx:            testTenantCtx,
+			applyMockCalls: setPersiterSelectByIDInClientMock(User{ID: userID}, nil, userID, testTenant),
+			expectedError:  nil,
+			expectedUser:   User{ID: userID},
+		},
 	}
 
 	for _, test := range tests {
 		s.T().Run(test.name, func(t *testing.T) {
-			serv := NewService(newDBMock()).(userService)
+			serv := newServiceForTest()
 			if test.applyMockCalls != nil {
 				if assertsCalls, err := test.applyMockCalls(&serv); err != nil {
 					assert.Fail(t, err.Error())
@@ -71,7 +90,12 @@ func (s *UserServiceSuite) TestGetByID() {
 				}
 			}
 
-			user, err := serv.getByID(userID)
+			ctx := testCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			user, err := serv.getByID(ctx, userID)
 
 			assert.Equal(t, test.expectedError, err)
 			assert.Equal(t, test.expectedUser, user)
@@ -88,13 +112,17 @@ func (s *UserServiceSuite) TestCreateUser() {
 			Alias:    "alias",
 			Email:    "email@email.com",
 		}
+		newUser = userRequest.toUser(userID, time.Time{}, false)
 	)
 
 	type test struct {
 		name          string
+		ctx           context.Context
+		mailer        mail.Mailer
 		mockCalls     mockPersisterApplier
 		expectedError error
 		expectedUser  User
+		expectedMail  []mail.Message
 	}
 
 	tests := []test{
@@ -171,6 +199,22 @@ func (s *UserServiceSuite) TestCreateUser() {
 			expectedError: customError,
 			expectedUser:  User{},
 		},
+		{
+			name: "create verification token return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByAnyMock(
+					nil,
+					nil,
+					userRequest.UserName,
+					userRequest.Alias,
+					userRequest.Email),
+				setPersiterWithTransactionMock(nil),
+				setPersiterCreateUserMock(userID, nil, userRequest),
+				setPersiterCreateVerificationTokenMock(customError, testToken, userID, testNow.Add(verificationTokenTTL)),
+			},
+			expectedError: customError,
+			expectedUser:  User{},
+		},
 		{
 			name: "select by user id  return error",
 			mockCalls: mockPersisterApplier{
@@ -182,6 +226,7 @@ func (s *UserServiceSuite) TestCreateUser() {
 					userRequest.Email),
 				setPersiterWithTransactionMock(nil),
 				setPersiterCreateUserMock(userID, nil, userRequest),
+				setPersiterCreateVerificationTokenMock(nil, testToken, userID, testNow.Add(verificationTokenTTL)),
 				setPersiterSelectByIDMock(User{}, customError, userID),
 			},
 			expectedError: customError,
@@ -198,16 +243,94 @@ func (s *UserServiceSuite) TestCreateUser() {
 					userRequest.Email),
 				setPersiterWithTransactionMock(nil),
 				setPersiterCreateUserMock(userID, nil, userRequest),
+				setPersiterCreateVerificationTokenMock(nil, testToken, userID, testNow.Add(verificationTokenTTL)),
 				setPersiterSelectByIDMock(userRequest.toUser(userID, time.Time{}, false), nil, userID),
+				setPersiterRecordMock(nil, audit.ActionCreate, nil, &newUser),
 			},
 			expectedError: nil,
 			expectedUser:  userRequest.toUser(userID, time.Time{}, false),
+			expectedMail:  []mail.Message{newVerificationMessage(userRequest.toUser(userID, time.Time{}, false), testToken)},
+		},
+		{
+			name: "tenant client is not active",
+			ctx:  testTenantCtx,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByAnyMock(
+					nil,
+					nil,
+					userRequest.UserName,
+					userRequest.Alias,
+					userRequest.Email),
+				setPersiterWithTransactionMock(nil),
+				setPersiterCreateUserMock(userID, nil, userRequest),
+				setPersiterLinkClientMock(false, nil, userID, testTenant),
+			},
+			expectedError: inactiveTenantError,
+			expectedUser:  User{},
+		},
+		{
+			name: "user is linked to the tenant",
+			ctx:  testTenantCtx,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByAnyMock(
+					nil,
+					nil,
+					userRequest.UserName,
+					userRequest.Alias,
+					userRequest.Email),
+				setPersiterWithTransactionMock(nil),
+				setPersiterCreateUserMock(userID, nil, userRequest),
+				setPersiterLinkClientMock(true, nil, userID, testTenant),
+				setPersiterCreateVerificationTokenMock(nil, testToken, userID, testNow.Add(verificationTokenTTL)),
+				setPersiterSelectByIDMock(newUser, nil, userID),
+				setPersiterRecordMock(nil, audit.ActionCreate, nil, &newUser),
+			},
+			expectedError: nil,
+			expectedUser:  newUser,
+			expectedMail:  []mail.Message{newVerificationMessage(newUser, testToken)},
+		},
+		{
+			name: "record return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByAnyMock(
+					nil,
+					nil,
+					userRequest.UserName,
+					userRequest.Alias,
+					userRequest.Email),
+				setPersiterWithTransactionMock(nil),
+				setPersiterCreateUserMock(userID, nil, userRequest),
+				setPersiterCreateVerificationTokenMock(nil, testToken, userID, testNow.Add(verificationTokenTTL)),
+				setPersiterSelectByIDMock(newUser, nil, userID),
+				setPersiterRecordMock(customError, audit.ActionCreate, nil, &newUser),
+			},
+			expectedError: customError,
+			expectedUser:  User{},
+		},
+		{
+			name:   "verification email cannot be sent",
+			mailer: failingMailer{err: customError},
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByAnyMock(
+					nil,
+					nil,
+					userRequest.UserName,
+					userRequest.Alias,
+					userRequest.Email),
+				setPersiterWithTransactionMock(nil),
+				setPersiterCreateUserMock(userID, nil, userRequest),
+				setPersiterCreateVerificationTokenMock(nil, testToken, userID, testNow.Add(verificationTokenTTL)),
+				setPersiterSelectByIDMock(userRequest.toUser(userID, time.Time{}, false), nil, userID),
+				setPersiterRecordMock(nil, audit.ActionCreate, nil, &newUser),
+			},
+			expectedError: fmt.Errorf("it could not send verification email due to: %w", customError),
+			expectedUser:  User{},
 		},
 	}
 
 	for _, test := range tests {
 		s.T().Run(test.name, func(t *testing.T) {
-			serv := NewService(newDBMock()).(userService)
+			serv := newServiceForTest()
 			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
 				assert.Fail(t, err.Error())
 				return
@@ -215,10 +338,22 @@ func (s *UserServiceSuite) TestCreateUser() {
 				defer assertsCalls(t)
 			}
 
-			user, err := serv.createUser(userRequest)
+			outbox := mail.NewOutbox()
+			serv.mailer = outbox
+			if test.mailer != nil {
+				serv.mailer = test.mailer
+			}
+
+			ctx := testCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			user, err := serv.createUser(ctx, userRequest)
 
 			assert.Equal(t, test.expectedError, err)
 			assert.Equal(t, test.expectedUser, user)
+			assert.Equal(t, test.expectedMail, outbox.Messages())
 		})
 	}
 }
@@ -231,11 +366,23 @@ func (s *UserServiceSuite) TestModifyUser() {
 		userRequest = ModifyUserRequest{
 			Active: &active,
 		}
+		alias          = "new alias"
+		email          = "new@email.com"
+		profileRequest = ModifyUserRequest{
+			Alias: &alias,
+			Email: &email,
+		}
+		version        = int64(2)
+		versionRequest = ModifyUserRequest{
+			Active:  &active,
+			Version: &version,
+		}
 	)
 
 	type test struct {
 		name          string
 		user          User
+		request       *ModifyUserRequest
 		mockCalls     mockPersisterApplier
 		expectedError error
 		expectedUser  User
@@ -314,6 +461,79 @@ func (s *UserServiceSuite) TestModifyUser() {
 			expectedError: customError,
 			expectedUser:  User{},
 		},
+		{
+			name:    "select by any inside transaction return error",
+			user:    User{ID: userID},
+			request: &profileRequest,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID, UserName: "name"}, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByAnyMock(nil, customError, "", alias, email),
+			},
+
+			expectedError: customError,
+			expectedUser:  User{},
+		},
+		{
+			name:    "another user has the same email",
+			user:    User{ID: userID},
+			request: &profileRequest,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID, UserName: "name"}, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByAnyMock(
+					[]User{{ID: userID, Alias: alias}, {ID: userID + 1, UserName: "other", Email: email}},
+					nil,
+					"", alias, email),
+			},
+
+			expectedError: userWithSameValueErrorFunc("email"),
+			expectedUser:  User{},
+		},
+		{
+			name:    "profile modified",
+			user:    User{ID: userID},
+			request: &profileRequest,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID, UserName: "name"}, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByAnyMock([]User{{ID: userID, Alias: alias}}, nil, "", alias, email),
+				setPersiterModifyUserMock(true, nil, profileRequest, User{ID: userID, UserName: "name"}),
+				setPersiterSelectByIDMock(User{ID: userID, UserName: "name", Alias: alias, Email: email}, nil, userID),
+				setPersiterRecordMock(
+					nil,
+					audit.ActionModify,
+					&User{ID: userID, UserName: "name"},
+					&User{ID: userID, UserName: "name", Alias: alias, Email: email}),
+			},
+
+			expectedError: nil,
+			expectedUser:  User{ID: userID, UserName: "name", Alias: alias, Email: email},
+		},
+		{
+			name:    "expected version is stale",
+			user:    User{ID: userID},
+			request: &versionRequest,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID, Version: version + 1}, nil, userID),
+			},
+
+			expectedError: versionMismatchError,
+			expectedUser:  User{},
+		},
+		{
+			name:    "user modified in the meantime",
+			user:    User{ID: userID},
+			request: &versionRequest,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID, Version: version}, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterModifyUserMock(false, nil, versionRequest, User{ID: userID, Version: version}),
+			},
+
+			expectedError: versionMismatchError,
+			expectedUser:  User{},
+		},
 		{
 			name: "get by id return error",
 			user: User{ID: userID},
@@ -332,6 +552,7 @@ func (s *UserServiceSuite) TestModifyUser() {
 				setPersiterWithTransactionMock(nil),
 				setPersiterModifyUserMock(true, nil, userRequest, User{ID: userID}),
 				setPersiterSelectByIDMock(User{ID: userID}, nil, userID),
+				setPersiterRecordMock(nil, audit.ActionModify, &User{ID: userID}, &User{ID: userID}),
 			},
 
 			expectedError: nil,
@@ -341,7 +562,7 @@ func (s *UserServiceSuite) TestModifyUser() {
 
 	for _, test := range tests {
 		s.T().Run(test.name, func(t *testing.T) {
-			serv := NewService(newDBMock()).(userService)
+			serv := newServiceForTest()
 			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
 				assert.Fail(t, err.Error())
 				return
@@ -349,7 +570,12 @@ func (s *UserServiceSuite) TestModifyUser() {
 				defer assertsCalls(t)
 			}
 
-			user, err := serv.modifyUser(userRequest, test.user)
+			request := userRequest
+			if test.request != nil {
+				request = *test.request
+			}
+
+			user, err := serv.modifyUser(testCtx, request, test.user)
 
 			assert.Equal(t, test.expectedError, err)
 			assert.Equal(t, test.expectedUser, user)
@@ -357,75 +583,1520 @@ func (s *UserServiceSuite) TestModifyUser() {
 	}
 }
 
-type mockPersisterApplier []func(us *userService) (func(t *testing.T), error)
+func (s *UserServiceSuite) TestDeleteUser() {
+	var (
+		userID      = int64(10)
+		inactive    = false
+		customError = errors.New("custom error")
+		user        = User{ID: userID, UserName: "name", Active: true}
+	)
 
-func (appliers mockPersisterApplier) apply(us *userService) (func(t *testing.T), error) {
-	var assertCalls []func(t *testing.T)
-	for i := range appliers {
-		if assertCall, err := appliers[i](us); err != nil {
-			return func(t *testing.T) {}, err
-		} else {
-			assertCalls = append(assertCalls, assertCall)
-		}
+	type test struct {
+		name          string
+		hard          bool
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedUser  User
 	}
-	return func(t *testing.T) {
-		for i := range assertCalls {
-			assertCalls[i](t)
-		}
-	}, nil
-}
 
-func setPersiterWithTransactionMock(
-	errorResponse error,
-) func(us *userService) (func(t *testing.T), error) {
-	return func(us *userService) (func(t *testing.T), error) {
-		r, ok := us.userRepository.(*dbMock)
-		if !ok {
-			return nil, errors.New("it could not cast to mock repository")
-		}
-		r.On(util.GetFunctionName(r.withTransaction), mock.Anything).
-			Return(errorResponse).
-			Once()
-		return func(t *testing.T) {
-			r.AssertExpectations(t)
-		}, nil
+	tests := []test{
+		{
+			name: "user not found",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{}, nil, userID),
+			},
+			expectedError: userNotFoundError,
+			expectedUser:  User{},
+		},
+		{
+			name: "with transaction return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(customError),
+			},
+			expectedError: customError,
+			expectedUser:  User{},
+		},
+		{
+			name: "expire roles return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterModifyUserMock(true, nil, ModifyUserRequest{Active: &inactive}, user),
+				setPersiterExecByUserIDMock("expireUserRoles", 0, customError, userID),
+			},
+			expectedError: customError,
+			expectedUser:  User{},
+		},
+		{
+			name: "delete verification tokens return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterModifyUserMock(true, nil, ModifyUserRequest{Active: &inactive}, user),
+				setPersiterExecByUserIDMock("expireUserRoles", 1, nil, userID),
+				setPersiterExecByUserIDMock("expireUserClients", 1, nil, userID),
+				setPersiterExecByUserIDMock("deleteUnusedVerificationTokens", 0, customError, userID),
+			},
+			expectedError: customError,
+			expectedUser:  User{},
+		},
+		{
+			name: "cancel tasks return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterModifyUserMock(true, nil, ModifyUserRequest{Active: &inactive}, user),
+				setPersiterExecByUserIDMock("expireUserRoles", 1, nil, userID),
+				setPersiterExecByUserIDMock("expireUserClients", 1, nil, userID),
+				setPersiterExecByUserIDMock("deleteUnusedVerificationTokens", 0, nil, userID),
+				setPersiterCancelUserTasksMock(0, customError, userID, testCaller),
+			},
+			expectedError: customError,
+			expectedUser:  User{},
+		},
+		{
+			name: "user deactivated and their unused verification tokens deleted",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterModifyUserMock(true, nil, ModifyUserRequest{Active: &inactive}, user),
+				setPersiterExecByUserIDMock("expireUserRoles", 1, nil, userID),
+				setPersiterExecByUserIDMock("expireUserClients", 2, nil, userID),
+				setPersiterExecByUserIDMock("deleteUnusedVerificationTokens", 1, nil, userID),
+				setPersiterCancelUserTasksMock(3, nil, userID, testCaller),
+				setPersiterSelectByIDMock(User{ID: userID, UserName: "name"}, nil, userID),
+				setPersiterRecordMock(nil, audit.ActionDeactivate, &user, &User{ID: userID, UserName: "name"}),
+			},
+			expectedError: nil,
+			expectedUser:  User{ID: userID, UserName: "name"},
+		},
+		{
+			name: "delete user return error",
+			hard: true,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterDeleteUserMock(false, customError, userID),
+			},
+			expectedError: customError,
+			expectedUser:  User{},
+		},
+		{
+			name: "user was deleted in the meantime",
+			hard: true,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterDeleteUserMock(false, nil, userID),
+			},
+			expectedError: userNotFoundError,
+			expectedUser:  User{},
+		},
+		{
+			name: "user deleted",
+			hard: true,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterDeleteUserMock(true, nil, userID),
+				setPersiterRecordMock(nil, audit.ActionDelete, &user, nil),
+			},
+			expectedError: nil,
+			expectedUser:  user,
+		},
 	}
-}
 
-func setPersiterSelectByIDMock(
-	userResponse User,
-	errorResponse error,
-	userID int64,
-) func(us *userService) (func(t *testing.T), error) {
-	return func(us *userService) (func(t *testing.T), error) {
-		r, ok := us.userRepository.(*dbMock)
-		if !ok {
-			return nil, errors.New("it could not cast to mock repository")
-		}
-		r.On(util.GetFunctionName(r.selectByID), userID).
-			Return(userResponse, errorResponse).
-			Once()
-		return func(t *testing.T) {
-			r.AssertExpectations(t)
-		}, nil
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			user, err := serv.deleteUser(testCtx, userID, test.hard)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedUser, user)
+		})
 	}
 }
 
-func setPersiterSelectByAnyMock(
+func (s *UserServiceSuite) TestGrantRole() {
+	var (
+		userID      = int64(10)
+		roleID      = int64(2)
+		customError = errors.New("custom error")
+		user        = User{ID: userID, UserName: "name", Active: true}
+		expired     = testNow.Add(-time.Hour)
+		request     = GrantRoleRequest{RoleID: roleID}
+		grant       = RoleGrant{ID: 1, UserID: userID, RoleID: roleID, RoleName: "admin", RoleType: "user"}
+		adminCtx    = auth.WithGrants(testCtx, auth.Grants{RoleTypes: []string{auth.AdminRoleType}})
+		writerCtx   = auth.WithGrants(testCtx, auth.Grants{
+			RoleTypes:   []string{"client"},
+			Permissions: []string{auth.PermissionUserRead, auth.PermissionUserWrite},
+		})
+	)
+
+	type test struct {
+		name          string
+		ctx           context.Context
+		request       GrantRoleRequest
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedGrant RoleGrant
+	}
+
+	tests := []test{
+		{
+			name:          "date_expired is in the past",
+			request:       GrantRoleRequest{RoleID: roleID, DateExpired: &expired},
+			mockCalls:     mockPersisterApplier{},
+			expectedError: invalidGrantExpirationError,
+		},
+		{
+			name:          "user not found",
+			request:       request,
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(User{}, nil, userID)},
+			expectedError: userNotFoundError,
+		},
+		{
+			name:          "user is not active",
+			request:       request,
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(User{ID: userID}, nil, userID)},
+			expectedError: inactiveUserError,
+		},
+		{
+			name:    "role not found",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectGrantableRoleMock(grantableRole{}, nil, roleID),
+			},
+			expectedError: roleNotFoundError,
+		},
+		{
+			name:    "role is not active",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectGrantableRoleMock(grantableRole{ID: roleID}, nil, roleID),
+			},
+			expectedError: inactiveRoleError,
+		},
+		{
+			name:    "role is already granted",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectGrantableRoleMock(grantableRole{ID: roleID, Active: true}, nil, roleID),
+				setPersiterSelectRoleGrantsMock([]RoleGrant{grant}, nil, userID, false),
+			},
+			expectedError: roleAlreadyGrantedError,
+		},
+		{
+			name:    "select role access return error",
+			ctx:     writerCtx,
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectGrantableRoleMock(grantableRole{ID: roleID, Active: true}, nil, roleID),
+				setPersiterSelectRoleAccessMock(roleAccess{}, customError, roleID),
+			},
+			expectedError: customError,
+		},
+		{
+			name:    "caller who is not an admin grants an admin role",
+			ctx:     writerCtx,
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectGrantableRoleMock(grantableRole{ID: roleID, Active: true}, nil, roleID),
+				setPersiterSelectRoleAccessMock(roleAccess{RoleTypes: []string{"client", auth.AdminRoleType}}, nil, roleID),
+			},
+			expectedError: roleNotGrantableError,
+		},
+		{
+			name:    "caller who is not an admin grants a permission they do not have",
+			ctx:     writerCtx,
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectGrantableRoleMock(grantableRole{ID: roleID, Active: true}, nil, roleID),
+				setPersiterSelectRoleAccessMock(roleAccess{
+					RoleTypes:   []string{"client"},
+					Permissions: []string{auth.PermissionRoleWrite},
+				}, nil, roleID),
+			},
+			expectedError: roleNotGrantableError,
+		},
+		{
+			name:    "caller who is not an admin grants access they have",
+			ctx:     writerCtx,
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectGrantableRoleMock(grantableRole{ID: roleID, Active: true}, nil, roleID),
+				setPersiterSelectRoleAccessMock(roleAccess{
+					RoleTypes:   []string{"client"},
+					Permissions: []string{auth.PermissionUserRead},
+				}, nil, roleID),
+				setPersiterSelectRoleGrantsMock(nil, nil, userID, false),
+				setPersiterCreateRoleGrantMock(grant.ID, nil, userID, roleID, nil),
+				setPersiterSelectRoleGrantByIDMock(grant, nil, grant.ID),
+				setPersiterRecordEntryMock(nil, audit.ActionGrant, userID, nil, grant),
+			},
+			expectedError: nil,
+			expectedGrant: grant,
+		},
+		{
+			name:    "create grant return error",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectGrantableRoleMock(grantableRole{ID: roleID, Active: true}, nil, roleID),
+				setPersiterSelectRoleGrantsMock(nil, nil, userID, false),
+				setPersiterCreateRoleGrantMock(0, customError, userID, roleID, nil),
+			},
+			expectedError: customError,
+		},
+		{
+			name:    "role granted",
+			request: request,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectGrantableRoleMock(grantableRole{ID: roleID, Active: true}, nil, roleID),
+				setPersiterSelectRoleGrantsMock([]RoleGrant{{ID: 5, RoleID: 3}}, nil, userID, false),
+				setPersiterCreateRoleGrantMock(grant.ID, nil, userID, roleID, nil),
+				setPersiterSelectRoleGrantByIDMock(grant, nil, grant.ID),
+				setPersiterRecordEntryMock(nil, audit.ActionGrant, userID, nil, grant),
+			},
+			expectedError: nil,
+			expectedGrant: grant,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			ctx := adminCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			grant, err := serv.grantRole(ctx, userID, test.request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedGrant, grant)
+		})
+	}
+}
+
+func (s *UserServiceSuite) TestListRoleGrants() {
+	var (
+		userID      = int64(10)
+		customError = errors.New("custom error")
+		grants      = []RoleGrant{{ID: 1, UserID: userID, RoleID: 2}}
+	)
+
+	type test struct {
+		name           string
+		mockCalls      mockPersisterApplier
+		expectedError  error
+		expectedGrants []RoleGrant
+	}
+
+	tests := []test{
+		{
+			name:          "user not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(User{}, nil, userID)},
+			expectedError: userNotFoundError,
+		},
+		{
+			name: "select grants return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID}, nil, userID),
+				setPersiterSelectRoleGrantsMock(nil, customError, userID, true),
+			},
+			expectedError: customError,
+		},
+		{
+			name: "user without grants",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID}, nil, userID),
+				setPersiterSelectRoleGrantsMock(nil, nil, userID, true),
+			},
+			expectedGrants: []RoleGrant{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID}, nil, userID),
+				setPersiterSelectRoleGrantsMock(grants, nil, userID, true),
+			},
+			expectedGrants: grants,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			grants, err := serv.listRoleGrants(testCtx, userID, true)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedGrants, grants)
+		})
+	}
+}
+
+func (s *UserServiceSuite) TestListEffectiveRoles() {
+	var (
+		userID      = int64(10)
+		customError = errors.New("custom error")
+		roles       = []EffectiveRole{
+			{RoleID: 2, RoleName: "client-admin", RoleType: "client", Source: sourceGranted, GrantID: 1},
+			{
+				RoleID:           3,
+				RoleName:         "client-viewer",
+				RoleType:         "client",
+				Source:           sourceInherited,
+				GrantID:          1,
+				InheritedThrough: []int64{2},
+			},
+		}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedRoles []EffectiveRole
+	}
+
+	tests := []test{
+		{
+			name:          "user not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(User{}, nil, userID)},
+			expectedError: userNotFoundError,
+		},
+		{
+			name: "select effective roles return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID}, nil, userID),
+				setPersiterSelectEffectiveRolesMock(nil, customError, userID),
+			},
+			expectedError: customError,
+		},
+		{
+			name: "user without grants",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID}, nil, userID),
+				setPersiterSelectEffectiveRolesMock(nil, nil, userID),
+			},
+			expectedRoles: []EffectiveRole{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID}, nil, userID),
+				setPersiterSelectEffectiveRolesMock(roles, nil, userID),
+			},
+			expectedRoles: roles,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			roles, err := serv.listEffectiveRoles(testCtx, userID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedRoles, roles)
+		})
+	}
+}
+
+func (s *UserServiceSuite) TestListPermissions() {
+	var (
+		userID      = int64(10)
+		customError = errors.New("custom error")
+		roles       = []EffectiveRole{
+			{RoleID: 2, RoleName: "client-admin", RoleType: "client", Source: sourceGranted, GrantID: 1},
+			{RoleID: 3, RoleName: "client-viewer", RoleType: "client", Source: sourceInherited, GrantID: 1},
+		}
+		permissions = []rolePermission{
+			{RoleID: 3, Permission: "user:read"},
+			{RoleID: 2, Permission: "user:write"},
+			{RoleID: 4, Permission: "user:write"},
+		}
+	)
+
+	type test struct {
+		name                string
+		mockCalls           mockPersisterApplier
+		expectedError       error
+		expectedPermissions []UserPermission
+	}
+
+	tests := []test{
+		{
+			name:          "user not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(User{}, nil, userID)},
+			expectedError: userNotFoundError,
+		},
+		{
+			name: "select role permissions return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID}, nil, userID),
+				setPersiterSelectEffectiveRolesMock(roles, nil, userID),
+				setPersiterSelectRolePermissionsMock(nil, customError),
+			},
+			expectedError: customError,
+		},
+		{
+			name: "user without grants",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID}, nil, userID),
+				setPersiterSelectEffectiveRolesMock(nil, nil, userID),
+				setPersiterSelectRolePermissionsMock(permissions, nil),
+			},
+			expectedPermissions: []UserPermission{},
+		},
+		{
+			name: "happy case",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID}, nil, userID),
+				setPersiterSelectEffectiveRolesMock(roles, nil, userID),
+				setPersiterSelectRolePermissionsMock(permissions, nil),
+			},
+			expectedPermissions: []UserPermission{
+				{Permission: "user:read", GrantedBy: []int64{3}},
+				{Permission: "user:write", GrantedBy: []int64{2}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			permissions, err := serv.listPermissions(testCtx, userID)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedPermissions, permissions)
+		})
+	}
+}
+
+func (s *UserServiceSuite) TestResolvePermissions() {
+	roles := []EffectiveRole{{RoleID: 1}, {RoleID: 2}}
+	permissions := []rolePermission{
+		{RoleID: 1, Permission: "role:read"},
+		{RoleID: 3, Permission: "role:write"},
+		{RoleID: 1, Permission: "user:read"},
+		{RoleID: 2, Permission: "user:read"},
+	}
+
+	assert.Equal(s.T(), []UserPermission{
+		{Permission: "role:read", GrantedBy: []int64{1}},
+		{Permission: "user:read", GrantedBy: []int64{1, 2}},
+	}, resolvePermissions(roles, permissions))
+}
+
+func (s *UserServiceSuite) TestRevokeRole() {
+	var (
+		userID  = int64(10)
+		roleID  = int64(2)
+		grant   = RoleGrant{ID: 1, UserID: userID, RoleID: roleID}
+		revoked = RoleGrant{ID: 1, UserID: userID, RoleID: roleID, DateExpired: &testNow}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+	}
+
+	tests := []test{
+		{
+			name:          "user not found",
+			mockCalls:     mockPersisterApplier{setPersiterSelectByIDMock(User{}, nil, userID)},
+			expectedError: userNotFoundError,
+		},
+		{
+			name: "role is not granted",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID}, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectRoleGrantsMock([]RoleGrant{{ID: 5, RoleID: 3}}, nil, userID, false),
+			},
+			expectedError: roleGrantNotFoundError,
+		},
+		{
+			name: "grant expired in the meantime",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID}, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectRoleGrantsMock([]RoleGrant{grant}, nil, userID, false),
+				setPersiterExpireRoleGrantMock(false, nil, grant.ID),
+			},
+			expectedError: roleGrantNotFoundError,
+		},
+		{
+			name: "role revoked",
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDMock(User{ID: userID}, nil, userID),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectRoleGrantsMock([]RoleGrant{grant}, nil, userID, false),
+				setPersiterExpireRoleGrantMock(true, nil, grant.ID),
+				setPersiterSelectRoleGrantByIDMock(revoked, nil, grant.ID),
+				setPersiterRecordEntryMock(nil, audit.ActionRevoke, userID, grant, revoked),
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			err := serv.revokeRole(testCtx, userID, roleID)
+
+			assert.Equal(t, test.expectedError, err)
+		})
+	}
+}
+
+func (s *UserServiceSuite) TestVerifyUser() {
+	var (
+		userID      = int64(10)
+		active      = true
+		used        = testNow.Add(-time.Minute)
+		customError = errors.New("custom error")
+		request     = VerifyUserRequest{Token: testToken}
+		token       = VerificationToken{Token: testToken, UserID: userID, DateExpired: testNow.Add(time.Hour)}
+		user        = User{ID: userID, UserName: "name"}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedUser  User
+	}
+
+	tests := []test{
+		{
+			name: "select verification token return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectVerificationTokenMock(VerificationToken{}, customError, testToken),
+			},
+			expectedError: customError,
+			expectedUser:  User{},
+		},
+		{
+			name: "token not found",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectVerificationTokenMock(VerificationToken{}, nil, testToken),
+			},
+			expectedError: invalidVerificationTokenError,
+			expectedUser:  User{},
+		},
+		{
+			name: "token already used",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectVerificationTokenMock(VerificationToken{Token: testToken, DateUsed: &used}, nil, testToken),
+			},
+			expectedError: verificationTokenUsedError,
+			expectedUser:  User{},
+		},
+		{
+			name: "token expired",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectVerificationTokenMock(
+					VerificationToken{Token: testToken, DateExpired: testNow.Add(-time.Second)}, nil, testToken),
+			},
+			expectedError: verificationTokenExpiredError,
+			expectedUser:  User{},
+		},
+		{
+			name: "token used in the meantime",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectVerificationTokenMock(token, nil, testToken),
+				setPersiterUseVerificationTokenMock(false, nil, testToken),
+			},
+			expectedError: verificationTokenUsedError,
+			expectedUser:  User{},
+		},
+		{
+			name: "modify user return error",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectVerificationTokenMock(token, nil, testToken),
+				setPersiterUseVerificationTokenMock(true, nil, testToken),
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterModifyUserMock(false, customError, ModifyUserRequest{Active: &active}, user),
+			},
+			expectedError: customError,
+			expectedUser:  User{},
+		},
+		{
+			name: "user verified",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectVerificationTokenMock(token, nil, testToken),
+				setPersiterUseVerificationTokenMock(true, nil, testToken),
+				setPersiterSelectByIDMock(user, nil, userID),
+				setPersiterModifyUserMock(true, nil, ModifyUserRequest{Active: &active}, user),
+				setPersiterSelectByIDMock(User{ID: userID, UserName: "name", Active: true}, nil, userID),
+				setPersiterRecordMock(nil, audit.ActionVerify, &user, &User{ID: userID, UserName: "name", Active: true}),
+			},
+			expectedError: nil,
+			expectedUser:  User{ID: userID, UserName: "name", Active: true},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			user, err := serv.verifyUser(testCtx, request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedUser, user)
+		})
+	}
+}
+
+func (s *UserServiceSuite) TestImportUsers() {
+	var (
+		customError = errors.New("custom error")
+		first       = NewUserRequest{UserName: "first", Alias: "first", Email: "first@email.com"}
+		second      = NewUserRequest{UserName: "second", Alias: "second", Email: "second@email.com"}
+		third       = NewUserRequest{UserName: "third", Alias: "third", Email: "third@email.com"}
+		rows        = []ImportRow{
+			{Row: 1, Request: first},
+			{Row: 2, Request: NewUserRequest{}, Err: customError},
+			{Row: 3, Request: NewUserRequest{UserName: "other", Alias: "other", Email: "FIRST@email.com"}},
+			{Row: 4, Request: second},
+			{Row: 5, Request: third},
+		}
+		duplicatedError = fmt.Errorf("%w in row %d", userWithSameValueErrorFunc("email"), 1)
+	)
+
+	createMocks := func(userID int64, request NewUserRequest) mockPersisterApplier {
+		user := request.toUser(userID, time.Time{}, false)
+		return mockPersisterApplier{
+			setPersiterCreateUserMock(userID, nil, request),
+			setPersiterCreateVerificationTokenMock(nil, testToken, userID, testNow.Add(verificationTokenTTL)),
+			setPersiterSelectByIDMock(user, nil, userID),
+			setPersiterRecordMock(nil, audit.ActionCreate, nil, &user),
+		}
+	}
+
+	type test struct {
+		name           string
+		atomic         bool
+		mockCalls      mockPersisterApplier
+		expectedError  error
+		expectedReport ImportReport
+	}
+
+	tests := []test{
+		{
+			name:   "each row is imported on its own",
+			atomic: false,
+			mockCalls: append(append(append(mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByAnyMock(nil, nil, first.UserName, first.Alias, first.Email),
+			}, createMocks(1, first)...),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByAnyMock([]User{{ID: 9, Alias: "second"}}, nil, second.UserName, second.Alias, second.Email),
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByAnyMock(nil, nil, third.UserName, third.Alias, third.Email),
+			), createMocks(3, third)...),
+			expectedError: nil,
+			expectedReport: ImportReport{
+				Total:   5,
+				Created: 2,
+				Failed:  3,
+				Rows: []ImportRowResult{
+					{Row: 1, Status: importStatusCreated, UserID: 1},
+					{Row: 2, Status: importStatusFailed, Error: customError.Error()},
+					{Row: 3, Status: importStatusFailed, Field: "email", Error: duplicatedError.Error()},
+					{Row: 4, Status: importStatusFailed, Field: "alias", Error: userWithSameValueErrorFunc("alias").Error()},
+					{Row: 5, Status: importStatusCreated, UserID: 3},
+				},
+			},
+		},
+		{
+			name:   "atomic import is rejected",
+			atomic: true,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByAnyMock(nil, nil, first.UserName, first.Alias, first.Email),
+				setPersiterSelectByAnyMock([]User{{ID: 9, Alias: "second"}}, nil, second.UserName, second.Alias, second.Email),
+				setPersiterSelectByAnyMock(nil, nil, third.UserName, third.Alias, third.Email),
+			},
+			expectedError: nil,
+			expectedReport: ImportReport{
+				Atomic: true,
+				Total:  5,
+				Failed: 3,
+				Rows: []ImportRowResult{
+					{Row: 1, Status: importStatusSkipped},
+					{Row: 2, Status: importStatusFailed, Error: customError.Error()},
+					{Row: 3, Status: importStatusFailed, Field: "email", Error: duplicatedError.Error()},
+					{Row: 4, Status: importStatusFailed, Field: "alias", Error: userWithSameValueErrorFunc("alias").Error()},
+					{Row: 5, Status: importStatusSkipped},
+				},
+			},
+		},
+		{
+			name:   "atomic import return error",
+			atomic: true,
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByAnyMock(nil, customError, first.UserName, first.Alias, first.Email),
+			},
+			expectedError:  customError,
+			expectedReport: ImportReport{},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			report, err := serv.importUsers(testCtx, rows, test.atomic)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedReport, report)
+		})
+	}
+}
+
+func (s *UserServiceSuite) TestImportUsersAtomically() {
+	var (
+		customError = errors.New("custom error")
+		first       = NewUserRequest{UserName: "first", Alias: "first", Email: "first@email.com"}
+		second      = NewUserRequest{UserName: "second", Alias: "second", Email: "second@email.com"}
+		rows        = []ImportRow{{Row: 1, Request: first}, {Row: 2, Request: second}}
+		firstUser   = first.toUser(1, time.Time{}, false)
+		secondUser  = second.toUser(2, time.Time{}, false)
+	)
+
+	type test struct {
+		name           string
+		mockCalls      mockPersisterApplier
+		expectedReport ImportReport
+		expectedMail   int
+	}
+
+	tests := []test{
+		{
+			name: "create user fails",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByAnyMock(nil, nil, first.UserName, first.Alias, first.Email),
+				setPersiterSelectByAnyMock(nil, nil, second.UserName, second.Alias, second.Email),
+				setPersiterCreateUserMock(1, nil, first),
+				setPersiterCreateVerificationTokenMock(nil, testToken, 1, testNow.Add(verificationTokenTTL)),
+				setPersiterSelectByIDMock(firstUser, nil, 1),
+				setPersiterRecordMock(nil, audit.ActionCreate, nil, &firstUser),
+				setPersiterCreateUserMock(0, customError, second),
+			},
+			expectedReport: ImportReport{
+				Atomic: true,
+				Total:  2,
+				Failed: 1,
+				Rows: []ImportRowResult{
+					{Row: 1, Status: importStatusSkipped},
+					{Row: 2, Status: importStatusFailed, Error: customError.Error()},
+				},
+			},
+			expectedMail: 0,
+		},
+		{
+			name: "every user is created",
+			mockCalls: mockPersisterApplier{
+				setPersiterWithTransactionMock(nil),
+				setPersiterSelectByAnyMock(nil, nil, first.UserName, first.Alias, first.Email),
+				setPersiterSelectByAnyMock(nil, nil, second.UserName, second.Alias, second.Email),
+				setPersiterCreateUserMock(1, nil, first),
+				setPersiterCreateVerificationTokenMock(nil, testToken, 1, testNow.Add(verificationTokenTTL)),
+				setPersiterSelectByIDMock(firstUser, nil, 1),
+				setPersiterRecordMock(nil, audit.ActionCreate, nil, &firstUser),
+				setPersiterCreateUserMock(2, nil, second),
+				setPersiterCreateVerificationTokenMock(nil, testToken, 2, testNow.Add(verificationTokenTTL)),
+				setPersiterSelectByIDMock(secondUser, nil, 2),
+				setPersiterRecordMock(nil, audit.ActionCreate, nil, &secondUser),
+			},
+			expectedReport: ImportReport{
+				Atomic:  true,
+				Total:   2,
+				Created: 2,
+				Rows: []ImportRowResult{
+					{Row: 1, Status: importStatusCreated, UserID: 1},
+					{Row: 2, Status: importStatusCreated, UserID: 2},
+				},
+			},
+			expectedMail: 2,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			outbox := mail.NewOutbox()
+			serv.mailer = outbox
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			report, err := serv.importUsers(testCtx, rows, true)
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedReport, report)
+			assert.Len(t, outbox.Messages(), test.expectedMail)
+		})
+	}
+}
+
+func (s *UserServiceSuite) TestExportUsers() {
+	var (
+		active      = true
+		filter      = UserFilter{Active: &active}
+		customError = errors.New("custom error")
+		users       = []User{{ID: 1}, {ID: 2}}
+	)
+
+	type test struct {
+		name          string
+		mockCalls     mockPersisterApplier
+		expectedError error
+		expectedUsers []User
+	}
+
+	tests := []test{
+		{
+			name:          "stream return error",
+			mockCalls:     mockPersisterApplier{setPersiterStreamByFilterMock(users[:1], customError, filter)},
+			expectedError: customError,
+			expectedUsers: users[:1],
+		},
+		{
+			name:          "happy case",
+			mockCalls:     mockPersisterApplier{setPersiterStreamByFilterMock(users, nil, filter)},
+			expectedError: nil,
+			expectedUsers: users,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			var exported []User
+			err := serv.exportUsers(testCtx, filter, func(u User) error {
+				exported = append(exported, u)
+				return nil
+			})
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedUsers, exported)
+		})
+	}
+}
+
+func (s *UserServiceSuite) TestGetHistory() {
+	var (
+		userID      = int64(10)
+		page        = audit.Page{Limit: 5, BeforeID: 20}
+		customError = errors.New("custom error")
+		history     = audit.History{Results: []audit.Entry{{ID: 19, EntityID: userID}}, Paging: audit.Paging{Limit: 5}}
+	)
+
+	type test struct {
+		name            string
+		ctx             context.Context
+		mockCalls       mockPersisterApplier
+		expectedError   error
+		expectedHistory audit.History
+	}
+
+	tests := []test{
+		{
+			name:            "select history return error",
+			mockCalls:       mockPersisterApplier{setPersiterSelectHistoryMock(audit.History{}, customError, userID, page)},
+			expectedError:   customError,
+			expectedHistory: audit.History{},
+		},
+		{
+			name:            "happy case",
+			mockCalls:       mockPersisterApplier{setPersiterSelectHistoryMock(history, nil, userID, page)},
+			expectedError:   nil,
+			expectedHistory: history,
+		},
+		{
+			name:            "user is not member of the tenant",
+			ctx:             testTenantCtx,
+			mockCalls:       mockPersisterApplier{setPersiterSelectByIDInClientMock(User{}, nil, userID, testTenant)},
+			expectedError:   userNotFoundError,
+			expectedHistory: audit.History{},
+		},
+		{
+			name: "user is member of the tenant",
+			ctx:  testTenantCtx,
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectByIDInClientMock(User{ID: userID}, nil, userID, testTenant),
+				setPersiterSelectHistoryMock(history, nil, userID, page),
+			},
+			expectedError:   nil,
+			expectedHistory: history,
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			ctx := testCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			history, err := serv.getHistory(ctx, userID, page)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedHistory, history)
+		})
+	}
+}
+
+func (s *UserServiceSuite) TestSearchUsers() {
+	var (
+		customError = errors.New("custom error")
+		users       = []User{{ID: 1, UserName: "a"}, {ID: 2, UserName: "b"}, {ID: 3, UserName: "c"}}
+		cursor      = searchCursor{SortBy: "id", ID: 10}
+	)
+
+	type test struct {
+		name             string
+		ctx              context.Context
+		request          SearchRequest
+		mockCalls        mockPersisterApplier
+		expectedError    error
+		expectedResponse SearchResponse
+	}
+
+	tests := []test{
+		{
+			name:    "cursor was generated for another sort",
+			request: SearchRequest{SortBy: "user_name", After: &cursor},
+			expectedError: fmt.Errorf("%w: it was generated for sorting by %s",
+				invalidCursorError, "id"),
+			expectedResponse: SearchResponse{},
+		},
+		{
+			name:    "select by search return error",
+			request: SearchRequest{},
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectBySearchMock(nil, customError, SearchRequest{SortBy: "id", Limit: defaultSearchLimit + 1}),
+			},
+			expectedError:    customError,
+			expectedResponse: SearchResponse{},
+		},
+		{
+			name:    "search is scoped to the tenant",
+			ctx:     testTenantCtx,
+			request: SearchRequest{},
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectBySearchMock(nil, nil, SearchRequest{
+					Filter: UserFilter{clientID: testTenant},
+					SortBy: "id",
+					Limit:  defaultSearchLimit + 1,
+				}),
+			},
+			expectedError:    nil,
+			expectedResponse: SearchResponse{Results: []User{}, Paging: Paging{Limit: defaultSearchLimit}},
+		},
+		{
+			name:    "empty result",
+			request: SearchRequest{Limit: 1000},
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectBySearchMock(nil, nil, SearchRequest{SortBy: "id", Limit: maxSearchLimit + 1}),
+			},
+			expectedError:    nil,
+			expectedResponse: SearchResponse{Results: []User{}, Paging: Paging{Limit: maxSearchLimit}},
+		},
+		{
+			name:    "last page",
+			request: SearchRequest{SortBy: "user_name", Limit: 3, After: &searchCursor{SortBy: "user_name", ID: 10}},
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectBySearchMock(users, nil, SearchRequest{
+					SortBy: "user_name",
+					Limit:  4,
+					After:  &searchCursor{SortBy: "user_name", ID: 10},
+				}),
+			},
+			expectedError:    nil,
+			expectedResponse: SearchResponse{Results: users, Paging: Paging{Limit: 3}},
+		},
+		{
+			name:    "there is a next page",
+			request: SearchRequest{SortBy: "user_name", Limit: 2},
+			mockCalls: mockPersisterApplier{
+				setPersiterSelectBySearchMock(users, nil, SearchRequest{SortBy: "user_name", Limit: 3}),
+			},
+			expectedError: nil,
+			expectedResponse: SearchResponse{
+				Results: users[:2],
+				Paging: Paging{
+					Limit:      2,
+					NextCursor: searchCursor{SortBy: "user_name", Value: "b", ID: 2}.encode(),
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s.T().Run(test.name, func(t *testing.T) {
+			serv := newServiceForTest()
+			if assertsCalls, err := test.mockCalls.apply(&serv); err != nil {
+				assert.Fail(t, err.Error())
+				return
+			} else {
+				defer assertsCalls(t)
+			}
+
+			ctx := testCtx
+			if test.ctx != nil {
+				ctx = test.ctx
+			}
+
+			response, err := serv.searchUsers(ctx, test.request)
+
+			assert.Equal(t, test.expectedError, err)
+			assert.Equal(t, test.expectedResponse, response)
+		})
+	}
+}
+
+const (
+	testToken  = "token"
+	testCaller = "admin"
+)
+
+var (
+	testNow       = time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+	testCtx       = auth.WithCaller(context.Background(), testCaller)
+	testTenant    = int64(7)
+	testTenantCtx = auth.WithTenant(testCtx, testTenant)
+)
+
+// newServiceForTest returns a service with a mocked repository, an in-memory mailer and a fixed token and clock.
+func newServiceForTest() userService {
+	serv := NewService(newDBMock(), mail.NewOutbox()).(userService)
+	serv.tokenGenerator = func() (string, error) {
+		return testToken, nil
+	}
+	serv.now = func() time.Time {
+		return testNow
+	}
+	return serv
+}
+
+type failingMailer struct {
+	err error
+}
+
+func (m failingMailer) Send(mail.Message) error {
+	return m.err
+}
+
+type mockPersisterApplier []func(us *userService) (func(t *testing.T), error)
+
+func (appliers mockPersisterApplier) apply(us *userService) (func(t *testing.T), error) {
+	var assertCalls []func(t *testing.T)
+	for i := range appliers {
+		if assertCall, err := appliers[i](us); err != nil {
+			return func(t *testing.T) {}, err
+		} else {
+			assertCalls = append(assertCalls, assertCall)
+		}
+	}
+	return func(t *testing.T) {
+		for i := range assertCalls {
+			assertCalls[i](t)
+		}
+	}, nil
+}
+
+func setPersiterWithTransactionMock(
+	errorResponse error,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.withTransaction), mock.Anything).
+			Return(errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByIDMock(
+	userResponse User,
+	errorResponse error,
+	userID int64,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectByID), userID).
+			Return(userResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByIDInClientMock(
+	userResponse User,
+	errorResponse error,
+	userID int64,
+	clientID int64,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectByIDInClient), userID, clientID).
+			Return(userResponse, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterLinkClientMock(
+	linked bool,
+	errorResponse error,
+	userID int64,
+	clientID int64,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.linkClient), userID, clientID).
+			Return(linked, errorResponse).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectByAnyMock(
+	users []User,
+	err error,
+	userName, alias, email string,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(
+			util.GetFunctionName(r.selectByAny),
+			userName,
+			alias,
+			email).
+			Return(users, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterCreateUserMock(
+	userIDResponse int64,
+	err error,
+	userRequest NewUserRequest,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(
+			util.GetFunctionName(r.createUser), userRequest).
+			Return(userIDResponse, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterModifyUserMock(
+	response bool,
+	err error,
+	request ModifyUserRequest,
+	user User,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(
+			util.GetFunctionName(r.modifyUser), request, user).
+			Return(response, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectBySearchMock(
+	users []User,
+	err error,
+	request SearchRequest,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectBySearch), request).
+			Return(users, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterExecByUserIDMock(
+	method string,
+	rowsAffected int64,
+	err error,
+	userID int64,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(method, userID).
+			Return(rowsAffected, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterCancelUserTasksMock(
+	rowsAffected int64,
+	err error,
+	userID int64,
+	actor string,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.cancelUserTasks), userID, actor).
+			Return(rowsAffected, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterDeleteUserMock(
+	response bool,
+	err error,
+	userID int64,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.deleteUser), userID).
+			Return(response, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterCreateVerificationTokenMock(
+	err error,
+	token string,
+	userID int64,
+	dateExpired time.Time,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.createVerificationToken), token, userID, dateExpired).
+			Return(err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectVerificationTokenMock(
+	response VerificationToken,
+	err error,
+	token string,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectVerificationToken), token).
+			Return(response, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterUseVerificationTokenMock(
+	response bool,
+	err error,
+	token string,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.useVerificationToken), token).
+			Return(response, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterStreamByFilterMock(
 	users []User,
 	err error,
-	userName, alias, email string,
+	filter UserFilter,
 ) func(us *userService) (func(t *testing.T), error) {
 	return func(us *userService) (func(t *testing.T), error) {
 		r, ok := us.userRepository.(*dbMock)
 		if !ok {
 			return nil, errors.New("it could not cast to mock repository")
 		}
-		r.On(
-			util.GetFunctionName(r.selectByAny),
-			userName,
-			alias,
-			email).
+		r.On(util.GetFunctionName(r.streamByFilter), filter).
 			Return(users, err).
 			Once()
 		return func(t *testing.T) {
@@ -434,19 +2105,34 @@ func setPersiterSelectByAnyMock(
 	}
 }
 
-func setPersiterCreateUserMock(
-	userIDResponse int64,
+func setPersiterRecordMock(
 	err error,
-	userRequest NewUserRequest,
+	action string,
+	before, after *User,
 ) func(us *userService) (func(t *testing.T), error) {
 	return func(us *userService) (func(t *testing.T), error) {
 		r, ok := us.userRepository.(*dbMock)
 		if !ok {
 			return nil, errors.New("it could not cast to mock repository")
 		}
-		r.On(
-			util.GetFunctionName(r.createUser), userRequest).
-			Return(userIDResponse, err).
+
+		var (
+			userID                    int64
+			beforeEntity, afterEntity any
+		)
+		if before != nil {
+			userID, beforeEntity = before.ID, *before
+		}
+		if after != nil {
+			userID, afterEntity = after.ID, *after
+		}
+		entry, entryErr := audit.NewEntry(testCaller, action, auditEntity, userID, beforeEntity, afterEntity)
+		if entryErr != nil {
+			return nil, entryErr
+		}
+
+		r.On(util.GetFunctionName(r.record), entry).
+			Return(err).
 			Once()
 		return func(t *testing.T) {
 			r.AssertExpectations(t)
@@ -454,19 +2140,158 @@ func setPersiterCreateUserMock(
 	}
 }
 
-func setPersiterModifyUserMock(
-	response bool,
+func setPersiterSelectHistoryMock(
+	response audit.History,
 	err error,
-	request ModifyUserRequest,
-	user User,
+	userID int64,
+	page audit.Page,
 ) func(us *userService) (func(t *testing.T), error) {
 	return func(us *userService) (func(t *testing.T), error) {
 		r, ok := us.userRepository.(*dbMock)
 		if !ok {
 			return nil, errors.New("it could not cast to mock repository")
 		}
-		r.On(
-			util.GetFunctionName(r.modifyUser), request, user).
+		r.On(util.GetFunctionName(r.selectHistory), userID, page).
+			Return(response, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterRecordEntryMock(
+	err error,
+	action string,
+	userID int64,
+	before, after any,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+
+		entry, entryErr := audit.NewEntry(testCaller, action, auditEntity, userID, before, after)
+		if entryErr != nil {
+			return nil, entryErr
+		}
+
+		r.On(util.GetFunctionName(r.record), entry).
+			Return(err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectGrantableRoleMock(
+	response grantableRole,
+	err error,
+	roleID int64,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectGrantableRole), roleID).
+			Return(response, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectRoleGrantsMock(
+	response []RoleGrant,
+	err error,
+	userID int64,
+	includeExpired bool,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectRoleGrants), userID, includeExpired).
+			Return(response, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectEffectiveRolesMock(
+	response []EffectiveRole,
+	err error,
+	userID int64,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectEffectiveRoles), userID).
+			Return(response, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectRolePermissionsMock(
+	response []rolePermission,
+	err error,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectRolePermissions)).
+			Return(response, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectRoleAccessMock(
+	response roleAccess,
+	err error,
+	roleID int64,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectRoleAccess), roleID).
+			Return(response, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterSelectRoleGrantByIDMock(
+	response RoleGrant,
+	err error,
+	grantID int64,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.selectRoleGrantByID), grantID).
 			Return(response, err).
 			Once()
 		return func(t *testing.T) {
@@ -474,3 +2299,42 @@ func setPersiterModifyUserMock(
 		}, nil
 	}
 }
+
+func setPersiterCreateRoleGrantMock(
+	grantID int64,
+	err error,
+	userID, roleID int64,
+	dateExpired *time.Time,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.createRoleGrant), userID, roleID, dateExpired).
+			Return(grantID, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
+
+func setPersiterExpireRoleGrantMock(
+	expired bool,
+	err error,
+	grantID int64,
+) func(us *userService) (func(t *testing.T), error) {
+	return func(us *userService) (func(t *testing.T), error) {
+		r, ok := us.userRepository.(*dbMock)
+		if !ok {
+			return nil, errors.New("it could not cast to mock repository")
+		}
+		r.On(util.GetFunctionName(r.expireRoleGrant), grantID).
+			Return(expired, err).
+			Once()
+		return func(t *testing.T) {
+			r.AssertExpectations(t)
+		}, nil
+	}
+}
@@ -1,6 +1,12 @@
 package user
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,6 +17,7 @@ type User struct {
 	Email       string    `json:"email"`
 	DateCreated time.Time `json:"date_created"`
 	Active      bool      `json:"active"`
+	Version     int64     `json:"version"`
 }
 
 func (u User) isEmptyUser() bool {
@@ -34,10 +41,369 @@ func (u NewUserRequest) toUser(userID int64, dateCreated time.Time, active bool)
 	}
 }
 
+type VerifyUserRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// VerificationToken is sent by email to new users, who redeem it for activating their account.
+type VerificationToken struct {
+	Token       string
+	UserID      int64
+	DateExpired time.Time
+	DateUsed    *time.Time
+	DateCreated time.Time
+}
+
+func (t VerificationToken) isEmpty() bool {
+	return t.Token == ""
+}
+
 type ModifyUserRequest struct {
-	Active *bool `json:"active"`
+	UserName *string `json:"user_name"`
+	Alias    *string `json:"alias"`
+	Email    *string `json:"email"`
+	Active   *bool   `json:"active"`
+
+	// Version is the user version expected by the client. It is taken from If-Match header.
+	Version *int64 `json:"-"`
 }
 
 func (u ModifyUserRequest) isEmpty() bool {
-	return u.Active == nil
+	return u.UserName == nil && u.Alias == nil && u.Email == nil && u.Active == nil
+}
+
+// validate checks that given profile fields are not blank.
+func (u ModifyUserRequest) validate() error {
+	fields := []string{"user_name", "alias", "email"}
+	for i, value := range []*string{u.UserName, u.Alias, u.Email} {
+		if value != nil && strings.TrimSpace(*value) == "" {
+			return fmt.Errorf("%s cannot be empty", fields[i])
+		}
+	}
+	return nil
+}
+
+// changesProfile tells whether the request modifies any of the unique fields of the user.
+func (u ModifyUserRequest) changesProfile() bool {
+	return u.UserName != nil || u.Alias != nil || u.Email != nil
+}
+
+// apply returns the user with the request changes applied.
+func (u ModifyUserRequest) apply(user User) User {
+	if u.UserName != nil {
+		user.UserName = *u.UserName
+	}
+	if u.Alias != nil {
+		user.Alias = *u.Alias
+	}
+	if u.Email != nil {
+		user.Email = *u.Email
+	}
+	if u.Active != nil {
+		user.Active = *u.Active
+	}
+	return user
+}
+
+// uniqueValues returns user_name, alias and email that the request wants to set. Unchanged fields are empty.
+func (u ModifyUserRequest) uniqueValues() (string, string, string) {
+	var userName, alias, email string
+	if u.UserName != nil {
+		userName = *u.UserName
+	}
+	if u.Alias != nil {
+		alias = *u.Alias
+	}
+	if u.Email != nil {
+		email = *u.Email
+	}
+	return userName, alias, email
+}
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// searchSortColumns are the user columns that a search can be sorted by. Ties are always
+// broken by id, so the order is stable between pages.
+var searchSortColumns = map[string]bool{
+	"id":           true,
+	"user_name":    true,
+	"alias":        true,
+	"email":        true,
+	"date_created": true,
+}
+
+// UserFilter keeps the conditions used for searching users. Empty fields are ignored.
+type UserFilter struct {
+	Active          *bool
+	DateCreatedFrom *time.Time
+	DateCreatedTo   *time.Time
+	UserName        string
+	Alias           string
+	Email           string
+
+	// clientID is the tenant of the request, it is never taken from the query params.
+	clientID int64
+}
+
+type SearchRequest struct {
+	Filter UserFilter
+	SortBy string
+	Desc   bool
+	Limit  int
+	After  *searchCursor
+}
+
+// searchCursor points to the last user returned in a page. It is sent to clients as an opaque string.
+type searchCursor struct {
+	SortBy string `json:"s"`
+	Value  string `json:"v"`
+	ID     int64  `json:"id"`
+}
+
+func newSearchCursor(sortBy string, u User) searchCursor {
+	cursor := searchCursor{SortBy: sortBy, ID: u.ID}
+	switch sortBy {
+	case "user_name":
+		cursor.Value = u.UserName
+	case "alias":
+		cursor.Value = u.Alias
+	case "email":
+		cursor.Value = u.Email
+	case "date_created":
+		cursor.Value = u.DateCreated.Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+func (c searchCursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSearchCursor(s string) (searchCursor, error) {
+	var cursor searchCursor
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor, invalidCursorError
+	}
+
+	if err = json.Unmarshal(b, &cursor); err != nil || cursor.ID <= 0 || !searchSortColumns[cursor.SortBy] {
+		return cursor, invalidCursorError
+	}
+
+	if cursor.SortBy == "date_created" {
+		if _, err = time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+			return cursor, invalidCursorError
+		}
+	}
+
+	return cursor, nil
+}
+
+// value returns the cursor's sort value typed as the column it belongs to.
+func (c searchCursor) value() any {
+	if c.SortBy == "date_created" {
+		t, _ := time.Parse(time.RFC3339Nano, c.Value)
+		return t
+	}
+	return c.Value
+}
+
+type Paging struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type SearchResponse struct {
+	Results []User `json:"results"`
+	Paging  Paging `json:"paging"`
+}
+
+const (
+	importStatusCreated = "created"
+	importStatusFailed  = "failed"
+	importStatusSkipped = "skipped"
+
+	maxImportRows = 5000
+)
+
+// ImportRow is a user read from an imported file. Err keeps why the row could not be read or is not valid.
+type ImportRow struct {
+	Row     int
+	Request NewUserRequest
+	Err     error
+}
+
+type ImportRowResult struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	UserID int64  `json:"user_id,omitempty"`
+	Field  string `json:"field,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	Atomic  bool              `json:"atomic"`
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+func newImportReport(rows []ImportRow, atomic bool) ImportReport {
+	report := ImportReport{
+		Atomic: atomic,
+		Total:  len(rows),
+		Rows:   make([]ImportRowResult, len(rows)),
+	}
+
+	for i := range rows {
+		report.Rows[i].Row = rows[i].Row
+		if rows[i].Err != nil {
+			report.fail(i, rows[i].Err)
+		}
+	}
+
+	return report
+}
+
+func (r *ImportReport) failed(i int) bool {
+	return r.Rows[i].Status == importStatusFailed
+}
+
+func (r *ImportReport) fail(i int, err error) {
+	if r.failed(i) {
+		return
+	}
+
+	r.Rows[i].Status = importStatusFailed
+	r.Rows[i].Error = err.Error()
+
+	var sameValueErr sameValueError
+	if errors.As(err, &sameValueErr) {
+		r.Rows[i].Field = sameValueErr.field
+	}
+
+	r.Failed++
+}
+
+func (r *ImportReport) create(i int, user User) {
+	r.Rows[i].Status = importStatusCreated
+	r.Rows[i].UserID = user.ID
+	r.Created++
+}
+
+// skipPending marks as skipped every row which was neither created nor failed.
+func (r *ImportReport) skipPending() {
+	for i := range r.Rows {
+		if r.Rows[i].Status == "" {
+			r.Rows[i].Status = importStatusSkipped
+		}
+	}
+}
+
+// RoleGrant is a role granted to a user through user_role. A grant without DateExpired never expires.
+type RoleGrant struct {
+	ID          int64      `json:"grant_id"`
+	UserID      int64      `json:"user_id"`
+	RoleID      int64      `json:"role_id"`
+	RoleName    string     `json:"role_name"`
+	RoleType    string     `json:"role_type"`
+	DateExpired *time.Time `json:"date_expired"`
+	DateCreated time.Time  `json:"date_created"`
+}
+
+func (g RoleGrant) isEmpty() bool {
+	return g.ID == 0
+}
+
+type GrantRoleRequest struct {
+	RoleID      int64      `json:"role_id" binding:"required"`
+	DateExpired *time.Time `json:"date_expired"`
+}
+
+// grantableRole keeps the role fields needed for granting it.
+type grantableRole struct {
+	ID     int64
+	Active bool
+}
+
+func (r grantableRole) isEmpty() bool {
+	return r.ID == 0
+}
+
+// roleAccess keeps the role types and permissions a role gives, itself or through its parents.
+type roleAccess struct {
+	RoleTypes   []string
+	Permissions []string
+}
+
+const (
+	sourceGranted   = "granted"
+	sourceInherited = "inherited"
+)
+
+// EffectiveRole is a role the user holds. GrantID is the grant it comes from, InheritedThrough lists the roles walked
+// from the granted role to reach it and it is empty when the role is granted itself.
+type EffectiveRole struct {
+	RoleID           int64   `json:"role_id"`
+	RoleName         string  `json:"role_name"`
+	RoleType         string  `json:"role_type"`
+	Source           string  `json:"source"`
+	GrantID          int64   `json:"grant_id"`
+	InheritedThrough []int64 `json:"inherited_through,omitempty"`
+}
+
+// parseInheritedThrough returns the roles walked before reaching an inherited role from the path of
+// auth.EffectiveRolesCTE, which ends with the inherited role itself.
+func parseInheritedThrough(path string) ([]int64, error) {
+	walked := strings.Split(path, ",")
+	through := make([]int64, 0, len(walked)-1)
+	for _, value := range walked[:len(walked)-1] {
+		roleID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("role path %q is not valid: %w", path, err)
+		}
+		through = append(through, roleID)
+	}
+	return through, nil
+}
+
+// UserPermission is a permission the user holds, GrantedBy lists the effective roles holding it.
+type UserPermission struct {
+	Permission string  `json:"permission"`
+	GrantedBy  []int64 `json:"granted_by"`
+}
+
+// rolePermission tells that RoleID holds the permission.
+type rolePermission struct {
+	RoleID     int64
+	Permission string
+}
+
+// resolvePermissions returns the permissions held by the effective roles, keeping the order of permissions which
+// must be sorted by name.
+func resolvePermissions(roles []EffectiveRole, permissions []rolePermission) []UserPermission {
+	effective := map[int64]bool{}
+	for _, r := range roles {
+		effective[r.RoleID] = true
+	}
+
+	var resolved []UserPermission
+	for _, p := range permissions {
+		if !effective[p.RoleID] {
+			continue
+		}
+		if last := len(resolved) - 1; last >= 0 && resolved[last].Permission == p.Permission {
+			resolved[last].GrantedBy = append(resolved[last].GrantedBy, p.RoleID)
+			continue
+		}
+		resolved = append(resolved, UserPermission{Permission: p.Permission, GrantedBy: []int64{p.RoleID}})
+	}
+
+	return resolved
 }